@@ -51,6 +51,16 @@ var (
 						flTaskSchedNoStart,
 						flTaskDeadline,
 						flTaskMaxFailures,
+						flTaskLabel,
+					},
+				},
+				{
+					Name:        "validate",
+					Description: "Validates a task manifest against the plugins and metrics loaded without creating the task",
+					Usage:       "validate -t <task_manifest>",
+					Action:      validateTaskManifest,
+					Flags: []cli.Flag{
+						flTaskManifest,
 					},
 				},
 				{
@@ -59,6 +69,7 @@ var (
 					Action: listTask,
 					Flags: []cli.Flag{
 						flVerbose,
+						flTaskSelector,
 					},
 				},
 				{
@@ -76,6 +87,14 @@ var (
 					Usage:  "remove <task_id>",
 					Action: removeTask,
 				},
+				{
+					Name:   "batch",
+					Usage:  "batch <batch_file> [--atomic]",
+					Action: batchTasks,
+					Flags: []cli.Flag{
+						flTaskBatchAtomic,
+					},
+				},
 				{
 					Name:   "export",
 					Usage:  "export <task_id>",
@@ -96,6 +115,46 @@ var (
 				},
 			},
 		},
+		{
+			Name: "template",
+			Subcommands: []cli.Command{
+				{
+					Name:        "create",
+					Description: "Creates a task template from a manifest with ${param} placeholders and the parameters it declares",
+					Usage:       "create <template_file>",
+					Action:      createTemplate,
+				},
+				{
+					Name:   "list",
+					Usage:  "list",
+					Action: listTemplates,
+				},
+				{
+					Name:   "get",
+					Usage:  "get <template_name> [--verbose]",
+					Action: getTemplate,
+					Flags: []cli.Flag{
+						flVerbose,
+					},
+				},
+				{
+					Name:   "remove",
+					Usage:  "remove <template_name>",
+					Action: removeTemplate,
+				},
+				{
+					Name:        "instantiate",
+					Description: "Creates a task from a template with the values of its parameters",
+					Usage:       "instantiate [<template_name>] [--param <key>=<value>]... [--values <values_file>] [--no-start]",
+					Action:      instantiateTemplate,
+					Flags: []cli.Flag{
+						flTemplateParam,
+						flTemplateValues,
+						flTaskSchedNoStart,
+					},
+				},
+			},
+		},
 		{
 			Name: "plugin",
 			Subcommands: []cli.Command{
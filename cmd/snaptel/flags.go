@@ -60,6 +60,21 @@ var (
 		Usage:  "Path to a config file",
 		Value:  "",
 	}
+	flClientCert = cli.StringFlag{
+		Name:   "client-cert",
+		Usage:  "A path to a client certificate to present to Snap's HTTPS API",
+		EnvVar: "SNAP_CLIENT_CERT",
+	}
+	flClientKey = cli.StringFlag{
+		Name:   "client-key",
+		Usage:  "A path to the key of the client certificate",
+		EnvVar: "SNAP_CLIENT_KEY",
+	}
+	flCACert = cli.StringFlag{
+		Name:   "ca-cert",
+		Usage:  "A path to a CA bundle to verify Snap's HTTPS API with",
+		EnvVar: "SNAP_CA_CERT",
+	}
 	flTimeout = cli.DurationFlag{
 		Name:  "timeout, t",
 		Usage: "Timeout to be set on HTTP request to the server",
@@ -152,6 +167,28 @@ var (
 		Name:  "max-failures",
 		Usage: "The number of consecutive failures before Snap disables the task",
 	}
+	flTaskLabel = cli.StringSliceFlag{
+		Name:  "label",
+		Usage: "A label of the task as key=value, can be repeated [added to the labels of the task manifest]",
+	}
+	flTaskSelector = cli.StringFlag{
+		Name:  "selector, l",
+		Usage: "Only the tasks with labels matching the selector [ex: team=infra,env!=dev]",
+	}
+	flTaskBatchAtomic = cli.BoolFlag{
+		Name:  "atomic",
+		Usage: "Remove the tasks created by the batch and skip its remaining operations once an operation fails",
+	}
+
+	// template
+	flTemplateParam = cli.StringSliceFlag{
+		Name:  "param, p",
+		Usage: "The value of a parameter of the template as key=value, can be repeated [overrides the values file]",
+	}
+	flTemplateValues = cli.StringFlag{
+		Name:  "values",
+		Usage: "A JSON or YAML file holding the values of the parameters, and optionally the name of the template",
+	}
 
 	// metric
 	flMetricVersion = cli.IntFlag{
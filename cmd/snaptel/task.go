@@ -26,6 +26,7 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"reflect"
 	"sort"
 	"strconv"
 	"strings"
@@ -33,6 +34,7 @@ import (
 	"text/tabwriter"
 	"time"
 
+	"github.com/intelsdi-x/snap/core"
 	"github.com/intelsdi-x/snap/mgmt/rest/client"
 	"github.com/intelsdi-x/snap/scheduler/wmap"
 	"github.com/robfig/cron"
@@ -79,12 +81,46 @@ func trunc(n int) string {
 }
 
 type task struct {
-	Version     int
-	Schedule    *client.Schedule
-	Workflow    *wmap.WorkflowMap
-	Name        string
-	Deadline    string
-	MaxFailures int `json:"max-failures"`
+	Version          int
+	Schedule         *client.Schedule
+	Workflow         *wmap.WorkflowMap
+	Name             string
+	Deadline         string
+	MaxFailures      int                   `json:"max-failures"`
+	Labels           map[string]string     `json:"labels"`
+	Dependencies     []core.TaskDependency `json:"dependencies"`
+	AdaptiveInterval bool                  `json:"adaptive-interval"`
+	Priority         string                `json:"priority"`
+}
+
+// creationRequest returns the request creating the task with the given workflow
+func (t *task) creationRequest(wf *wmap.WorkflowMap, start bool) core.TaskCreationRequest {
+	return core.TaskCreationRequest{
+		Name: t.Name,
+		Schedule: &core.Schedule{
+			Type:           t.Schedule.Type,
+			Interval:       t.Schedule.Interval,
+			StartTimestamp: t.Schedule.StartTimestamp,
+			StopTimestamp:  t.Schedule.StopTimestamp,
+			Count:          t.Schedule.Count,
+			Alignment:      t.Schedule.Alignment,
+			Offset:         t.Schedule.Offset,
+			Jitter:         t.Schedule.Jitter,
+			CatchUp:        t.Schedule.CatchUp,
+			Entries:        t.Schedule.Entries,
+			Timezone:       t.Schedule.Timezone,
+			Blackouts:      t.Schedule.Blackouts,
+			Trigger:        t.Schedule.Trigger,
+		},
+		Workflow:         wf,
+		Deadline:         t.Deadline,
+		Start:            start,
+		MaxFailures:      t.MaxFailures,
+		Labels:           t.Labels,
+		Dependencies:     t.Dependencies,
+		AdaptiveInterval: t.AdaptiveInterval,
+		Priority:         t.Priority,
+	}
 }
 
 func createTask(ctx *cli.Context) error {
@@ -247,6 +283,17 @@ func (t *task) setScheduleFromCliOptions(ctx *cli.Context) error {
 		return nil
 	}
 
+	// A triggered schedule fires on its trigger, the interval is optional
+	if t.Schedule.Type == "triggered" {
+		if interval := ctx.String("interval"); interval != "" {
+			if _, err := time.ParseDuration(interval); err != nil {
+				return fmt.Errorf("Usage error (bad interval value); %v", err)
+			}
+			t.Schedule.Interval = interval
+		}
+		return nil
+	}
+
 	// Grab the interval for the schedule (if one was provided). Note that if an
 	// interval value was not passed in and there is no interval defined for the
 	// schedule associated with this task, it's an error
@@ -338,6 +385,17 @@ func (t *task) mergeCliOptions(ctx *cli.Context) error {
 		}
 		t.MaxFailures = maxFailures
 	}
+	// add the labels of the task (if any 'label' was provided in the CLI options)
+	for _, l := range ctx.StringSlice("label") {
+		kv := strings.SplitN(l, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("Invalid label '%s', expected key=value", l)
+		}
+		if t.Labels == nil {
+			t.Labels = map[string]string{}
+		}
+		t.Labels[kv[0]] = kv[1]
+	}
 	// set the schedule for the task from the CLI options (and return the results
 	// of that method call, indicating whether or not an error was encountered while
 	// setting up that schedule)
@@ -383,7 +441,7 @@ func createTaskUsingTaskManifest(ctx *cli.Context) error {
 	}
 
 	// and use the resulting struct to create a new task
-	r := pClient.CreateTask(t.Schedule, t.Workflow, t.Name, t.Deadline, !ctx.IsSet("no-start"), t.MaxFailures)
+	r := pClient.CreateTaskFromRequest(t.creationRequest(t.Workflow, !ctx.IsSet("no-start")))
 
 	if r.Err != nil {
 		errors := strings.Split(r.Err.Error(), " -- ")
@@ -401,6 +459,82 @@ func createTaskUsingTaskManifest(ctx *cli.Context) error {
 	return nil
 }
 
+// validateTaskManifest validates a task manifest in snapteld without creating
+// the task, printing the errors found and the config each metric and plugin of
+// the workflow resolves to
+func validateTaskManifest(ctx *cli.Context) error {
+	path := ctx.String("task-manifest")
+	if path == "" {
+		return newUsageError("Must provide a task manifest", ctx)
+	}
+	ext := filepath.Ext(path)
+	file, e := ioutil.ReadFile(path)
+	if e != nil {
+		return fmt.Errorf("File error [%s] - %v\n", ext, e)
+	}
+	file = []byte(os.ExpandEnv(string(file)))
+	switch ext {
+	case ".yaml", ".yml":
+		file, e = yaml.YAMLToJSON(file)
+		if e != nil {
+			return fmt.Errorf("Error parsing YAML file input - %v\n", e)
+		}
+	case ".json":
+	default:
+		return fmt.Errorf("Unsupported file type %s\n", ext)
+	}
+
+	r := pClient.ValidateTask(file)
+	if r.Err != nil {
+		return fmt.Errorf("Error validating task:\n%v\n", r.Err)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', 0)
+	if len(r.Metrics) > 0 {
+		printFields(w, false, 0, "METRIC", "VERSION", "PATH")
+		for _, m := range r.Metrics {
+			printFields(w, false, 0, m.Name, m.Version, m.Path)
+			printResolvedConfig(w, m.Config)
+		}
+		w.Flush()
+		fmt.Println()
+	}
+	if len(r.Plugins) > 0 {
+		printFields(w, false, 0, "PLUGIN", "VERSION", "PATH")
+		for _, p := range r.Plugins {
+			printFields(w, false, 0, p.Type+":"+p.Name, p.Version, p.Path)
+			printResolvedConfig(w, p.Config)
+		}
+		w.Flush()
+		fmt.Println()
+	}
+	if !r.Valid {
+		printFields(w, false, 0, "PATH", "ERROR")
+		for _, err := range r.Errors {
+			path := err.Path
+			if path == "" {
+				path = "-"
+			}
+			printFields(w, false, 0, path, err.Message)
+		}
+		w.Flush()
+		return fmt.Errorf("Task manifest is not valid\n")
+	}
+	fmt.Println("Task manifest is valid")
+	return nil
+}
+
+// printResolvedConfig prints the config of a metric or plugin sorted by key
+func printResolvedConfig(w *tabwriter.Writer, config map[string]interface{}) {
+	keys := make([]string, 0, len(config))
+	for k := range config {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		printFields(w, true, 2, fmt.Sprintf("%s: %v", k, config[k]))
+	}
+}
+
 func createTaskUsingWFManifest(ctx *cli.Context) error {
 	// Get the workflow manifest filename from the command-line
 	path := ctx.String("workflow-manifest")
@@ -445,7 +579,7 @@ func createTaskUsingWFManifest(ctx *cli.Context) error {
 	}
 
 	// and use the resulting struct (along with the workflow map we constructed, above) to create a new task
-	r := pClient.CreateTask(t.Schedule, wf, t.Name, t.Deadline, !ctx.IsSet("no-start"), t.MaxFailures)
+	r := pClient.CreateTaskFromRequest(t.creationRequest(wf, !ctx.IsSet("no-start")))
 	if r.Err != nil {
 		errors := strings.Split(r.Err.Error(), " -- ")
 		errString := "Error creating task: "
@@ -530,7 +664,7 @@ func mergeDateTime(tm, dt string) *time.Time {
 }
 
 func listTask(ctx *cli.Context) error {
-	tasks := pClient.GetTasks()
+	tasks := pClient.GetTasksBySelector(ctx.String("selector"))
 	termWidth, _, _ := terminal.GetSize(int(os.Stdout.Fd()))
 	verbose := ctx.Bool("verbose")
 	if tasks.Err != nil {
@@ -539,6 +673,10 @@ func listTask(ctx *cli.Context) error {
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', 0)
 	if tasks.Len() == 0 {
+		if ctx.IsSet("selector") {
+			fmt.Println("No task found matching the selector.")
+			return nil
+		}
 		fmt.Println("No task found. Have you created a task?")
 		return nil
 	}
@@ -741,6 +879,68 @@ func removeTask(ctx *cli.Context) error {
 	return nil
 }
 
+// batchTasks runs the operations of a JSON or YAML batch file, where a task
+// to create is given by its manifest:
+//
+//	atomic: true
+//	operations:
+//	  - action: create
+//	    task: <task manifest>
+//	  - action: stop
+//	    id: <task_id>
+func batchTasks(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		return newUsageError("Incorrect usage", ctx)
+	}
+	path := ctx.Args().First()
+	ext := filepath.Ext(path)
+	file, e := ioutil.ReadFile(path)
+	if e != nil {
+		return fmt.Errorf("File error [%s] - %v\n", ext, e)
+	}
+	file = []byte(os.ExpandEnv(string(file)))
+	switch ext {
+	case ".yaml", ".yml":
+		file, e = yaml.YAMLToJSON(file)
+		if e != nil {
+			return fmt.Errorf("Error parsing YAML file input - %v\n", e)
+		}
+	case ".json":
+	default:
+		return fmt.Errorf("Unsupported file type %s\n", ext)
+	}
+	batch := struct {
+		Atomic     bool                    `json:"atomic"`
+		Operations []client.BatchOperation `json:"operations"`
+	}{}
+	if e = json.Unmarshal(file, &batch); e != nil {
+		showLineWithError(file, e)
+		return fmt.Errorf("Error parsing JSON file input - %v\n", e)
+	}
+
+	r := pClient.BatchTasks(batch.Operations, batch.Atomic || ctx.Bool("atomic"))
+	if r.Err != nil {
+		return fmt.Errorf("Error running batch:\n%v\n", r.Err)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', 0)
+	printFields(w, false, 0, "#", "ACTION", "ID", "NAME", "STATUS", "ERROR")
+	failed := 0
+	for i, res := range r.Results {
+		printFields(w, false, 0, i+1, res.Action, res.ID, res.Name, res.Status, res.Error)
+		if res.Status == "failed" {
+			failed++
+		}
+	}
+	w.Flush()
+	if r.RolledBack {
+		fmt.Println("The tasks created by the batch were removed.")
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d operation(s) of the batch failed\n", failed)
+	}
+	return nil
+}
+
 func exportTask(ctx *cli.Context) error {
 	if len(ctx.Args()) != 1 {
 		return newUsageError("Incorrect usage", ctx)
@@ -807,7 +1007,7 @@ func validateScheduleExists(schedule *client.Schedule) error {
 	if schedule == nil {
 		return fmt.Errorf("Error: Task manifest did not include a schedule")
 	}
-	if *schedule == (client.Schedule{}) {
+	if reflect.DeepEqual(*schedule, client.Schedule{}) {
 		return fmt.Errorf("Error: Task manifest included an empty schedule. Task manifests need to include a schedule.")
 	}
 	return nil
@@ -0,0 +1,192 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ghodss/yaml"
+	"github.com/urfave/cli"
+
+	"github.com/intelsdi-x/snap/core"
+)
+
+// readJSONOrYAMLFile reads a JSON or YAML file, returning its content as JSON.
+// Unlike task manifests, the environment variables of the file are not
+// expanded, as templates are expanded by snapteld when instantiated.
+func readJSONOrYAMLFile(path string) ([]byte, error) {
+	ext := filepath.Ext(path)
+	file, e := ioutil.ReadFile(path)
+	if e != nil {
+		return nil, fmt.Errorf("File error [%s] - %v\n", ext, e)
+	}
+	switch ext {
+	case ".yaml", ".yml":
+		file, e = yaml.YAMLToJSON(file)
+		if e != nil {
+			return nil, fmt.Errorf("Error parsing YAML file input - %v\n", e)
+		}
+	case ".json":
+	default:
+		return nil, fmt.Errorf("Unsupported file type %s\n", ext)
+	}
+	return file, nil
+}
+
+func createTemplate(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		return newUsageError("Incorrect usage", ctx)
+	}
+	file, err := readJSONOrYAMLFile(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	r := pClient.CreateTemplate(file)
+	if r.Err != nil {
+		return fmt.Errorf("Error creating template:\n%v\n", r.Err)
+	}
+	fmt.Println("Template created:")
+	fmt.Printf("Name: %s\n", r.Name)
+	fmt.Printf("Parameters: %d\n", len(r.Parameters))
+	return nil
+}
+
+func listTemplates(ctx *cli.Context) error {
+	r := pClient.GetTemplates()
+	if r.Err != nil {
+		return fmt.Errorf("Error getting templates:\n%v\n", r.Err)
+	}
+	if len(r.Templates) == 0 {
+		fmt.Println("No template found. Have you created a template?")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', 0)
+	printFields(w, false, 0, "NAME", "PARAMETERS", "DESCRIPTION")
+	for _, t := range r.Templates {
+		names := make([]string, len(t.Parameters))
+		for i, p := range t.Parameters {
+			names[i] = p.Name
+		}
+		printFields(w, false, 0, t.Name, strings.Join(names, ","), t.Description)
+	}
+	w.Flush()
+	return nil
+}
+
+func getTemplate(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		return newUsageError("Incorrect usage", ctx)
+	}
+	r := pClient.GetTemplate(ctx.Args().First())
+	if r.Err != nil {
+		return fmt.Errorf("Error getting template:\n%v\n", r.Err)
+	}
+	if ctx.Bool("verbose") {
+		tb, err := json.Marshal(r.TaskTemplate)
+		if err != nil {
+			return fmt.Errorf("Error getting template:\n%v\n", err)
+		}
+		fmt.Println(string(tb))
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', 0)
+	printFields(w, false, 0, "PARAMETER", "TYPE", "DEFAULT", "DESCRIPTION")
+	for _, p := range r.Parameters {
+		typ := p.Type
+		if typ == "" {
+			typ = core.TemplateParamString
+		}
+		def := "(required)"
+		if p.Default != nil {
+			def = fmt.Sprint(p.Default)
+		}
+		printFields(w, false, 0, p.Name, typ, def, p.Description)
+	}
+	w.Flush()
+	return nil
+}
+
+func removeTemplate(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		return newUsageError("Incorrect usage", ctx)
+	}
+	name := ctx.Args().First()
+	if err := pClient.RemoveTemplate(name); err != nil {
+		return fmt.Errorf("Error removing template:\n%v\n", err)
+	}
+	fmt.Println("Template removed:")
+	fmt.Printf("Name: %s\n", name)
+	return nil
+}
+
+// instantiateTemplate creates a task from a template with the values of a
+// values file, overridden by the values given with --param
+func instantiateTemplate(ctx *cli.Context) error {
+	if len(ctx.Args()) > 1 {
+		return newUsageError("Incorrect usage", ctx)
+	}
+	tv := &core.TemplateValues{}
+	if path := ctx.String("values"); path != "" {
+		file, err := readJSONOrYAMLFile(path)
+		if err != nil {
+			return err
+		}
+		if tv, err = core.ReadTemplateValues(bytes.NewReader(file)); err != nil {
+			return fmt.Errorf("Error parsing values file - %v\n", err)
+		}
+	}
+	if len(ctx.Args()) == 1 {
+		tv.Template = ctx.Args().First()
+	}
+	if tv.Template == "" {
+		return newUsageError("Must provide the name of a template", ctx)
+	}
+	if tv.Values == nil {
+		tv.Values = map[string]interface{}{}
+	}
+	for _, param := range ctx.StringSlice("param") {
+		kv := strings.SplitN(param, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return newUsageError(fmt.Sprintf("Invalid parameter '%s', expected key=value", param), ctx)
+		}
+		tv.Values[kv[0]] = kv[1]
+	}
+	var start *bool
+	if ctx.Bool("no-start") {
+		start = new(bool)
+	}
+
+	r := pClient.InstantiateTemplate(tv.Template, tv.Values, start)
+	if r.Err != nil {
+		return fmt.Errorf("Error creating task:\n%v\n", r.Err)
+	}
+	fmt.Println("Task created")
+	fmt.Printf("ID: %s\n", r.ID)
+	fmt.Printf("Name: %s\n", r.Name)
+	fmt.Printf("State: %s\n", r.TaskState)
+	return nil
+}
@@ -30,6 +30,7 @@ import (
 	"time"
 
 	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
 
 	"github.com/intelsdi-x/gomit"
 	"github.com/intelsdi-x/snap/control/plugin"
@@ -427,7 +428,7 @@ func (ap *availablePlugins) getPool(key string) (strategy.Pool, serror.SnapError
 	return pool, nil
 }
 
-func (ap *availablePlugins) collectMetrics(pluginKey string, metricTypes []core.Metric, taskID string) ([]core.Metric, error) {
+func (ap *availablePlugins) collectMetrics(ctx context.Context, pluginKey string, metricTypes []core.Metric, taskID string) ([]core.Metric, error) {
 	var results []core.Metric
 	pool, serr := ap.getPool(pluginKey)
 	if serr != nil {
@@ -468,8 +469,14 @@ func (ap *availablePlugins) collectMetrics(pluginKey string, metricTypes []core.
 		return nil, serror.New(errors.New("unable to cast client to PluginCollectorClient"))
 	}
 
-	// collect metrics
-	metrics, err := cli.CollectMetrics(metricsToCollect)
+	// collect metrics, within the deadline of the job when the client supports it
+	var metrics []core.Metric
+	var err error
+	if ccli, ok := cli.(client.PluginContextClient); ok {
+		metrics, err = ccli.CollectMetricsContext(ctx, metricsToCollect)
+	} else {
+		metrics, err = cli.CollectMetrics(metricsToCollect)
+	}
 	if err != nil {
 		return nil, serror.New(err)
 	}
@@ -547,7 +554,7 @@ func (ap *availablePlugins) streamMetrics(
 	return metricChan, errChan, nil
 }
 
-func (ap *availablePlugins) publishMetrics(metrics []core.Metric, pluginName string, pluginVersion int, config map[string]ctypes.ConfigValue, taskID string) []error {
+func (ap *availablePlugins) publishMetrics(ctx context.Context, metrics []core.Metric, pluginName string, pluginVersion int, config map[string]ctypes.ConfigValue, taskID string) []error {
 	key := fmt.Sprintf("%s"+core.Separator+"%s"+core.Separator+"%d", plugin.PublisherPluginType.String(), pluginName, pluginVersion)
 	pool, serr := ap.getPool(key)
 	if serr != nil {
@@ -570,7 +577,12 @@ func (ap *availablePlugins) publishMetrics(metrics []core.Metric, pluginName str
 		return []error{errors.New("unable to cast client to PluginPublisherClient")}
 	}
 
-	err := cli.Publish(metrics, config)
+	var err error
+	if ccli, ok := cli.(client.PluginContextClient); ok {
+		err = ccli.PublishContext(ctx, metrics, config)
+	} else {
+		err = cli.Publish(metrics, config)
+	}
 	if err != nil {
 		return []error{err}
 	}
@@ -579,7 +591,7 @@ func (ap *availablePlugins) publishMetrics(metrics []core.Metric, pluginName str
 	return nil
 }
 
-func (ap *availablePlugins) processMetrics(metrics []core.Metric, pluginName string, pluginVersion int, config map[string]ctypes.ConfigValue, taskID string) ([]core.Metric, []error) {
+func (ap *availablePlugins) processMetrics(ctx context.Context, metrics []core.Metric, pluginName string, pluginVersion int, config map[string]ctypes.ConfigValue, taskID string) ([]core.Metric, []error) {
 	var errs []error
 	key := fmt.Sprintf("%s"+core.Separator+"%s"+core.Separator+"%d", plugin.ProcessorPluginType.String(), pluginName, pluginVersion)
 	pool, serr := ap.getPool(key)
@@ -604,7 +616,13 @@ func (ap *availablePlugins) processMetrics(metrics []core.Metric, pluginName str
 		return nil, []error{errors.New("unable to cast client to PluginProcessorClient")}
 	}
 
-	mts, errp := cli.Process(metrics, config)
+	var mts []core.Metric
+	var errp error
+	if ccli, ok := cli.(client.PluginContextClient); ok {
+		mts, errp = ccli.ProcessContext(ctx, metrics, config)
+	} else {
+		mts, errp = cli.Process(metrics, config)
+	}
 	if errp != nil {
 		return nil, []error{errp}
 	}
@@ -0,0 +1,204 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package builtin provides the processors and publishers which run inside
+// snapteld. They are referenced in a task workflow like plugins, by a name
+// starting with "builtin-", but no plugin needs to be loaded to use them.
+package builtin
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/intelsdi-x/snap/control/plugin"
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/ctypes"
+)
+
+const (
+	// Prefix is the prefix of the names reserved for built-in plugins
+	Prefix = "builtin-"
+	// Version is the version of all the built-in processors and publishers
+	Version = 1
+)
+
+var (
+	// ErrProcessorNotFound - The error message for a built-in processor which does not exist
+	ErrProcessorNotFound = errors.New("Built-in processor not found")
+	// ErrPublisherNotFound - The error message for a built-in publisher which does not exist
+	ErrPublisherNotFound = errors.New("Built-in publisher not found")
+)
+
+// Processor is a processor running inside snapteld. It processes metrics
+// the same way as the ProcessMetrics function of a processor plugin.
+type Processor interface {
+	// Process returns the processed metrics
+	Process(metrics []core.Metric, config map[string]ctypes.ConfigValue, taskID string) ([]core.Metric, error)
+	// ValidateConfig returns an error if the config of a task cannot be used by the processor
+	ValidateConfig(config map[string]ctypes.ConfigValue) error
+}
+
+// Publisher is a publisher running inside snapteld. It publishes metrics
+// the same way as the PublishMetrics function of a publisher plugin.
+type Publisher interface {
+	// Publish publishes the metrics
+	Publish(metrics []core.Metric, config map[string]ctypes.ConfigValue, taskID string) error
+	// ValidateConfig returns an error if the config of a task cannot be used by the publisher
+	ValidateConfig(config map[string]ctypes.ConfigValue) error
+}
+
+var processors = map[string]Processor{
+	Prefix + "rename":    &renameProcessor{},
+	Prefix + "tag":       &tagProcessor{},
+	Prefix + "unit":      &unitProcessor{},
+	Prefix + "rate":      newRateProcessor(),
+	Prefix + "threshold": &thresholdProcessor{},
+}
+
+// prometheus is the built-in publisher which exposes the latest metrics of
+// the tasks publishing to it, see WritePrometheus
+var prometheus = newPrometheusPublisher()
+
+var publishers = map[string]Publisher{
+	Prefix + "prometheus": prometheus,
+}
+
+// IsBuiltin returns true if the name is reserved for built-in plugins
+func IsBuiltin(name string) bool {
+	return strings.HasPrefix(strings.ToLower(name), Prefix)
+}
+
+// Get returns the built-in processor with the given name. A version below 1
+// selects the latest version, like it does for plugins.
+func Get(name string, version int) (Processor, error) {
+	p, ok := processors[strings.ToLower(name)]
+	if !ok || version > Version {
+		return nil, fmt.Errorf("%v: %s version %d", ErrProcessorNotFound, name, version)
+	}
+	return p, nil
+}
+
+// GetPublisher returns the built-in publisher with the given name. A version
+// below 1 selects the latest version, like it does for plugins.
+func GetPublisher(name string, version int) (Publisher, error) {
+	p, ok := publishers[strings.ToLower(name)]
+	if !ok || version > Version {
+		return nil, fmt.Errorf("%v: %s version %d", ErrPublisherNotFound, name, version)
+	}
+	return p, nil
+}
+
+// Names returns the names of the built-in processors, sorted
+func Names() []string {
+	names := make([]string, 0, len(processors))
+	for name := range processors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// copyMetric returns a copy of a metric which can be modified
+func copyMetric(m core.Metric) plugin.MetricType {
+	tags := make(map[string]string, len(m.Tags()))
+	for k, v := range m.Tags() {
+		tags[k] = v
+	}
+	return plugin.MetricType{
+		Namespace_:          m.Namespace(),
+		LastAdvertisedTime_: m.LastAdvertisedTime(),
+		Version_:            m.Version(),
+		Config_:             m.Config(),
+		Data_:               m.Data(),
+		Tags_:               tags,
+		Unit_:               m.Unit(),
+		Description_:        m.Description(),
+		Timestamp_:          m.Timestamp(),
+	}
+}
+
+// getString returns the string value of a config key, and false if it is not set
+func getString(config map[string]ctypes.ConfigValue, key string) (string, bool, error) {
+	cv, ok := config[key]
+	if !ok {
+		return "", false, nil
+	}
+	s, ok := cv.(ctypes.ConfigValueStr)
+	if !ok {
+		return "", false, fmt.Errorf("config item '%s' must be a string", key)
+	}
+	return s.Value, true, nil
+}
+
+// getFloat returns the numeric value of a config key, and false if it is not set.
+// Integers are accepted since whole numbers in a task manifest are read as integers.
+func getFloat(config map[string]ctypes.ConfigValue, key string) (float64, bool, error) {
+	cv, ok := config[key]
+	if !ok {
+		return 0, false, nil
+	}
+	switch v := cv.(type) {
+	case ctypes.ConfigValueFloat:
+		return v.Value, true, nil
+	case ctypes.ConfigValueInt:
+		return float64(v.Value), true, nil
+	}
+	return 0, false, fmt.Errorf("config item '%s' must be a number", key)
+}
+
+// getBool returns the boolean value of a config key, false if it is not set
+func getBool(config map[string]ctypes.ConfigValue, key string) (bool, error) {
+	cv, ok := config[key]
+	if !ok {
+		return false, nil
+	}
+	b, ok := cv.(ctypes.ConfigValueBool)
+	if !ok {
+		return false, fmt.Errorf("config item '%s' must be a boolean", key)
+	}
+	return b.Value, nil
+}
+
+// regexpCache keeps the regular expressions of the configs of tasks so they
+// are not compiled each time metrics are processed
+type regexpCache struct {
+	sync.Mutex
+	table map[string]*regexp.Regexp
+}
+
+func (c *regexpCache) get(expr string) (*regexp.Regexp, error) {
+	c.Lock()
+	defer c.Unlock()
+	if re, ok := c.table[expr]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	if c.table == nil {
+		c.table = map[string]*regexp.Regexp{}
+	}
+	c.table[expr] = re
+	return re, nil
+}
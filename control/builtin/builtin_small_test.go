@@ -0,0 +1,274 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builtin
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/intelsdi-x/snap/control/plugin"
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/ctypes"
+	"github.com/intelsdi-x/snap/pkg/telemetry"
+)
+
+func newMetric(data interface{}, ts time.Time, ns ...string) core.Metric {
+	return plugin.MetricType{
+		Namespace_: core.NewNamespace(ns...),
+		Data_:      data,
+		Tags_:      map[string]string{"host": "web-01"},
+		Timestamp_: ts,
+	}
+}
+
+func process(name string, metrics []core.Metric, config map[string]ctypes.ConfigValue) []core.Metric {
+	p, err := Get(name, -1)
+	So(err, ShouldBeNil)
+	So(p.ValidateConfig(config), ShouldBeNil)
+	mts, err := p.Process(metrics, config, "task-1")
+	So(err, ShouldBeNil)
+	return mts
+}
+
+func TestGet(t *testing.T) {
+	Convey("Built-in processors", t, func() {
+		So(Names(), ShouldResemble, []string{"builtin-rate", "builtin-rename", "builtin-tag", "builtin-threshold", "builtin-unit"})
+		So(IsBuiltin("builtin-rename"), ShouldBeTrue)
+		So(IsBuiltin("passthru"), ShouldBeFalse)
+		_, err := Get("builtin-rename", Version)
+		So(err, ShouldBeNil)
+		_, err = Get("builtin-rename", Version+1)
+		So(err, ShouldNotBeNil)
+		_, err = Get("builtin-unknown", -1)
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestRename(t *testing.T) {
+	Convey("Rename rewrites namespaces", t, func() {
+		ns := core.NewNamespace("intel", "procfs").AddDynamicElement("cpu_id", "cpu").AddStaticElement("user")
+		ns[2].Value = "0"
+		m := plugin.MetricType{Namespace_: ns, Data_: 1}
+		mts := process("builtin-rename", []core.Metric{m, newMetric(2, time.Now(), "intel", "disk")}, map[string]ctypes.ConfigValue{
+			"pattern":     ctypes.ConfigValueStr{Value: "^/intel/procfs/(.*)/user$"},
+			"replacement": ctypes.ConfigValueStr{Value: "/intel/procfs/$1/user_percentage"},
+		})
+		So(len(mts), ShouldEqual, 2)
+		So(mts[0].Namespace().String(), ShouldEqual, "/intel/procfs/0/user_percentage")
+		So(mts[0].Namespace()[2].IsDynamic(), ShouldBeTrue)
+		So(mts[1].Namespace().String(), ShouldEqual, "/intel/disk")
+		Convey("and rejects an invalid pattern", func() {
+			p, _ := Get("builtin-rename", -1)
+			So(p.ValidateConfig(map[string]ctypes.ConfigValue{
+				"pattern":     ctypes.ConfigValueStr{Value: "("},
+				"replacement": ctypes.ConfigValueStr{Value: ""},
+			}), ShouldNotBeNil)
+			So(p.ValidateConfig(map[string]ctypes.ConfigValue{}), ShouldNotBeNil)
+		})
+	})
+}
+
+func TestTag(t *testing.T) {
+	Convey("Tag adds and drops tags", t, func() {
+		m := newMetric(1, time.Now(), "intel", "foo")
+		mts := process("builtin-tag", []core.Metric{m}, map[string]ctypes.ConfigValue{
+			"add":  ctypes.ConfigValueStr{Value: "env=prod, dc=eu"},
+			"drop": ctypes.ConfigValueStr{Value: "host"},
+		})
+		So(mts[0].Tags(), ShouldResemble, map[string]string{"env": "prod", "dc": "eu"})
+		So(m.Tags(), ShouldResemble, map[string]string{"host": "web-01"})
+		Convey("and rejects an invalid config", func() {
+			p, _ := Get("builtin-tag", -1)
+			So(p.ValidateConfig(map[string]ctypes.ConfigValue{}), ShouldNotBeNil)
+			So(p.ValidateConfig(map[string]ctypes.ConfigValue{"add": ctypes.ConfigValueStr{Value: "env"}}), ShouldNotBeNil)
+		})
+	})
+}
+
+func TestUnit(t *testing.T) {
+	Convey("Unit converts numeric data", t, func() {
+		Convey("between known units", func() {
+			mts := process("builtin-unit", []core.Metric{newMetric(2048, time.Now(), "intel", "mem")}, map[string]ctypes.ConfigValue{
+				"from": ctypes.ConfigValueStr{Value: "B"},
+				"to":   ctypes.ConfigValueStr{Value: "KiB"},
+			})
+			So(mts[0].Data(), ShouldEqual, 2.0)
+			So(mts[0].Unit(), ShouldEqual, "KiB")
+			mts = process("builtin-unit", []core.Metric{newMetric(100, time.Now(), "intel", "temp")}, map[string]ctypes.ConfigValue{
+				"from": ctypes.ConfigValueStr{Value: "C"},
+				"to":   ctypes.ConfigValueStr{Value: "F"},
+			})
+			So(mts[0].Data(), ShouldAlmostEqual, 212.0, 1e-9)
+		})
+		Convey("with a factor and an offset", func() {
+			mts := process("builtin-unit", []core.Metric{newMetric(0.5, time.Now(), "intel", "load"), newMetric("n/a", time.Now(), "intel", "state")}, map[string]ctypes.ConfigValue{
+				"factor": ctypes.ConfigValueInt{Value: 100},
+				"unit":   ctypes.ConfigValueStr{Value: "%"},
+			})
+			So(mts[0].Data(), ShouldEqual, 50.0)
+			So(mts[0].Unit(), ShouldEqual, "%")
+			So(mts[1].Data(), ShouldEqual, "n/a")
+		})
+		Convey("and rejects incompatible units", func() {
+			p, _ := Get("builtin-unit", -1)
+			So(p.ValidateConfig(map[string]ctypes.ConfigValue{
+				"from": ctypes.ConfigValueStr{Value: "B"},
+				"to":   ctypes.ConfigValueStr{Value: "s"},
+			}), ShouldNotBeNil)
+			So(p.ValidateConfig(map[string]ctypes.ConfigValue{}), ShouldNotBeNil)
+		})
+	})
+}
+
+func TestRate(t *testing.T) {
+	Convey("Rate computes the rate of change of metrics", t, func() {
+		now := time.Now()
+		config := map[string]ctypes.ConfigValue{"unit": ctypes.ConfigValueStr{Value: "B/s"}}
+		mts := process("builtin-rate", []core.Metric{newMetric(100, now, "intel", "rx")}, config)
+		So(mts, ShouldBeEmpty)
+		mts = process("builtin-rate", []core.Metric{newMetric(400, now.Add(10*time.Second), "intel", "rx")}, config)
+		So(len(mts), ShouldEqual, 1)
+		So(mts[0].Data(), ShouldEqual, 30.0)
+		So(mts[0].Unit(), ShouldEqual, "B/s")
+		Convey("dropping counter resets", func() {
+			mts := process("builtin-rate", []core.Metric{newMetric(10, now.Add(20*time.Second), "intel", "rx")}, config)
+			So(mts, ShouldBeEmpty)
+		})
+		Convey("per the given duration", func() {
+			config["per"] = ctypes.ConfigValueStr{Value: "1m"}
+			mts := process("builtin-rate", []core.Metric{newMetric(500, now.Add(20*time.Second), "intel", "rx")}, config)
+			So(mts[0].Data(), ShouldEqual, 600.0)
+		})
+		Convey("separately for each task", func() {
+			p, _ := Get("builtin-rate", -1)
+			mts, err := p.Process([]core.Metric{newMetric(500, now.Add(20*time.Second), "intel", "rx")}, config, "task-2")
+			So(err, ShouldBeNil)
+			So(mts, ShouldBeEmpty)
+		})
+	})
+}
+
+func TestThreshold(t *testing.T) {
+	Convey("Threshold keeps metrics within a range", t, func() {
+		metrics := []core.Metric{
+			newMetric(5, time.Now(), "intel", "a"),
+			newMetric(50.5, time.Now(), "intel", "b"),
+			newMetric(95, time.Now(), "intel", "c"),
+			newMetric("high", time.Now(), "intel", "d"),
+		}
+		mts := process("builtin-threshold", metrics, map[string]ctypes.ConfigValue{
+			"min": ctypes.ConfigValueInt{Value: 10},
+			"max": ctypes.ConfigValueFloat{Value: 90.5},
+		})
+		So(len(mts), ShouldEqual, 1)
+		So(mts[0].Data(), ShouldEqual, 50.5)
+		mts = process("builtin-threshold", metrics, map[string]ctypes.ConfigValue{
+			"min":    ctypes.ConfigValueInt{Value: 10},
+			"max":    ctypes.ConfigValueFloat{Value: 90.5},
+			"invert": ctypes.ConfigValueBool{Value: true},
+		})
+		So(len(mts), ShouldEqual, 2)
+		p, _ := Get("builtin-threshold", -1)
+		So(p.ValidateConfig(map[string]ctypes.ConfigValue{}), ShouldNotBeNil)
+		So(p.ValidateConfig(map[string]ctypes.ConfigValue{"min": ctypes.ConfigValueStr{Value: "1"}}), ShouldNotBeNil)
+	})
+}
+
+func TestPrometheus(t *testing.T) {
+	Convey("Prometheus exposes the latest published metrics", t, func() {
+		p, err := GetPublisher("builtin-prometheus", -1)
+		So(err, ShouldBeNil)
+		So(p.ValidateConfig(map[string]ctypes.ConfigValue{"expire": ctypes.ConfigValueStr{Value: "soon"}}), ShouldNotBeNil)
+		_, err = GetPublisher("builtin-rename", -1)
+		So(err, ShouldNotBeNil)
+
+		ns := core.NewNamespace("intel", "procfs").AddDynamicElement("cpu_id", "cpu").AddStaticElement("user-time")
+		ns[2].Value = "0"
+		cpu := plugin.MetricType{Namespace_: ns, Data_: 12.5, Tags_: map[string]string{"host": `web "01"`}, Description_: "CPU time"}
+		So(p.Publish([]core.Metric{
+			cpu,
+			newMetric(1024, time.Now(), "intel", "mem", "free"),
+			newMetric(true, time.Now(), "intel", "up"),
+			newMetric("n/a", time.Now(), "intel", "state"),
+		}, nil, "task-1"), ShouldBeNil)
+		cpu.Data_ = 15
+		So(p.Publish([]core.Metric{cpu}, nil, "task-2"), ShouldBeNil)
+
+		var buf bytes.Buffer
+		So(WritePrometheus(&buf), ShouldBeNil)
+		So(buf.String(), ShouldEqual, `# TYPE intel_mem_free untyped
+intel_mem_free{host="web-01",snap_task_id="task-1"} 1024
+# HELP intel_procfs_user_time CPU time
+# TYPE intel_procfs_user_time untyped
+intel_procfs_user_time{cpu_id="0",host="web \"01\"",snap_task_id="task-1"} 12.5
+intel_procfs_user_time{cpu_id="0",host="web \"01\"",snap_task_id="task-2"} 15
+# TYPE intel_up untyped
+intel_up{host="web-01",snap_task_id="task-1"} 1
+`)
+		Convey("until they expire", func() {
+			So(p.Publish([]core.Metric{newMetric(1, time.Now(), "intel", "expired")}, map[string]ctypes.ConfigValue{
+				"expire": ctypes.ConfigValueStr{Value: "1ns"},
+			}, "task-1"), ShouldBeNil)
+			time.Sleep(time.Millisecond)
+			buf.Reset()
+			So(WritePrometheus(&buf), ShouldBeNil)
+			So(buf.String(), ShouldNotContainSubstring, "intel_expired")
+		})
+		Convey("and drop the expired ones when publishing", func() {
+			expire := map[string]ctypes.ConfigValue{"expire": ctypes.ConfigValueStr{Value: "1ns"}}
+			So(p.Publish([]core.Metric{newMetric(1, time.Now(), "intel", "expired")}, expire, "task-3"), ShouldBeNil)
+			time.Sleep(time.Millisecond)
+			So(p.Publish([]core.Metric{newMetric(1, time.Now(), "intel", "fresh")}, expire, "task-3"), ShouldBeNil)
+			prometheus.Lock()
+			_, ok := prometheus.series[`intel_expired{host="web-01",snap_task_id="task-3"}`]
+			prometheus.Unlock()
+			So(ok, ShouldBeFalse)
+		})
+	})
+}
+
+func TestTelemetry(t *testing.T) {
+	telemetry.Add(2, "control", "plugins", "psutil", "restarts")
+	telemetry.Add(1, "control", "cache", "hits")
+	Convey("Telemetry exposes the metrics of snapteld", t, func() {
+		mts := TelemetryMetricTypes()
+		So(len(mts), ShouldEqual, len(telemetry.Definitions))
+		So(mts[0].Namespace().String(), ShouldEqual, "/intel/snap/scheduler/tasks/*/hits")
+		Convey("to tasks", func() {
+			requested := core.NewNamespace("intel", "snap", "control", "plugins").AddDynamicElement("plugin_name", "").AddStaticElement("restarts")
+			collected := CollectTelemetry([]core.Metric{plugin.MetricType{Namespace_: requested}})
+			So(len(collected), ShouldEqual, 1)
+			So(collected[0].Namespace().String(), ShouldEqual, "/intel/snap/control/plugins/psutil/restarts")
+			So(collected[0].Namespace()[4].IsDynamic(), ShouldBeTrue)
+			So(collected[0].Data(), ShouldEqual, 2.0)
+		})
+		Convey("to Prometheus", func() {
+			var buf bytes.Buffer
+			So(WritePrometheus(&buf), ShouldBeNil)
+			So(buf.String(), ShouldContainSubstring, `intel_snap_control_plugins_restarts{plugin_name="psutil"} 2`)
+			So(buf.String(), ShouldContainSubstring, "intel_snap_control_cache_hits 1")
+		})
+	})
+}
@@ -0,0 +1,265 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builtin
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/ctypes"
+	"github.com/intelsdi-x/snap/pkg/metricfilter"
+)
+
+const (
+	// PrometheusContentType is the content type of the Prometheus text format
+	PrometheusContentType = "text/plain; version=0.0.4"
+	// defaultPrometheusExpire is how long a metric is exposed after it was last published
+	defaultPrometheusExpire = 5 * time.Minute
+	// promTaskIDLabel is the label holding the ID of the task which published
+	// a metric, so that the series of different tasks are kept apart
+	promTaskIDLabel = "snap_task_id"
+)
+
+type promSeries struct {
+	name   string
+	labels string
+	help   string
+	value  float64
+	expire time.Time
+}
+
+// prometheusPublisher keeps the latest value of the numeric metrics it
+// receives so that they can be scraped by Prometheus. The static elements of
+// a namespace make the name of the metric, while its dynamic elements, its
+// tags and the ID of the task which published it make the labels. A metric is no longer exposed when it has not
+// been published for the duration of the "expire" config item (5m by default).
+type prometheusPublisher struct {
+	sync.Mutex
+	series map[string]*promSeries
+}
+
+func newPrometheusPublisher() *prometheusPublisher {
+	return &prometheusPublisher{series: map[string]*promSeries{}}
+}
+
+func (p *prometheusPublisher) config(config map[string]ctypes.ConfigValue) (time.Duration, error) {
+	expire, ok, err := getString(config, "expire")
+	if err != nil || !ok {
+		return defaultPrometheusExpire, err
+	}
+	d, err := time.ParseDuration(expire)
+	if err != nil {
+		return 0, fmt.Errorf("config item 'expire' is not a valid duration: %v", err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("config item 'expire' must be greater than 0")
+	}
+	return d, nil
+}
+
+func (p *prometheusPublisher) ValidateConfig(config map[string]ctypes.ConfigValue) error {
+	_, err := p.config(config)
+	return err
+}
+
+func (p *prometheusPublisher) Publish(metrics []core.Metric, config map[string]ctypes.ConfigValue, taskID string) error {
+	expire, err := p.config(config)
+	if err != nil {
+		return err
+	}
+	p.Lock()
+	defer p.Unlock()
+	now := time.Now()
+	p.prune(now)
+	for _, m := range metrics {
+		v, ok := metricfilter.ToFloat(m.Data())
+		if !ok {
+			if b, isBool := m.Data().(bool); isBool {
+				v, ok = 0, true
+				if b {
+					v = 1
+				}
+			}
+		}
+		if !ok {
+			continue
+		}
+		name, labels := promNameAndLabels(m, taskID)
+		if name == "" {
+			continue
+		}
+		key := name + labels
+		s, ok := p.series[key]
+		if !ok {
+			s = &promSeries{name: name, labels: labels}
+			p.series[key] = s
+		}
+		s.help = m.Description()
+		s.value = v
+		s.expire = now.Add(expire)
+	}
+	return nil
+}
+
+// WritePrometheus writes the metrics published to builtin-prometheus by all
+// the tasks, followed by the metrics of snapteld itself, in the Prometheus
+// text format
+func WritePrometheus(w io.Writer) error {
+	return prometheus.write(w, telemetrySeries())
+}
+
+// prune removes the metrics which have expired. The caller must hold the lock.
+func (p *prometheusPublisher) prune(now time.Time) {
+	for key, s := range p.series {
+		if now.After(s.expire) {
+			delete(p.series, key)
+		}
+	}
+}
+
+// write writes the metrics which have not expired and the extra series,
+// grouped by name
+func (p *prometheusPublisher) write(w io.Writer, extra []*promSeries) error {
+	p.Lock()
+	p.prune(time.Now())
+	series := make([]*promSeries, 0, len(p.series)+len(extra))
+	for _, s := range p.series {
+		c := *s
+		series = append(series, &c)
+	}
+	p.Unlock()
+	series = append(series, extra...)
+	sort.Sort(promSeriesByName(series))
+
+	bw := bufio.NewWriter(w)
+	for i, s := range series {
+		if i == 0 || series[i-1].name != s.name {
+			if s.help != "" {
+				fmt.Fprintf(bw, "# HELP %s %s\n", s.name, escapeHelp(s.help))
+			}
+			fmt.Fprintf(bw, "# TYPE %s untyped\n", s.name)
+		}
+		fmt.Fprintf(bw, "%s%s %s\n", s.name, s.labels, formatPromValue(s.value))
+	}
+	return bw.Flush()
+}
+
+type promSeriesByName []*promSeries
+
+func (s promSeriesByName) Len() int      { return len(s) }
+func (s promSeriesByName) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s promSeriesByName) Less(i, j int) bool {
+	if s[i].name != s[j].name {
+		return s[i].name < s[j].name
+	}
+	return s[i].labels < s[j].labels
+}
+
+// promNameAndLabels returns the name of a metric, made of the static
+// elements of its namespace, and its labels in the exposition format,
+// made of its tags, the dynamic elements of its namespace and the ID of the
+// task which published it, if any
+func promNameAndLabels(m core.Metric, taskID string) (string, string) {
+	var elements []string
+	labels := map[string]string{}
+	for k, v := range m.Tags() {
+		labels[promName(k, false)] = v
+	}
+	for _, e := range m.Namespace() {
+		if e.IsDynamic() {
+			labels[promName(e.Name, false)] = e.Value
+			continue
+		}
+		elements = append(elements, e.Value)
+	}
+	if taskID != "" {
+		labels[promTaskIDLabel] = taskID
+	}
+	if len(elements) == 0 {
+		return "", ""
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var buf bytes.Buffer
+	for i, k := range keys {
+		if i == 0 {
+			buf.WriteString("{")
+		} else {
+			buf.WriteString(",")
+		}
+		fmt.Fprintf(&buf, "%s=\"%s\"", k, escapeLabelValue(labels[k]))
+	}
+	if len(keys) > 0 {
+		buf.WriteString("}")
+	}
+	return promName(strings.Join(elements, "_"), true), buf.String()
+}
+
+// promName replaces the characters which are not allowed in the name of a
+// metric or of a label by underscores
+func promName(s string, colons bool) string {
+	var buf bytes.Buffer
+	for i, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_':
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				buf.WriteRune('_')
+			}
+		case r == ':' && colons:
+		default:
+			r = '_'
+		}
+		buf.WriteRune(r)
+	}
+	return buf.String()
+}
+
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+func escapeLabelValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(s)
+}
+
+func formatPromValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,165 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builtin
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/ctypes"
+	"github.com/intelsdi-x/snap/pkg/metricfilter"
+)
+
+const (
+	// rateStateTTL is how long the last sample of a metric is kept when the
+	// metric is no longer processed
+	rateStateTTL = 10 * time.Minute
+)
+
+type rateSample struct {
+	value     float64
+	timestamp time.Time
+	seen      time.Time
+}
+
+// rateProcessor replaces the data of numeric metrics by its rate of change
+// since the previous sample of the same metric in the same task, per the
+// duration of the "per" config item (1s by default). The first sample of a
+// metric only sets the reference and is dropped, as are samples which
+// decrease, like counters being reset, unless "allow_negative" is true.
+type rateProcessor struct {
+	sync.Mutex
+	samples   map[string]rateSample
+	lastPurge time.Time
+}
+
+func newRateProcessor() *rateProcessor {
+	return &rateProcessor{samples: map[string]rateSample{}}
+}
+
+type rateConfig struct {
+	per           time.Duration
+	allowNegative bool
+	unit          string
+}
+
+func (r *rateProcessor) config(config map[string]ctypes.ConfigValue) (*rateConfig, error) {
+	c := &rateConfig{per: time.Second}
+	per, ok, err := getString(config, "per")
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		if c.per, err = time.ParseDuration(per); err != nil {
+			return nil, fmt.Errorf("config item 'per' is not a valid duration: %v", err)
+		}
+		if c.per <= 0 {
+			return nil, fmt.Errorf("config item 'per' must be greater than 0")
+		}
+	}
+	if c.allowNegative, err = getBool(config, "allow_negative"); err != nil {
+		return nil, err
+	}
+	if c.unit, _, err = getString(config, "unit"); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (r *rateProcessor) ValidateConfig(config map[string]ctypes.ConfigValue) error {
+	_, err := r.config(config)
+	return err
+}
+
+func (r *rateProcessor) Process(metrics []core.Metric, config map[string]ctypes.ConfigValue, taskID string) ([]core.Metric, error) {
+	c, err := r.config(config)
+	if err != nil {
+		return nil, err
+	}
+	r.Lock()
+	defer r.Unlock()
+	now := time.Now()
+	processed := make([]core.Metric, 0, len(metrics))
+	for _, m := range metrics {
+		v, ok := metricfilter.ToFloat(m.Data())
+		if !ok {
+			continue
+		}
+		ts := m.Timestamp()
+		if ts.IsZero() {
+			ts = now
+		}
+		key := sampleKey(taskID, m)
+		prev, ok := r.samples[key]
+		r.samples[key] = rateSample{value: v, timestamp: ts, seen: now}
+		if !ok {
+			continue
+		}
+		elapsed := ts.Sub(prev.timestamp)
+		if elapsed <= 0 {
+			continue
+		}
+		rate := (v - prev.value) * float64(c.per) / float64(elapsed)
+		if rate < 0 && !c.allowNegative {
+			continue
+		}
+		mt := copyMetric(m)
+		mt.Data_ = rate
+		if c.unit != "" {
+			mt.Unit_ = c.unit
+		}
+		processed = append(processed, mt)
+	}
+	r.purge(now)
+	return processed, nil
+}
+
+// purge drops the samples of metrics which are no longer processed
+func (r *rateProcessor) purge(now time.Time) {
+	if now.Sub(r.lastPurge) < time.Minute {
+		return
+	}
+	r.lastPurge = now
+	for key, s := range r.samples {
+		if now.Sub(s.seen) > rateStateTTL {
+			delete(r.samples, key)
+		}
+	}
+}
+
+// sampleKey identifies a metric of a task by its namespace and tags
+func sampleKey(taskID string, m core.Metric) string {
+	var buf bytes.Buffer
+	buf.WriteString(taskID)
+	buf.WriteString(m.Namespace().String())
+	keys := make([]string, 0, len(m.Tags()))
+	for k := range m.Tags() {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&buf, ";%s=%s", k, m.Tags()[k])
+	}
+	return buf.String()
+}
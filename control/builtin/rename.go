@@ -0,0 +1,95 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builtin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/ctypes"
+)
+
+// renameProcessor rewrites the namespace of metrics. The namespace, joined
+// with "/", is matched against the regular expression of the "pattern" config
+// item and replaced with the "replacement" config item, which may refer to
+// the groups of the expression as $1, $2...
+type renameProcessor struct {
+	regexps regexpCache
+}
+
+func (r *renameProcessor) config(config map[string]ctypes.ConfigValue) (string, string, error) {
+	pattern, ok, err := getString(config, "pattern")
+	if err != nil {
+		return "", "", err
+	}
+	if !ok || pattern == "" {
+		return "", "", fmt.Errorf("required key missing (pattern)")
+	}
+	replacement, ok, err := getString(config, "replacement")
+	if err != nil {
+		return "", "", err
+	}
+	if !ok {
+		return "", "", fmt.Errorf("required key missing (replacement)")
+	}
+	if _, err := r.regexps.get(pattern); err != nil {
+		return "", "", fmt.Errorf("config item 'pattern' is not a valid regular expression: %v", err)
+	}
+	return pattern, replacement, nil
+}
+
+func (r *renameProcessor) ValidateConfig(config map[string]ctypes.ConfigValue) error {
+	_, _, err := r.config(config)
+	return err
+}
+
+func (r *renameProcessor) Process(metrics []core.Metric, config map[string]ctypes.ConfigValue, taskID string) ([]core.Metric, error) {
+	pattern, replacement, err := r.config(config)
+	if err != nil {
+		return nil, err
+	}
+	re, _ := r.regexps.get(pattern)
+	processed := make([]core.Metric, 0, len(metrics))
+	for _, m := range metrics {
+		ns := "/" + strings.Join(m.Namespace().Strings(), "/")
+		renamed := re.ReplaceAllString(ns, replacement)
+		if renamed == ns {
+			processed = append(processed, m)
+			continue
+		}
+		mt := copyMetric(m)
+		mt.Namespace_ = rewriteNamespace(m.Namespace(), strings.Split(strings.Trim(renamed, "/"), "/"))
+		processed = append(processed, mt)
+	}
+	return processed, nil
+}
+
+// rewriteNamespace returns the namespace with the given elements. Elements
+// left unchanged keep their name and description, so dynamic elements stay dynamic.
+func rewriteNamespace(old core.Namespace, elements []string) core.Namespace {
+	ns := core.NewNamespace(elements...)
+	for i := range ns {
+		if i < len(old) && old[i].Value == ns[i].Value {
+			ns[i] = old[i]
+		}
+	}
+	return ns
+}
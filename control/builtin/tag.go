@@ -0,0 +1,92 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builtin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/ctypes"
+)
+
+// tagProcessor adds tags to and drops tags from metrics. The "add" config
+// item is a comma separated list of key=value pairs and the "drop" config
+// item a comma separated list of keys.
+type tagProcessor struct{}
+
+func (t *tagProcessor) config(config map[string]ctypes.ConfigValue) (map[string]string, []string, error) {
+	add, _, err := getString(config, "add")
+	if err != nil {
+		return nil, nil, err
+	}
+	drop, _, err := getString(config, "drop")
+	if err != nil {
+		return nil, nil, err
+	}
+	tags := map[string]string{}
+	for _, pair := range splitList(add) {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return nil, nil, fmt.Errorf("config item 'add' must be a list of key=value pairs, found '%s'", pair)
+		}
+		tags[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	keys := splitList(drop)
+	if len(tags) == 0 && len(keys) == 0 {
+		return nil, nil, fmt.Errorf("config item 'add' or 'drop' is required")
+	}
+	return tags, keys, nil
+}
+
+func (t *tagProcessor) ValidateConfig(config map[string]ctypes.ConfigValue) error {
+	_, _, err := t.config(config)
+	return err
+}
+
+func (t *tagProcessor) Process(metrics []core.Metric, config map[string]ctypes.ConfigValue, taskID string) ([]core.Metric, error) {
+	add, drop, err := t.config(config)
+	if err != nil {
+		return nil, err
+	}
+	processed := make([]core.Metric, len(metrics))
+	for i, m := range metrics {
+		mt := copyMetric(m)
+		for _, k := range drop {
+			delete(mt.Tags_, k)
+		}
+		for k, v := range add {
+			mt.Tags_[k] = v
+		}
+		processed[i] = mt
+	}
+	return processed, nil
+}
+
+// splitList splits a comma separated list, ignoring empty items
+func splitList(s string) []string {
+	items := []string{}
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,111 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builtin
+
+import (
+	"time"
+
+	"github.com/intelsdi-x/snap/control/plugin"
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/pkg/telemetry"
+)
+
+// TelemetryCollector is the name of the built-in collector of the metrics
+// snapteld exposes about itself
+const TelemetryCollector = Prefix + "telemetry"
+
+// TelemetryMetricTypes returns the metrics of snapteld to add to the metric catalog
+func TelemetryMetricTypes() []core.Metric {
+	mts := make([]core.Metric, len(telemetry.Definitions))
+	for i, d := range telemetry.Definitions {
+		mts[i] = plugin.MetricType{
+			Namespace_:   d.Namespace,
+			Version_:     Version,
+			Description_: d.Description,
+			Unit_:        d.Unit,
+		}
+	}
+	return mts
+}
+
+// CollectTelemetry returns the current values of the requested metrics of
+// snapteld. The dynamic elements of a requested namespace match any value.
+func CollectTelemetry(mts []core.Metric) []core.Metric {
+	samples := telemetry.Snapshot()
+	now := time.Now()
+	collected := []core.Metric{}
+	for _, mt := range mts {
+		for _, s := range samples {
+			ns, ok := matchTelemetry(mt.Namespace(), s)
+			if !ok {
+				continue
+			}
+			collected = append(collected, plugin.MetricType{
+				Namespace_:   ns,
+				Version_:     Version,
+				Config_:      mt.Config(),
+				Data_:        s.Value,
+				Tags_:        mt.Tags(),
+				Unit_:        mt.Unit(),
+				Description_: mt.Description(),
+				Timestamp_:   now,
+			})
+		}
+	}
+	return collected
+}
+
+// matchTelemetry returns the namespace of a sample if it matches the requested namespace
+func matchTelemetry(requested core.Namespace, s telemetry.Sample) (core.Namespace, bool) {
+	values := append(append([]string{}, telemetry.Prefix...), s.Namespace...)
+	if len(values) != len(requested) {
+		return nil, false
+	}
+	ns := make(core.Namespace, len(requested))
+	copy(ns, requested)
+	for i, e := range requested {
+		if e.IsDynamic() || e.Value == "*" {
+			ns[i].Value = values[i]
+			continue
+		}
+		if e.Value != values[i] {
+			return nil, false
+		}
+	}
+	return ns, true
+}
+
+// telemetrySeries returns the current values of the metrics of snapteld to
+// be exposed along with the metrics published to builtin-prometheus
+func telemetrySeries() []*promSeries {
+	var series []*promSeries
+	for _, s := range telemetry.Snapshot() {
+		for _, d := range telemetry.Definitions {
+			ns, ok := matchTelemetry(d.Namespace, s)
+			if !ok {
+				continue
+			}
+			name, labels := promNameAndLabels(plugin.MetricType{Namespace_: ns}, "")
+			series = append(series, &promSeries{name: name, labels: labels, help: d.Description, value: s.Value})
+			break
+		}
+	}
+	return series
+}
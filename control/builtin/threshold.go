@@ -0,0 +1,92 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builtin
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/ctypes"
+	"github.com/intelsdi-x/snap/pkg/metricfilter"
+)
+
+// thresholdProcessor keeps the numeric metrics whose data is between the
+// "min" and "max" config items, inclusive. With "invert" set to true it keeps
+// the metrics outside of this range instead. Metrics whose data is not a
+// number are dropped.
+type thresholdProcessor struct{}
+
+type thresholdConfig struct {
+	min    float64
+	max    float64
+	invert bool
+}
+
+func (t *thresholdProcessor) config(config map[string]ctypes.ConfigValue) (*thresholdConfig, error) {
+	c := &thresholdConfig{min: math.Inf(-1), max: math.Inf(1)}
+	min, hasMin, err := getFloat(config, "min")
+	if err != nil {
+		return nil, err
+	}
+	max, hasMax, err := getFloat(config, "max")
+	if err != nil {
+		return nil, err
+	}
+	if !hasMin && !hasMax {
+		return nil, fmt.Errorf("config item 'min' or 'max' is required")
+	}
+	if hasMin {
+		c.min = min
+	}
+	if hasMax {
+		c.max = max
+	}
+	if c.min > c.max {
+		return nil, fmt.Errorf("config item 'min' must be lower than or equal to 'max'")
+	}
+	if c.invert, err = getBool(config, "invert"); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (t *thresholdProcessor) ValidateConfig(config map[string]ctypes.ConfigValue) error {
+	_, err := t.config(config)
+	return err
+}
+
+func (t *thresholdProcessor) Process(metrics []core.Metric, config map[string]ctypes.ConfigValue, taskID string) ([]core.Metric, error) {
+	c, err := t.config(config)
+	if err != nil {
+		return nil, err
+	}
+	processed := make([]core.Metric, 0, len(metrics))
+	for _, m := range metrics {
+		v, ok := metricfilter.ToFloat(m.Data())
+		if !ok {
+			continue
+		}
+		if (v >= c.min && v <= c.max) != c.invert {
+			processed = append(processed, m)
+		}
+	}
+	return processed, nil
+}
@@ -0,0 +1,148 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builtin
+
+import (
+	"fmt"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/ctypes"
+	"github.com/intelsdi-x/snap/pkg/metricfilter"
+)
+
+// unit is a unit of measure which converts to the base unit of its
+// dimension as base = value * scale + offset
+type unit struct {
+	dimension string
+	scale     float64
+	offset    float64
+}
+
+var units = map[string]unit{
+	"bit": {"data", 0.125, 0},
+	"B":   {"data", 1, 0},
+	"kB":  {"data", 1e3, 0},
+	"MB":  {"data", 1e6, 0},
+	"GB":  {"data", 1e9, 0},
+	"TB":  {"data", 1e12, 0},
+	"KiB": {"data", 1 << 10, 0},
+	"MiB": {"data", 1 << 20, 0},
+	"GiB": {"data", 1 << 30, 0},
+	"TiB": {"data", 1 << 40, 0},
+	"ns":  {"time", 1e-9, 0},
+	"us":  {"time", 1e-6, 0},
+	"ms":  {"time", 1e-3, 0},
+	"s":   {"time", 1, 0},
+	"min": {"time", 60, 0},
+	"h":   {"time", 3600, 0},
+	"d":   {"time", 86400, 0},
+	"K":   {"temperature", 1, 0},
+	"C":   {"temperature", 1, 273.15},
+	"F":   {"temperature", 5.0 / 9.0, 459.67 * 5.0 / 9.0},
+}
+
+// unitProcessor converts the data of numeric metrics to another unit. The
+// conversion is given either by the "from" and "to" units, or by a "factor"
+// and an "offset" (value * factor + offset) along with the new "unit".
+// Metrics whose data is not a number are left unchanged.
+type unitProcessor struct{}
+
+type unitConversion struct {
+	factor float64
+	offset float64
+	unit   string
+}
+
+func (u *unitProcessor) config(config map[string]ctypes.ConfigValue) (*unitConversion, error) {
+	from, hasFrom, err := getString(config, "from")
+	if err != nil {
+		return nil, err
+	}
+	to, hasTo, err := getString(config, "to")
+	if err != nil {
+		return nil, err
+	}
+	if hasFrom || hasTo {
+		fu, ok := units[from]
+		if !ok {
+			return nil, fmt.Errorf("config item 'from' is not a known unit: '%s'", from)
+		}
+		tu, ok := units[to]
+		if !ok {
+			return nil, fmt.Errorf("config item 'to' is not a known unit: '%s'", to)
+		}
+		if fu.dimension != tu.dimension {
+			return nil, fmt.Errorf("cannot convert %s to %s", from, to)
+		}
+		// value * fu.scale + fu.offset = converted * tu.scale + tu.offset
+		return &unitConversion{
+			factor: fu.scale / tu.scale,
+			offset: (fu.offset - tu.offset) / tu.scale,
+			unit:   to,
+		}, nil
+	}
+	c := &unitConversion{factor: 1}
+	factor, hasFactor, err := getFloat(config, "factor")
+	if err != nil {
+		return nil, err
+	}
+	offset, hasOffset, err := getFloat(config, "offset")
+	if err != nil {
+		return nil, err
+	}
+	if !hasFactor && !hasOffset {
+		return nil, fmt.Errorf("config items 'from' and 'to', or 'factor' are required")
+	}
+	if hasFactor {
+		c.factor = factor
+	}
+	c.offset = offset
+	if c.unit, _, err = getString(config, "unit"); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (u *unitProcessor) ValidateConfig(config map[string]ctypes.ConfigValue) error {
+	_, err := u.config(config)
+	return err
+}
+
+func (u *unitProcessor) Process(metrics []core.Metric, config map[string]ctypes.ConfigValue, taskID string) ([]core.Metric, error) {
+	c, err := u.config(config)
+	if err != nil {
+		return nil, err
+	}
+	processed := make([]core.Metric, len(metrics))
+	for i, m := range metrics {
+		v, ok := metricfilter.ToFloat(m.Data())
+		if !ok {
+			processed[i] = m
+			continue
+		}
+		mt := copyMetric(m)
+		mt.Data_ = v*c.factor + c.offset
+		if c.unit != "" {
+			mt.Unit_ = c.unit
+		}
+		processed[i] = mt
+	}
+	return processed, nil
+}
@@ -26,6 +26,7 @@ import (
 	"os"
 	"reflect"
 	"strconv"
+	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -51,6 +52,9 @@ var (
 	defaultTLSCertPath       = ""
 	defaultTLSKeyPath        = ""
 	defaultCACertPaths       = ""
+	defaultPluginWriteBack   = false
+	defaultPluginOverlayPath = ""
+	defaultSelfTelemetry     = false
 )
 
 type pluginConfig struct {
@@ -59,6 +63,9 @@ type pluginConfig struct {
 	Publisher   *pluginTypeConfigItem `json:"publisher"`
 	Processor   *pluginTypeConfigItem `json:"processor"`
 	pluginCache map[string]*cdata.ConfigDataNode
+	// mutex protects the maps of the plugin config and the cache of the merged
+	// config of the plugins, which are read by collects while being changed
+	mutex sync.Mutex
 }
 
 type pluginTypeConfigItem struct {
@@ -92,6 +99,25 @@ type Config struct {
 	TLSCertPath       string                       `json:"tls_cert_path"yaml:"tls_cert_path"`
 	TLSKeyPath        string                       `json:"tls_key_path"yaml:"tls_key_path"`
 	CACertPaths       string                       `json:"ca_cert_paths"yaml:"ca_cert_paths"`
+
+	// PluginConfigWriteBack enables saving the plugin config changes made through
+	// the REST API to the overlay file found at PluginConfigOverlayPath
+	PluginConfigWriteBack   bool   `json:"plugin_config_write_back"yaml:"plugin_config_write_back"`
+	PluginConfigOverlayPath string `json:"plugin_config_overlay_path"yaml:"plugin_config_overlay_path"`
+
+	// SelfTelemetry adds the metrics of snapteld itself to the metric catalog
+	// under /intel/snap
+	SelfTelemetry bool `json:"self_telemetry"yaml:"self_telemetry"`
+
+	// PluginRateLimits caps the collect calls per second of collector plugins,
+	// by plugin name
+	PluginRateLimits map[string]float64 `json:"plugin_rate_limits,omitempty"yaml:"plugin_rate_limits"`
+
+	// pluginsMutex serializes the runtime changes of the plugin config, and
+	// guards the tags and the plugin rate limits replaced on reload
+	pluginsMutex sync.RWMutex
+	// filePlugins holds the plugin config as read from the config file
+	filePlugins *pluginConfig
 }
 
 const (
@@ -155,6 +181,23 @@ const (
 					},
 					"ca_cert_paths": {
 						"type": "string"
+					},
+					"plugin_config_write_back": {
+						"type": "boolean"
+					},
+					"plugin_config_overlay_path": {
+						"type": "string"
+					},
+					"self_telemetry": {
+						"type": "boolean"
+					},
+					"plugin_rate_limits": {
+						"type": ["object", "null"],
+						"properties" : {},
+						"additionalProperties": {
+							"type": "number",
+							"minimum": 0
+						}
 					}
 				},
 				"additionalProperties": false
@@ -181,6 +224,10 @@ func GetDefaultConfig() *Config {
 		TLSCertPath:       defaultTLSCertPath,
 		TLSKeyPath:        defaultTLSKeyPath,
 		CACertPaths:       defaultCACertPaths,
+
+		PluginConfigWriteBack:   defaultPluginWriteBack,
+		PluginConfigOverlayPath: defaultPluginOverlayPath,
+		SelfTelemetry:           defaultSelfTelemetry,
 	}
 }
 
@@ -219,34 +266,54 @@ func newPluginTags() map[string]map[string]string {
 }
 
 func (p *Config) GetPluginConfigDataNode(pluginType core.PluginType, name string, ver int) cdata.ConfigDataNode {
+	p.pluginsMutex.Lock()
+	defer p.pluginsMutex.Unlock()
 	return *p.Plugins.getPluginConfigDataNode(pluginType, name, ver)
 }
 
 func (p *Config) MergePluginConfigDataNode(pluginType core.PluginType, name string, ver int, cdn *cdata.ConfigDataNode) cdata.ConfigDataNode {
+	p.pluginsMutex.Lock()
+	defer p.pluginsMutex.Unlock()
+	p.beginPluginConfigChange()
 	p.Plugins.mergePluginConfigDataNode(pluginType, name, ver, cdn)
+	p.endPluginConfigChange()
 	return *p.Plugins.getPluginConfigDataNode(pluginType, name, ver)
 }
 
 func (p *Config) MergePluginConfigDataNodeAll(cdn *cdata.ConfigDataNode) cdata.ConfigDataNode {
+	p.pluginsMutex.Lock()
+	defer p.pluginsMutex.Unlock()
+	p.beginPluginConfigChange()
 	p.Plugins.mergePluginConfigDataNodeAll(cdn)
+	p.endPluginConfigChange()
 	return *p.Plugins.All
 }
 
 func (p *Config) DeletePluginConfigDataNodeField(pluginType core.PluginType, name string, ver int, fields ...string) cdata.ConfigDataNode {
+	p.pluginsMutex.Lock()
+	defer p.pluginsMutex.Unlock()
+	p.beginPluginConfigChange()
 	for _, field := range fields {
 		p.Plugins.deletePluginConfigDataNodeField(pluginType, name, ver, field)
 	}
+	p.endPluginConfigChange()
 	return *p.Plugins.getPluginConfigDataNode(pluginType, name, ver)
 }
 
 func (p *Config) DeletePluginConfigDataNodeFieldAll(fields ...string) cdata.ConfigDataNode {
+	p.pluginsMutex.Lock()
+	defer p.pluginsMutex.Unlock()
+	p.beginPluginConfigChange()
 	for _, field := range fields {
 		p.Plugins.deletePluginConfigDataNodeFieldAll(field)
 	}
+	p.endPluginConfigChange()
 	return *p.Plugins.All
 }
 
 func (p *Config) GetPluginConfigDataNodeAll() cdata.ConfigDataNode {
+	p.pluginsMutex.Lock()
+	defer p.pluginsMutex.Unlock()
 	return *p.Plugins.All
 }
 
@@ -293,6 +360,40 @@ func (p *pluginConfig) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// MarshalJSON marshals pluginConfig into the json read by UnmarshalJSON
+func (p *pluginConfig) MarshalJSON() ([]byte, error) {
+	t := map[string]interface{}{}
+	if p.All != nil {
+		t["all"] = p.All
+	}
+	for _, typ := range pluginConfigTypes {
+		item := p.typeItem(typ)
+		if item == nil {
+			continue
+		}
+		plugins := map[string]interface{}{}
+		if item.All != nil {
+			plugins["all"] = item.All
+		}
+		for name, plugin := range item.Plugins {
+			pc := map[string]interface{}{}
+			if plugin.ConfigDataNode != nil {
+				pc["all"] = plugin.ConfigDataNode
+			}
+			if len(plugin.Versions) > 0 {
+				versions := map[string]*cdata.ConfigDataNode{}
+				for ver, n := range plugin.Versions {
+					versions[strconv.Itoa(ver)] = n
+				}
+				pc["versions"] = versions
+			}
+			plugins[name] = pc
+		}
+		t[typ] = plugins
+	}
+	return json.Marshal(t)
+}
+
 func newPluginConfigItem(opts ...pluginConfigOpt) *pluginConfigItem {
 	p := &pluginConfigItem{
 		ConfigDataNode: cdata.NewNode(),
@@ -315,6 +416,8 @@ func optAddPluginConfigItem(key string, value ctypes.ConfigValue) pluginConfigOp
 }
 
 func (p *pluginConfig) mergePluginConfigDataNodeAll(cdn *cdata.ConfigDataNode) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
 	// clear cache
 	p.pluginCache = make(map[string]*cdata.ConfigDataNode)
 
@@ -323,6 +426,8 @@ func (p *pluginConfig) mergePluginConfigDataNodeAll(cdn *cdata.ConfigDataNode) {
 }
 
 func (p *pluginConfig) deletePluginConfigDataNodeFieldAll(key string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
 	// clear cache
 	p.pluginCache = make(map[string]*cdata.ConfigDataNode)
 
@@ -343,6 +448,8 @@ func (p *pluginConfig) switchPluginConfigType(pluginType core.PluginType) *plugi
 }
 
 func (p *pluginConfig) mergePluginConfigDataNode(pluginType core.PluginType, name string, ver int, cdn *cdata.ConfigDataNode) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
 	// clear cache
 	p.pluginCache = make(map[string]*cdata.ConfigDataNode)
 	configItem := p.switchPluginConfigType(pluginType)
@@ -374,6 +481,8 @@ func (p *pluginConfig) mergePluginConfigDataNode(pluginType core.PluginType, nam
 }
 
 func (p *pluginConfig) deletePluginConfigDataNodeField(pluginType core.PluginType, name string, ver int, key string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
 	// clear cache
 	p.pluginCache = make(map[string]*cdata.ConfigDataNode)
 	configItem := p.switchPluginConfigType(pluginType)
@@ -394,6 +503,8 @@ func (p *pluginConfig) deletePluginConfigDataNodeField(pluginType core.PluginTyp
 }
 
 func (p *pluginConfig) getPluginConfigDataNode(pluginType core.PluginType, name string, ver int) *cdata.ConfigDataNode {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
 	// check cache
 	key := fmt.Sprintf("%d"+core.Separator+"%s"+core.Separator+"%d", pluginType, name, ver)
 	if res, ok := p.pluginCache[key]; ok {
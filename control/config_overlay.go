@@ -0,0 +1,400 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/cdata"
+	"github.com/intelsdi-x/snap/core/ctypes"
+)
+
+const (
+	// PluginConfigSourceFile identifies a plugin config value read from the config file
+	PluginConfigSourceFile = "file"
+	// PluginConfigSourceRuntime identifies a plugin config value set through the API at runtime
+	PluginConfigSourceRuntime = "runtime"
+
+	// DefaultPluginConfigOverlayFile is the name of the overlay file written next to
+	// the global config file when no overlay path is configured
+	DefaultPluginConfigOverlayFile = "snapteld-plugin-config.json"
+)
+
+var (
+	// ErrPluginConfigOverlayPathNotSet - The error message when write back is enabled without an overlay path
+	ErrPluginConfigOverlayPathNotSet = errors.New("Plugin config write back is enabled but the overlay path is not set")
+
+	pluginConfigTypes = []string{"collector", "processor", "publisher"}
+)
+
+// pluginConfigOverlay is the on-disk representation of the changes made to the
+// plugin config at runtime.
+type pluginConfigOverlay struct {
+	Entries []*pluginConfigOverlayEntry `json:"entries"`
+}
+
+// pluginConfigOverlayEntry holds the changes made to a single level of the plugin
+// config hierarchy. An empty type addresses the config of all plugins, an empty
+// name the config of all plugins of the type and a version of 0 the config of all
+// versions of the plugin.
+type pluginConfigOverlayEntry struct {
+	Type    string                `json:"type,omitempty"`
+	Name    string                `json:"name,omitempty"`
+	Version int                   `json:"version,omitempty"`
+	Set     *cdata.ConfigDataNode `json:"set,omitempty"`
+	Deleted []string              `json:"deleted,omitempty"`
+}
+
+// LoadPluginConfigOverlay records the plugin config read from the config file
+// and, when write back is enabled, applies the runtime changes saved in the
+// overlay file on top of it.
+func (p *Config) LoadPluginConfigOverlay() error {
+	p.pluginsMutex.Lock()
+	defer p.pluginsMutex.Unlock()
+	p.filePlugins = p.Plugins.clone()
+	if !p.PluginConfigWriteBack {
+		return nil
+	}
+	if p.PluginConfigOverlayPath == "" {
+		return ErrPluginConfigOverlayPathNotSet
+	}
+	b, err := ioutil.ReadFile(p.PluginConfigOverlayPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	overlay := &pluginConfigOverlay{}
+	if err := json.Unmarshal(b, overlay); err != nil {
+		return fmt.Errorf("Error parsing plugin config overlay %v: %v", p.PluginConfigOverlayPath, err)
+	}
+	if err := overlay.apply(p.Plugins); err != nil {
+		return err
+	}
+	log.WithFields(log.Fields{
+		"_block":  "LoadPluginConfigOverlay",
+		"_module": "config",
+		"path":    p.PluginConfigOverlayPath,
+		"entries": len(overlay.Entries),
+	}).Info("plugin config overlay loaded")
+	return nil
+}
+
+// GetPluginConfigDataNodeSources returns the source (file or runtime) of each
+// item of the config of the given plugin.
+func (p *Config) GetPluginConfigDataNodeSources(pluginType core.PluginType, name string, ver int) map[string]string {
+	p.pluginsMutex.Lock()
+	defer p.pluginsMutex.Unlock()
+	current := p.Plugins.getPluginConfigDataNode(pluginType, name, ver)
+	if current == nil {
+		return map[string]string{}
+	}
+	var file *cdata.ConfigDataNode
+	if p.filePlugins != nil {
+		file = p.filePlugins.getPluginConfigDataNode(pluginType, name, ver)
+	}
+	return pluginConfigSources(current, file, p.filePlugins == nil)
+}
+
+// GetPluginConfigDataNodeAllSources returns the source (file or runtime) of each
+// item of the config shared by all plugins.
+func (p *Config) GetPluginConfigDataNodeAllSources() map[string]string {
+	p.pluginsMutex.Lock()
+	defer p.pluginsMutex.Unlock()
+	var file *cdata.ConfigDataNode
+	if p.filePlugins != nil {
+		file = p.filePlugins.All
+	}
+	return pluginConfigSources(p.Plugins.All, file, p.filePlugins == nil)
+}
+
+// reloadPlugins replaces the plugin config read from the config file while
+// keeping the changes made at runtime on top of it.
+func (p *Config) reloadPlugins(plugins *pluginConfig) error {
+	p.pluginsMutex.Lock()
+	defer p.pluginsMutex.Unlock()
+	p.beginPluginConfigChange()
+	next := plugins.clone()
+	if err := pluginConfigDiff(p.filePlugins, p.Plugins).apply(next); err != nil {
+		return err
+	}
+	p.filePlugins = plugins.clone()
+	// the plugin config is shared with the plugin manager and read by running
+	// collects, so it is replaced in place rather than swapped
+	p.Plugins.replace(next)
+	p.endPluginConfigChange()
+	return nil
+}
+
+// beginPluginConfigChange records the plugin config read from the config file
+// before it is first changed at runtime. The caller must hold pluginsMutex.
+func (p *Config) beginPluginConfigChange() {
+	if p.filePlugins == nil {
+		p.filePlugins = p.Plugins.clone()
+	}
+}
+
+// endPluginConfigChange writes the runtime changes of the plugin config to the
+// overlay file when write back is enabled. The caller must hold pluginsMutex.
+func (p *Config) endPluginConfigChange() {
+	if !p.PluginConfigWriteBack {
+		return
+	}
+	if err := p.savePluginConfigOverlay(); err != nil {
+		log.WithFields(log.Fields{
+			"_block":  "endPluginConfigChange",
+			"_module": "config",
+			"path":    p.PluginConfigOverlayPath,
+			"_error":  err.Error(),
+		}).Error("unable to persist plugin config changes")
+	}
+}
+
+func (p *Config) savePluginConfigOverlay() error {
+	if p.PluginConfigOverlayPath == "" {
+		return ErrPluginConfigOverlayPathNotSet
+	}
+	b, err := json.MarshalIndent(pluginConfigDiff(p.filePlugins, p.Plugins), "", "  ")
+	if err != nil {
+		return err
+	}
+	// write to a temporary file first so a crash never leaves a truncated overlay
+	dir, file := filepath.Split(p.PluginConfigOverlayPath)
+	if dir == "" {
+		dir = "."
+	}
+	tmp, err := ioutil.TempFile(dir, "."+file)
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), p.PluginConfigOverlayPath)
+}
+
+// pluginConfigSources returns, for each item of current, whether it matches
+// the value read from the config file.
+func pluginConfigSources(current, file *cdata.ConfigDataNode, fileOnly bool) map[string]string {
+	sources := map[string]string{}
+	var fileTable map[string]ctypes.ConfigValue
+	if file != nil {
+		fileTable = file.Table()
+	}
+	for k, v := range current.Table() {
+		if fv, ok := fileTable[k]; fileOnly || (ok && fv == v) {
+			sources[k] = PluginConfigSourceFile
+			continue
+		}
+		sources[k] = PluginConfigSourceRuntime
+	}
+	return sources
+}
+
+// pluginConfigDiff returns the overlay which turns base into current.
+func pluginConfigDiff(base, current *pluginConfig) *pluginConfigOverlay {
+	entries := map[string]*pluginConfigOverlayEntry{}
+	entry := func(typ, name string, ver int) *pluginConfigOverlayEntry {
+		key := fmt.Sprintf("%s"+core.Separator+"%s"+core.Separator+"%d", typ, name, ver)
+		e, ok := entries[key]
+		if !ok {
+			e = &pluginConfigOverlayEntry{Type: typ, Name: name, Version: ver}
+			entries[key] = e
+		}
+		return e
+	}
+	current.walk(func(typ, name string, ver int, n *cdata.ConfigDataNode) {
+		var baseTable map[string]ctypes.ConfigValue
+		if bn := base.node(typ, name, ver, false); bn != nil {
+			baseTable = bn.Table()
+		}
+		for k, v := range n.Table() {
+			if bv, ok := baseTable[k]; ok && bv == v {
+				continue
+			}
+			e := entry(typ, name, ver)
+			if e.Set == nil {
+				e.Set = cdata.NewNode()
+			}
+			e.Set.AddItem(k, v)
+		}
+	})
+	base.walk(func(typ, name string, ver int, n *cdata.ConfigDataNode) {
+		var currentTable map[string]ctypes.ConfigValue
+		if cn := current.node(typ, name, ver, false); cn != nil {
+			currentTable = cn.Table()
+		}
+		for k := range n.Table() {
+			if _, ok := currentTable[k]; !ok {
+				e := entry(typ, name, ver)
+				e.Deleted = append(e.Deleted, k)
+			}
+		}
+	})
+
+	keys := make([]string, 0, len(entries))
+	for k := range entries {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	overlay := &pluginConfigOverlay{Entries: make([]*pluginConfigOverlayEntry, 0, len(keys))}
+	for _, k := range keys {
+		sort.Strings(entries[k].Deleted)
+		overlay.Entries = append(overlay.Entries, entries[k])
+	}
+	return overlay
+}
+
+// apply replays the changes held by the overlay on the given plugin config
+func (o *pluginConfigOverlay) apply(p *pluginConfig) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	// clear cache
+	p.pluginCache = make(map[string]*cdata.ConfigDataNode)
+	for _, e := range o.Entries {
+		n := p.node(e.Type, e.Name, e.Version, true)
+		if n == nil {
+			return fmt.Errorf("Error applying plugin config overlay: unknown plugin type '%v'", e.Type)
+		}
+		for _, k := range e.Deleted {
+			n.DeleteItem(k)
+		}
+		if e.Set != nil {
+			n.Merge(e.Set)
+		}
+	}
+	return nil
+}
+
+// typeItem returns the config of the given plugin type
+func (p *pluginConfig) typeItem(typ string) *pluginTypeConfigItem {
+	switch typ {
+	case "collector":
+		return p.Collector
+	case "processor":
+		return p.Processor
+	case "publisher":
+		return p.Publisher
+	}
+	return nil
+}
+
+// node returns the config node found at the given level of the hierarchy. Missing
+// plugin and version levels are created when create is true.
+func (p *pluginConfig) node(typ, name string, ver int, create bool) *cdata.ConfigDataNode {
+	if typ == "" {
+		return p.All
+	}
+	item := p.typeItem(typ)
+	if item == nil {
+		return nil
+	}
+	if name == "" {
+		return item.All
+	}
+	plugin, ok := item.Plugins[name]
+	if !ok {
+		if !create {
+			return nil
+		}
+		plugin = newPluginConfigItem()
+		item.Plugins[name] = plugin
+	}
+	if ver == 0 {
+		return plugin.ConfigDataNode
+	}
+	n, ok := plugin.Versions[ver]
+	if !ok {
+		if !create {
+			return nil
+		}
+		n = cdata.NewNode()
+		plugin.Versions[ver] = n
+	}
+	return n
+}
+
+// walk calls fn for every node of the plugin config hierarchy
+func (p *pluginConfig) walk(fn func(typ, name string, ver int, n *cdata.ConfigDataNode)) {
+	if p.All != nil {
+		fn("", "", 0, p.All)
+	}
+	for _, typ := range pluginConfigTypes {
+		item := p.typeItem(typ)
+		if item == nil {
+			continue
+		}
+		if item.All != nil {
+			fn(typ, "", 0, item.All)
+		}
+		for name, plugin := range item.Plugins {
+			if plugin.ConfigDataNode != nil {
+				fn(typ, name, 0, plugin.ConfigDataNode)
+			}
+			for ver, n := range plugin.Versions {
+				fn(typ, name, ver, n)
+			}
+		}
+	}
+}
+
+// replace sets the plugin config to the one of next
+func (p *pluginConfig) replace(next *pluginConfig) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.All = next.All
+	p.Collector = next.Collector
+	p.Publisher = next.Publisher
+	p.Processor = next.Processor
+	// clear cache
+	p.pluginCache = make(map[string]*cdata.ConfigDataNode)
+}
+
+// reloadTags replaces the tags added to the collected metrics
+func (p *Config) reloadTags(tags map[string]map[string]string) {
+	p.pluginsMutex.Lock()
+	defer p.pluginsMutex.Unlock()
+	p.Tags = tags
+}
+
+// clone returns a deep copy of the plugin config
+func (p *pluginConfig) clone() *pluginConfig {
+	c := newPluginConfig()
+	p.walk(func(typ, name string, ver int, n *cdata.ConfigDataNode) {
+		c.node(typ, name, ver, true).Merge(n)
+	})
+	return c
+}
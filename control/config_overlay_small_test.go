@@ -0,0 +1,118 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/cdata"
+	"github.com/intelsdi-x/snap/core/ctypes"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// newFileConfig returns a config holding the plugin config a config file would provide
+func newFileConfig(overlayPath string) *Config {
+	cfg := GetDefaultConfig()
+	cfg.PluginConfigWriteBack = true
+	cfg.PluginConfigOverlayPath = overlayPath
+	cfg.Plugins.All.AddItem("password", ctypes.ConfigValueStr{Value: "p@ssw0rd"})
+	cfg.Plugins.Collector.Plugins["foo"] = newPluginConfigItem(optAddPluginConfigItem("user", ctypes.ConfigValueStr{Value: "john"}))
+	return cfg
+}
+
+func TestPluginConfigOverlay(t *testing.T) {
+	Convey("Given a config with plugin config write back enabled", t, func() {
+		dir, err := ioutil.TempDir("", "snap-plugin-config")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		overlayPath := filepath.Join(dir, DefaultPluginConfigOverlayFile)
+
+		cfg := newFileConfig(overlayPath)
+		So(cfg.LoadPluginConfigOverlay(), ShouldBeNil)
+
+		Convey("values from the config file are reported as such", func() {
+			sources := cfg.GetPluginConfigDataNodeSources(core.CollectorPluginType, "foo", 1)
+			So(sources, ShouldResemble, map[string]string{
+				"password": PluginConfigSourceFile,
+				"user":     PluginConfigSourceFile,
+			})
+			_, err := os.Stat(overlayPath)
+			So(os.IsNotExist(err), ShouldBeTrue)
+		})
+
+		Convey("runtime changes are persisted and reported as such", func() {
+			cdn := cdata.NewNode()
+			cdn.AddItem("user", ctypes.ConfigValueStr{Value: "jane"})
+			cdn.AddItem("port", ctypes.ConfigValueInt{Value: 8080})
+			cfg.MergePluginConfigDataNode(core.CollectorPluginType, "foo", 1, cdn)
+			cfg.DeletePluginConfigDataNodeFieldAll("password")
+
+			sources := cfg.GetPluginConfigDataNodeSources(core.CollectorPluginType, "foo", 1)
+			So(sources, ShouldResemble, map[string]string{
+				"user": PluginConfigSourceRuntime,
+				"port": PluginConfigSourceRuntime,
+			})
+			_, err := os.Stat(overlayPath)
+			So(err, ShouldBeNil)
+
+			Convey("and restored on top of the config file", func() {
+				restored := newFileConfig(overlayPath)
+				So(restored.LoadPluginConfigOverlay(), ShouldBeNil)
+				node := restored.GetPluginConfigDataNode(core.CollectorPluginType, "foo", 1)
+				So(node.Table(), ShouldResemble, map[string]ctypes.ConfigValue{
+					"user": ctypes.ConfigValueStr{Value: "jane"},
+					"port": ctypes.ConfigValueInt{Value: 8080},
+				})
+				So(restored.GetPluginConfigDataNodeSources(core.CollectorPluginType, "foo", 1), ShouldResemble, sources)
+				So(restored.GetPluginConfigDataNodeAllSources(), ShouldBeEmpty)
+			})
+			Convey("and not restored when write back is disabled", func() {
+				restored := newFileConfig(overlayPath)
+				restored.PluginConfigWriteBack = false
+				So(restored.LoadPluginConfigOverlay(), ShouldBeNil)
+				node := restored.GetPluginConfigDataNode(core.CollectorPluginType, "foo", 1)
+				So(node.Table()["user"], ShouldResemble, ctypes.ConfigValueStr{Value: "john"})
+			})
+		})
+
+		Convey("an invalid overlay file is reported", func() {
+			So(ioutil.WriteFile(overlayPath, []byte("{"), 0600), ShouldBeNil)
+			So(newFileConfig(overlayPath).LoadPluginConfigOverlay(), ShouldNotBeNil)
+		})
+	})
+}
+
+func TestPluginConfigZeroValue(t *testing.T) {
+	Convey("A config not built by GetDefaultConfig can change its plugin config", t, func() {
+		cfg := &Config{Plugins: newPluginConfig()}
+		cdn := cdata.NewNode()
+		cdn.AddItem("user", ctypes.ConfigValueStr{Value: "jane"})
+		So(func() { cfg.MergePluginConfigDataNode(core.CollectorPluginType, "foo", 1, cdn) }, ShouldNotPanic)
+		node := cfg.GetPluginConfigDataNode(core.CollectorPluginType, "foo", 1)
+		So(node.Table()["user"], ShouldResemble, ctypes.ConfigValueStr{Value: "jane"})
+	})
+}
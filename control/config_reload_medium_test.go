@@ -0,0 +1,102 @@
+// +build medium
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/cdata"
+	"github.com/intelsdi-x/snap/core/ctypes"
+)
+
+// TestReloadConfigDuringCollects reloads the config while the metrics of
+// snapteld are collected, and is meant to be run with -race.
+func TestReloadConfigDuringCollects(t *testing.T) {
+	Convey("Given a started control collecting the metrics of snapteld", t, func() {
+		cfg := getTestSGConfig()
+		cfg.SelfTelemetry = true
+		c := New(cfg)
+		So(c.Start(), ShouldBeNil)
+		defer c.Stop()
+		requested := mockRequestedMetric{namespace: core.NewNamespace("intel", "snap", "control", "cache", "hits")}
+		So(c.SubscribeDeps("task-id", []core.RequestedMetric{requested}, nil, cdata.NewTree()), ShouldBeEmpty)
+
+		Convey("When the config is reloaded during collects", func() {
+			const reloads = 50
+			done := make(chan struct{})
+			errs := make(chan error, 1)
+			var wg sync.WaitGroup
+			for i := 0; i < 4; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for {
+						select {
+						case <-done:
+							return
+						default:
+						}
+						if _, cerrs := c.CollectMetrics("task-id", nil); len(cerrs) > 0 {
+							select {
+							case errs <- cerrs[0]:
+							default:
+							}
+							return
+						}
+					}
+				}()
+			}
+			for i := 0; i < reloads; i++ {
+				next := GetDefaultConfig()
+				next.Plugins.Collector.Plugins["mock"] = newPluginConfigItem(optAddPluginConfigItem("reload", ctypes.ConfigValueInt{Value: i}))
+				next.Tags = map[string]map[string]string{"/intel/snap": {"reload": fmt.Sprintf("%d", i)}}
+				So(c.ReloadConfig(next), ShouldBeNil)
+			}
+			close(done)
+			wg.Wait()
+
+			Convey("Then the collects succeed", func() {
+				select {
+				case err := <-errs:
+					So(err, ShouldBeNil)
+				default:
+				}
+			})
+			Convey("Then the plugin manager sees the last config", func() {
+				cdn := c.pluginManager.GetPluginConfig().getPluginConfigDataNode(core.CollectorPluginType, "mock", 1)
+				So(cdn.Table()["reload"], ShouldResemble, ctypes.ConfigValueInt{Value: reloads - 1})
+			})
+			Convey("Then the collected metrics carry the last tags", func() {
+				mts, cerrs := c.CollectMetrics("task-id", nil)
+				So(cerrs, ShouldBeEmpty)
+				for _, m := range mts {
+					So(m.Tags()["reload"], ShouldEqual, fmt.Sprintf("%d", reloads-1))
+				}
+			})
+		})
+	})
+}
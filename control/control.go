@@ -33,9 +33,11 @@ import (
 	"time"
 
 	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 
 	"github.com/intelsdi-x/gomit"
+	"github.com/intelsdi-x/snap/control/builtin"
 	"github.com/intelsdi-x/snap/control/plugin"
 	"github.com/intelsdi-x/snap/control/plugin/client"
 	"github.com/intelsdi-x/snap/control/strategy"
@@ -92,6 +94,8 @@ type pluginControl struct {
 
 	subscriptionGroups ManagesSubscriptionGroups
 	grpcSecurity       client.GRPCSecurity
+	// rateLimiter enforces the rate limits of the collector plugins
+	rateLimiter *rateLimiter
 }
 
 type subscribedPlugin struct {
@@ -206,6 +210,7 @@ func New(cfg *Config) *pluginControl {
 	}
 	c := &pluginControl{}
 	c.Config = cfg
+	c.rateLimiter = newRateLimiter()
 	// Initialize components
 	// Event Manager
 	c.eventManager = gomit.NewEventController()
@@ -455,6 +460,10 @@ func (p *pluginControl) Start() error {
 		}).Info("auto discover path is disabled")
 	}
 
+	if p.Config.SelfTelemetry {
+		p.addTelemetryMetrics()
+	}
+
 	lis, err := net.Listen("tcp", fmt.Sprintf("%v:%v", p.Config.ListenAddr, p.Config.ListenPort))
 	if err != nil {
 		controlLogger.WithField("error", err.Error()).Error("Failed to start control grpc listener")
@@ -742,6 +751,23 @@ func (p *pluginControl) ValidateDeps(requested []core.RequestedMetric, plugins [
 	return p.subscriptionGroups.ValidateDeps(requested, plugins, configTree, asserts...)
 }
 
+// ResolveConfig returns the config the requested metrics, as expanded from the
+// metric catalog, and the given plugins are given once subscribed with the
+// config tree. The configs of the plugins are returned in the order of the
+// plugins.
+func (p *pluginControl) ResolveConfig(requested []core.RequestedMetric, plugins []core.SubscribedPlugin, configTree *cdata.ConfigDataTree) ([]core.Metric, []*cdata.ConfigDataNode) {
+	var mts []core.Metric
+	pmts, _, _ := p.getMetricsAndCollectors(requested, configTree)
+	for _, pmt := range pmts {
+		mts = append(mts, pmt.Metrics()...)
+	}
+	cfgs := make([]*cdata.ConfigDataNode, len(plugins))
+	for i, plg := range plugins {
+		cfgs[i] = p.subscriptionGroups.resolvePluginConfig(plg)
+	}
+	return mts, cfgs
+}
+
 // SubscribeDeps will subscribe to collectors, processors and publishers.  The collectors are subscribed by mapping the provided
 // array of core.RequestedMetrics to the corresponding plugins while processors and publishers provided in the array of core.Plugin
 // will be subscribed directly.  The ID provides a logical grouping of subscriptions.
@@ -839,6 +865,11 @@ func (p *pluginControl) getMetricsAndCollectors(requested []core.RequestedMetric
 			pmt.metricTypes = append(pmt.metricTypes, mt)
 			newMetricsGroupedByPlugin[key] = pmt
 
+			// the built-in collector has no plugin to be subscribed to
+			if builtin.IsBuiltin(cp.Name()) {
+				continue
+			}
+
 			plugin := subscribedPlugin{
 				name:     cp.Name(),
 				typeName: cp.TypeName(),
@@ -985,7 +1016,14 @@ func (p *pluginControl) MetricExists(mns core.Namespace, ver int) bool {
 // CollectMetrics is a blocking call to collector plugins returning a collection
 // of metrics and errors.  If an error is encountered no metrics will be
 // returned.
-func (p *pluginControl) CollectMetrics(id string, allTags map[string]map[string]string) (metrics []core.Metric, errs []error) {
+func (p *pluginControl) CollectMetrics(id string, allTags map[string]map[string]string) ([]core.Metric, []error) {
+	return p.CollectMetricsContext(context.Background(), id, allTags)
+}
+
+// CollectMetricsContext collects the metrics like CollectMetrics, passing the
+// deadline of ctx on to the collector plugins and aborting their calls once
+// ctx is canceled.
+func (p *pluginControl) CollectMetricsContext(ctx context.Context, id string, allTags map[string]map[string]string) (metrics []core.Metric, errs []error) {
 	// If control is not started we don't want tasks to be able to
 	// go through a workflow.
 	if !p.Started {
@@ -1038,14 +1076,34 @@ func (p *pluginControl) CollectMetrics(id string, allTags map[string]map[string]
 
 		wg.Add(1)
 
-		go func(pluginKey string, mt []core.Metric) {
-			mts, err := p.pluginRunner.AvailablePlugins().collectMetrics(pluginKey, mt, id)
+		// the metrics of snapteld are collected in-process
+		if builtin.IsBuiltin(pmt.plugin.Name()) {
+			go func(mt []core.Metric) {
+				cMetrics <- builtin.CollectTelemetry(mt)
+			}(pmt.metricTypes)
+			continue
+		}
+
+		go func(pluginKey, name string, mt []core.Metric) {
+			if limit := p.Config.pluginRateLimit(name); limit > 0 {
+				if err := p.rateLimiter.wait(name, limit); err != nil {
+					controlLogger.WithFields(log.Fields{
+						"_block":                "CollectorMetrics",
+						"subscription-group-id": id,
+						"plugin-name":           name,
+						"rate-limit":            limit,
+					}).Warn(err)
+					cError <- err
+					return
+				}
+			}
+			mts, err := p.pluginRunner.AvailablePlugins().collectMetrics(ctx, pluginKey, mt, id)
 			if err != nil {
 				cError <- err
 			} else {
 				cMetrics <- mts
 			}
-		}(pluginKey, pmt.metricTypes)
+		}(pluginKey, pmt.plugin.Name(), pmt.metricTypes)
 	}
 
 	go func() {
@@ -1128,6 +1186,12 @@ func (p *pluginControl) StreamMetrics(
 
 // PublishMetrics
 func (p *pluginControl) PublishMetrics(metrics []core.Metric, config map[string]ctypes.ConfigValue, taskID, pluginName string, pluginVersion int) []error {
+	return p.PublishMetricsContext(context.Background(), metrics, config, taskID, pluginName, pluginVersion)
+}
+
+// PublishMetricsContext publishes the metrics like PublishMetrics, within the
+// deadline of ctx
+func (p *pluginControl) PublishMetricsContext(ctx context.Context, metrics []core.Metric, config map[string]ctypes.ConfigValue, taskID, pluginName string, pluginVersion int) []error {
 	// If control is not started we don't want tasks to be able to
 	// go through a workflow.
 	if !p.Started {
@@ -1144,11 +1208,29 @@ func (p *pluginControl) PublishMetrics(metrics []core.Metric, config map[string]
 		merged[k] = v
 	}
 
-	return p.pluginRunner.AvailablePlugins().publishMetrics(metrics, pluginName, pluginVersion, merged, taskID)
+	// built-in publishers run in-process instead of through a plugin
+	if builtin.IsBuiltin(pluginName) {
+		pub, err := builtin.GetPublisher(pluginName, pluginVersion)
+		if err != nil {
+			return []error{err}
+		}
+		if err := pub.Publish(metrics, merged, taskID); err != nil {
+			return []error{err}
+		}
+		return nil
+	}
+
+	return p.pluginRunner.AvailablePlugins().publishMetrics(ctx, metrics, pluginName, pluginVersion, merged, taskID)
 }
 
 // ProcessMetrics
 func (p *pluginControl) ProcessMetrics(metrics []core.Metric, config map[string]ctypes.ConfigValue, taskID, pluginName string, pluginVersion int) ([]core.Metric, []error) {
+	return p.ProcessMetricsContext(context.Background(), metrics, config, taskID, pluginName, pluginVersion)
+}
+
+// ProcessMetricsContext processes the metrics like ProcessMetrics, within the
+// deadline of ctx
+func (p *pluginControl) ProcessMetricsContext(ctx context.Context, metrics []core.Metric, config map[string]ctypes.ConfigValue, taskID, pluginName string, pluginVersion int) ([]core.Metric, []error) {
 	// If control is not started we don't want tasks to be able to
 	// go through a workflow.
 	if !p.Started {
@@ -1165,7 +1247,20 @@ func (p *pluginControl) ProcessMetrics(metrics []core.Metric, config map[string]
 		merged[k] = v
 	}
 
-	return p.pluginRunner.AvailablePlugins().processMetrics(metrics, pluginName, pluginVersion, merged, taskID)
+	// built-in processors run in-process instead of through a plugin
+	if builtin.IsBuiltin(pluginName) {
+		proc, err := builtin.Get(pluginName, pluginVersion)
+		if err != nil {
+			return nil, []error{err}
+		}
+		mts, err := proc.Process(metrics, merged, taskID)
+		if err != nil {
+			return nil, []error{err}
+		}
+		return mts, nil
+	}
+
+	return p.pluginRunner.AvailablePlugins().processMetrics(ctx, metrics, pluginName, pluginVersion, merged, taskID)
 }
 
 func (p *pluginControl) SetAutodiscoverPaths(paths []string) {
@@ -1180,6 +1275,22 @@ func (p *pluginControl) GetTempDir() string {
 	return p.Config.TempDirPath
 }
 
+// ReloadConfig applies the plugin config, the tags and the plugin rate limits
+// of cfg to the running control module. Changing the other settings of cfg
+// requires a restart.
+func (p *pluginControl) ReloadConfig(cfg *Config) error {
+	if err := p.Config.reloadPlugins(cfg.Plugins); err != nil {
+		return err
+	}
+	p.Config.reloadTags(cfg.Tags)
+	p.pluginManager.SetPluginTags(cfg.Tags)
+	p.Config.reloadPluginRateLimits(cfg.PluginRateLimits)
+	controlLogger.WithFields(log.Fields{
+		"_block": "reload-config",
+	}).Info("plugin config and tags reloaded")
+	return nil
+}
+
 func (p *pluginControl) SetPluginTrustLevel(trust int) {
 	p.pluginTrust = trust
 }
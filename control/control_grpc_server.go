@@ -34,7 +34,8 @@ type ControlGRPCServer struct {
 // --------- Scheduler's managesMetrics implementation ----------
 func (pc *ControlGRPCServer) PublishMetrics(ctx context.Context, r *rpc.PubProcMetricsRequest) (*rpc.ErrorReply, error) {
 	metrics := common.ToCoreMetrics(r.Metrics)
-	errs := pc.control.PublishMetrics(
+	errs := pc.control.PublishMetricsContext(
+		ctx,
 		metrics,
 		common.ParseConfig(r.Config),
 		r.TaskId, r.PluginName,
@@ -45,7 +46,8 @@ func (pc *ControlGRPCServer) PublishMetrics(ctx context.Context, r *rpc.PubProcM
 
 func (pc *ControlGRPCServer) ProcessMetrics(ctx context.Context, r *rpc.PubProcMetricsRequest) (*rpc.ProcessMetricsReply, error) {
 	metrics := common.ToCoreMetrics(r.Metrics)
-	mts, errs := pc.control.ProcessMetrics(
+	mts, errs := pc.control.ProcessMetricsContext(
+		ctx,
 		metrics,
 		common.ParseConfig(r.Config),
 		r.TaskId, r.PluginName,
@@ -66,7 +68,7 @@ func (pc *ControlGRPCServer) CollectMetrics(ctx context.Context, r *rpc.CollectM
 			AllTags[k][entry.Key] = entry.Value
 		}
 	}
-	mts, errs := pc.control.CollectMetrics(r.TaskID, AllTags)
+	mts, errs := pc.control.CollectMetricsContext(ctx, r.TaskID, AllTags)
 	var reply *rpc.CollectMetricsResponse
 	if mts == nil {
 		reply = &rpc.CollectMetricsResponse{
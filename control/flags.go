@@ -89,5 +89,21 @@ var (
 		EnvVar: "SNAP_TEMP_DIR_PATH",
 	}
 
-	Flags = []cli.Flag{flNumberOfPLs, flPluginLoadTimeout, flAutoDiscover, flPluginTrust, flKeyringPaths, flCache, flControlRpcPort, flControlRpcAddr, flTempDirPath, flTLSCert, flTLSKey, flCACertPaths}
+	flPluginConfigWriteBack = cli.BoolFlag{
+		Name:  "plugin-config-write-back",
+		Usage: "Persist plugin config changes made through the REST API to an overlay file",
+	}
+
+	flPluginConfigOverlayPath = cli.StringFlag{
+		Name:   "plugin-config-overlay-path",
+		Usage:  fmt.Sprintf("Path of the plugin config overlay file (default: %v next to the config file)", DefaultPluginConfigOverlayFile),
+		EnvVar: "SNAP_PLUGIN_CONFIG_OVERLAY_PATH",
+	}
+
+	flSelfTelemetry = cli.BoolFlag{
+		Name:  "self-telemetry",
+		Usage: "Add the metrics of snapteld itself to the metric catalog under /intel/snap",
+	}
+
+	Flags = []cli.Flag{flNumberOfPLs, flPluginLoadTimeout, flAutoDiscover, flPluginTrust, flKeyringPaths, flCache, flControlRpcPort, flControlRpcAddr, flTempDirPath, flTLSCert, flTLSKey, flCACertPaths, flPluginConfigWriteBack, flPluginConfigOverlayPath, flSelfTelemetry}
 )
@@ -22,6 +22,8 @@ package client
 import (
 	"time"
 
+	"golang.org/x/net/context"
+
 	"github.com/intelsdi-x/snap/control/plugin"
 	"github.com/intelsdi-x/snap/control/plugin/cpolicy"
 	"github.com/intelsdi-x/snap/core"
@@ -66,3 +68,12 @@ type PluginPublisherClient interface {
 	PluginClient
 	Publish([]core.Metric, map[string]ctypes.ConfigValue) error
 }
+
+// PluginContextClient A client bounding its collect, process and publish calls
+// by a context, whose deadline is passed on to the plugin and whose
+// cancellation aborts the calls in flight.
+type PluginContextClient interface {
+	CollectMetricsContext(context.Context, []core.Metric) ([]core.Metric, error)
+	ProcessContext(context.Context, []core.Metric, map[string]ctypes.ConfigValue) ([]core.Metric, error)
+	PublishContext(context.Context, []core.Metric, map[string]ctypes.ConfigValue) error
+}
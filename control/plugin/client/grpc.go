@@ -35,6 +35,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
 
 	"github.com/intelsdi-x/snap/control/plugin"
@@ -48,6 +49,13 @@ import (
 	"google.golang.org/grpc/metadata"
 )
 
+var (
+	// ErrCallTimedOut - The error message for a plugin call not completed before its deadline
+	ErrCallTimedOut = errors.New("Plugin call timed out")
+	// ErrCallCanceled - The error message for a plugin call canceled before completing
+	ErrCallCanceled = errors.New("Plugin call canceled")
+)
+
 // SecureSide identifies security mode to apply in securing gRPC
 type SecureSide int
 
@@ -361,13 +369,21 @@ func (g *grpcClient) Close() error {
 }
 
 func (g *grpcClient) Publish(metrics []core.Metric, config map[string]ctypes.ConfigValue) error {
+	return g.PublishContext(context.Background(), metrics, config)
+}
+
+// PublishContext publishes the metrics, bounding the call by the deadline of
+// ctx and the timeout of the client
+func (g *grpcClient) PublishContext(ctx context.Context, metrics []core.Metric, config map[string]ctypes.ConfigValue) error {
 	arg := &rpc.PubProcArg{
 		Metrics: NewMetrics(metrics),
 		Config:  ToConfigMap(config),
 	}
-	reply, err := g.publisher.Publish(getContext(g.timeout), arg)
+	ctx, cancel := context.WithTimeout(ctx, g.timeout)
+	defer cancel()
+	reply, err := g.publisher.Publish(ctx, arg)
 	if err != nil {
-		return err
+		return callError(ctx, err)
 	}
 	if reply.Error != "" {
 		return errors.New(reply.Error)
@@ -376,14 +392,22 @@ func (g *grpcClient) Publish(metrics []core.Metric, config map[string]ctypes.Con
 }
 
 func (g *grpcClient) Process(metrics []core.Metric, config map[string]ctypes.ConfigValue) ([]core.Metric, error) {
+	return g.ProcessContext(context.Background(), metrics, config)
+}
+
+// ProcessContext processes the metrics, bounding the call by the deadline of
+// ctx and the timeout of the client
+func (g *grpcClient) ProcessContext(ctx context.Context, metrics []core.Metric, config map[string]ctypes.ConfigValue) ([]core.Metric, error) {
 	arg := &rpc.PubProcArg{
 		Metrics: NewMetrics(metrics),
 		Config:  ToConfigMap(config),
 	}
-	reply, err := g.processor.Process(getContext(g.timeout), arg)
+	ctx, cancel := context.WithTimeout(ctx, g.timeout)
+	defer cancel()
+	reply, err := g.processor.Process(ctx, arg)
 
 	if err != nil {
-		return nil, err
+		return nil, callError(ctx, err)
 	}
 	if reply.Error != "" {
 		return nil, errors.New(reply.Error)
@@ -396,13 +420,21 @@ func (g *grpcClient) Process(metrics []core.Metric, config map[string]ctypes.Con
 }
 
 func (g *grpcClient) CollectMetrics(mts []core.Metric) ([]core.Metric, error) {
+	return g.CollectMetricsContext(context.Background(), mts)
+}
+
+// CollectMetricsContext collects the metrics, bounding the call by the
+// deadline of ctx and the timeout of the client
+func (g *grpcClient) CollectMetricsContext(ctx context.Context, mts []core.Metric) ([]core.Metric, error) {
 	arg := &rpc.MetricsArg{
 		Metrics: NewMetrics(mts),
 	}
-	reply, err := g.collector.CollectMetrics(getContext(g.timeout), arg)
+	ctx, cancel := context.WithTimeout(ctx, g.timeout)
+	defer cancel()
+	reply, err := g.collector.CollectMetrics(ctx, arg)
 
 	if err != nil {
-		return nil, err
+		return nil, callError(ctx, err)
 	}
 
 	if reply.Error != "" {
@@ -413,6 +445,18 @@ func (g *grpcClient) CollectMetrics(mts []core.Metric) ([]core.Metric, error) {
 	return metrics, nil
 }
 
+// callError tells the calls which ran out of time or were canceled apart from
+// the ones failing in the plugin
+func callError(ctx context.Context, err error) error {
+	switch {
+	case ctx.Err() == context.DeadlineExceeded || grpc.Code(err) == codes.DeadlineExceeded:
+		return ErrCallTimedOut
+	case ctx.Err() == context.Canceled || grpc.Code(err) == codes.Canceled:
+		return ErrCallCanceled
+	}
+	return err
+}
+
 func (g *grpcClient) UpdateCollectedMetrics(mts []core.Metric) error {
 	if g.stream != nil {
 		arg := &rpc.CollectArg{
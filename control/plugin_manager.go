@@ -41,6 +41,7 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/intelsdi-x/gomit"
+	"github.com/intelsdi-x/snap/control/builtin"
 	"github.com/intelsdi-x/snap/control/plugin"
 	"github.com/intelsdi-x/snap/control/plugin/client"
 	"github.com/intelsdi-x/snap/control/plugin/cpolicy"
@@ -69,6 +70,8 @@ var (
 	ErrPluginCannotBeUnloaded = errors.New("Plugin is used by running task. Stop the task to be able to unload the plugin")
 	// ErrPluginNotInLoadedState - error message when a plugin must ne in a loaded state
 	ErrPluginNotInLoadedState = errors.New("Plugin must be in a LoadedState")
+	// ErrPluginNameReserved - error message when a plugin has the name of a built-in plugin
+	ErrPluginNameReserved = errors.New("Plugin names starting with '" + builtin.Prefix + "' are reserved for built-in plugins")
 
 	pmLogger = log.WithField("_module", "control-plugin-mgr")
 
@@ -260,6 +263,9 @@ type pluginManager struct {
 	pprof             bool
 	tempDirPath       string
 	grpcSecurity      client.GRPCSecurity
+	// pluginTagsMutex protects the tags, which are reloaded while collects
+	// add them to the metrics
+	pluginTagsMutex sync.RWMutex
 }
 
 func newPluginManager(opts ...pluginManagerOpt) *pluginManager {
@@ -343,6 +349,8 @@ func (p *pluginManager) GetPluginConfig() *pluginConfig {
 
 // SetPluginTags sets plugin tags
 func (p *pluginManager) SetPluginTags(tags map[string]map[string]string) {
+	p.pluginTagsMutex.Lock()
+	defer p.pluginTagsMutex.Unlock()
 	p.pluginTags = tags
 }
 
@@ -647,6 +655,15 @@ func (p *pluginManager) LoadPlugin(details *pluginDetails, emitter gomit.Emitter
 			return
 		}
 
+		if builtin.IsBuiltin(resp.Meta.Name) {
+			resultChan <- result{nil, serror.New(ErrPluginNameReserved, map[string]interface{}{
+				"plugin-name":    resp.Meta.Name,
+				"plugin-version": resp.Meta.Version,
+				"plugin-type":    resp.Type.String(),
+			})}
+			return
+		}
+
 		aErr := p.loadedPlugins.add(lPlugin)
 		if aErr != nil {
 			pmLogger.WithFields(log.Fields{
@@ -821,6 +838,7 @@ func (p *pluginManager) AddStandardAndWorkflowTags(m core.Metric, allTags map[st
 	tags[core.STD_TAG_PLUGIN_RUNNING_ON] = hostname
 
 	// apply tags from global tags
+	p.pluginTagsMutex.RLock()
 	for ns, nsTags := range p.pluginTags {
 		if hasPrefix(m.Namespace().Strings(), split(ns)) {
 			for k, v := range nsTags {
@@ -828,6 +846,7 @@ func (p *pluginManager) AddStandardAndWorkflowTags(m core.Metric, allTags map[st
 			}
 		}
 	}
+	p.pluginTagsMutex.RUnlock()
 	// apply tags from workflow
 	for ns, nsTags := range allTags {
 		if hasPrefix(m.Namespace().Strings(), split(ns)) {
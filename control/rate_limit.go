@@ -0,0 +1,99 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// rateLimitMaxWait is how far ahead the calls to a rate limited plugin are
+// queued before the next ones are rejected
+const rateLimitMaxWait = time.Second
+
+// ErrPluginRateLimited - The error message for a collect call rejected by the rate limit of a plugin
+var ErrPluginRateLimited = errors.New("Collect calls exceed the rate limit of the plugin")
+
+// rateLimiter spaces out the calls to each plugin so that they do not exceed
+// the max calls per second of the plugin.
+type rateLimiter struct {
+	mutex *sync.Mutex
+	// next holds, by plugin name, the earliest time of the next call
+	next map[string]time.Time
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{
+		mutex: &sync.Mutex{},
+		next:  map[string]time.Time{},
+	}
+}
+
+// reserve books the next call to the named plugin, given its limit in calls
+// per second, and returns how long the call must wait for its turn. The call
+// is not booked, and false is returned, when its turn is more than
+// rateLimitMaxWait away.
+func (r *rateLimiter) reserve(name string, limit float64, now time.Time) (time.Duration, bool) {
+	if limit <= 0 {
+		return 0, true
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	slot := r.next[name]
+	if slot.Before(now) {
+		slot = now
+	}
+	wait := slot.Sub(now)
+	if wait > rateLimitMaxWait {
+		return wait, false
+	}
+	r.next[name] = slot.Add(time.Duration(float64(time.Second) / limit))
+	return wait, true
+}
+
+// wait blocks until the named plugin can be called within its limit, or
+// returns ErrPluginRateLimited when too many calls are already waiting
+func (r *rateLimiter) wait(name string, limit float64) error {
+	d, ok := r.reserve(name, limit, time.Now())
+	if !ok {
+		return ErrPluginRateLimited
+	}
+	if d > 0 {
+		time.Sleep(d)
+	}
+	return nil
+}
+
+// pluginRateLimit returns the max collect calls per second of the named
+// plugin, or 0 when it is not limited
+func (p *Config) pluginRateLimit(name string) float64 {
+	p.pluginsMutex.RLock()
+	defer p.pluginsMutex.RUnlock()
+	return p.PluginRateLimits[name]
+}
+
+// reloadPluginRateLimits replaces the rate limits of the plugins, which are
+// read by running collects
+func (p *Config) reloadPluginRateLimits(limits map[string]float64) {
+	p.pluginsMutex.Lock()
+	defer p.pluginsMutex.Unlock()
+	p.PluginRateLimits = limits
+}
@@ -0,0 +1,72 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRateLimiter(t *testing.T) {
+	Convey("Given a rate limiter", t, func() {
+		r := newRateLimiter()
+		now := time.Now()
+
+		Convey("calls to a plugin without limit never wait", func() {
+			for i := 0; i < 100; i++ {
+				wait, ok := r.reserve("foo", 0, now)
+				So(ok, ShouldBeTrue)
+				So(wait, ShouldEqual, 0)
+			}
+		})
+		Convey("calls to a limited plugin are spaced out", func() {
+			for i := 0; i < 4; i++ {
+				wait, ok := r.reserve("foo", 4, now)
+				So(ok, ShouldBeTrue)
+				So(wait, ShouldEqual, time.Duration(i)*250*time.Millisecond)
+			}
+			Convey("and rejected once a second of calls is waiting", func() {
+				wait, ok := r.reserve("foo", 4, now)
+				So(ok, ShouldBeTrue)
+				So(wait, ShouldEqual, time.Second)
+				_, ok = r.reserve("foo", 4, now)
+				So(ok, ShouldBeFalse)
+				So(r.wait("foo", 4), ShouldEqual, ErrPluginRateLimited)
+			})
+			Convey("without affecting the other plugins", func() {
+				wait, ok := r.reserve("bar", 4, now)
+				So(ok, ShouldBeTrue)
+				So(wait, ShouldEqual, 0)
+			})
+		})
+		Convey("a plugin not called for a while is not owed calls", func() {
+			r.reserve("foo", 4, now.Add(-time.Minute))
+			wait, ok := r.reserve("foo", 4, now)
+			So(ok, ShouldBeTrue)
+			So(wait, ShouldEqual, 0)
+			wait, _ = r.reserve("foo", 4, now)
+			So(wait, ShouldEqual, 250*time.Millisecond)
+		})
+	})
+}
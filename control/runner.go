@@ -34,6 +34,7 @@ import (
 	"github.com/intelsdi-x/snap/core"
 	"github.com/intelsdi-x/snap/core/control_event"
 	"github.com/intelsdi-x/snap/pkg/aci"
+	"github.com/intelsdi-x/snap/pkg/telemetry"
 )
 
 var (
@@ -304,6 +305,7 @@ func (r *runner) HandleGomitEvent(e gomit.Event) {
 					return
 				}
 				pool.IncRestartCount()
+				telemetry.Add(1, "control", "plugins", v.Name, "restarts")
 
 				runnerLog.WithFields(log.Fields{
 					"_block":        "handle-events",
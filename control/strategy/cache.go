@@ -26,6 +26,7 @@ import (
 
 	"github.com/intelsdi-x/snap/core"
 	"github.com/intelsdi-x/snap/pkg/chrono"
+	"github.com/intelsdi-x/snap/pkg/telemetry"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -68,6 +69,7 @@ func (c *cache) get(ns string, version int) interface{} {
 	key := fmt.Sprintf("%v:%v", ns, version)
 	if cell, ok = c.table[key]; ok && chrono.Chrono.Now().Sub(cell.time) < c.ttl {
 		cell.hits++
+		telemetry.Add(1, "control", "cache", "hits")
 		cacheLog.WithFields(log.Fields{
 			"namespace": key,
 			"hits":      cell.hits,
@@ -85,6 +87,7 @@ func (c *cache) get(ns string, version int) interface{} {
 		}
 	}
 	c.table[key].misses++
+	telemetry.Add(1, "control", "cache", "misses")
 	cacheLog.WithFields(log.Fields{
 		"namespace": key,
 		"hits":      c.table[key].hits,
@@ -27,10 +27,12 @@ import (
 	"net/http"
 	"sync"
 
+	"github.com/intelsdi-x/snap/control/builtin"
 	"github.com/intelsdi-x/snap/control/plugin"
 	"github.com/intelsdi-x/snap/core"
 	"github.com/intelsdi-x/snap/core/cdata"
 	"github.com/intelsdi-x/snap/core/control_event"
+	"github.com/intelsdi-x/snap/core/ctypes"
 	"github.com/intelsdi-x/snap/core/serror"
 
 	log "github.com/sirupsen/logrus"
@@ -62,6 +64,7 @@ type ManagesSubscriptionGroups interface {
 		configTree *cdata.ConfigDataTree, asserts ...core.SubscribedPluginAssert) (serrs []serror.SnapError)
 	validateMetric(metric core.Metric) (serrs []serror.SnapError)
 	validatePluginUnloading(*loadedPlugin) (errs []serror.SnapError)
+	resolvePluginConfig(core.SubscribedPlugin) *cdata.ConfigDataNode
 }
 
 type subscriptionGroup struct {
@@ -268,12 +271,36 @@ func (s *subscriptionGroups) validatePluginUnloading(pluginToUnload *loadedPlugi
 	return errs
 }
 
+// resolvePluginConfig returns the config a plugin is given once subscribed:
+// its config merged with the global config of the plugin and the defaults of
+// its config policy
+func (s *subscriptionGroups) resolvePluginConfig(pl core.SubscribedPlugin) *cdata.ConfigDataNode {
+	typ, err := core.ToPluginType(pl.TypeName())
+	if err != nil {
+		return pl.Config()
+	}
+	mergedConfig := pl.Config().ReverseMerge(
+		s.Config.Plugins.getPluginConfigDataNode(typ, pl.Name(), pl.Version()))
+	lp, err := s.pluginManager.get(key(pl))
+	if err != nil || lp.ConfigPolicy == nil {
+		return mergedConfig
+	}
+	ncdTable, errs := lp.ConfigPolicy.Get([]string{""}).Process(mergedConfig.Table())
+	if errs != nil && errs.HasErrors() {
+		return mergedConfig
+	}
+	return cdata.FromTable(*ncdTable)
+}
+
 func (p *subscriptionGroups) validatePluginSubscription(pl core.SubscribedPlugin, mergedConfig *cdata.ConfigDataNode) []serror.SnapError {
 	var serrs = []serror.SnapError{}
 	controlLogger.WithFields(log.Fields{
 		"_block": "validate-plugin-subscription",
 		"plugin": fmt.Sprintf("%s:%d", pl.Name(), pl.Version()),
 	}).Info(fmt.Sprintf("validating dependencies for plugin %s:%d", pl.Name(), pl.Version()))
+	if pl.TypeName() != core.CollectorPluginType.String() && builtin.IsBuiltin(pl.Name()) {
+		return validateBuiltinSubscription(pl, mergedConfig)
+	}
 	lp, err := p.pluginManager.get(key(pl))
 	if err != nil {
 		serrs = append(serrs, pluginNotFoundError(pl))
@@ -672,6 +699,29 @@ func comparePlugins(newPlugins,
 	return
 }
 
+// validateBuiltinSubscription validates the config of a built-in processor
+// or publisher, which has no plugin to be subscribed to
+func validateBuiltinSubscription(pl core.SubscribedPlugin, mergedConfig *cdata.ConfigDataNode) []serror.SnapError {
+	var validator interface {
+		ValidateConfig(map[string]ctypes.ConfigValue) error
+	}
+	var err error
+	if pl.TypeName() == core.PublisherPluginType.String() {
+		validator, err = builtin.GetPublisher(pl.Name(), pl.Version())
+	} else {
+		validator, err = builtin.Get(pl.Name(), pl.Version())
+	}
+	if err != nil {
+		return []serror.SnapError{pluginNotFoundError(pl)}
+	}
+	if err := validator.ValidateConfig(mergedConfig.Table()); err != nil {
+		se := serror.New(err)
+		se.SetFields(map[string]interface{}{"name": pl.Name(), "version": pl.Version()})
+		return []serror.SnapError{se}
+	}
+	return nil
+}
+
 func pluginNotFoundError(pl core.SubscribedPlugin) serror.SnapError {
 	se := serror.New(fmt.Errorf("Plugin not found: type(%s) name(%s) version(%d)", pl.TypeName(), pl.Name(), pl.Version()))
 	se.SetFields(map[string]interface{}{
@@ -0,0 +1,57 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/intelsdi-x/snap/control/builtin"
+	"github.com/intelsdi-x/snap/control/plugin"
+	"github.com/intelsdi-x/snap/control/plugin/cpolicy"
+)
+
+// addTelemetryMetrics adds the metrics of snapteld to the metric catalog.
+// They are exposed by the built-in telemetry collector, which is not a
+// loaded plugin and is collected in-process.
+func (p *pluginControl) addTelemetryMetrics() {
+	lp := &loadedPlugin{
+		Meta: plugin.PluginMeta{
+			Name:    builtin.TelemetryCollector,
+			Version: builtin.Version,
+			Type:    plugin.CollectorPluginType,
+		},
+		Details:      &pluginDetails{},
+		Type:         plugin.CollectorPluginType,
+		State:        LoadedState,
+		LoadedTime:   time.Now(),
+		ConfigPolicy: cpolicy.New(),
+	}
+	for _, mt := range builtin.TelemetryMetricTypes() {
+		if err := p.metricCatalog.AddLoadedMetricType(lp, mt); err != nil {
+			controlLogger.WithFields(log.Fields{
+				"_block":    "add-telemetry-metrics",
+				"namespace": mt.Namespace().String(),
+				"error":     err,
+			}).Error("error adding snapteld metric to the metric catalog")
+		}
+	}
+}
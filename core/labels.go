@@ -0,0 +1,126 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	labelEquals    = "="
+	labelNotEquals = "!="
+	labelExists    = "exists"
+	labelNotExists = "!exists"
+)
+
+// LabelSelector selects tasks by their labels. It is parsed from a comma
+// separated list of requirements which must all hold:
+//   key=value, key==value  the label is set to value
+//   key!=value             the label is not set to value
+//   key                    the label is set
+//   !key                   the label is not set
+type LabelSelector []labelRequirement
+
+type labelRequirement struct {
+	key   string
+	op    string
+	value string
+}
+
+// ParseLabelSelector parses a selector like team=infra,env!=dev. An empty
+// string selects every task.
+func ParseLabelSelector(s string) (LabelSelector, error) {
+	var sel LabelSelector
+	if strings.TrimSpace(s) == "" {
+		return sel, nil
+	}
+	for _, r := range strings.Split(s, ",") {
+		r = strings.TrimSpace(r)
+		var req labelRequirement
+		switch {
+		case strings.Contains(r, "!="):
+			kv := strings.SplitN(r, "!=", 2)
+			req = labelRequirement{key: kv[0], op: labelNotEquals, value: kv[1]}
+		case strings.Contains(r, "=="):
+			kv := strings.SplitN(r, "==", 2)
+			req = labelRequirement{key: kv[0], op: labelEquals, value: kv[1]}
+		case strings.Contains(r, "="):
+			kv := strings.SplitN(r, "=", 2)
+			req = labelRequirement{key: kv[0], op: labelEquals, value: kv[1]}
+		case strings.HasPrefix(r, "!"):
+			req = labelRequirement{key: r[1:], op: labelNotExists}
+		default:
+			req = labelRequirement{key: r, op: labelExists}
+		}
+		req.key, req.value = strings.TrimSpace(req.key), strings.TrimSpace(req.value)
+		if validateLabel(req.key, req.value) != nil {
+			return nil, fmt.Errorf("Invalid label selector requirement '%s'", r)
+		}
+		sel = append(sel, req)
+	}
+	return sel, nil
+}
+
+// Matches returns true when the labels meet every requirement of the selector
+func (s LabelSelector) Matches(labels map[string]string) bool {
+	for _, r := range s {
+		v, ok := labels[r.key]
+		switch r.op {
+		case labelEquals:
+			if !ok || v != r.value {
+				return false
+			}
+		case labelNotEquals:
+			if ok && v == r.value {
+				return false
+			}
+		case labelExists:
+			if !ok {
+				return false
+			}
+		case labelNotExists:
+			if ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// ValidateLabels returns an error when a label cannot be used in a selector
+func ValidateLabels(labels map[string]string) error {
+	for k, v := range labels {
+		if err := validateLabel(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateLabel(key, value string) error {
+	if key == "" || strings.ContainsAny(key, "=!, \t") {
+		return fmt.Errorf("Invalid label key '%s'", key)
+	}
+	if strings.ContainsAny(value, "=!,") {
+		return fmt.Errorf("Invalid value '%s' for label '%s'", value, key)
+	}
+	return nil
+}
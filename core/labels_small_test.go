@@ -0,0 +1,73 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLabelSelector(t *testing.T) {
+	labels := map[string]string{"team": "infra", "env": "prod"}
+
+	Convey("Parsing a label selector", t, func() {
+		Convey("an empty selector matches every task", func() {
+			sel, err := ParseLabelSelector("")
+			So(err, ShouldBeNil)
+			So(sel.Matches(labels), ShouldBeTrue)
+			So(sel.Matches(nil), ShouldBeTrue)
+		})
+		Convey("equality requirements match the value of a label", func() {
+			sel, err := ParseLabelSelector("team=infra, env==prod")
+			So(err, ShouldBeNil)
+			So(sel.Matches(labels), ShouldBeTrue)
+			So(sel.Matches(map[string]string{"team": "infra"}), ShouldBeFalse)
+		})
+		Convey("inequality requirements match a missing label", func() {
+			sel, err := ParseLabelSelector("team=infra,env!=dev")
+			So(err, ShouldBeNil)
+			So(sel.Matches(labels), ShouldBeTrue)
+			So(sel.Matches(map[string]string{"team": "infra"}), ShouldBeTrue)
+			So(sel.Matches(map[string]string{"team": "infra", "env": "dev"}), ShouldBeFalse)
+		})
+		Convey("existence requirements match whether a label is set", func() {
+			sel, err := ParseLabelSelector("team,!owner")
+			So(err, ShouldBeNil)
+			So(sel.Matches(labels), ShouldBeTrue)
+			So(sel.Matches(map[string]string{"team": "infra", "owner": "ops"}), ShouldBeFalse)
+			So(sel.Matches(map[string]string{"env": "prod"}), ShouldBeFalse)
+		})
+		Convey("invalid requirements are rejected", func() {
+			for _, s := range []string{"team=infra,", "=infra", "team!", "team=a=b"} {
+				_, err := ParseLabelSelector(s)
+				So(err, ShouldNotBeNil)
+			}
+		})
+	})
+
+	Convey("Validating labels", t, func() {
+		So(ValidateLabels(labels), ShouldBeNil)
+		So(ValidateLabels(map[string]string{"": "infra"}), ShouldNotBeNil)
+		So(ValidateLabels(map[string]string{"team": "a,b"}), ShouldNotBeNil)
+	})
+}
@@ -22,6 +22,7 @@ package core
 import (
 	"errors"
 	"fmt"
+	"reflect"
 	"time"
 
 	"github.com/intelsdi-x/snap/pkg/schedule"
@@ -32,17 +33,71 @@ import (
 // swagger:model Schedule
 type Schedule struct {
 	// required: true
-	// enum: simple, windowed, streaming, cron
+	// enum: simple, windowed, streaming, cron, triggered
 	Type string `json:"type"`
 	// required: true
 	Interval       string     `json:"interval"`
 	StartTimestamp *time.Time `json:"start_timestamp,omitempty"`
 	StopTimestamp  *time.Time `json:"stop_timestamp,omitempty"`
 	Count          uint       `json:"count,omitempty"`
+	// Alignment of the firings of simple and windowed schedules
+	// enum: unaligned, aligned, offset
+	Alignment string `json:"alignment,omitempty"`
+	// Offset of the firings with the offset alignment
+	Offset string `json:"offset,omitempty"`
+	// Jitter is the maximum splay of the firings, which is fixed for each task
+	Jitter string `json:"jitter,omitempty"`
+	// CatchUp is the policy for the intervals missed
+	// enum: skip, fire-once, fire-all
+	CatchUp string `json:"catch_up,omitempty"`
+	// Entries are cron entries of a cron schedule in addition to the one of interval
+	Entries []string `json:"entries,omitempty"`
+	// Timezone is the IANA time zone in which cron entries and blackout
+	// windows are evaluated, defaults to the local time of snapteld
+	Timezone string `json:"timezone,omitempty"`
+	// Blackouts are windows of time during which the schedule does not fire
+	Blackouts []BlackoutWindow `json:"blackouts,omitempty"`
+	// Trigger is the metric threshold firing a triggered schedule
+	Trigger *ScheduleTrigger `json:"trigger,omitempty"`
+}
+
+// BlackoutWindow is a window of time during which a schedule does not fire,
+// either recurring from each firing of the cron entry start for duration, or
+// single between start_timestamp and stop_timestamp.
+//
+// swagger:model BlackoutWindow
+type BlackoutWindow struct {
+	Start          string     `json:"start,omitempty"`
+	Duration       string     `json:"duration,omitempty"`
+	StartTimestamp *time.Time `json:"start_timestamp,omitempty"`
+	StopTimestamp  *time.Time `json:"stop_timestamp,omitempty"`
+}
+
+// ScheduleTrigger describes the metric a triggered schedule watches, collected
+// by another task given by its ID or name, and the threshold it must go above
+// or below for the schedule to fire.
+//
+// swagger:model ScheduleTrigger
+type ScheduleTrigger struct {
+	// required: true
+	Task string `json:"task"`
+	// required: true
+	Metric     string   `json:"metric"`
+	Above      *float64 `json:"above,omitempty"`
+	Below      *float64 `json:"below,omitempty"`
+	Hysteresis float64  `json:"hysteresis,omitempty"`
+	Cooldown   string   `json:"cooldown,omitempty"`
+}
+
+// isEmpty returns whether none of the fields of the schedule is set
+func (s *Schedule) isEmpty() bool {
+	return reflect.DeepEqual(*s, Schedule{})
 }
 
 var (
 	ErrMissingScheduleInterval = errors.New("missing `interval` in configuration of schedule")
+	ErrMissingScheduleTrigger  = errors.New("missing `trigger` in configuration of triggered schedule")
+	ErrInvalidScheduleTrigger  = errors.New("exactly one of `above` and `below` must be set in the trigger of schedule")
 )
 
 func makeSchedule(s Schedule) (schedule.Schedule, error) {
@@ -63,6 +118,12 @@ func makeSchedule(s Schedule) (schedule.Schedule, error) {
 			s.StopTimestamp,
 			s.Count,
 		)
+		if sch.IntervalOptions, err = intervalOptions(s); err != nil {
+			return nil, err
+		}
+		if sch.Calendar, err = calendar(s); err != nil {
+			return nil, err
+		}
 
 		err = sch.Validate()
 		if err != nil {
@@ -73,16 +134,193 @@ func makeSchedule(s Schedule) (schedule.Schedule, error) {
 		if s.Interval == "" {
 			return nil, ErrMissingScheduleInterval
 		}
-		sch := schedule.NewCronSchedule(s.Interval)
+		sch := schedule.NewCronSchedule(s.Interval, s.Entries...)
+		var err error
+		if sch.Calendar, err = calendar(s); err != nil {
+			return nil, err
+		}
 
-		err := sch.Validate()
+		err = sch.Validate()
 		if err != nil {
 			return nil, err
 		}
 		return sch, nil
 	case "streaming":
 		return schedule.NewStreamingSchedule(), nil
+	case "triggered":
+		return makeTriggeredSchedule(s)
 	default:
 		return nil, fmt.Errorf("unknown schedule type `%s`", s.Type)
 	}
 }
+
+// intervalOptions parses the options of the firings of an interval schedule
+func intervalOptions(s Schedule) (schedule.IntervalOptions, error) {
+	o := schedule.IntervalOptions{
+		Alignment: schedule.Alignment(s.Alignment),
+		CatchUp:   schedule.CatchUp(s.CatchUp),
+	}
+	var err error
+	if s.Offset != "" {
+		if o.Offset, err = time.ParseDuration(s.Offset); err != nil {
+			return o, err
+		}
+	}
+	if s.Jitter != "" {
+		if o.Jitter, err = time.ParseDuration(s.Jitter); err != nil {
+			return o, err
+		}
+	}
+	return o, nil
+}
+
+// calendar parses the time zone and the blackout windows of a schedule
+func calendar(s Schedule) (schedule.Calendar, error) {
+	var c schedule.Calendar
+	if s.Timezone != "" {
+		loc, err := time.LoadLocation(s.Timezone)
+		if err != nil {
+			return c, fmt.Errorf("unknown `timezone` of schedule: %v", err)
+		}
+		c.Location = loc
+	}
+	for _, b := range s.Blackouts {
+		blackout := schedule.Blackout{
+			Start:     b.Start,
+			StartTime: b.StartTimestamp,
+			StopTime:  b.StopTimestamp,
+		}
+		if b.Duration != "" {
+			d, err := time.ParseDuration(b.Duration)
+			if err != nil {
+				return c, err
+			}
+			blackout.Duration = d
+		}
+		c.Blackouts = append(c.Blackouts, blackout)
+	}
+	return c, nil
+}
+
+// calendarSchedule sets the time zone and the blackout windows of s from c
+func calendarSchedule(s *Schedule, c schedule.Calendar) {
+	if c.Location != nil {
+		s.Timezone = c.Location.String()
+	}
+	for _, b := range c.Blackouts {
+		blackout := BlackoutWindow{
+			Start:          b.Start,
+			StartTimestamp: b.StartTime,
+			StopTimestamp:  b.StopTime,
+		}
+		if b.Duration > 0 {
+			blackout.Duration = b.Duration.String()
+		}
+		s.Blackouts = append(s.Blackouts, blackout)
+	}
+}
+
+func makeTriggeredSchedule(s Schedule) (schedule.Schedule, error) {
+	if s.Trigger == nil {
+		return nil, ErrMissingScheduleTrigger
+	}
+	// the interval is optional, a schedule without one fires once on each trigger
+	var d time.Duration
+	if s.Interval != "" {
+		var err error
+		if d, err = time.ParseDuration(s.Interval); err != nil {
+			return nil, err
+		}
+	}
+	tr := schedule.Trigger{
+		Task:       s.Trigger.Task,
+		Metric:     s.Trigger.Metric,
+		Hysteresis: s.Trigger.Hysteresis,
+	}
+	switch {
+	case s.Trigger.Above != nil && s.Trigger.Below == nil:
+		tr.Threshold = *s.Trigger.Above
+	case s.Trigger.Below != nil && s.Trigger.Above == nil:
+		tr.Threshold, tr.Below = *s.Trigger.Below, true
+	default:
+		return nil, ErrInvalidScheduleTrigger
+	}
+	if s.Trigger.Cooldown != "" {
+		c, err := time.ParseDuration(s.Trigger.Cooldown)
+		if err != nil {
+			return nil, err
+		}
+		tr.Cooldown = c
+	}
+
+	sch := schedule.NewTriggeredSchedule(d, tr)
+	if err := sch.Validate(); err != nil {
+		return nil, err
+	}
+	return sch, nil
+}
+
+// MakeSchedule returns the schedule.Schedule described by the given Schedule.
+func MakeSchedule(s Schedule) (schedule.Schedule, error) {
+	return makeSchedule(s)
+}
+
+// ScheduleFromSchedule returns the Schedule describing the given schedule.Schedule.
+// It returns nil if the schedule type is unknown.
+func ScheduleFromSchedule(s schedule.Schedule) *Schedule {
+	switch v := s.(type) {
+	case *schedule.WindowedSchedule:
+		sch := &Schedule{
+			Type:           "windowed",
+			Interval:       v.Interval.String(),
+			StartTimestamp: v.StartTime,
+			StopTimestamp:  v.StopTime,
+			Count:          v.Count,
+			Alignment:      string(v.Alignment),
+			CatchUp:        string(v.CatchUp),
+		}
+		if v.Offset > 0 {
+			sch.Offset = v.Offset.String()
+		}
+		if v.Jitter > 0 {
+			sch.Jitter = v.Jitter.String()
+		}
+		calendarSchedule(sch, v.Calendar)
+		return sch
+	case *schedule.CronSchedule:
+		sch := &Schedule{
+			Type:     "cron",
+			Interval: v.Entry(),
+			Entries:  v.Entries()[1:],
+		}
+		calendarSchedule(sch, v.Calendar)
+		return sch
+	case *schedule.StreamingSchedule:
+		return &Schedule{
+			Type: "streaming",
+		}
+	case *schedule.TriggeredSchedule:
+		sch := &Schedule{
+			Type: "triggered",
+			Trigger: &ScheduleTrigger{
+				Task:       v.Trigger.Task,
+				Metric:     v.Trigger.Metric,
+				Hysteresis: v.Trigger.Hysteresis,
+			},
+		}
+		if v.Interval > 0 {
+			sch.Interval = v.Interval.String()
+		}
+		if v.Trigger.Cooldown > 0 {
+			sch.Trigger.Cooldown = v.Trigger.Cooldown.String()
+		}
+		threshold := v.Trigger.Threshold
+		if v.Trigger.Below {
+			sch.Trigger.Below = &threshold
+		} else {
+			sch.Trigger.Above = &threshold
+		}
+		return sch
+	}
+	return nil
+}
@@ -82,6 +82,7 @@ type Task interface {
 	SetName(string)
 	SetID(string)
 	MissedCount() uint
+	SkippedCount() uint
 	FailedCount() uint
 	LastFailureMessage() string
 	LastRunTime() *time.Time
@@ -98,13 +99,37 @@ type Task interface {
 	Option(...TaskOption) TaskOption
 	WMap() *wmap.WorkflowMap
 	Schedule() schedule.Schedule
+	PublishBuffers() []PublishBufferStatus
+	Labels() map[string]string
+	SetLabels(map[string]string)
+	Dependencies() []TaskDependency
+	SetDependencies([]TaskDependency)
+	AdaptiveInterval() bool
+	SetAdaptiveInterval(bool)
+	EffectiveInterval() time.Duration
+	Priority() string
+	SetPriority(string)
+}
+
+// PublishBufferStatus describes the metrics held by the buffer of a publish node
+// of a task while its publisher fails
+type PublishBufferStatus struct {
+	PluginName    string `json:"plugin_name"`
+	PluginVersion int    `json:"plugin_version"`
+	// Depth is the number of buffered batches of metrics
+	Depth int `json:"depth"`
+	// Size is the size of the buffered metrics in bytes
+	Size int64 `json:"size"`
+	// Dropped is the number of batches dropped because of the limits of the buffer
+	Dropped uint64 `json:"dropped"`
 }
 
 type TaskOption func(Task) TaskOption
 
 // TaskDeadlineDuration sets the tasks deadline.
 // The deadline is the amount of time that can pass before a worker begins
-// processing the tasks collect job.
+// processing the tasks collect job, and within which the plugin calls of the
+// job must complete.
 func TaskDeadlineDuration(v time.Duration) TaskOption {
 	return func(t Task) TaskOption {
 		previous := t.DeadlineDuration()
@@ -150,6 +175,16 @@ func SetTaskName(name string) TaskOption {
 	}
 }
 
+// SetTaskLabels sets the labels of the task, which are used to select tasks
+// with a LabelSelector.
+func SetTaskLabels(labels map[string]string) TaskOption {
+	return func(t Task) TaskOption {
+		previous := t.Labels()
+		t.SetLabels(labels)
+		return SetTaskLabels(previous)
+	}
+}
+
 func SetTaskID(id string) TaskOption {
 	return func(t Task) TaskOption {
 		previous := t.ID()
@@ -174,6 +209,16 @@ func SetMaxCollectDuration(d time.Duration) TaskOption {
 	}
 }
 
+// SetAdaptiveInterval sets whether the task stretches the interval of its
+// schedule while its collection cannot keep up with it.
+func SetAdaptiveInterval(v bool) TaskOption {
+	return func(t Task) TaskOption {
+		previous := t.AdaptiveInterval()
+		t.SetAdaptiveInterval(v)
+		return SetAdaptiveInterval(previous)
+	}
+}
+
 type TaskErrors interface {
 	Errors() []serror.SnapError
 }
@@ -188,6 +233,10 @@ type TaskCreationRequest struct {
 	MaxFailures        int               `json:"max-failures"`
 	MaxCollectDuration string            `json:"max-collect-duration"`
 	MaxMetricsBuffer   int64             `json:"max-metrics-buffer"`
+	Labels             map[string]string `json:"labels,omitempty"`
+	Dependencies       []TaskDependency  `json:"dependencies,omitempty"`
+	AdaptiveInterval   bool              `json:"adaptive-interval,omitempty"`
+	Priority           string            `json:"priority,omitempty"`
 }
 
 func (tr *TaskCreationRequest) UnmarshalJSON(data []byte) error {
@@ -233,6 +282,22 @@ func (tr *TaskCreationRequest) UnmarshalJSON(data []byte) error {
 			if err := json.Unmarshal(v, &(tr.MaxMetricsBuffer)); err != nil {
 				return fmt.Errorf("%v (while parsing 'max-metrics-buffer')", err)
 			}
+		case "labels":
+			if err := json.Unmarshal(v, &(tr.Labels)); err != nil {
+				return fmt.Errorf("%v (while parsing 'labels')", err)
+			}
+		case "dependencies":
+			if err := json.Unmarshal(v, &(tr.Dependencies)); err != nil {
+				return fmt.Errorf("%v (while parsing 'dependencies')", err)
+			}
+		case "adaptive-interval":
+			if err := json.Unmarshal(v, &(tr.AdaptiveInterval)); err != nil {
+				return fmt.Errorf("%v (while parsing 'adaptive-interval')", err)
+			}
+		case "priority":
+			if err := json.Unmarshal(v, &(tr.Priority)); err != nil {
+				return fmt.Errorf("%v (while parsing 'priority')", err)
+			}
 		default:
 			return fmt.Errorf("Unrecognized key '%v' in task creation request", k)
 		}
@@ -255,6 +320,17 @@ func CreateTaskFromContent(body io.ReadCloser,
 	if err != nil {
 		return nil, err
 	}
+	return createTaskFromRequest(tr, mode, fp)
+}
+
+// createTaskFromRequest creates the task of a task creation request, see
+// CreateTaskFromContent
+func createTaskFromRequest(tr *TaskCreationRequest,
+	mode *bool,
+	fp func(sch schedule.Schedule,
+		wfMap *wmap.WorkflowMap,
+		startOnCreate bool,
+		opts ...TaskOption) (Task, TaskErrors)) (Task, error) {
 
 	if err := validateTaskRequest(tr); err != nil {
 		return nil, err
@@ -300,6 +376,31 @@ func CreateTaskFromContent(body io.ReadCloser,
 		opts = append(opts, SetMaxCollectDuration(dl))
 	}
 
+	if len(tr.Labels) > 0 {
+		if err := ValidateLabels(tr.Labels); err != nil {
+			return nil, err
+		}
+		opts = append(opts, SetTaskLabels(tr.Labels))
+	}
+
+	if len(tr.Dependencies) > 0 {
+		if err := ValidateDependencies(tr.Dependencies); err != nil {
+			return nil, err
+		}
+		opts = append(opts, SetTaskDependencies(tr.Dependencies))
+	}
+
+	if tr.AdaptiveInterval {
+		opts = append(opts, SetAdaptiveInterval(true))
+	}
+
+	if tr.Priority != "" {
+		if err := ValidateTaskPriority(tr.Priority); err != nil {
+			return nil, err
+		}
+		opts = append(opts, SetTaskPriority(tr.Priority))
+	}
+
 	if fp == nil {
 		return nil, errors.New("Missing workflow creation routine")
 	}
@@ -322,6 +423,150 @@ func CreateTaskFromContent(body io.ReadCloser,
 	return task, nil
 }
 
+// TaskUpdateRequest holds the changes to an existing task. The fields left
+// empty are not changed.
+type TaskUpdateRequest struct {
+	Name        string            `json:"name,omitempty"`
+	Deadline    string            `json:"deadline,omitempty"`
+	Workflow    *wmap.WorkflowMap `json:"workflow,omitempty"`
+	Schedule    *Schedule         `json:"schedule,omitempty"`
+	MaxFailures int               `json:"max-failures,omitempty"`
+	// Labels replace the labels of the task when they are given, an empty
+	// map removing them
+	Labels map[string]string `json:"labels,omitempty"`
+	// Dependencies replace the dependencies of the task when they are given,
+	// an empty list removing them
+	Dependencies []TaskDependency `json:"dependencies,omitempty"`
+}
+
+func (tr *TaskUpdateRequest) UnmarshalJSON(data []byte) error {
+	t := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &t); err != nil {
+		return err
+	}
+	for k, v := range t {
+		switch k {
+		case "name":
+			if err := json.Unmarshal(v, &(tr.Name)); err != nil {
+				return fmt.Errorf("%v (while parsing 'name')", err)
+			}
+		case "deadline":
+			if err := json.Unmarshal(v, &(tr.Deadline)); err != nil {
+				return fmt.Errorf("%v (while parsing 'deadline')", err)
+			}
+		case "workflow":
+			if err := json.Unmarshal(v, &(tr.Workflow)); err != nil {
+				return err
+			}
+		case "schedule":
+			if err := json.Unmarshal(v, &(tr.Schedule)); err != nil {
+				return err
+			}
+		case "max-failures":
+			if err := json.Unmarshal(v, &(tr.MaxFailures)); err != nil {
+				return fmt.Errorf("%v (while parsing 'max-failures')", err)
+			}
+		case "labels":
+			if err := json.Unmarshal(v, &(tr.Labels)); err != nil {
+				return fmt.Errorf("%v (while parsing 'labels')", err)
+			}
+			if tr.Labels == nil {
+				tr.Labels = map[string]string{}
+			}
+		case "dependencies":
+			if err := json.Unmarshal(v, &(tr.Dependencies)); err != nil {
+				return fmt.Errorf("%v (while parsing 'dependencies')", err)
+			}
+			if tr.Dependencies == nil {
+				tr.Dependencies = []TaskDependency{}
+			}
+		default:
+			return fmt.Errorf("Unrecognized key '%v' in task update request", k)
+		}
+	}
+	return nil
+}
+
+// UpdateTaskFromContent updates the task with the given id according to
+// content, which holds the fields of a task to change. The function pointer is
+// responsible for effectively updating and returning the task.
+func UpdateTaskFromContent(id string, body io.ReadCloser,
+	fp func(id string,
+		sch schedule.Schedule,
+		wfMap *wmap.WorkflowMap,
+		opts ...TaskOption) (Task, TaskErrors)) (Task, error) {
+
+	var tr TaskUpdateRequest
+	if errCode, err := UnmarshalBody(&tr, body); errCode != 0 && err != nil {
+		return nil, err
+	}
+	if tr.Schedule == nil && tr.Workflow == nil && tr.Deadline == "" && tr.MaxFailures == 0 && tr.Name == "" && tr.Labels == nil && tr.Dependencies == nil {
+		return nil, fmt.Errorf("Task update must include a schedule, workflow, deadline, max-failures, name, labels or dependencies")
+	}
+
+	var sch schedule.Schedule
+	if tr.Schedule != nil {
+		if tr.Schedule.isEmpty() {
+			return nil, fmt.Errorf("The schedule of a task must not be empty")
+		}
+		var err error
+		if sch, err = makeSchedule(*tr.Schedule); err != nil {
+			return nil, err
+		}
+	}
+	if tr.Workflow != nil && *tr.Workflow == (wmap.WorkflowMap{}) {
+		return nil, fmt.Errorf("The workflow of a task must not be empty")
+	}
+
+	var opts []TaskOption
+	if tr.Deadline != "" {
+		dl, err := time.ParseDuration(tr.Deadline)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, TaskDeadlineDuration(dl))
+	}
+	if tr.Name != "" {
+		opts = append(opts, SetTaskName(tr.Name))
+	}
+	if tr.MaxFailures != 0 {
+		opts = append(opts, OptionStopOnFailure(tr.MaxFailures))
+	}
+	if tr.Labels != nil {
+		if err := ValidateLabels(tr.Labels); err != nil {
+			return nil, err
+		}
+		opts = append(opts, SetTaskLabels(tr.Labels))
+	}
+	if tr.Dependencies != nil {
+		if err := ValidateDependencies(tr.Dependencies); err != nil {
+			return nil, err
+		}
+		opts = append(opts, SetTaskDependencies(tr.Dependencies))
+	}
+
+	if fp == nil {
+		return nil, errors.New("Missing task update routine")
+	}
+	task, errs := fp(id, sch, tr.Workflow, opts...)
+	if errs != nil && len(errs.Errors()) != 0 {
+		var errMsg string
+		for _, e := range errs.Errors() {
+			errMsg = errMsg + e.Error() + " -- "
+
+			log.WithFields(log.Fields{
+				"_file":     "core/task.go",
+				"_function": "UpdateTaskFromContent",
+				"_error":    e.Error(),
+				"_fields":   e.Fields(),
+			}).Error("error updating task")
+		}
+
+		return nil, errors.New(errMsg[:len(errMsg)-4])
+	}
+	return task, nil
+}
+
 func createTaskRequest(body io.ReadCloser) (*TaskCreationRequest, error) {
 	var tr TaskCreationRequest
 	errCode, err := UnmarshalBody(&tr, body)
@@ -343,13 +588,20 @@ func UnmarshalBody(in interface{}, body io.ReadCloser) (int, error) {
 	return 0, nil
 }
 
+var (
+	// ErrTaskScheduleEmpty - The error message for a task request without a schedule
+	ErrTaskScheduleEmpty = errors.New("Task must include a schedule, and the schedule must not be empty")
+	// ErrTaskWorkflowEmpty - The error message for a task request without a workflow
+	ErrTaskWorkflowEmpty = errors.New("Task must include a workflow, and the workflow must not be empty")
+)
+
 func validateTaskRequest(tr *TaskCreationRequest) error {
-	if tr.Schedule == nil || *tr.Schedule == (Schedule{}) {
-		return fmt.Errorf("Task must include a schedule, and the schedule must not be empty")
+	if tr.Schedule == nil || tr.Schedule.isEmpty() {
+		return ErrTaskScheduleEmpty
 	}
 
 	if tr.Workflow == nil || *tr.Workflow == (wmap.WorkflowMap{}) {
-		return fmt.Errorf("Task must include a workflow, and the workflow must not be empty")
+		return ErrTaskWorkflowEmpty
 	}
 	return nil
 }
@@ -0,0 +1,87 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"fmt"
+)
+
+// The events of a task which the tasks depending on it react to
+const (
+	DependencyOnEnded    = "ended"
+	DependencyOnDisabled = "disabled"
+	DependencyOnStopped  = "stopped"
+)
+
+// The actions run on a task when an event of the task it depends on occurs
+const (
+	DependencyActionStart  = "start"
+	DependencyActionStop   = "stop"
+	DependencyActionEnable = "enable"
+)
+
+// TaskDependency makes a task react to an event of another task, given by its
+// ID or its name: when the other task ends, is disabled or is stopped, the
+// action is run on the dependent task. For instance a diagnostic task can be
+// started when a baseline task is disabled, or a cleanup task when a task with
+// a windowed schedule ends.
+type TaskDependency struct {
+	Task   string `json:"task"`
+	Event  string `json:"event"`
+	Action string `json:"action"`
+}
+
+// Matches returns whether the dependency reacts to the given event of the
+// task with the given ID and name
+func (d TaskDependency) Matches(id, name, event string) bool {
+	return d.Event == event && (d.Task == id || d.Task == name)
+}
+
+// ValidateDependencies checks the events and the actions of dependencies
+func ValidateDependencies(deps []TaskDependency) error {
+	for _, d := range deps {
+		if d.Task == "" {
+			return fmt.Errorf("Task dependency must name the task it depends on")
+		}
+		switch d.Event {
+		case DependencyOnEnded, DependencyOnDisabled, DependencyOnStopped:
+		default:
+			return fmt.Errorf("Unknown event '%s' of task dependency on '%s', expected one of %s, %s or %s",
+				d.Event, d.Task, DependencyOnEnded, DependencyOnDisabled, DependencyOnStopped)
+		}
+		switch d.Action {
+		case DependencyActionStart, DependencyActionStop, DependencyActionEnable:
+		default:
+			return fmt.Errorf("Unknown action '%s' of task dependency on '%s', expected one of %s, %s or %s",
+				d.Action, d.Task, DependencyActionStart, DependencyActionStop, DependencyActionEnable)
+		}
+	}
+	return nil
+}
+
+// SetTaskDependencies sets the tasks the task depends on and the actions
+// their events trigger on it.
+func SetTaskDependencies(deps []TaskDependency) TaskOption {
+	return func(t Task) TaskOption {
+		previous := t.Dependencies()
+		t.SetDependencies(deps)
+		return SetTaskDependencies(previous)
+	}
+}
@@ -0,0 +1,52 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTaskDependencies(t *testing.T) {
+	Convey("Parsing the dependencies of a task creation request", t, func() {
+		tr := &TaskCreationRequest{}
+		err := json.Unmarshal([]byte(`{"dependencies": [{"task": "baseline", "event": "disabled", "action": "start"}]}`), tr)
+		So(err, ShouldBeNil)
+		So(tr.Dependencies, ShouldResemble, []TaskDependency{{Task: "baseline", Event: DependencyOnDisabled, Action: DependencyActionStart}})
+		So(ValidateDependencies(tr.Dependencies), ShouldBeNil)
+
+		Convey("a dependency matches the ID or the name of a task", func() {
+			d := tr.Dependencies[0]
+			So(d.Matches("1234", "baseline", DependencyOnDisabled), ShouldBeTrue)
+			So(d.Matches("baseline", "Task-baseline", DependencyOnDisabled), ShouldBeTrue)
+			So(d.Matches("1234", "baseline", DependencyOnEnded), ShouldBeFalse)
+			So(d.Matches("1234", "cleanup", DependencyOnDisabled), ShouldBeFalse)
+		})
+	})
+	Convey("Validating dependencies", t, func() {
+		So(ValidateDependencies([]TaskDependency{{Event: DependencyOnEnded, Action: DependencyActionStart}}), ShouldNotBeNil)
+		So(ValidateDependencies([]TaskDependency{{Task: "baseline", Event: "failed", Action: DependencyActionStart}}), ShouldNotBeNil)
+		So(ValidateDependencies([]TaskDependency{{Task: "baseline", Event: DependencyOnEnded, Action: "remove"}}), ShouldNotBeNil)
+	})
+}
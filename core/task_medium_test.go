@@ -22,9 +22,12 @@ limitations under the License.
 package core
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/intelsdi-x/snap/core/serror"
@@ -306,3 +309,49 @@ func TestCreateTaskFromContent(t *testing.T) {
 		})
 	})
 }
+
+func TestValidateTaskFromContent(t *testing.T) {
+	validationRoutine := func(sch schedule.Schedule, wfMap *wmap.WorkflowMap) *TaskValidation {
+		v := &TaskValidation{}
+		v.Metrics = append(v.Metrics, NewResolvedConfig("workflow.collect.metrics['/intel/mock/foo']", "/intel/mock/foo", "", 1, nil))
+		if sch == nil {
+			v.AddError("workflow.collect.publish[0]", serror.New(errors.New("Dummy error")))
+		}
+		return v
+	}
+
+	Convey("Proper JSON file", t, func() {
+		body := ioutil.NopCloser(bytes.NewReader(JSON_FILE_CONTENT))
+		v := ValidateTaskFromContent(body, validationRoutine)
+		So(v.Valid, ShouldBeTrue)
+		So(v.Errors, ShouldBeEmpty)
+		So(v.Metrics, ShouldHaveLength, 1)
+		So(v.Metrics[0].Path, ShouldEqual, "workflow.collect.metrics['/intel/mock/foo']")
+	})
+
+	Convey("Every error is returned with its path", t, func() {
+		body := ioutil.NopCloser(strings.NewReader(`{
+			"schedule": {"type": "simple"},
+			"deadline": "soon",
+			"labels": {"team": "a=b"},
+			"workflow": {"collect": {"metrics": {"/intel/mock/foo": {}}}}
+		}`))
+		v := ValidateTaskFromContent(body, validationRoutine)
+		So(v.Valid, ShouldBeFalse)
+		paths := []string{}
+		for _, e := range v.Errors {
+			paths = append(paths, e.Path)
+		}
+		So(paths, ShouldResemble, []string{"schedule", "deadline", "labels", "workflow.collect.publish[0]"})
+		So(v.Errors[0].Message, ShouldEqual, ErrMissingScheduleInterval.Error())
+	})
+
+	Convey("Missing workflow", t, func() {
+		body := ioutil.NopCloser(strings.NewReader(`{"schedule": {"type": "streaming"}}`))
+		v := ValidateTaskFromContent(body, validationRoutine)
+		So(v.Valid, ShouldBeFalse)
+		So(v.Errors, ShouldHaveLength, 1)
+		So(v.Errors[0].Path, ShouldEqual, "workflow")
+		So(v.Metrics, ShouldBeEmpty)
+	})
+}
@@ -0,0 +1,56 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"fmt"
+)
+
+// The priority classes of tasks, whose jobs are served in proportion to the
+// weight of their class and shed from the lowest class first when the queues
+// of the scheduler are full
+const (
+	TaskPriorityHigh   = "high"
+	TaskPriorityNormal = "normal"
+	TaskPriorityLow    = "low"
+)
+
+// TaskPriorities lists the priority classes of tasks from the highest
+var TaskPriorities = []string{TaskPriorityHigh, TaskPriorityNormal, TaskPriorityLow}
+
+// ValidateTaskPriority checks that p names a priority class
+func ValidateTaskPriority(p string) error {
+	for _, c := range TaskPriorities {
+		if p == c {
+			return nil
+		}
+	}
+	return fmt.Errorf("Unknown task priority '%s', expected one of %s, %s or %s",
+		p, TaskPriorityHigh, TaskPriorityNormal, TaskPriorityLow)
+}
+
+// SetTaskPriority sets the priority class of the jobs of the task.
+func SetTaskPriority(p string) TaskOption {
+	return func(t Task) TaskOption {
+		previous := t.Priority()
+		t.SetPriority(p)
+		return SetTaskPriority(previous)
+	}
+}
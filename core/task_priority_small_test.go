@@ -0,0 +1,45 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTaskPriority(t *testing.T) {
+	Convey("Parsing the priority of a task creation request", t, func() {
+		tr := &TaskCreationRequest{}
+		So(json.Unmarshal([]byte(`{"priority": "high"}`), tr), ShouldBeNil)
+		So(tr.Priority, ShouldEqual, TaskPriorityHigh)
+		So(ValidateTaskPriority(tr.Priority), ShouldBeNil)
+	})
+	Convey("Validating priorities", t, func() {
+		for _, p := range TaskPriorities {
+			So(ValidateTaskPriority(p), ShouldBeNil)
+		}
+		So(ValidateTaskPriority("urgent"), ShouldNotBeNil)
+		So(ValidateTaskPriority(""), ShouldNotBeNil)
+	})
+}
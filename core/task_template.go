@@ -0,0 +1,295 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/intelsdi-x/snap/pkg/schedule"
+	"github.com/intelsdi-x/snap/scheduler/wmap"
+)
+
+// The types of the parameters of a task template
+const (
+	TemplateParamString   = "string"
+	TemplateParamInteger  = "integer"
+	TemplateParamNumber   = "number"
+	TemplateParamBoolean  = "boolean"
+	TemplateParamDuration = "duration"
+)
+
+var (
+	// ErrTemplateNameInvalid - The error message for a template with an invalid name
+	ErrTemplateNameInvalid = errors.New("Template name must start with a letter or a digit and contain only letters, digits, '.', '_' and '-'")
+	// ErrTemplateTaskMissing - The error message for a template without a task manifest
+	ErrTemplateTaskMissing = errors.New("Template must include a task manifest")
+
+	templateNameRegexp  = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+	templateParamRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	placeholderRegexp   = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+)
+
+// TaskTemplate is a task manifest with ${param} placeholders, instantiated
+// into tasks with the values of the parameters it declares. A placeholder
+// making up a whole JSON string is replaced by the value of the parameter as
+// typed, so that "${port}" becomes 8080 for an integer parameter, while
+// placeholders within a string or a key are replaced by the text of the
+// value. The other placeholders are expanded from the environment like in
+// task manifests, before the values are substituted.
+type TaskTemplate struct {
+	Name        string              `json:"name"`
+	Description string              `json:"description,omitempty"`
+	Parameters  []TemplateParameter `json:"parameters"`
+	Task        json.RawMessage     `json:"task"`
+}
+
+// TemplateParameter declares a parameter of a task template. A parameter
+// without a default value is required.
+type TemplateParameter struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	Type        string      `json:"type,omitempty"`
+	Default     interface{} `json:"default,omitempty"`
+}
+
+// Validate checks the name and the parameters of a template, and that its
+// task is a JSON object
+func (t *TaskTemplate) Validate() error {
+	if !templateNameRegexp.MatchString(t.Name) {
+		return ErrTemplateNameInvalid
+	}
+	var manifest map[string]interface{}
+	if len(t.Task) == 0 || json.Unmarshal(t.Task, &manifest) != nil || manifest == nil {
+		return ErrTemplateTaskMissing
+	}
+	seen := map[string]bool{}
+	for _, p := range t.Parameters {
+		if !templateParamRegexp.MatchString(p.Name) {
+			return fmt.Errorf("Invalid template parameter name '%s'", p.Name)
+		}
+		if seen[p.Name] {
+			return fmt.Errorf("Template parameter '%s' is declared more than once", p.Name)
+		}
+		seen[p.Name] = true
+		switch p.Type {
+		case "", TemplateParamString, TemplateParamInteger, TemplateParamNumber, TemplateParamBoolean, TemplateParamDuration:
+		default:
+			return fmt.Errorf("Unknown type '%s' of template parameter '%s'", p.Type, p.Name)
+		}
+		if p.Default != nil {
+			if _, err := p.convert(p.Default); err != nil {
+				return fmt.Errorf("Invalid default value of template parameter '%s': %v", p.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Instantiate returns the task manifest of the template with its placeholders
+// replaced by the given values, or by the default values of the parameters
+// which are not given
+func (t *TaskTemplate) Instantiate(values map[string]interface{}) ([]byte, error) {
+	params := map[string]interface{}{}
+	declared := map[string]bool{}
+	for _, p := range t.Parameters {
+		declared[p.Name] = true
+		v, ok := values[p.Name]
+		if !ok {
+			v = p.Default
+		}
+		if v == nil {
+			return nil, fmt.Errorf("Missing value of template parameter '%s'", p.Name)
+		}
+		cv, err := p.convert(v)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid value of template parameter '%s': %v", p.Name, err)
+		}
+		params[p.Name] = cv
+	}
+	for name := range values {
+		if !declared[name] {
+			return nil, fmt.Errorf("Unknown template parameter '%s'", name)
+		}
+	}
+
+	// the environment variables are expanded in the template rather than in
+	// the task, so that the values of the parameters are kept as given
+	text := os.Expand(string(t.Task), func(name string) string {
+		if declared[name] {
+			return "${" + name + "}"
+		}
+		return os.Getenv(name)
+	})
+	var manifest interface{}
+	d := json.NewDecoder(strings.NewReader(text))
+	d.UseNumber()
+	if err := d.Decode(&manifest); err != nil {
+		return nil, err
+	}
+	return json.Marshal(substitute(manifest, params))
+}
+
+// substitute replaces the placeholders of the strings and the keys of a
+// decoded JSON document
+func substitute(v interface{}, params map[string]interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[substituteString(k, params)] = substitute(e, params)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, e := range v {
+			l[i] = substitute(e, params)
+		}
+		return l
+	case string:
+		if m := placeholderRegexp.FindStringSubmatch(v); m != nil && m[0] == v {
+			if value, ok := params[m[1]]; ok {
+				return value
+			}
+		}
+		return substituteString(v, params)
+	}
+	return v
+}
+
+func substituteString(s string, params map[string]interface{}) string {
+	return placeholderRegexp.ReplaceAllStringFunc(s, func(placeholder string) string {
+		name := placeholder[2 : len(placeholder)-1]
+		if value, ok := params[name]; ok {
+			return fmt.Sprint(value)
+		}
+		return placeholder
+	})
+}
+
+// convert returns a value given to the parameter as its type. Values given
+// as strings, like on the command line, are parsed.
+func (p TemplateParameter) convert(v interface{}) (interface{}, error) {
+	s, isString := v.(string)
+	switch p.Type {
+	case "", TemplateParamString:
+		switch v.(type) {
+		case string, float64, json.Number, bool, int, int64:
+			return fmt.Sprint(v), nil
+		}
+	case TemplateParamInteger:
+		if isString {
+			return strconv.ParseInt(s, 10, 64)
+		}
+		switch n := v.(type) {
+		case float64:
+			if n == float64(int64(n)) {
+				return int64(n), nil
+			}
+		case json.Number:
+			return n.Int64()
+		case int:
+			return int64(n), nil
+		case int64:
+			return n, nil
+		}
+	case TemplateParamNumber:
+		if isString {
+			return strconv.ParseFloat(s, 64)
+		}
+		switch n := v.(type) {
+		case float64:
+			return n, nil
+		case json.Number:
+			return n.Float64()
+		case int:
+			return float64(n), nil
+		case int64:
+			return float64(n), nil
+		}
+	case TemplateParamBoolean:
+		if isString {
+			return strconv.ParseBool(s)
+		}
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+	case TemplateParamDuration:
+		if isString {
+			if _, err := time.ParseDuration(s); err != nil {
+				return nil, err
+			}
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("%v is not a %s", v, p.Type)
+}
+
+// Function used to create a task from a template according to the values of
+// its parameters
+// . Mode is used to specify if the created task should start right away or
+// not, and defaults to the start field of the task manifest
+// . function pointer is responsible for effectively creating and returning the created task
+func CreateTaskFromTemplate(t *TaskTemplate,
+	values map[string]interface{},
+	mode *bool,
+	fp func(sch schedule.Schedule,
+		wfMap *wmap.WorkflowMap,
+		startOnCreate bool,
+		opts ...TaskOption) (Task, TaskErrors)) (Task, error) {
+
+	manifest, err := t.Instantiate(values)
+	if err != nil {
+		return nil, err
+	}
+	tr := &TaskCreationRequest{}
+	if err := json.Unmarshal(manifest, tr); err != nil {
+		return nil, err
+	}
+	return createTaskFromRequest(tr, mode, fp)
+}
+
+// TemplateValues are the values of the parameters of a template used to
+// create a task, as read from a values file or the body of a request.
+type TemplateValues struct {
+	Template string                 `json:"template"`
+	Values   map[string]interface{} `json:"values"`
+}
+
+// ReadTemplateValues reads the JSON template values of content
+func ReadTemplateValues(body io.Reader) (*TemplateValues, error) {
+	tv := &TemplateValues{}
+	d := json.NewDecoder(body)
+	d.UseNumber()
+	if err := d.Decode(tv); err != nil {
+		return nil, err
+	}
+	if tv.Template != "" && !templateNameRegexp.MatchString(tv.Template) {
+		return nil, ErrTemplateNameInvalid
+	}
+	return tv, nil
+}
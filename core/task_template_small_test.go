@@ -0,0 +1,126 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTaskTemplate(t *testing.T) {
+	tmpl := &TaskTemplate{
+		Name: "collect-to-file",
+		Parameters: []TemplateParameter{
+			{Name: "interval", Type: TemplateParamDuration, Default: "10s"},
+			{Name: "port", Type: TemplateParamInteger},
+			{Name: "host", Default: "localhost"},
+			{Name: "verbose", Type: TemplateParamBoolean, Default: false},
+		},
+		Task: json.RawMessage(`{
+			"schedule": {"type": "simple", "interval": "${interval}"},
+			"workflow": {"collect": {
+				"metrics": {"/intel/mock/${host}/*": {}},
+				"config": {"/intel/mock": {"port": "${port}", "url": "http://${host}:${port}", "verbose": "${verbose}", "home": "${TEMPLATE_TEST_HOME}"}}
+			}}
+		}`),
+	}
+
+	Convey("Validating a task template", t, func() {
+		So(tmpl.Validate(), ShouldBeNil)
+
+		Convey("the name must be valid", func() {
+			invalid := *tmpl
+			invalid.Name = "collect to file"
+			So(invalid.Validate(), ShouldEqual, ErrTemplateNameInvalid)
+		})
+		Convey("the task must be a JSON object", func() {
+			invalid := *tmpl
+			invalid.Task = json.RawMessage(`"task"`)
+			So(invalid.Validate(), ShouldEqual, ErrTemplateTaskMissing)
+		})
+		Convey("parameters must be declared once with a known type", func() {
+			invalid := *tmpl
+			invalid.Parameters = []TemplateParameter{{Name: "port"}, {Name: "port"}}
+			So(invalid.Validate(), ShouldNotBeNil)
+			invalid.Parameters = []TemplateParameter{{Name: "port", Type: "port"}}
+			So(invalid.Validate(), ShouldNotBeNil)
+		})
+		Convey("defaults must match the type of their parameter", func() {
+			invalid := *tmpl
+			invalid.Parameters = []TemplateParameter{{Name: "port", Type: TemplateParamInteger, Default: "http"}}
+			So(invalid.Validate(), ShouldNotBeNil)
+		})
+	})
+
+	Convey("Instantiating a task template", t, func() {
+		os.Setenv("TEMPLATE_TEST_HOME", "/home/snap")
+		defer os.Unsetenv("TEMPLATE_TEST_HOME")
+
+		Convey("substitutes typed values and text", func() {
+			b, err := tmpl.Instantiate(map[string]interface{}{"port": "8080", "verbose": true})
+			So(err, ShouldBeNil)
+			var manifest struct {
+				Schedule Schedule `json:"schedule"`
+				Workflow struct {
+					Collect struct {
+						Metrics map[string]interface{}            `json:"metrics"`
+						Config  map[string]map[string]interface{} `json:"config"`
+					} `json:"collect"`
+				} `json:"workflow"`
+			}
+			So(json.Unmarshal(b, &manifest), ShouldBeNil)
+			So(manifest.Schedule.Interval, ShouldEqual, "10s")
+			So(manifest.Workflow.Collect.Metrics, ShouldContainKey, "/intel/mock/localhost/*")
+			cfg := manifest.Workflow.Collect.Config["/intel/mock"]
+			So(cfg["port"], ShouldEqual, float64(8080))
+			So(cfg["url"], ShouldEqual, "http://localhost:8080")
+			So(cfg["verbose"], ShouldEqual, true)
+			So(cfg["home"], ShouldEqual, "/home/snap")
+		})
+		Convey("fails when a required value is missing", func() {
+			_, err := tmpl.Instantiate(nil)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "port")
+		})
+		Convey("fails on unknown parameters and invalid values", func() {
+			_, err := tmpl.Instantiate(map[string]interface{}{"port": 8080, "user": "snap"})
+			So(err, ShouldNotBeNil)
+			_, err = tmpl.Instantiate(map[string]interface{}{"port": 8080, "interval": "often"})
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("Reading template values", t, func() {
+		tv, err := ReadTemplateValues(strings.NewReader(`{"template": "collect-to-file", "values": {"port": 8080}}`))
+		So(err, ShouldBeNil)
+		So(tv.Template, ShouldEqual, "collect-to-file")
+		_, err = tmpl.Instantiate(tv.Values)
+		So(err, ShouldBeNil)
+
+		_, err = ReadTemplateValues(strings.NewReader(`{"template": "../tasks"}`))
+		So(err, ShouldEqual, ErrTemplateNameInvalid)
+	})
+}
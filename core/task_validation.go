@@ -0,0 +1,172 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	"github.com/intelsdi-x/snap/core/cdata"
+	"github.com/intelsdi-x/snap/core/ctypes"
+	"github.com/intelsdi-x/snap/core/serror"
+	"github.com/intelsdi-x/snap/pkg/schedule"
+	"github.com/intelsdi-x/snap/scheduler/wmap"
+)
+
+// TaskValidation is the outcome of validating a task manifest without
+// creating the task: the errors found in the manifest and the config each
+// metric and plugin of its workflow resolves to.
+type TaskValidation struct {
+	Valid   bool                  `json:"valid"`
+	Errors  []TaskValidationError `json:"errors"`
+	Metrics []ResolvedConfig      `json:"metrics"`
+	Plugins []ResolvedConfig      `json:"plugins"`
+}
+
+// TaskValidationError is an error of a task manifest, located by the JSON path
+// of the element it applies to, like workflow.collect.publish[0]. The path is
+// empty for the errors about the manifest as a whole.
+type TaskValidationError struct {
+	Path    string                 `json:"path"`
+	Message string                 `json:"message"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+}
+
+// ResolvedConfig is the config a metric or a plugin of a workflow is given
+// once the task is created: its config in the workflow merged with the global
+// config of the plugin and the defaults of the config policy of the plugin.
+// Name is the namespace of a metric and the name of a plugin.
+type ResolvedConfig struct {
+	Path    string                 `json:"path"`
+	Name    string                 `json:"name"`
+	Type    string                 `json:"type,omitempty"`
+	Version int                    `json:"version"`
+	Config  map[string]interface{} `json:"config"`
+}
+
+// NewResolvedConfig returns the ResolvedConfig of the metric or plugin at the
+// given path of a workflow
+func NewResolvedConfig(path, name, typeName string, version int, cfg *cdata.ConfigDataNode) ResolvedConfig {
+	values := map[string]interface{}{}
+	if cfg != nil {
+		for k, v := range cfg.Table() {
+			switch v := v.(type) {
+			case ctypes.ConfigValueInt:
+				values[k] = v.Value
+			case ctypes.ConfigValueStr:
+				values[k] = v.Value
+			case ctypes.ConfigValueFloat:
+				values[k] = v.Value
+			case ctypes.ConfigValueBool:
+				values[k] = v.Value
+			}
+		}
+	}
+	return ResolvedConfig{Path: path, Name: name, Type: typeName, Version: version, Config: values}
+}
+
+// AddError records an error at the given path of the manifest
+func (v *TaskValidation) AddError(path string, e serror.SnapError) {
+	v.Errors = append(v.Errors, TaskValidationError{
+		Path:    path,
+		Message: e.Error(),
+		Fields:  e.Fields(),
+	})
+}
+
+// AddErrors records errors at the given path of the manifest
+func (v *TaskValidation) AddErrors(path string, errs []serror.SnapError) {
+	for _, e := range errs {
+		v.AddError(path, e)
+	}
+}
+
+// Merge adds the errors and resolved configs of other to v
+func (v *TaskValidation) Merge(other *TaskValidation) {
+	if other == nil {
+		return
+	}
+	v.Errors = append(v.Errors, other.Errors...)
+	v.Metrics = append(v.Metrics, other.Metrics...)
+	v.Plugins = append(v.Plugins, other.Plugins...)
+}
+
+// Function used to validate a task according to content (1st parameter)
+// without creating it. The content is parsed like by CreateTaskFromContent,
+// but every error found is returned instead of the first one.
+// . function pointer is responsible for validating the schedule and the
+// workflow against the plugins and metrics loaded. It is given a nil schedule
+// when the schedule of the content is not valid.
+func ValidateTaskFromContent(body io.ReadCloser,
+	fp func(sch schedule.Schedule,
+		wfMap *wmap.WorkflowMap) *TaskValidation) *TaskValidation {
+
+	v := &TaskValidation{}
+	tr, err := createTaskRequest(body)
+	if err != nil {
+		v.AddError("", serror.New(err))
+		return v
+	}
+
+	var sch schedule.Schedule
+	if tr.Schedule == nil || tr.Schedule.isEmpty() {
+		v.AddError("schedule", serror.New(ErrTaskScheduleEmpty))
+	} else if sch, err = makeSchedule(*tr.Schedule); err != nil {
+		v.AddError("schedule", serror.New(err))
+	}
+
+	if tr.Deadline != "" {
+		if _, err := time.ParseDuration(tr.Deadline); err != nil {
+			v.AddError("deadline", serror.New(err))
+		}
+	}
+	if tr.MaxCollectDuration != "" {
+		if _, err := time.ParseDuration(tr.MaxCollectDuration); err != nil {
+			v.AddError("max-collect-duration", serror.New(err))
+		}
+	}
+	if len(tr.Labels) > 0 {
+		if err := ValidateLabels(tr.Labels); err != nil {
+			v.AddError("labels", serror.New(err))
+		}
+	}
+	if len(tr.Dependencies) > 0 {
+		if err := ValidateDependencies(tr.Dependencies); err != nil {
+			v.AddError("dependencies", serror.New(err))
+		}
+	}
+	if tr.Priority != "" {
+		if err := ValidateTaskPriority(tr.Priority); err != nil {
+			v.AddError("priority", serror.New(err))
+		}
+	}
+
+	if tr.Workflow == nil || *tr.Workflow == (wmap.WorkflowMap{}) {
+		v.AddError("workflow", serror.New(ErrTaskWorkflowEmpty))
+	} else if fp == nil {
+		v.AddError("workflow", serror.New(errors.New("Missing workflow validation routine")))
+	} else {
+		v.Merge(fp(sch, tr.Workflow))
+	}
+
+	v.Valid = len(v.Errors) == 0
+	return v
+}
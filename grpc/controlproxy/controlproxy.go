@@ -19,10 +19,11 @@ limitations under the License.
 package controlproxy
 
 import (
-	"context"
 	"errors"
 	"time"
 
+	"golang.org/x/net/context"
+
 	"github.com/intelsdi-x/snap/core"
 	"github.com/intelsdi-x/snap/core/cdata"
 	"github.com/intelsdi-x/snap/core/ctypes"
@@ -61,6 +62,17 @@ func (c ControlProxy) PublishMetrics(metrics []core.Metric,
 	taskId string,
 	pluginName string,
 	pluginVersion int) []error {
+	return c.PublishMetricsContext(context.Background(), metrics, config, taskId, pluginName, pluginVersion)
+}
+
+// PublishMetricsContext publishes the metrics within the deadline of ctx,
+// which is passed on to the remote control and its plugins
+func (c ControlProxy) PublishMetricsContext(ctx context.Context,
+	metrics []core.Metric,
+	config map[string]ctypes.ConfigValue,
+	taskId string,
+	pluginName string,
+	pluginVersion int) []error {
 
 	req := &rpc.PubProcMetricsRequest{
 		Metrics:       common.NewMetrics(metrics),
@@ -69,7 +81,9 @@ func (c ControlProxy) PublishMetrics(metrics []core.Metric,
 		TaskId:        taskId,
 		Config:        common.ToConfigMap(config),
 	}
-	reply, err := c.Client.PublishMetrics(getContext(), req)
+	ctx, cancel := context.WithTimeout(ctx, MAX_CONNECTION_TIMEOUT)
+	defer cancel()
+	reply, err := c.Client.PublishMetrics(ctx, req)
 	var errs []error
 	if err != nil {
 		errs = append(errs, err)
@@ -81,6 +95,17 @@ func (c ControlProxy) PublishMetrics(metrics []core.Metric,
 }
 
 func (c ControlProxy) ProcessMetrics(metrics []core.Metric,
+	config map[string]ctypes.ConfigValue,
+	taskId string,
+	pluginName string,
+	pluginVersion int) ([]core.Metric, []error) {
+	return c.ProcessMetricsContext(context.Background(), metrics, config, taskId, pluginName, pluginVersion)
+}
+
+// ProcessMetricsContext processes the metrics within the deadline of ctx,
+// which is passed on to the remote control and its plugins
+func (c ControlProxy) ProcessMetricsContext(ctx context.Context,
+	metrics []core.Metric,
 	config map[string]ctypes.ConfigValue,
 	taskId string,
 	pluginName string,
@@ -92,7 +117,9 @@ func (c ControlProxy) ProcessMetrics(metrics []core.Metric,
 		TaskId:        taskId,
 		Config:        common.ToConfigMap(config),
 	}
-	reply, err := c.Client.ProcessMetrics(getContext(), req)
+	ctx, cancel := context.WithTimeout(ctx, MAX_CONNECTION_TIMEOUT)
+	defer cancel()
+	reply, err := c.Client.ProcessMetrics(ctx, req)
 	var errs []error
 	if err != nil {
 		errs = append(errs, err)
@@ -104,6 +131,12 @@ func (c ControlProxy) ProcessMetrics(metrics []core.Metric,
 }
 
 func (c ControlProxy) CollectMetrics(taskID string, AllTags map[string]map[string]string) ([]core.Metric, []error) {
+	return c.CollectMetricsContext(context.Background(), taskID, AllTags)
+}
+
+// CollectMetricsContext collects the metrics within the deadline of ctx,
+// which is passed on to the remote control and its plugins
+func (c ControlProxy) CollectMetricsContext(ctx context.Context, taskID string, AllTags map[string]map[string]string) ([]core.Metric, []error) {
 	var allTags map[string]*rpc.Map
 	for k, v := range AllTags {
 		tags := &rpc.Map{}
@@ -120,7 +153,9 @@ func (c ControlProxy) CollectMetrics(taskID string, AllTags map[string]map[strin
 		TaskID:  taskID,
 		AllTags: allTags,
 	}
-	reply, err := c.Client.CollectMetrics(getContext(), req)
+	ctx, cancel := context.WithTimeout(ctx, MAX_CONNECTION_TIMEOUT)
+	defer cancel()
+	reply, err := c.Client.CollectMetrics(ctx, req)
 	var errs []error
 	if err != nil {
 		errs = append(errs, err)
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"fmt"
+
 	"github.com/julienschmidt/httprouter"
 )
 
@@ -12,7 +14,46 @@ type API interface {
 	BindConfigManager(Config)
 }
 
+// Route is a REST API endpoint. Role is the role required to call it when
+// REST API authentication is enabled. The calls of routes with an Audit
+// operation, like task.create, are recorded in the audit log.
 type Route struct {
 	Method, Path string
 	Handle       httprouter.Handle
+	Role         Role
+	Audit        string
+}
+
+// Role is a level of access to the REST API. Each role is granted the
+// permissions of the roles below it.
+type Role int
+
+const (
+	// RoleReadOnly can read plugins, metrics, tasks and their configuration
+	RoleReadOnly Role = iota
+	// RoleTaskOperator can also create, start, stop and remove tasks
+	RoleTaskOperator
+	// RolePluginAdmin can also load and unload plugins and change their configuration
+	RolePluginAdmin
+	// RoleAdmin can also manage API tokens, tribe agreements and profiling
+	RoleAdmin
+)
+
+var roleNames = []string{"read-only", "task-operator", "plugin-admin", "admin"}
+
+func (r Role) String() string {
+	if r < 0 || int(r) >= len(roleNames) {
+		return fmt.Sprintf("Role(%d)", int(r))
+	}
+	return roleNames[r]
+}
+
+// ParseRole returns the role of the given name
+func ParseRole(name string) (Role, error) {
+	for i, n := range roleNames {
+		if n == name {
+			return Role(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown role '%s', expected one of %v", name, roleNames)
 }
@@ -12,4 +12,6 @@ type Config interface {
 	MergePluginConfigDataNodeAll(cdn *cdata.ConfigDataNode) cdata.ConfigDataNode
 	DeletePluginConfigDataNodeField(pluginType core.PluginType, name string, ver int, fields ...string) cdata.ConfigDataNode
 	DeletePluginConfigDataNodeFieldAll(fields ...string) cdata.ConfigDataNode
+	GetPluginConfigDataNodeSources(pluginType core.PluginType, name string, ver int) map[string]string
+	GetPluginConfigDataNodeAllSources() map[string]string
 }
@@ -16,4 +16,12 @@ type Tasks interface {
 	RemoveTask(string) error
 	WatchTask(string, core.TaskWatcherHandler) (core.TaskWatcherCloser, error)
 	EnableTask(string) (core.Task, error)
+	ReplayTask(string) (int, error)
+	UpdateTask(string, schedule.Schedule, *wmap.WorkflowMap, ...core.TaskOption) (core.Task, core.TaskErrors)
+	ValidateTask(schedule.Schedule, *wmap.WorkflowMap) *core.TaskValidation
+	AddTemplate(*core.TaskTemplate) error
+	GetTemplates() []*core.TaskTemplate
+	GetTemplate(string) (*core.TaskTemplate, error)
+	RemoveTemplate(string) error
+	InstantiateTemplate(string, map[string]interface{}, *bool) (core.Task, error)
 }
@@ -0,0 +1,152 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/urfave/negroni"
+
+	"github.com/intelsdi-x/snap/mgmt/rest/api"
+	"github.com/intelsdi-x/snap/mgmt/rest/v2"
+	"github.com/intelsdi-x/snap/pkg/audit"
+)
+
+// AuditResponse is the list of audit log entries returned by the API
+type AuditResponse struct {
+	Entries []audit.Entry `json:"entries"`
+}
+
+// auditBody hashes the body of a request as it is read by the handle
+type auditBody struct {
+	io.ReadCloser
+	hash hash.Hash
+	read int64
+}
+
+func (b *auditBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.hash.Write(p[:n])
+	b.read += int64(n)
+	return n, err
+}
+
+// audit wraps the handle of a route so that its calls are recorded in the
+// audit log as the given operation. An operation ending with {action} is
+// completed by the action query parameter, like task.{action} for
+// PUT /v2/tasks/:id?action=start.
+func (s *Server) audit(operation string, handle httprouter.Handle) httprouter.Handle {
+	return func(rw http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		// dry runs do not change anything
+		dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+		if !audit.Enabled() || dryRun {
+			handle(rw, r, p)
+			return
+		}
+		op := operation
+		if strings.HasSuffix(op, "{action}") {
+			op = strings.TrimSuffix(op, "{action}") + r.URL.Query().Get("action")
+		}
+		body := &auditBody{ReadCloser: r.Body, hash: sha256.New()}
+		r.Body = body
+		handle(rw, r, p)
+		status := rw.(negroni.ResponseWriter).Status()
+
+		e := audit.Entry{
+			Actor:     "anonymous",
+			Source:    r.RemoteAddr,
+			Operation: op,
+			Target:    auditTarget(p),
+			Status:    status,
+			Outcome:   audit.OutcomeSuccess,
+		}
+		if id, ok := r.Context().Value(identityKey{}).(identity); ok {
+			e.Actor, e.Role = id.name, id.role.String()
+		}
+		if body.read > 0 {
+			e.PayloadHash = hex.EncodeToString(body.hash.Sum(nil))
+		}
+		if status >= 400 {
+			e.Outcome = audit.OutcomeFailure
+			e.Error = http.StatusText(status)
+		}
+		audit.Record(e)
+	}
+}
+
+// auditTarget identifies what a call changes from the parameters of its
+// path, like collector:mock:1 for a plugin
+func auditTarget(p httprouter.Params) string {
+	values := make([]string, 0, len(p))
+	for _, param := range p {
+		values = append(values, param.Value)
+	}
+	return strings.Join(values, ":")
+}
+
+// addAuditRoutes exposes the audit log to admins
+func (s *Server) addAuditRoutes() {
+	s.r.GET("/v2/audit", s.authorize(api.RoleAdmin, s.getAudit))
+}
+
+func (s *Server) getAudit(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	values := r.URL.Query()
+	q := audit.Query{
+		Operation: values.Get("operation"),
+		Actor:     values.Get("actor"),
+		Target:    values.Get("target"),
+	}
+	var err error
+	if v := values.Get("since"); v != "" {
+		if q.Since, err = time.Parse(time.RFC3339, v); err != nil {
+			v2.Write(400, v2.FromError(err), w)
+			return
+		}
+	}
+	if v := values.Get("until"); v != "" {
+		if q.Until, err = time.Parse(time.RFC3339, v); err != nil {
+			v2.Write(400, v2.FromError(err), w)
+			return
+		}
+	}
+	if v := values.Get("limit"); v != "" {
+		if q.Limit, err = strconv.Atoi(v); err != nil {
+			v2.Write(400, v2.FromError(err), w)
+			return
+		}
+	}
+	entries, err := audit.Search(q)
+	switch err {
+	case nil:
+		v2.Write(200, AuditResponse{Entries: entries}, w)
+	case audit.ErrNotEnabled:
+		v2.Write(404, v2.FromError(err), w)
+	default:
+		v2.Write(500, v2.FromError(err), w)
+	}
+}
@@ -0,0 +1,81 @@
+// +build medium
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/intelsdi-x/snap/pkg/audit"
+)
+
+func TestRestAPIAudit(t *testing.T) {
+	Convey("Given a REST API with an audit log", t, func() {
+		dir, err := ioutil.TempDir("", "snap-rest-audit")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		l, err := audit.New(filepath.Join(dir, "audit.log"), 0, 0)
+		So(err, ShouldBeNil)
+		audit.SetLogger(l)
+		defer audit.SetLogger(nil)
+		defer l.Close()
+
+		cfg := GetDefaultConfig()
+		cfg.Tokens = []*TokenConfig{{Name: "grafana", Token: "dashboards", Role: "read-only"}}
+		s := newAuthServer(cfg)
+		s.addAuditRoutes()
+
+		Convey("the calls changing the tokens are recorded", func() {
+			So(serve(s, "POST", "/v2/tokens", `{"name": "deployer", "role": "plugin-admin"}`, password("secret")).Code, ShouldEqual, 201)
+			So(serve(s, "DELETE", "/v2/tokens/unknown", "", password("secret")).Code, ShouldEqual, 404)
+
+			rec := serve(s, "GET", "/v2/audit?operation=token.", "", password("secret"))
+			So(rec.Code, ShouldEqual, 200)
+			var resp AuditResponse
+			So(json.Unmarshal(rec.Body.Bytes(), &resp), ShouldBeNil)
+			So(resp.Entries, ShouldHaveLength, 2)
+
+			created := resp.Entries[0]
+			So(created.Operation, ShouldEqual, "token.create")
+			So(created.Actor, ShouldEqual, "password")
+			So(created.Role, ShouldEqual, "admin")
+			So(created.Source, ShouldNotBeEmpty)
+			So(created.PayloadHash, ShouldNotBeEmpty)
+			So(created.Outcome, ShouldEqual, audit.OutcomeSuccess)
+
+			removed := resp.Entries[1]
+			So(removed.Operation, ShouldEqual, "token.remove")
+			So(removed.Target, ShouldEqual, "unknown")
+			So(removed.Status, ShouldEqual, 404)
+			So(removed.Outcome, ShouldEqual, audit.OutcomeFailure)
+		})
+		Convey("the audit log is only queried by admins", func() {
+			So(serve(s, "GET", "/v2/audit", "", bearer("dashboards")).Code, ShouldEqual, 403)
+			So(serve(s, "GET", "/v2/audit?since=yesterday", "", password("secret")).Code, ShouldEqual, 400)
+		})
+	})
+}
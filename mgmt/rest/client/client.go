@@ -24,6 +24,7 @@ import (
 	"bytes"
 	"compress/gzip"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -50,6 +51,7 @@ var (
 	ErrUnknown     = errors.New("Unknown error calling API")
 	ErrNilResponse = errors.New("Nil response from JSON unmarshalling")
 	ErrDirNotFile  = errors.New("Provided plugin path is a directory not file")
+	ErrBadCACert   = errors.New("No certificate found in the CA bundle")
 )
 
 const (
@@ -79,6 +81,8 @@ type Client struct {
 	// Basic http auth username/password
 	Username string
 	Password string
+	// client certificate presented to, and CA bundle verifying, Snap's HTTPS API
+	certPath, keyPath, caPath string
 }
 
 // Checks validity of URL
@@ -112,6 +116,21 @@ func Timeout(t time.Duration) metaOp {
 	}
 }
 
+//ClientCert is an option that can be provided to the func client.New in order to present a client certificate to Snap's HTTPS API.
+func ClientCert(certPath, keyPath string) metaOp {
+	return func(c *Client) {
+		c.certPath = certPath
+		c.keyPath = keyPath
+	}
+}
+
+//CACert is an option that can be provided to the func client.New in order to verify Snap's HTTPS API with a CA bundle.
+func CACert(path string) metaOp {
+	return func(c *Client) {
+		c.caPath = path
+	}
+}
+
 var (
 	secureTransport = &http.Transport{
 		TLSClientConfig: &tls.Config{InsecureSkipVerify: false},
@@ -149,10 +168,44 @@ func New(url, ver string, insecure bool, opts ...metaOp) (*Client, error) {
 	for _, opt := range opts {
 		opt(c)
 	}
+	if c.certPath != "" || c.keyPath != "" || c.caPath != "" {
+		tlsConfig, err := c.tlsConfig(insecure)
+		if err != nil {
+			return nil, err
+		}
+		c.http.Transport = &http.Transport{
+			TLSClientConfig: tlsConfig,
+			IdleConnTimeout: time.Second,
+		}
+	}
 	c.prefix = url + "/" + ver
 	return c, nil
 }
 
+// tlsConfig returns the TLS configuration presenting the client certificate
+// and verifying the server with the CA bundle of the client
+func (c *Client) tlsConfig(insecure bool) (*tls.Config, error) {
+	config := &tls.Config{InsecureSkipVerify: insecure}
+	if c.certPath != "" || c.keyPath != "" {
+		cert, err := tls.LoadX509KeyPair(c.certPath, c.keyPath)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	if c.caPath != "" {
+		b, err := ioutil.ReadFile(c.caPath)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(b) {
+			return nil, ErrBadCACert
+		}
+	}
+	return config, nil
+}
+
 // String returns the string representation of the content type given a content number.
 func (t contentType) String() string {
 	return contentTypes[t]
@@ -266,6 +319,57 @@ func (c *Client) do(method, path string, ct contentType, body ...[]byte) (*rbody
 	return httpRespToAPIResp(rsp)
 }
 
+// postV2 posts a JSON body to an endpoint of the v2 API, which responds
+// without the envelope of the v1 API. The response is decoded into out when
+// its status is one of ok, and its message returned as an error otherwise.
+func (c *Client) postV2(path string, body []byte, out interface{}, ok ...int) error {
+	return c.doV2("POST", path, body, out, ok...)
+}
+
+// doV2 calls an endpoint of the v2 API like postV2 does, with any method. The
+// response is not decoded when out is nil.
+func (c *Client) doV2(method, path string, body []byte, out interface{}, ok ...int) error {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, c.URL+"/v2"+path, reader)
+	if err != nil {
+		return err
+	}
+	addAuth(req, c.Username, c.Password)
+	if body != nil {
+		req.Header.Add("Content-Type", ContentTypeJSON.String())
+	}
+	rsp, err := c.http.Do(req)
+	if err != nil {
+		if strings.Contains(err.Error(), "tls: oversized record") || strings.Contains(err.Error(), "malformed HTTP response") {
+			return fmt.Errorf("error connecting to API URI: %s. Do you have an http/https mismatch?", c.URL)
+		}
+		return fmt.Errorf("URL target is not available. %v", err)
+	}
+	defer rsp.Body.Close()
+
+	for _, code := range ok {
+		if rsp.StatusCode == code {
+			if out == nil {
+				return nil
+			}
+			return json.NewDecoder(rsp.Body).Decode(out)
+		}
+	}
+	if rsp.StatusCode == 401 {
+		return fmt.Errorf("Invalid credentials")
+	}
+	var e struct {
+		Message string `json:"message"`
+	}
+	if err := json.NewDecoder(rsp.Body).Decode(&e); err != nil || e.Message == "" {
+		return fmt.Errorf("Request failed with status %d", rsp.StatusCode)
+	}
+	return errors.New(e.Message)
+}
+
 func httpRespToAPIResp(rsp *http.Response) (*rbody.APIResponse, error) {
 	if rsp.StatusCode == 401 {
 		return nil, fmt.Errorf("Invalid credentials")
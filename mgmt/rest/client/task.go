@@ -25,6 +25,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
@@ -34,7 +35,7 @@ import (
 )
 
 type Schedule struct {
-	// Type specifies the type of the schedule. Currently, the type of "simple", "windowed" and "cron" are supported.
+	// Type specifies the type of the schedule. Currently, the type of "simple", "windowed", "cron" and "triggered" are supported.
 	Type string `json:"type,omitempty"`
 	// Interval specifies the time duration.
 	Interval string `json:"interval,omitempty"`
@@ -45,6 +46,22 @@ type Schedule struct {
 	// Count specifies the number of expected runs (defaults to 0 what means no limit, set to 1 means single run task).
 	// Count is supported by "simple" and "windowed" schedules
 	Count uint `json:"count,omitempty"`
+	// Alignment specifies how the firings of "simple" and "windowed" schedules are aligned: "unaligned", "aligned" or "offset".
+	Alignment string `json:"alignment,omitempty"`
+	// Offset specifies the shift of the firings with the "offset" alignment.
+	Offset string `json:"offset,omitempty"`
+	// Jitter specifies the maximum splay of the firings, which is fixed for each task.
+	Jitter string `json:"jitter,omitempty"`
+	// CatchUp specifies the policy for missed intervals: "skip", "fire-once" or "fire-all".
+	CatchUp string `json:"catch_up,omitempty"`
+	// Entries specifies cron entries of a "cron" schedule in addition to the one of Interval.
+	Entries []string `json:"entries,omitempty"`
+	// Timezone specifies the IANA time zone in which cron entries and blackout windows are evaluated.
+	Timezone string `json:"timezone,omitempty"`
+	// Blackouts specifies windows of time during which the schedule does not fire.
+	Blackouts []core.BlackoutWindow `json:"blackouts,omitempty"`
+	// Trigger specifies the metric threshold firing a "triggered" schedule.
+	Trigger *core.ScheduleTrigger `json:"trigger,omitempty"`
 }
 
 // CreateTask creates a task given the schedule, workflow, task name, and task state.
@@ -52,6 +69,12 @@ type Schedule struct {
 // Otherwise, it's in the Stopped state. CreateTask is accomplished through a POST HTTP JSON request.
 // A ScheduledTask is returned if it succeeds, otherwise an error is returned.
 func (c *Client) CreateTask(s *Schedule, wf *wmap.WorkflowMap, name string, deadline string, startTask bool, maxFailures int) *CreateTaskResult {
+	return c.CreateTaskWithLabels(s, wf, name, deadline, startTask, maxFailures, nil)
+}
+
+// CreateTaskWithLabels creates a task like CreateTask, with labels which are
+// used to select the task.
+func (c *Client) CreateTaskWithLabels(s *Schedule, wf *wmap.WorkflowMap, name string, deadline string, startTask bool, maxFailures int, labels map[string]string) *CreateTaskResult {
 	t := core.TaskCreationRequest{
 		Schedule: &core.Schedule{
 			Type:           s.Type,
@@ -59,10 +82,19 @@ func (c *Client) CreateTask(s *Schedule, wf *wmap.WorkflowMap, name string, dead
 			StartTimestamp: s.StartTimestamp,
 			StopTimestamp:  s.StopTimestamp,
 			Count:          s.Count,
+			Alignment:      s.Alignment,
+			Offset:         s.Offset,
+			Jitter:         s.Jitter,
+			CatchUp:        s.CatchUp,
+			Entries:        s.Entries,
+			Timezone:       s.Timezone,
+			Blackouts:      s.Blackouts,
+			Trigger:        s.Trigger,
 		},
 		Workflow:    wf,
 		Start:       startTask,
 		MaxFailures: maxFailures,
+		Labels:      labels,
 	}
 	if name != "" {
 		t.Name = name
@@ -70,6 +102,12 @@ func (c *Client) CreateTask(s *Schedule, wf *wmap.WorkflowMap, name string, dead
 	if deadline != "" {
 		t.Deadline = deadline
 	}
+	return c.CreateTaskFromRequest(t)
+}
+
+// CreateTaskFromRequest creates the task described by a task creation
+// request, which holds all the settings of a task manifest.
+func (c *Client) CreateTaskFromRequest(t core.TaskCreationRequest) *CreateTaskResult {
 	// Marshal to JSON for request body
 	j, err := json.Marshal(t)
 	if err != nil {
@@ -179,7 +217,17 @@ func (c *Client) WatchTask(id string) *WatchTasksResult {
 // A list of scheduled tasks returns if it succeeds.
 // Otherwise. an error is returned.
 func (c *Client) GetTasks() *GetTasksResult {
-	resp, err := c.do("GET", "/tasks", ContentTypeJSON, nil)
+	return c.GetTasksBySelector("")
+}
+
+// GetTasksBySelector retrieves the tasks with labels matching the selector,
+// like team=infra,env!=dev. An empty selector retrieves every task.
+func (c *Client) GetTasksBySelector(selector string) *GetTasksResult {
+	path := "/tasks"
+	if selector != "" {
+		path += "?selector=" + url.QueryEscape(selector)
+	}
+	resp, err := c.do("GET", path, ContentTypeJSON, nil)
 	if err != nil {
 		return &GetTasksResult{Err: err}
 	}
@@ -292,12 +340,74 @@ func (c *Client) EnableTask(id string) *EnableTaskResult {
 	}
 }
 
+// BatchOperation is an action on a task run by BatchTasks: create, start, stop
+// or remove. Task holds the JSON manifest of a task to create, and ID the task
+// the other actions apply to.
+type BatchOperation struct {
+	Action string          `json:"action"`
+	ID     string          `json:"id,omitempty"`
+	Task   json.RawMessage `json:"task,omitempty"`
+}
+
+// BatchTasks runs the given operations on tasks in order through an HTTP POST
+// call to /v2/tasks/batch. When atomic is true, the tasks created by the batch
+// are removed and the remaining operations skipped once an operation fails.
+// The outcome of each operation is returned, or an error if the batch was
+// rejected as a whole.
+func (c *Client) BatchTasks(ops []BatchOperation, atomic bool) *BatchTasksResult {
+	b, err := json.Marshal(struct {
+		Atomic     bool             `json:"atomic"`
+		Operations []BatchOperation `json:"operations"`
+	}{atomic, ops})
+	if err != nil {
+		return &BatchTasksResult{Err: err}
+	}
+	r := &BatchTasksResult{}
+	r.Err = c.postV2("/tasks/batch", b, r, 200)
+	return r
+}
+
+// ValidateTask validates a JSON task manifest against the plugins and metrics
+// loaded in snapteld without creating the task, through an HTTP POST call to
+// /v2/tasks?dry_run=true. The errors found in the manifest are returned with
+// their JSON path, along with the config each metric and plugin of its
+// workflow resolves to.
+func (c *Client) ValidateTask(manifest []byte) *ValidateTaskResult {
+	r := &ValidateTaskResult{TaskValidation: &core.TaskValidation{}}
+	r.Err = c.postV2("/tasks?dry_run=true", manifest, r.TaskValidation, 200, 400)
+	return r
+}
+
 // CreateTaskResult is the response from snap/client on a CreateTask call.
 type CreateTaskResult struct {
 	*rbody.AddScheduledTask
 	Err error
 }
 
+// BatchTasksResult is the response from snap/client on a BatchTasks call.
+type BatchTasksResult struct {
+	Results    []BatchResult `json:"results"`
+	RolledBack bool          `json:"rolled_back"`
+	Err        error         `json:"-"`
+}
+
+// ValidateTaskResult is the response from snap/client on a ValidateTask call.
+type ValidateTaskResult struct {
+	*core.TaskValidation
+	Err error
+}
+
+// BatchResult is the outcome of an operation of a batch: succeeded, failed,
+// skipped or rolled_back.
+type BatchResult struct {
+	Action string `json:"action"`
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Href   string `json:"href"`
+	Status string `json:"status"`
+	Error  string `json:"error"`
+}
+
 // WatchTaskResult is the response from snap/client on a WatchTask call.
 type WatchTasksResult struct {
 	count     int
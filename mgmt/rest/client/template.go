@@ -0,0 +1,105 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"net/url"
+
+	"github.com/intelsdi-x/snap/core"
+)
+
+// CreateTemplate adds a task template to snapteld through an HTTP POST call
+// to /v2/templates. The template is given as JSON, and returned as created.
+func (c *Client) CreateTemplate(template []byte) *GetTemplateResult {
+	r := &GetTemplateResult{TaskTemplate: &core.TaskTemplate{}}
+	r.Err = c.postV2("/templates", template, r, 201)
+	return r
+}
+
+// GetTemplates retrieves the task templates of snapteld, sorted by name,
+// through an HTTP GET call to /v2/templates.
+func (c *Client) GetTemplates() *GetTemplatesResult {
+	r := &GetTemplatesResult{}
+	r.Err = c.doV2("GET", "/templates", nil, r, 200)
+	return r
+}
+
+// GetTemplate retrieves the task template with the given name through an
+// HTTP GET call to /v2/templates/:name.
+func (c *Client) GetTemplate(name string) *GetTemplateResult {
+	r := &GetTemplateResult{TaskTemplate: &core.TaskTemplate{}}
+	r.Err = c.doV2("GET", "/templates/"+url.QueryEscape(name), nil, r, 200)
+	return r
+}
+
+// RemoveTemplate removes the task template with the given name through an
+// HTTP DELETE call to /v2/templates/:name. The tasks created from the
+// template are not affected.
+func (c *Client) RemoveTemplate(name string) error {
+	return c.doV2("DELETE", "/templates/"+url.QueryEscape(name), nil, nil, 204)
+}
+
+// InstantiateTemplate creates a task from the task template with the given
+// name and the values of its parameters, through an HTTP POST call to
+// /v2/templates/:name/instantiate. The task is started according to start,
+// or to the start field of the template when start is nil.
+func (c *Client) InstantiateTemplate(name string, values map[string]interface{}, start *bool) *InstantiateTemplateResult {
+	b, err := json.Marshal(struct {
+		Values map[string]interface{} `json:"values"`
+		Start  *bool                  `json:"start,omitempty"`
+	}{values, start})
+	if err != nil {
+		return &InstantiateTemplateResult{Err: err}
+	}
+	r := &InstantiateTemplateResult{}
+	r.Err = c.postV2("/templates/"+url.QueryEscape(name)+"/instantiate", b, r, 201)
+	return r
+}
+
+// GetTemplatesResult is the response from snap/client on a GetTemplates call.
+type GetTemplatesResult struct {
+	Templates []Template `json:"templates"`
+	Err       error      `json:"-"`
+}
+
+// GetTemplateResult is the response from snap/client on a GetTemplate or
+// CreateTemplate call.
+type GetTemplateResult struct {
+	*core.TaskTemplate
+	Href string `json:"href"`
+	Err  error  `json:"-"`
+}
+
+// Template is a task template of a GetTemplates call.
+type Template struct {
+	core.TaskTemplate
+	Href string `json:"href"`
+}
+
+// InstantiateTemplateResult is the response from snap/client on an
+// InstantiateTemplate call: the task created from the template.
+type InstantiateTemplateResult struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	TaskState string `json:"task_state"`
+	Href      string `json:"href"`
+	Err       error  `json:"-"`
+}
@@ -13,6 +13,9 @@ const (
 	defaultPortSetByConfig bool   = false
 	defaultPprof           bool   = false
 	defaultCorsd           string = ""
+	defaultTokensPath      string = ""
+	defaultClientCA        string = ""
+	defaultClientAuth      string = clientAuthNone
 )
 
 // holds the configuration passed in through the SNAP config file
@@ -31,6 +34,32 @@ type Config struct {
 	portSetByConfig  bool   ``
 	Pprof            bool   `json:"pprof"yaml:"pprof"`
 	Corsd            string `json:"allowed_origins"yaml:"allowed_origins"`
+	// Tokens are the API tokens granted a role when REST API authentication
+	// is enabled, in addition to the password which is granted every role
+	Tokens []*TokenConfig `json:"tokens"yaml:"tokens"`
+	// TokensPath is the file where the tokens created through the API are saved
+	TokensPath string `json:"tokens_path"yaml:"tokens_path"`
+	// RestClientCA is the CA bundle the client certificates are verified
+	// with, when RestClientAuth is verify-if-given or require
+	RestClientCA   string `json:"rest_client_ca"yaml:"rest_client_ca"`
+	RestClientAuth string `json:"rest_client_auth"yaml:"rest_client_auth"`
+	// ClientCerts grant a role to the verified client certificates by the
+	// common name of their subject
+	ClientCerts []*ClientCertConfig `json:"client_certs"yaml:"client_certs"`
+}
+
+// ClientCertConfig grants a role to the client certificates with a subject
+// common name
+type ClientCertConfig struct {
+	CommonName string `json:"common_name"yaml:"common_name"`
+	Role       string `json:"role"yaml:"role"`
+}
+
+// TokenConfig is an API token set in the config file
+type TokenConfig struct {
+	Name  string `json:"name"yaml:"name"`
+	Token string `json:"token"yaml:"token"`
+	Role  string `json:"role"yaml:"role"`
 }
 
 const (
@@ -69,6 +98,53 @@ const (
 					},
 					"allowed_origins" : {
 						"type": "string"
+					},
+					"tokens": {
+						"type": ["array", "null"],
+						"items": {
+							"type": "object",
+							"properties": {
+								"name": {
+									"type": "string"
+								},
+								"token": {
+									"type": "string"
+								},
+								"role": {
+									"type": "string",
+									"enum": ["read-only", "task-operator", "plugin-admin", "admin"]
+								}
+							},
+							"required": ["name", "token", "role"],
+							"additionalProperties": false
+						}
+					},
+					"tokens_path": {
+						"type": "string"
+					},
+					"rest_client_ca": {
+						"type": "string"
+					},
+					"rest_client_auth": {
+						"type": "string",
+						"enum": ["none", "verify-if-given", "require"]
+					},
+					"client_certs": {
+						"type": ["array", "null"],
+						"items": {
+							"type": "object",
+							"properties": {
+								"common_name": {
+									"type": "string"
+								},
+								"role": {
+									"type": "string",
+									"enum": ["read-only", "task-operator", "plugin-admin", "admin"]
+								}
+							},
+							"required": ["common_name", "role"],
+							"additionalProperties": false
+						}
 					}
 				},
 				"additionalProperties": false
@@ -90,6 +166,9 @@ func GetDefaultConfig() *Config {
 		portSetByConfig:  defaultPortSetByConfig,
 		Pprof:            defaultPprof,
 		Corsd:            defaultCorsd,
+		TokensPath:       defaultTokensPath,
+		RestClientCA:     defaultClientCA,
+		RestClientAuth:   defaultClientAuth,
 	}
 }
 
@@ -52,6 +52,14 @@ var (
 		Name:  "rest-key",
 		Usage: "A path to a key file to use for HTTPS deployment of Snap's REST API",
 	}
+	flRestClientCA = cli.StringFlag{
+		Name:  "rest-client-ca",
+		Usage: "A path to a CA bundle to verify the client certificates presented to Snap's REST API",
+	}
+	flRestClientAuth = cli.StringFlag{
+		Name:  "rest-client-auth",
+		Usage: fmt.Sprintf("Client certificate verification of Snap's HTTPS REST API: none, verify-if-given or require (default: %v)", defaultClientAuth),
+	}
 	flRestAuth = cli.BoolFlag{
 		Name:  "rest-auth",
 		Usage: "Enables Snap's REST API authentication",
@@ -66,5 +74,5 @@ var (
 	}
 
 	// Flags consumed by snapteld
-	Flags = []cli.Flag{flAPIDisabled, flAPIAddr, flAPIPort, flRestHTTPS, flRestCert, flRestKey, flRestAuth, flPProf, flCorsd}
+	Flags = []cli.Flag{flAPIDisabled, flAPIAddr, flAPIPort, flRestHTTPS, flRestCert, flRestKey, flRestClientCA, flRestClientAuth, flRestAuth, flPProf, flCorsd}
 )
@@ -0,0 +1,48 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/intelsdi-x/snap/control/builtin"
+	"github.com/intelsdi-x/snap/mgmt/rest/api"
+)
+
+// addPrometheusRoutes exposes the metrics published to the builtin-prometheus
+// publisher and the metrics of snapteld itself so that Prometheus can scrape
+// snapteld
+func (s *Server) addPrometheusRoutes() {
+	s.r.GET("/metrics", s.authorize(api.RoleReadOnly, s.prometheusMetrics))
+}
+
+func (s *Server) prometheusMetrics(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	w.Header().Set("Content-Type", builtin.PrometheusContentType)
+	w.WriteHeader(http.StatusOK)
+	if err := builtin.WritePrometheus(w); err != nil {
+		restLogger.WithFields(log.Fields{
+			"_block": "prometheus-metrics",
+			"error":  err,
+		}).Error("error writing metrics")
+	}
+}
@@ -35,8 +35,10 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/intelsdi-x/snap/core"
 	"github.com/intelsdi-x/snap/core/cdata"
 	"github.com/intelsdi-x/snap/core/ctypes"
+	"github.com/intelsdi-x/snap/mgmt/rest/v2"
 	"github.com/intelsdi-x/snap/mgmt/rest/v2/mock"
 	log "github.com/sirupsen/logrus"
 	. "github.com/smartystreets/goconvey/convey"
@@ -210,6 +212,19 @@ func TestV2Plugin(t *testing.T) {
 				fmt.Sprintf(mock.GET_PLUGIN_CONFIG_ITEM))
 		})
 
+		Convey("Get plugin config items with sources - v2/plugins/:type/:name/:version/config?sources", func() {
+			resp, err := http.Get(
+				fmt.Sprintf("http://localhost:%d/v2/plugins/publisher/bar/3/config?sources", r.port))
+			So(err, ShouldBeNil)
+			So(resp.StatusCode, ShouldEqual, 200)
+			body, err := ioutil.ReadAll(resp.Body)
+			So(err, ShouldBeNil)
+			So(
+				string(body),
+				ShouldResemble,
+				fmt.Sprintf(mock.GET_PLUGIN_CONFIG_ITEM_SOURCES))
+		})
+
 		Convey("Set plugin config item- v2/plugins/:type/:name/:version/config", func() {
 			c := &http.Client{}
 			pluginName := "foo"
@@ -294,6 +309,90 @@ func TestV2Task(t *testing.T) {
 			)
 		})
 
+		Convey("Validate tasks - v2/tasks?dry_run=true", func() {
+			post := func(task string) (int, core.TaskValidation) {
+				resp, err := http.Post(
+					fmt.Sprintf("http://localhost:%d/v2/tasks?dry_run=true", r.port),
+					"application/json",
+					strings.NewReader(task))
+				So(err, ShouldBeNil)
+				var body core.TaskValidation
+				So(json.NewDecoder(resp.Body).Decode(&body), ShouldBeNil)
+				return resp.StatusCode, body
+			}
+
+			code, v := post(mock.TASK)
+			So(code, ShouldEqual, 200)
+			So(v.Valid, ShouldBeTrue)
+			So(v.Metrics, ShouldHaveLength, 1)
+			So(v.Metrics[0].Name, ShouldEqual, "/one/two/three")
+
+			code, v = post(`{
+				"schedule": {"type": "simple", "interval": "1 second"},
+				"workflow": {"collect": {
+					"metrics": {"/one/two/three": {}},
+					"config": {"/one/two": {"user": "snap"}},
+					"publish": [{"plugin_name": "file", "config": {"file": "/tmp/out"}}, {"plugin_name": "unknown"}]
+				}}
+			}`)
+			So(code, ShouldEqual, 400)
+			So(v.Valid, ShouldBeFalse)
+			So(v.Errors, ShouldHaveLength, 2)
+			So(v.Errors[0].Path, ShouldEqual, "schedule")
+			So(v.Errors[1].Path, ShouldEqual, "workflow.collect.publish[1]")
+			So(v.Metrics[0].Config, ShouldResemble, map[string]interface{}{"user": "snap"})
+			So(v.Plugins, ShouldHaveLength, 1)
+			So(v.Plugins[0].Path, ShouldEqual, "workflow.collect.publish[0]")
+			So(v.Plugins[0].Config, ShouldResemble, map[string]interface{}{"file": "/tmp/out"})
+		})
+
+		Convey("Run a batch of task operations - v2/tasks/batch", func() {
+			post := func(batch string) (int, v2.TaskBatchResponse) {
+				resp, err := http.Post(
+					fmt.Sprintf("http://localhost:%d/v2/tasks/batch", r.port),
+					"application/json",
+					strings.NewReader(batch))
+				So(err, ShouldBeNil)
+				var body v2.TaskBatchResponse
+				if resp.StatusCode == 200 {
+					So(json.NewDecoder(resp.Body).Decode(&body), ShouldBeNil)
+				}
+				return resp.StatusCode, body
+			}
+
+			code, body := post(fmt.Sprintf(`{"operations": [
+				{"action": "create", "task": %s},
+				{"action": "start", "id": "MockTask1234"},
+				{"action": "stop", "id": "MockTask1234"},
+				{"action": "remove", "id": "MockTask1234"}
+			]}`, mock.TASK))
+			So(code, ShouldEqual, 200)
+			So(body.Results, ShouldHaveLength, 4)
+			So(body.Results[0].ID, ShouldEqual, "MyTaskID")
+			So(body.Results[0].Href, ShouldEqual, fmt.Sprintf("http://localhost:%d/v2/tasks/MyTaskID", r.port))
+			for _, res := range body.Results {
+				So(res.Status, ShouldEqual, v2.BatchSucceeded)
+			}
+			So(body.RolledBack, ShouldBeFalse)
+
+			code, body = post(fmt.Sprintf(`{"atomic": true, "operations": [
+				{"action": "create", "task": %s},
+				{"action": "create", "task": {"version": 1}},
+				{"action": "start", "id": "MockTask1234"}
+			]}`, mock.TASK))
+			So(code, ShouldEqual, 200)
+			So(body.RolledBack, ShouldBeTrue)
+			So(body.Results[0].Status, ShouldEqual, v2.BatchRolledBack)
+			So(body.Results[1].Status, ShouldEqual, v2.BatchFailed)
+			So(body.Results[1].Error, ShouldNotBeEmpty)
+			So(body.Results[2].Status, ShouldEqual, v2.BatchSkipped)
+
+			code, _ = post(`{"operations": [{"action": "restart", "id": "MockTask1234"}]}`)
+			So(code, ShouldEqual, 400)
+			code, _ = post(`{"operations": [{"action": "start"}]}`)
+			So(code, ShouldEqual, 400)
+		})
+
 		Convey("Get tasks - v2/tasks", func() {
 			resp, err := http.Get(
 				fmt.Sprintf("http://localhost:%d/v2/tasks", r.port))
@@ -314,6 +413,49 @@ func TestV2Task(t *testing.T) {
 			)
 		})
 
+		Convey("Get tasks by labels - v2/tasks?selector", func() {
+			resp, err := http.Get(
+				fmt.Sprintf("http://localhost:%d/v2/tasks?selector=team=infra,env!=dev", r.port))
+			So(err, ShouldBeNil)
+			So(resp.StatusCode, ShouldEqual, 200)
+			body, err := ioutil.ReadAll(resp.Body)
+			So(err, ShouldBeNil)
+			So(
+				string(body),
+				ShouldResemble,
+				fmt.Sprintf(mock.GET_TASKS_SELECTOR_RESPONSE, r.port),
+			)
+
+			resp, err = http.Get(
+				fmt.Sprintf("http://localhost:%d/v2/tasks?selector=team!", r.port))
+			So(err, ShouldBeNil)
+			So(resp.StatusCode, ShouldEqual, 400)
+		})
+
+		Convey("Stop tasks by labels - v2/tasks?selector", func() {
+			c := &http.Client{}
+			req, err := http.NewRequest(
+				"PUT",
+				fmt.Sprintf("http://localhost:%d/v2/tasks?action=stop&selector=team=infra", r.port),
+				nil)
+			So(err, ShouldBeNil)
+			resp, err := c.Do(req)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode, ShouldEqual, 200)
+			body, err := ioutil.ReadAll(resp.Body)
+			So(err, ShouldBeNil)
+			So(string(body), ShouldResemble, mock.STOP_TASKS_SELECTOR_RESPONSE)
+
+			req, err = http.NewRequest(
+				"DELETE",
+				fmt.Sprintf("http://localhost:%d/v2/tasks", r.port),
+				nil)
+			So(err, ShouldBeNil)
+			resp, err = c.Do(req)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode, ShouldEqual, 400)
+		})
+
 		Convey("Get task - v2/tasks/:id", func() {
 			taskID := "1234"
 			resp, err := http.Get(
@@ -418,6 +560,56 @@ func TestV2Task(t *testing.T) {
 				fmt.Sprintf(mock.ENABLE_TASK_RESPONSE_ID_ENABLE))
 		})
 
+		Convey("Replay tasks - v2/tasks/:id", func() {
+			c := &http.Client{}
+			taskID := "MockTask1234"
+			req, err := http.NewRequest(
+				"PUT",
+				fmt.Sprintf("http://localhost:%d/v2/tasks/%s", r.port, taskID),
+				bytes.NewReader([]byte{}))
+			So(err, ShouldBeNil)
+			q := req.URL.Query()
+			q.Add("action", "replay")
+			req.URL.RawQuery = q.Encode()
+			resp, err := c.Do(req)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusOK)
+			body, err := ioutil.ReadAll(resp.Body)
+			So(err, ShouldBeNil)
+			So(
+				string(body),
+				ShouldResemble,
+				fmt.Sprintf(mock.REPLAY_TASK_RESPONSE))
+		})
+
+		Convey("Update tasks - v2/tasks/:id", func() {
+			c := &http.Client{}
+			taskID := "MockTask1234"
+			req, err := http.NewRequest(
+				"PATCH",
+				fmt.Sprintf("http://localhost:%d/v2/tasks/%s", r.port, taskID),
+				strings.NewReader(mock.UPDATE_TASK))
+			So(err, ShouldBeNil)
+			resp, err := c.Do(req)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusOK)
+			body, err := ioutil.ReadAll(resp.Body)
+			So(err, ShouldBeNil)
+			So(
+				string(body),
+				ShouldResemble,
+				fmt.Sprintf(mock.UPDATE_TASK_RESPONSE, r.port))
+
+			req, err = http.NewRequest(
+				"PATCH",
+				fmt.Sprintf("http://localhost:%d/v2/tasks/%s", r.port, taskID),
+				strings.NewReader(`{"start": true}`))
+			So(err, ShouldBeNil)
+			resp, err = c.Do(req)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode, ShouldEqual, 500)
+		})
+
 		Convey("Remove tasks - v2/tasks/:id", func() {
 			c := &http.Client{}
 			taskID := "MockTask1234"
@@ -441,6 +633,51 @@ func TestV2Task(t *testing.T) {
 				ShouldResemble,
 				fmt.Sprintf(mock.REMOVE_TASK_RESPONSE_ID))
 		})
+
+		Convey("Task templates - v2/templates", func() {
+			c := &http.Client{}
+			do := func(method, path, body string) (int, []byte) {
+				req, err := http.NewRequest(method, fmt.Sprintf("http://localhost:%d/v2%s", r.port, path), strings.NewReader(body))
+				So(err, ShouldBeNil)
+				resp, err := c.Do(req)
+				So(err, ShouldBeNil)
+				b, err := ioutil.ReadAll(resp.Body)
+				So(err, ShouldBeNil)
+				return resp.StatusCode, b
+			}
+
+			code, body := do("GET", "/templates", "")
+			So(code, ShouldEqual, 200)
+			var templates v2.TemplatesResponse
+			So(json.Unmarshal(body, &templates), ShouldBeNil)
+			So(templates.Templates, ShouldHaveLength, 1)
+			So(templates.Templates[0].Name, ShouldEqual, "collect-mock")
+			So(templates.Templates[0].Href, ShouldEqual, fmt.Sprintf("http://localhost:%d/v2/templates/collect-mock", r.port))
+			code, _ = do("GET", "/templates/unknown", "")
+			So(code, ShouldEqual, 404)
+
+			code, _ = do("POST", "/templates", `{"name": "collect-cpu", "parameters": [{"name": "interval"}], "task": {"schedule": {"type": "simple", "interval": "${interval}"}}}`)
+			So(code, ShouldEqual, 201)
+			code, _ = do("POST", "/templates", `{"name": "collect-mock", "task": {}}`)
+			So(code, ShouldEqual, 409)
+			code, _ = do("POST", "/templates", `{"name": "collect-cpu", "parameters": [{"name": "interval", "type": "color"}], "task": {}}`)
+			So(code, ShouldEqual, 400)
+
+			code, body = do("POST", "/templates/collect-mock/instantiate", `{"values": {"deadline": "2s"}}`)
+			So(code, ShouldEqual, 201)
+			var task v2.Task
+			So(json.Unmarshal(body, &task), ShouldBeNil)
+			So(task.ID, ShouldEqual, "MyTaskID")
+			code, _ = do("POST", "/templates/collect-mock/instantiate", `{"values": {"interval": "5s"}}`)
+			So(code, ShouldEqual, 400)
+			code, _ = do("POST", "/templates/unknown/instantiate", `{}`)
+			So(code, ShouldEqual, 404)
+
+			code, _ = do("DELETE", "/templates/collect-mock", "")
+			So(code, ShouldEqual, 204)
+			code, _ = do("DELETE", "/templates/unknown", "")
+			So(code, ShouldEqual, 404)
+		})
 	})
 }
 
@@ -20,6 +20,8 @@ limitations under the License.
 package rest
 
 import (
+	"context"
+	"crypto/subtle"
 	"crypto/tls"
 	"errors"
 	"fmt"
@@ -43,7 +45,7 @@ import (
 )
 
 const (
-	allowedMethods = "GET, POST, DELETE, PUT, OPTIONS"
+	allowedMethods = "GET, POST, DELETE, PUT, PATCH, OPTIONS"
 	allowedHeaders = "Origin, X-Requested-With, Content-Type, Accept"
 	maxAge         = 3600
 )
@@ -69,6 +71,13 @@ type Server struct {
 	killChan       chan struct{}
 	err            chan error
 	allowedOrigins map[string]bool
+	cors           *cors.Cors
+	tokens         *tokenStore
+	// clientCerts are the roles granted to the verified client certificates
+	// by the common name of their subject
+	clientCerts map[string]api.Role
+	// settingsMutex guards the settings which can be reloaded at runtime
+	settingsMutex sync.RWMutex
 	// the following instance variables are used to cleanly shutdown the server
 	serverListener net.Listener
 	closingChan    chan bool
@@ -83,13 +92,24 @@ func New(cfg *Config) (*Server, error) {
 		addrString: cfg.Address,
 		pprof:      cfg.Pprof,
 	}
+	var err error
+	if s.tokens, err = newTokenStore(cfg); err != nil {
+		return nil, err
+	}
 	if cfg.HTTPS {
-		var err error
 		s.snapTLS, err = newtls(cfg.RestCertificate, cfg.RestKey)
 		if err != nil {
 			return nil, err
 		}
+		if err := s.snapTLS.setClientAuth(cfg.RestClientAuth, cfg.RestClientCA); err != nil {
+			return nil, err
+		}
 		protocolPrefix = "https"
+	} else if cfg.RestClientAuth != "" && cfg.RestClientAuth != clientAuthNone {
+		return nil, ErrClientAuthWithoutHTTPS
+	}
+	if err := s.setClientCerts(cfg.ClientCerts); err != nil {
+		return nil, err
 	}
 	restLogger.Info(fmt.Sprintf("Configuring REST API with HTTPS set to: %v", cfg.HTTPS))
 
@@ -107,19 +127,10 @@ func New(cfg *Config) (*Server, error) {
 
 	// CORS has to be turned on explicitly in the global config.
 	// Otherwise, it defauts to the same origin.
-	origins, err := s.getAllowedOrigins(cfg.Corsd)
-	if err != nil {
+	if err := s.setCORS(cfg.Corsd); err != nil {
 		return nil, err
 	}
-	if len(origins) > 0 {
-		c := cors.New(cors.Options{
-			AllowedOrigins: origins,
-			AllowedMethods: []string{allowedMethods},
-			AllowedHeaders: []string{allowedHeaders},
-			MaxAge:         maxAge,
-		})
-		s.n.Use(c)
-	}
+	s.n.Use(negroni.HandlerFunc(s.corsMiddleware))
 
 	// Use negroni to handle routes
 	s.n.UseHandler(s.r)
@@ -152,40 +163,152 @@ func (s *Server) BindConfigManager(c api.Config) {
 
 // SetAPIAuth sets API authentication to enabled or disabled
 func (s *Server) SetAPIAuth(auth bool) {
+	s.settingsMutex.Lock()
+	defer s.settingsMutex.Unlock()
 	s.auth = auth
 }
 
 // SetAPIAuthPwd sets the API authentication password from snapteld
 func (s *Server) SetAPIAuthPwd(pwd string) {
+	s.settingsMutex.Lock()
+	defer s.settingsMutex.Unlock()
 	s.authpwd = pwd
 }
 
-// Auth Middleware for REST API
+// ReloadConfig applies the CORS and authentication settings of cfg, including
+// the roles of the client certificates, to the running server. Changing the
+// other settings of cfg requires a restart.
+func (s *Server) ReloadConfig(cfg *Config) error {
+	if err := s.setCORS(cfg.Corsd); err != nil {
+		return err
+	}
+	if err := s.tokens.setFileTokens(cfg.Tokens); err != nil {
+		return err
+	}
+	if err := s.setClientCerts(cfg.ClientCerts); err != nil {
+		return err
+	}
+	s.SetAPIAuth(cfg.RestAuth)
+	s.SetAPIAuthPwd(cfg.RestAuthPassword)
+	restLogger.WithFields(log.Fields{
+		"_block": "reload-config",
+	}).Info("REST API CORS, authentication, token and client certificate settings reloaded")
+	return nil
+}
+
+// identityKey is the key of the identity of an authenticated request in its
+// context
+type identityKey struct{}
+
+// identity is who made an authenticated request: the name of an API token,
+// "password" for the password or cert:<common name> for a client certificate,
+// and the role granted to it
+type identity struct {
+	name string
+	role api.Role
+}
+
+// Auth Middleware for REST API. When authentication is enabled, a request
+// is authenticated by the password, which is granted every role, or by an
+// API token given as the password of basic authentication or as a bearer
+// token. The role of the request is checked against the role of each route.
+// A verified client certificate whose common name is granted a role
+// authenticates a request even when authentication is disabled.
 func (s *Server) authMiddleware(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
 	reqOrigin := r.Header.Get("Origin")
 	s.setAllowedOrigins(rw, reqOrigin)
 
+	s.settingsMutex.RLock()
+	auth, authpwd := s.auth, s.authpwd
+	s.settingsMutex.RUnlock()
+
 	defer r.Body.Close()
-	if s.auth {
-		_, password, ok := r.BasicAuth()
-		// If we have valid password or going to tribe/agreements endpoint
-		// go to next. tribe/agreements endpoint used for populating
-		// snaptel help page when tribe mode is turned on.
-		if ok && password == s.authpwd {
-			next(rw, r)
-		} else {
-			v2.Write(401, v2.UnauthError{Code: 401, Message: "Not authorized. Please specify the same password that used to start snapteld. E.g: [snaptel -p plugin list] or [curl http://localhost:8181/v2/plugins -u snap]"}, rw)
-		}
-	} else {
+	if id, ok := s.authenticateCert(r); ok {
+		next(rw, r.WithContext(context.WithValue(r.Context(), identityKey{}, id)))
+		return
+	}
+	if !auth {
 		next(rw, r)
+		return
+	}
+	id, ok := s.authenticate(r, authpwd)
+	if !ok {
+		v2.Write(401, v2.UnauthError{Code: 401, Message: "Not authorized. Please specify the same password that used to start snapteld or an API token. E.g: [snaptel -p plugin list] or [curl http://localhost:8181/v2/plugins -u snap]"}, rw)
+		return
+	}
+	next(rw, r.WithContext(context.WithValue(r.Context(), identityKey{}, id)))
+}
+
+// authenticate returns the identity of the credentials of a request
+func (s *Server) authenticate(r *http.Request, authpwd string) (identity, bool) {
+	var secret string
+	if h := r.Header.Get("Authorization"); strings.HasPrefix(h, "Bearer ") {
+		secret = strings.TrimSpace(strings.TrimPrefix(h, "Bearer "))
+	} else if _, password, ok := r.BasicAuth(); ok {
+		if authpwd != "" && subtle.ConstantTimeCompare([]byte(password), []byte(authpwd)) == 1 {
+			return identity{name: "password", role: api.RoleAdmin}, true
+		}
+		secret = password
+	}
+	if t, ok := s.tokens.authenticate(secret); ok {
+		return identity{name: t.Name, role: t.role}, true
+	}
+	return identity{}, false
+}
+
+// authenticateCert returns the identity of the verified client certificate of
+// a request, when its common name is granted a role
+func (s *Server) authenticateCert(r *http.Request) (identity, bool) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return identity{}, false
+	}
+	cn := r.TLS.VerifiedChains[0][0].Subject.CommonName
+	s.settingsMutex.RLock()
+	role, ok := s.clientCerts[cn]
+	s.settingsMutex.RUnlock()
+	if !ok {
+		return identity{}, false
+	}
+	return identity{name: "cert:" + cn, role: role}, true
+}
+
+// setClientCerts replaces the roles granted to the client certificates
+func (s *Server) setClientCerts(certs []*ClientCertConfig) error {
+	clientCerts := make(map[string]api.Role, len(certs))
+	for _, cc := range certs {
+		role, err := api.ParseRole(cc.Role)
+		if err != nil {
+			return fmt.Errorf("%v (client certificate %s)", err, cc.CommonName)
+		}
+		clientCerts[cc.CommonName] = role
+	}
+	s.settingsMutex.Lock()
+	defer s.settingsMutex.Unlock()
+	s.clientCerts = clientCerts
+	return nil
+}
+
+// authorize wraps the handle of a route so that it is only called by requests
+// granted the given role. Requests which were not authenticated, because
+// authentication is disabled, are not restricted.
+func (s *Server) authorize(role api.Role, handle httprouter.Handle) httprouter.Handle {
+	return func(rw http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		if id, ok := r.Context().Value(identityKey{}).(identity); ok && id.role < role {
+			v2.Write(403, v2.UnauthError{Code: 403, Message: fmt.Sprintf("Forbidden. This request requires the %s role, the credentials given are granted the %s role", role, id.role)}, rw)
+			return
+		}
+		handle(rw, r, p)
 	}
 }
 
 // CORS origins have to be turned on explicitly in the global config.
 // Otherwise, it defaults to the same origin.
 func (s *Server) setAllowedOrigins(rw http.ResponseWriter, ro string) {
-	if len(s.allowedOrigins) > 0 {
-		if _, ok := s.allowedOrigins[ro]; ok {
+	s.settingsMutex.RLock()
+	allowedOrigins := s.allowedOrigins
+	s.settingsMutex.RUnlock()
+	if len(allowedOrigins) > 0 {
+		if _, ok := allowedOrigins[ro]; ok {
 			// localhost CORS is not supported by all browsers. It has to use "*".
 			if strings.Contains(ro, "127.0.0.1") || strings.Contains(ro, "localhost") {
 				ro = "*"
@@ -198,6 +321,40 @@ func (s *Server) setAllowedOrigins(rw http.ResponseWriter, ro string) {
 	}
 }
 
+// CORS Middleware for REST API, handling preflight requests of the allowed origins
+func (s *Server) corsMiddleware(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	s.settingsMutex.RLock()
+	c := s.cors
+	s.settingsMutex.RUnlock()
+	if c == nil {
+		next(rw, r)
+		return
+	}
+	c.ServeHTTP(rw, r, next)
+}
+
+// setCORS replaces the origins allowed to access the REST API
+func (s *Server) setCORS(corsd string) error {
+	origins, allowedOrigins, err := s.getAllowedOrigins(corsd)
+	if err != nil {
+		return err
+	}
+	var c *cors.Cors
+	if len(origins) > 0 {
+		c = cors.New(cors.Options{
+			AllowedOrigins: origins,
+			AllowedMethods: []string{allowedMethods},
+			AllowedHeaders: []string{allowedHeaders},
+			MaxAge:         maxAge,
+		})
+	}
+	s.settingsMutex.Lock()
+	defer s.settingsMutex.Unlock()
+	s.allowedOrigins = allowedOrigins
+	s.cors = c
+	return nil
+}
+
 func (s *Server) SetAddress(addrString string) {
 	s.addrString = addrString
 	restLogger.Info(fmt.Sprintf("Address used for binding: [%v]", s.addrString))
@@ -244,17 +401,17 @@ func (s *Server) Port() int {
 func (s *Server) run(addrString string) {
 	restLogger.Info("Starting REST API on ", addrString)
 	if s.snapTLS != nil {
-		cer, err := tls.LoadX509KeyPair(s.snapTLS.cert, s.snapTLS.key)
+		config, err := s.snapTLS.config()
 		if err != nil {
 			s.err <- err
 			return
 		}
-		config := &tls.Config{Certificates: []tls.Certificate{cer}}
 		ln, err := tls.Listen("tcp", addrString, config)
 		if err != nil {
 			log.Fatal(err)
 		}
 		s.serverListener = ln
+		s.addr = ln.Addr()
 		s.wg.Add(1)
 		go s.serveTLS(ln)
 	} else {
@@ -302,13 +459,20 @@ func (s *Server) serve(ln net.Listener) {
 func (s *Server) addRoutes() {
 	for _, apiInstance := range s.apis {
 		for _, route := range apiInstance.GetRoutes() {
-			s.r.Handle(route.Method, route.Path, route.Handle)
+			handle := route.Handle
+			if route.Audit != "" {
+				handle = s.audit(route.Audit, handle)
+			}
+			s.r.Handle(route.Method, route.Path, s.authorize(route.Role, handle))
 		}
 	}
+	s.addTokenRoutes()
+	s.addAuditRoutes()
 	s.addPprofRoutes()
+	s.addPrometheusRoutes()
 }
 
-func (s *Server) getAllowedOrigins(corsd string) ([]string, error) {
+func (s *Server) getAllowedOrigins(corsd string) ([]string, map[string]bool, error) {
 	// Avoids panics when validating URLs.
 	defer func() {
 		if r := recover(); r != nil {
@@ -323,11 +487,11 @@ func (s *Server) getAllowedOrigins(corsd string) ([]string, error) {
 	}()
 
 	if corsd == "" {
-		return []string{}, nil
+		return []string{}, map[string]bool{}, nil
 	}
 
 	vo := []string{}
-	s.allowedOrigins = map[string]bool{}
+	allowedOrigins := map[string]bool{}
 
 	os := strings.Split(corsd, ",")
 	for _, o := range os {
@@ -339,13 +503,13 @@ func (s *Server) getAllowedOrigins(corsd string) ([]string, error) {
 		// Checks if scheme or host exists when no error occurred.
 		if err != nil || u.Scheme == "" || u.Host == "" {
 			restLogger.Errorf("Invalid origin found %s", to)
-			return []string{}, fmt.Errorf("Invalid origin found: %s.", to)
+			return []string{}, nil, fmt.Errorf("Invalid origin found: %s.", to)
 		}
 
 		vo = append(vo, to)
-		s.allowedOrigins[to] = true
+		allowedOrigins[to] = true
 	}
-	return vo, nil
+	return vo, allowedOrigins, nil
 }
 
 // Monkey patch ListenAndServe and TCP alive code from https://golang.org/src/net/http/server.go
@@ -22,17 +22,80 @@ package rest
 import (
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/pem"
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"math/big"
 	"os"
 	"time"
 )
 
+// client certificate verification modes of the HTTPS REST API
+const (
+	clientAuthNone          = "none"
+	clientAuthVerifyIfGiven = "verify-if-given"
+	clientAuthRequire       = "require"
+)
+
+var (
+	// ErrBadClientAuth - The error message for an unknown client certificate verification mode
+	ErrBadClientAuth = errors.New("Invalid client certificate verification mode, expected one of none, verify-if-given or require")
+	// ErrClientCANotSet - The error message for verifying client certificates without a CA bundle
+	ErrClientCANotSet = errors.New("Verifying client certificates requires a CA bundle")
+	// ErrClientAuthWithoutHTTPS - The error message for verifying client certificates without HTTPS
+	ErrClientAuthWithoutHTTPS = errors.New("Verifying client certificates requires HTTPS")
+)
+
 type snapTLS struct {
 	cert, key string
+	// clientCAs verify the client certificates as set by clientAuth
+	clientCAs  *x509.CertPool
+	clientAuth tls.ClientAuthType
+}
+
+// config returns the TLS configuration of the REST API server
+func (t *snapTLS) config() (*tls.Config, error) {
+	cer, err := tls.LoadX509KeyPair(t.cert, t.key)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cer},
+		ClientCAs:    t.clientCAs,
+		ClientAuth:   t.clientAuth,
+	}, nil
+}
+
+// setClientAuth sets how client certificates are verified, mode being one of
+// none, verify-if-given or require, with the CA bundle at caPath
+func (t *snapTLS) setClientAuth(mode, caPath string) error {
+	switch mode {
+	case "", clientAuthNone:
+		t.clientAuth = tls.NoClientCert
+		return nil
+	case clientAuthVerifyIfGiven:
+		t.clientAuth = tls.VerifyClientCertIfGiven
+	case clientAuthRequire:
+		t.clientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return ErrBadClientAuth
+	}
+	if caPath == "" {
+		return ErrClientCANotSet
+	}
+	b, err := ioutil.ReadFile(caPath)
+	if err != nil {
+		return err
+	}
+	t.clientCAs = x509.NewCertPool()
+	if !t.clientCAs.AppendCertsFromPEM(b) {
+		return fmt.Errorf("%v: no certificate found in %s", ErrBadCert, caPath)
+	}
+	return nil
 }
 
 func newtls(certPath, keyPath string) (*snapTLS, error) {
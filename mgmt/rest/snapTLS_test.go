@@ -0,0 +1,158 @@
+// +build medium
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// newTestCert returns a certificate signed by parent, or self-signed when
+// parent is nil
+func newTestCert(cn string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	So(err, ShouldBeNil)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage |= x509.KeyUsageCertSign
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	So(err, ShouldBeNil)
+	cert, err := x509.ParseCertificate(der)
+	So(err, ShouldBeNil)
+	return cert, key, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func clientWithCert(cert *x509.Certificate, key *ecdsa.PrivateKey) *http.Client {
+	config := &tls.Config{InsecureSkipVerify: true}
+	if cert != nil {
+		config.Certificates = []tls.Certificate{{Certificate: [][]byte{cert.Raw}, PrivateKey: key}}
+	}
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: config}}
+}
+
+func TestRestAPIClientCertificates(t *testing.T) {
+	Convey("Given a CA and client certificates", t, func() {
+		dir, err := ioutil.TempDir("", "snap-rest-mtls")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		ca, caKey, caPEM := newTestCert("snap-ca", nil, nil)
+		caPath := filepath.Join(dir, "ca.pem")
+		So(ioutil.WriteFile(caPath, caPEM, 0600), ShouldBeNil)
+		deployer, deployerKey, _ := newTestCert("deployer", ca, caKey)
+		unknown, unknownKey, _ := newTestCert("unknown", ca, caKey)
+		other, otherKey, _ := newTestCert("other-ca", nil, nil)
+		rogue, rogueKey, _ := newTestCert("deployer", other, otherKey)
+
+		cfg := GetDefaultConfig()
+		cfg.HTTPS = true
+		cfg.RestClientCA = caPath
+		cfg.ClientCerts = []*ClientCertConfig{{CommonName: "deployer", Role: "plugin-admin"}}
+
+		Convey("client certificates are required", func() {
+			cfg.RestClientAuth = "require"
+			s := newAuthServer(cfg)
+			ts := httptest.NewUnstartedServer(s.n)
+			ts.TLS, err = s.snapTLS.config()
+			So(err, ShouldBeNil)
+			ts.StartTLS()
+			defer ts.Close()
+
+			Convey("the common name of a verified certificate is granted its role", func() {
+				c := clientWithCert(deployer, deployerKey)
+				req, _ := http.NewRequest("DELETE", ts.URL+"/v2/plugins", nil)
+				resp, err := c.Do(req)
+				So(err, ShouldBeNil)
+				So(resp.StatusCode, ShouldEqual, 204)
+				resp, err = c.Get(ts.URL + "/v2/tokens")
+				So(err, ShouldBeNil)
+				So(resp.StatusCode, ShouldEqual, 403)
+			})
+			Convey("a verified certificate without a role falls back to the password", func() {
+				c := clientWithCert(unknown, unknownKey)
+				resp, err := c.Get(ts.URL + "/v2/read")
+				So(err, ShouldBeNil)
+				So(resp.StatusCode, ShouldEqual, 401)
+			})
+			Convey("connections without a certificate or with an unknown CA are refused", func() {
+				_, err := clientWithCert(nil, nil).Get(ts.URL + "/v2/read")
+				So(err, ShouldNotBeNil)
+				_, err = clientWithCert(rogue, rogueKey).Get(ts.URL + "/v2/read")
+				So(err, ShouldNotBeNil)
+			})
+		})
+		Convey("client certificates are verified if given", func() {
+			cfg.RestClientAuth = "verify-if-given"
+			s := newAuthServer(cfg)
+			ts := httptest.NewUnstartedServer(s.n)
+			ts.TLS, err = s.snapTLS.config()
+			So(err, ShouldBeNil)
+			ts.StartTLS()
+			defer ts.Close()
+
+			req, _ := http.NewRequest("GET", ts.URL+"/v2/read", nil)
+			req.SetBasicAuth("snap", "secret")
+			resp, err := clientWithCert(nil, nil).Do(req)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode, ShouldEqual, 200)
+		})
+		Convey("invalid settings are rejected", func() {
+			cfg.RestClientAuth = "always"
+			_, err := New(cfg)
+			So(err, ShouldEqual, ErrBadClientAuth)
+
+			cfg.RestClientAuth = "require"
+			cfg.RestClientCA = ""
+			_, err = New(cfg)
+			So(err, ShouldEqual, ErrClientCANotSet)
+
+			cfg.HTTPS = false
+			_, err = New(cfg)
+			So(err, ShouldEqual, ErrClientAuthWithoutHTTPS)
+		})
+	})
+}
@@ -0,0 +1,352 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/intelsdi-x/snap/mgmt/rest/api"
+	"github.com/intelsdi-x/snap/mgmt/rest/v2"
+)
+
+const (
+	tokenSourceFile = "file"
+	tokenSourceAPI  = "api"
+)
+
+var (
+	// ErrTokenNameEmpty - The error message for a token without a name
+	ErrTokenNameEmpty = errors.New("Token name cannot be empty")
+	// ErrTokenExists - The error message for a token whose name is already used
+	ErrTokenExists = errors.New("Token already exists")
+	// ErrTokenNotFound - The error message for an unknown token
+	ErrTokenNotFound = errors.New("Token not found")
+	// ErrTokenFromConfig - The error message for removing a token set in the config file
+	ErrTokenFromConfig = errors.New("Token is set in the config file and cannot be removed through the API")
+)
+
+// token is an API token. Only the hash of its secret is kept.
+type token struct {
+	Name    string    `json:"name"`
+	Role    string    `json:"role"`
+	Hash    string    `json:"hash"`
+	Created time.Time `json:"created"`
+
+	role   api.Role
+	source string
+}
+
+// TokenResponse is a token as returned by the API. The secret is only
+// returned when the token is created.
+type TokenResponse struct {
+	Name    string     `json:"name"`
+	Role    string     `json:"role"`
+	Source  string     `json:"source"`
+	Created *time.Time `json:"created,omitempty"`
+	Token   string     `json:"token,omitempty"`
+}
+
+// TokensResponse is the list of tokens returned by the API
+type TokensResponse struct {
+	Tokens []TokenResponse `json:"tokens"`
+}
+
+// TokenRequest is the body of a request creating a token
+type TokenRequest struct {
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+// tokenStore holds the tokens set in the config file and the tokens created
+// through the API, which are saved to the tokens file when there is one
+type tokenStore struct {
+	sync.RWMutex
+	fileTokens map[string]*token
+	apiTokens  map[string]*token
+	path       string
+}
+
+func newTokenStore(cfg *Config) (*tokenStore, error) {
+	ts := &tokenStore{
+		fileTokens: map[string]*token{},
+		apiTokens:  map[string]*token{},
+		path:       cfg.TokensPath,
+	}
+	if err := ts.setFileTokens(cfg.Tokens); err != nil {
+		return nil, err
+	}
+	if ts.path == "" {
+		return ts, nil
+	}
+	b, err := ioutil.ReadFile(ts.path)
+	if os.IsNotExist(err) {
+		return ts, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var tokens []*token
+	if err := json.Unmarshal(b, &tokens); err != nil {
+		return nil, fmt.Errorf("%v (while reading tokens file %s)", err, ts.path)
+	}
+	for _, t := range tokens {
+		if t.role, err = api.ParseRole(t.Role); err != nil {
+			return nil, fmt.Errorf("%v (token %s in tokens file %s)", err, t.Name, ts.path)
+		}
+		t.source = tokenSourceAPI
+		ts.apiTokens[t.Name] = t
+	}
+	return ts, nil
+}
+
+// setFileTokens replaces the tokens set in the config file
+func (ts *tokenStore) setFileTokens(tokens []*TokenConfig) error {
+	fileTokens := make(map[string]*token, len(tokens))
+	for _, tc := range tokens {
+		if tc.Name == "" {
+			return ErrTokenNameEmpty
+		}
+		if _, ok := fileTokens[tc.Name]; ok {
+			return fmt.Errorf("%v: %s", ErrTokenExists, tc.Name)
+		}
+		role, err := api.ParseRole(tc.Role)
+		if err != nil {
+			return fmt.Errorf("%v (token %s)", err, tc.Name)
+		}
+		fileTokens[tc.Name] = &token{
+			Name:   tc.Name,
+			Role:   role.String(),
+			Hash:   hashToken(tc.Token),
+			role:   role,
+			source: tokenSourceFile,
+		}
+	}
+	ts.Lock()
+	defer ts.Unlock()
+	ts.fileTokens = fileTokens
+	return nil
+}
+
+// authenticate returns the token whose secret is given
+func (ts *tokenStore) authenticate(secret string) (*token, bool) {
+	if secret == "" {
+		return nil, false
+	}
+	hash := []byte(hashToken(secret))
+	ts.RLock()
+	defer ts.RUnlock()
+	for _, tokens := range []map[string]*token{ts.fileTokens, ts.apiTokens} {
+		for _, t := range tokens {
+			if subtle.ConstantTimeCompare(hash, []byte(t.Hash)) == 1 {
+				return t, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// create adds a token with the given name and role and returns its secret
+func (ts *tokenStore) create(name string, role api.Role) (*token, string, error) {
+	if name == "" {
+		return nil, "", ErrTokenNameEmpty
+	}
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", err
+	}
+	secret := hex.EncodeToString(b)
+	t := &token{
+		Name:    name,
+		Role:    role.String(),
+		Hash:    hashToken(secret),
+		Created: time.Now(),
+		role:    role,
+		source:  tokenSourceAPI,
+	}
+	ts.Lock()
+	defer ts.Unlock()
+	if ts.exists(name) {
+		return nil, "", ErrTokenExists
+	}
+	ts.apiTokens[name] = t
+	if err := ts.save(); err != nil {
+		delete(ts.apiTokens, name)
+		return nil, "", err
+	}
+	return t, secret, nil
+}
+
+// remove removes a token created through the API
+func (ts *tokenStore) remove(name string) error {
+	ts.Lock()
+	defer ts.Unlock()
+	t, ok := ts.apiTokens[name]
+	if !ok {
+		if _, ok := ts.fileTokens[name]; ok {
+			return ErrTokenFromConfig
+		}
+		return ErrTokenNotFound
+	}
+	delete(ts.apiTokens, name)
+	if err := ts.save(); err != nil {
+		ts.apiTokens[name] = t
+		return err
+	}
+	return nil
+}
+
+// list returns the tokens sorted by name
+func (ts *tokenStore) list() []TokenResponse {
+	ts.RLock()
+	defer ts.RUnlock()
+	tokens := []TokenResponse{}
+	for _, m := range []map[string]*token{ts.fileTokens, ts.apiTokens} {
+		for _, t := range m {
+			tokens = append(tokens, t.response())
+		}
+	}
+	sort.Sort(tokensByName(tokens))
+	return tokens
+}
+
+func (ts *tokenStore) exists(name string) bool {
+	_, inFile := ts.fileTokens[name]
+	_, inAPI := ts.apiTokens[name]
+	return inFile || inAPI
+}
+
+// save writes the tokens created through the API to the tokens file,
+// replacing it atomically. It is called with the lock held.
+func (ts *tokenStore) save() error {
+	if ts.path == "" {
+		return nil
+	}
+	tokens := make([]*token, 0, len(ts.apiTokens))
+	for _, t := range ts.apiTokens {
+		tokens = append(tokens, t)
+	}
+	b, err := json.MarshalIndent(tokens, "", "  ")
+	if err != nil {
+		return err
+	}
+	dir, file := filepath.Split(ts.path)
+	tmp, err := ioutil.TempFile(dir, "."+file)
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), ts.path)
+}
+
+func (t *token) response() TokenResponse {
+	tr := TokenResponse{Name: t.Name, Role: t.Role, Source: t.source}
+	if !t.Created.IsZero() {
+		created := t.Created
+		tr.Created = &created
+	}
+	return tr
+}
+
+func hashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+type tokensByName []TokenResponse
+
+func (t tokensByName) Len() int           { return len(t) }
+func (t tokensByName) Swap(i, j int)      { t[i], t[j] = t[j], t[i] }
+func (t tokensByName) Less(i, j int) bool { return t[i].Name < t[j].Name }
+
+// addTokenRoutes exposes the management of the API tokens to admins
+func (s *Server) addTokenRoutes() {
+	s.r.GET("/v2/tokens", s.authorize(api.RoleAdmin, s.getTokens))
+	s.r.POST("/v2/tokens", s.authorize(api.RoleAdmin, s.audit("token.create", s.addToken)))
+	s.r.DELETE("/v2/tokens/:name", s.authorize(api.RoleAdmin, s.audit("token.remove", s.removeToken)))
+}
+
+func (s *Server) getTokens(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	v2.Write(200, TokensResponse{Tokens: s.tokens.list()}, w)
+}
+
+func (s *Server) addToken(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var req TokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		v2.Write(400, v2.FromError(err), w)
+		return
+	}
+	role, err := api.ParseRole(req.Role)
+	if err != nil {
+		v2.Write(400, v2.FromError(err), w)
+		return
+	}
+	t, secret, err := s.tokens.create(req.Name, role)
+	switch err {
+	case nil:
+	case ErrTokenNameEmpty:
+		v2.Write(400, v2.FromError(err), w)
+		return
+	case ErrTokenExists:
+		v2.Write(409, v2.FromError(err), w)
+		return
+	default:
+		v2.Write(500, v2.FromError(err), w)
+		return
+	}
+	tr := t.response()
+	tr.Token = secret
+	v2.Write(201, tr, w)
+}
+
+func (s *Server) removeToken(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	switch err := s.tokens.remove(p.ByName("name")); err {
+	case nil:
+		v2.Write(204, nil, w)
+	case ErrTokenNotFound:
+		v2.Write(404, v2.FromError(err), w)
+	case ErrTokenFromConfig:
+		v2.Write(409, v2.FromError(err), w)
+	default:
+		v2.Write(500, v2.FromError(err), w)
+	}
+}
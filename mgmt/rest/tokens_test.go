@@ -0,0 +1,129 @@
+// +build medium
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/intelsdi-x/snap/mgmt/rest/api"
+)
+
+func newAuthServer(cfg *Config) *Server {
+	s, err := New(cfg)
+	So(err, ShouldBeNil)
+	s.SetAPIAuth(true)
+	s.SetAPIAuthPwd("secret")
+	s.r.Handle("GET", "/v2/read", s.authorize(api.RoleReadOnly, func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		w.WriteHeader(200)
+	}))
+	s.r.Handle("DELETE", "/v2/plugins", s.authorize(api.RolePluginAdmin, func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		w.WriteHeader(204)
+	}))
+	s.addTokenRoutes()
+	return s
+}
+
+func serve(s *Server, method, path, body string, auth func(*http.Request)) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if auth != nil {
+		auth(req)
+	}
+	rec := httptest.NewRecorder()
+	s.n.ServeHTTP(rec, req)
+	return rec
+}
+
+func password(p string) func(*http.Request) {
+	return func(r *http.Request) { r.SetBasicAuth("snap", p) }
+}
+
+func bearer(t string) func(*http.Request) {
+	return func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+t) }
+}
+
+func TestRestAPITokens(t *testing.T) {
+	Convey("Given a REST API with authentication and tokens", t, func() {
+		dir, err := ioutil.TempDir("", "snap-rest-tokens")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		cfg := GetDefaultConfig()
+		cfg.Tokens = []*TokenConfig{{Name: "grafana", Token: "dashboards", Role: "read-only"}}
+		cfg.TokensPath = filepath.Join(dir, "tokens.json")
+		s := newAuthServer(cfg)
+
+		Convey("requests without valid credentials are unauthorized", func() {
+			So(serve(s, "GET", "/v2/read", "", nil).Code, ShouldEqual, 401)
+			So(serve(s, "GET", "/v2/read", "", password("wrong")).Code, ShouldEqual, 401)
+		})
+		Convey("the password is granted every role", func() {
+			So(serve(s, "DELETE", "/v2/plugins", "", password("secret")).Code, ShouldEqual, 204)
+			So(serve(s, "GET", "/v2/tokens", "", password("secret")).Code, ShouldEqual, 200)
+		})
+		Convey("a read-only token cannot change plugins", func() {
+			So(serve(s, "GET", "/v2/read", "", password("dashboards")).Code, ShouldEqual, 200)
+			So(serve(s, "GET", "/v2/read", "", bearer("dashboards")).Code, ShouldEqual, 200)
+			So(serve(s, "DELETE", "/v2/plugins", "", bearer("dashboards")).Code, ShouldEqual, 403)
+			So(serve(s, "GET", "/v2/tokens", "", bearer("dashboards")).Code, ShouldEqual, 403)
+		})
+		Convey("tokens are managed through the API", func() {
+			rec := serve(s, "POST", "/v2/tokens", `{"name": "deployer", "role": "plugin-admin"}`, password("secret"))
+			So(rec.Code, ShouldEqual, 201)
+			var created TokenResponse
+			So(json.Unmarshal(rec.Body.Bytes(), &created), ShouldBeNil)
+			So(created.Token, ShouldNotBeEmpty)
+			So(created.Source, ShouldEqual, "api")
+			So(serve(s, "DELETE", "/v2/plugins", "", bearer(created.Token)).Code, ShouldEqual, 204)
+
+			So(serve(s, "POST", "/v2/tokens", `{"name": "deployer", "role": "read-only"}`, password("secret")).Code, ShouldEqual, 409)
+			So(serve(s, "POST", "/v2/tokens", `{"name": "other", "role": "root"}`, password("secret")).Code, ShouldEqual, 400)
+
+			Convey("and saved to the tokens file without their secret", func() {
+				b, err := ioutil.ReadFile(cfg.TokensPath)
+				So(err, ShouldBeNil)
+				So(string(b), ShouldNotContainSubstring, created.Token)
+				restarted := newAuthServer(cfg)
+				So(serve(restarted, "DELETE", "/v2/plugins", "", bearer(created.Token)).Code, ShouldEqual, 204)
+			})
+			Convey("and removed", func() {
+				So(serve(s, "DELETE", "/v2/tokens/deployer", "", password("secret")).Code, ShouldEqual, 204)
+				So(serve(s, "DELETE", "/v2/plugins", "", bearer(created.Token)).Code, ShouldEqual, 401)
+				So(serve(s, "DELETE", "/v2/tokens/grafana", "", password("secret")).Code, ShouldEqual, 409)
+				So(serve(s, "DELETE", "/v2/tokens/unknown", "", password("secret")).Code, ShouldEqual, 404)
+			})
+		})
+		Convey("tokens with an unknown role are rejected", func() {
+			cfg.Tokens = []*TokenConfig{{Name: "bad", Token: "x", Role: "root"}}
+			_, err := New(cfg)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -42,41 +42,41 @@ func New(wg *sync.WaitGroup, killChan chan struct{}, protocol string) *apiV1 {
 func (s *apiV1) GetRoutes() []api.Route {
 	routes := []api.Route{
 		// plugin routes
-		api.Route{Method: "GET", Path: prefix + "/plugins", Handle: s.getPlugins},
-		api.Route{Method: "GET", Path: prefix + "/plugins/:type", Handle: s.getPlugins},
-		api.Route{Method: "GET", Path: prefix + "/plugins/:type/:name", Handle: s.getPlugins},
-		api.Route{Method: "GET", Path: prefix + "/plugins/:type/:name/:version", Handle: s.getPlugin},
-		api.Route{Method: "POST", Path: prefix + "/plugins", Handle: s.loadPlugin},
-		api.Route{Method: "DELETE", Path: prefix + "/plugins/:type/:name/:version", Handle: s.unloadPlugin},
-		api.Route{Method: "GET", Path: prefix + "/plugins/:type/:name/:version/config", Handle: s.getPluginConfigItem},
-		api.Route{Method: "PUT", Path: prefix + "/plugins/:type/:name/:version/config", Handle: s.setPluginConfigItem},
-		api.Route{Method: "DELETE", Path: prefix + "/plugins/:type/:name/:version/config", Handle: s.deletePluginConfigItem},
+		api.Route{Method: "GET", Path: prefix + "/plugins", Handle: s.getPlugins, Role: api.RoleReadOnly},
+		api.Route{Method: "GET", Path: prefix + "/plugins/:type", Handle: s.getPlugins, Role: api.RoleReadOnly},
+		api.Route{Method: "GET", Path: prefix + "/plugins/:type/:name", Handle: s.getPlugins, Role: api.RoleReadOnly},
+		api.Route{Method: "GET", Path: prefix + "/plugins/:type/:name/:version", Handle: s.getPlugin, Role: api.RoleReadOnly},
+		api.Route{Method: "POST", Path: prefix + "/plugins", Handle: s.loadPlugin, Role: api.RolePluginAdmin, Audit: "plugin.load"},
+		api.Route{Method: "DELETE", Path: prefix + "/plugins/:type/:name/:version", Handle: s.unloadPlugin, Role: api.RolePluginAdmin, Audit: "plugin.unload"},
+		api.Route{Method: "GET", Path: prefix + "/plugins/:type/:name/:version/config", Handle: s.getPluginConfigItem, Role: api.RoleReadOnly},
+		api.Route{Method: "PUT", Path: prefix + "/plugins/:type/:name/:version/config", Handle: s.setPluginConfigItem, Role: api.RolePluginAdmin, Audit: "plugin.config.set"},
+		api.Route{Method: "DELETE", Path: prefix + "/plugins/:type/:name/:version/config", Handle: s.deletePluginConfigItem, Role: api.RolePluginAdmin, Audit: "plugin.config.delete"},
 
 		// metric routes
-		api.Route{Method: "GET", Path: prefix + "/metrics", Handle: s.getMetrics},
-		api.Route{Method: "GET", Path: prefix + "/metrics/*namespace", Handle: s.getMetricsFromTree},
+		api.Route{Method: "GET", Path: prefix + "/metrics", Handle: s.getMetrics, Role: api.RoleReadOnly},
+		api.Route{Method: "GET", Path: prefix + "/metrics/*namespace", Handle: s.getMetricsFromTree, Role: api.RoleReadOnly},
 
 		// task routes
-		api.Route{Method: "GET", Path: prefix + "/tasks", Handle: s.getTasks},
-		api.Route{Method: "GET", Path: prefix + "/tasks/:id", Handle: s.getTask},
-		api.Route{Method: "GET", Path: prefix + "/tasks/:id/watch", Handle: s.watchTask},
-		api.Route{Method: "POST", Path: prefix + "/tasks", Handle: s.addTask},
-		api.Route{Method: "PUT", Path: prefix + "/tasks/:id/start", Handle: s.startTask},
-		api.Route{Method: "PUT", Path: prefix + "/tasks/:id/stop", Handle: s.stopTask},
-		api.Route{Method: "DELETE", Path: prefix + "/tasks/:id", Handle: s.removeTask},
-		api.Route{Method: "PUT", Path: prefix + "/tasks/:id/enable", Handle: s.enableTask},
+		api.Route{Method: "GET", Path: prefix + "/tasks", Handle: s.getTasks, Role: api.RoleReadOnly},
+		api.Route{Method: "GET", Path: prefix + "/tasks/:id", Handle: s.getTask, Role: api.RoleReadOnly},
+		api.Route{Method: "GET", Path: prefix + "/tasks/:id/watch", Handle: s.watchTask, Role: api.RoleReadOnly},
+		api.Route{Method: "POST", Path: prefix + "/tasks", Handle: s.addTask, Role: api.RoleTaskOperator, Audit: "task.create"},
+		api.Route{Method: "PUT", Path: prefix + "/tasks/:id/start", Handle: s.startTask, Role: api.RoleTaskOperator, Audit: "task.start"},
+		api.Route{Method: "PUT", Path: prefix + "/tasks/:id/stop", Handle: s.stopTask, Role: api.RoleTaskOperator, Audit: "task.stop"},
+		api.Route{Method: "DELETE", Path: prefix + "/tasks/:id", Handle: s.removeTask, Role: api.RoleTaskOperator, Audit: "task.remove"},
+		api.Route{Method: "PUT", Path: prefix + "/tasks/:id/enable", Handle: s.enableTask, Role: api.RoleTaskOperator, Audit: "task.enable"},
 	}
 	// tribe routes
 	if s.tribeManager != nil {
 		routes = append(routes, []api.Route{
-			api.Route{Method: "GET", Path: prefix + "/tribe/agreements", Handle: s.getAgreements},
-			api.Route{Method: "POST", Path: prefix + "/tribe/agreements", Handle: s.addAgreement},
-			api.Route{Method: "GET", Path: prefix + "/tribe/agreements/:name", Handle: s.getAgreement},
-			api.Route{Method: "DELETE", Path: prefix + "/tribe/agreements/:name", Handle: s.deleteAgreement},
-			api.Route{Method: "PUT", Path: prefix + "/tribe/agreements/:name/join", Handle: s.joinAgreement},
-			api.Route{Method: "DELETE", Path: prefix + "/tribe/agreements/:name/leave", Handle: s.leaveAgreement},
-			api.Route{Method: "GET", Path: prefix + "/tribe/members", Handle: s.getMembers},
-			api.Route{Method: "GET", Path: prefix + "/tribe/member/:name", Handle: s.getMember},
+			api.Route{Method: "GET", Path: prefix + "/tribe/agreements", Handle: s.getAgreements, Role: api.RoleReadOnly},
+			api.Route{Method: "POST", Path: prefix + "/tribe/agreements", Handle: s.addAgreement, Role: api.RoleAdmin, Audit: "tribe.agreement.create"},
+			api.Route{Method: "GET", Path: prefix + "/tribe/agreements/:name", Handle: s.getAgreement, Role: api.RoleReadOnly},
+			api.Route{Method: "DELETE", Path: prefix + "/tribe/agreements/:name", Handle: s.deleteAgreement, Role: api.RoleAdmin, Audit: "tribe.agreement.delete"},
+			api.Route{Method: "PUT", Path: prefix + "/tribe/agreements/:name/join", Handle: s.joinAgreement, Role: api.RoleAdmin, Audit: "tribe.agreement.join"},
+			api.Route{Method: "DELETE", Path: prefix + "/tribe/agreements/:name/leave", Handle: s.leaveAgreement, Role: api.RoleAdmin, Audit: "tribe.agreement.leave"},
+			api.Route{Method: "GET", Path: prefix + "/tribe/members", Handle: s.getMembers, Role: api.RoleReadOnly},
+			api.Route{Method: "GET", Path: prefix + "/tribe/member/:name", Handle: s.getMember, Role: api.RoleReadOnly},
 		}...)
 	}
 	return routes
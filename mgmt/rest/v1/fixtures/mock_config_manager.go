@@ -66,6 +66,22 @@ func (MockConfigManager) DeletePluginConfigDataNodeFieldAll(fields ...string) cd
 	return *mockConfig
 }
 
+func (MockConfigManager) GetPluginConfigDataNodeSources(core.PluginType, string, int) map[string]string {
+	return mockConfigSources()
+}
+
+func (MockConfigManager) GetPluginConfigDataNodeAllSources() map[string]string {
+	return mockConfigSources()
+}
+
+func mockConfigSources() map[string]string {
+	sources := map[string]string{}
+	for k := range mockConfig.Table() {
+		sources[k] = "file"
+	}
+	return sources
+}
+
 // These constants are the expected plugin config responses from running
 // rest_v1_test.go on the plugin config routes found in mgmt/rest/server.go
 const (
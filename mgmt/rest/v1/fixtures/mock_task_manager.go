@@ -74,6 +74,7 @@ func (t *mockTask) GetName() string                     { return t.MyName }
 func (t *mockTask) SetName(string)                      { return }
 func (t *mockTask) SetID(string)                        { return }
 func (t *mockTask) MissedCount() uint                   { return 0 }
+func (t *mockTask) SkippedCount() uint                  { return 0 }
 func (t *mockTask) FailedCount() uint                   { return 0 }
 func (t *mockTask) LastFailureMessage() string          { return "" }
 func (t *mockTask) LastRunTime() *time.Time             { return &time.Time{} }
@@ -87,6 +88,18 @@ func (t *mockTask) MaxMetricsBuffer() int64             { return 0 }
 func (t *mockTask) SetMaxMetricsBuffer(int64)           {}
 func (t *mockTask) MaxCollectDuration() time.Duration   { return time.Second }
 func (t *mockTask) SetMaxCollectDuration(time.Duration) {}
+func (t *mockTask) PublishBuffers() []core.PublishBufferStatus {
+	return nil
+}
+func (t *mockTask) Labels() map[string]string             { return nil }
+func (t *mockTask) SetLabels(map[string]string)           {}
+func (t *mockTask) Dependencies() []core.TaskDependency   { return nil }
+func (t *mockTask) SetDependencies([]core.TaskDependency) {}
+func (t *mockTask) AdaptiveInterval() bool                { return false }
+func (t *mockTask) SetAdaptiveInterval(bool)              {}
+func (t *mockTask) EffectiveInterval() time.Duration      { return 0 }
+func (t *mockTask) Priority() string                      { return "" }
+func (t *mockTask) SetPriority(string)                    {}
 func (t *mockTask) Option(...core.TaskOption) core.TaskOption {
 	return core.TaskDeadlineDuration(0)
 }
@@ -129,12 +142,41 @@ func (m *MockTaskManager) CreateTask(
 		MyState:             "failed",
 		MyHref:              "http://localhost:8181/v2/tasks/MyTaskID"}, nil
 }
+func (m *MockTaskManager) UpdateTask(
+	id string,
+	sch schedule.Schedule,
+	wmap *wmap.WorkflowMap,
+	opts ...core.TaskOption) (core.Task, core.TaskErrors) {
+	return &mockTask{
+		MyID:                id,
+		MyName:              "TaskUpdated",
+		MySchedule:          &core.Schedule{},
+		MyCreationTimestamp: time.Now().Unix(),
+		MyLastRunTimestamp:  time.Now().Unix(),
+		MyHitCount:          99,
+		MyMissCount:         5,
+		MyState:             "Running",
+		MyHref:              "http://localhost:8181/v1/tasks/" + id}, nil
+}
+func (m *MockTaskManager) ValidateTask(sch schedule.Schedule, wmap *wmap.WorkflowMap) *core.TaskValidation {
+	return &core.TaskValidation{Valid: true}
+}
+func (m *MockTaskManager) AddTemplate(t *core.TaskTemplate) error { return nil }
+func (m *MockTaskManager) GetTemplates() []*core.TaskTemplate     { return nil }
+func (m *MockTaskManager) GetTemplate(name string) (*core.TaskTemplate, error) {
+	return nil, nil
+}
+func (m *MockTaskManager) RemoveTemplate(name string) error { return nil }
+func (m *MockTaskManager) InstantiateTemplate(name string, values map[string]interface{}, start *bool) (core.Task, error) {
+	return nil, nil
+}
 func (m *MockTaskManager) GetTasks() map[string]core.Task {
 	return taskCatalog
 }
 func (m *MockTaskManager) StartTask(id string) []serror.SnapError { return nil }
 func (m *MockTaskManager) StopTask(id string) []serror.SnapError  { return nil }
 func (m *MockTaskManager) RemoveTask(id string) error             { return nil }
+func (m *MockTaskManager) ReplayTask(id string) (int, error)      { return 2, nil }
 func (m *MockTaskManager) WatchTask(id string, handler core.TaskWatcherHandler) (core.TaskWatcherCloser, error) {
 	return nil, nil
 }
@@ -131,6 +131,7 @@ type ScheduledTask struct {
 	LastFailureMessage string            `json:"last_failure_message,omitempty"`
 	State              string            `json:"task_state"`
 	Href               string            `json:"href"`
+	Labels             map[string]string `json:"labels,omitempty"`
 }
 
 func (s *ScheduledTask) CreationTime() time.Time {
@@ -157,6 +158,7 @@ func SchedulerTaskFromTask(t core.Task) *ScheduledTask {
 		FailedCount:        int(t.FailedCount()),
 		LastFailureMessage: t.LastFailureMessage(),
 		State:              t.State().String(),
+		Labels:             t.Labels(),
 	}
 	if st.LastRunTimestamp < 0 {
 		st.LastRunTimestamp = -1
@@ -218,12 +220,7 @@ func (s *ScheduledTaskEnabled) ResponseBodyType() string {
 func assertSchedule(s schedule.Schedule, t *AddScheduledTask) {
 	switch v := s.(type) {
 	case *schedule.WindowedSchedule:
-		t.Schedule = &core.Schedule{
-			Type:           "windowed",
-			Interval:       v.Interval.String(),
-			StartTimestamp: v.StartTime,
-			StopTimestamp:  v.StopTime,
-		}
+		t.Schedule = core.ScheduleFromSchedule(v)
 		return
 	case *schedule.CronSchedule:
 		t.Schedule = &core.Schedule{
@@ -231,6 +228,9 @@ func assertSchedule(s schedule.Schedule, t *AddScheduledTask) {
 			Interval: v.Entry(),
 		}
 		return
+	case *schedule.TriggeredSchedule:
+		t.Schedule = core.ScheduleFromSchedule(v)
+		return
 	}
 }
 
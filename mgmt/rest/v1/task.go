@@ -56,16 +56,23 @@ func (s *apiV1) addTask(w http.ResponseWriter, r *http.Request, _ httprouter.Par
 }
 
 func (s *apiV1) getTasks(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	selector, err := core.ParseLabelSelector(r.URL.Query().Get("selector"))
+	if err != nil {
+		rbody.Write(400, rbody.FromError(err), w)
+		return
+	}
 	sts := s.taskManager.GetTasks()
 
 	tasks := &rbody.ScheduledTaskListReturned{}
-	tasks.ScheduledTasks = make([]rbody.ScheduledTask, len(sts))
+	tasks.ScheduledTasks = make([]rbody.ScheduledTask, 0, len(sts))
 
-	i := 0
 	for _, t := range sts {
-		tasks.ScheduledTasks[i] = *rbody.SchedulerTaskFromTask(t)
-		tasks.ScheduledTasks[i].Href = taskURI(r.Host, version, t)
-		i++
+		if !selector.Matches(t.Labels()) {
+			continue
+		}
+		task := rbody.SchedulerTaskFromTask(t)
+		task.Href = taskURI(r.Host, version, t)
+		tasks.ScheduledTasks = append(tasks.ScheduledTasks, *task)
 	}
 	sort.Sort(tasks)
 	rbody.Write(200, tasks, w)
@@ -70,7 +70,7 @@ func (s *apiV2) GetRoutes() []api.Route {
 		// Responses:
 		// 200: PluginsResponse
 		// 401: UnauthResponse
-		api.Route{Method: "GET", Path: prefix + "/plugins", Handle: s.getPlugins},
+		api.Route{Method: "GET", Path: prefix + "/plugins", Handle: s.getPlugins, Role: api.RoleReadOnly},
 		// swagger:route GET /plugins/{ptype}/{pname}/{pversion} plugins getPlugin
 		//
 		// Get
@@ -88,7 +88,7 @@ func (s *apiV2) GetRoutes() []api.Route {
 		// 404: ErrorResponse
 		// 500: ErrorResponse
 		// 401: UnauthResponse
-		api.Route{Method: "GET", Path: prefix + "/plugins/:type/:name/:version", Handle: s.getPlugin},
+		api.Route{Method: "GET", Path: prefix + "/plugins/:type/:name/:version", Handle: s.getPlugin, Role: api.RoleReadOnly},
 		// swagger:route POST /plugins plugins loadPlugin
 		//
 		// Load
@@ -110,7 +110,7 @@ func (s *apiV2) GetRoutes() []api.Route {
 		// 415: ErrorResponse
 		// 500: ErrorResponse
 		// 401: UnauthResponse
-		api.Route{Method: "POST", Path: prefix + "/plugins", Handle: s.loadPlugin},
+		api.Route{Method: "POST", Path: prefix + "/plugins", Handle: s.loadPlugin, Role: api.RolePluginAdmin, Audit: "plugin.load"},
 		// swagger:route DELETE /plugins/{ptype}/{pname}/{pversion} plugins unloadPlugin
 		//
 		// Unload
@@ -129,12 +129,14 @@ func (s *apiV2) GetRoutes() []api.Route {
 		// 409: ErrorResponse
 		// 500: ErrorResponse
 		// 401: UnauthResponse
-		api.Route{Method: "DELETE", Path: prefix + "/plugins/:type/:name/:version", Handle: s.unloadPlugin},
+		api.Route{Method: "DELETE", Path: prefix + "/plugins/:type/:name/:version", Handle: s.unloadPlugin, Role: api.RolePluginAdmin, Audit: "plugin.unload"},
 		// swagger:route GET /plugins/{ptype}/{pname}/{pversion}/config plugins getPluginConfigItem
 		//
 		// Get Config
 		//
 		// An empty config is returned if there are no configs for the plugin.
+		// When the sources query parameter is given, the source of each config item,
+		// file or runtime, is returned along with the config.
 		//
 		// Produces:
 		// application/json
@@ -145,7 +147,7 @@ func (s *apiV2) GetRoutes() []api.Route {
 		// 200: PluginConfigResponse
 		// 400: ErrorResponse
 		// 401: UnauthResponse
-		api.Route{Method: "GET", Path: prefix + "/plugins/:type/:name/:version/config", Handle: s.getPluginConfigItem},
+		api.Route{Method: "GET", Path: prefix + "/plugins/:type/:name/:version/config", Handle: s.getPluginConfigItem, Role: api.RoleReadOnly},
 		// swagger:route PUT /plugins/{ptype}/{pname}/{pversion}/config plugins setPluginConfigItem
 		//
 		// Set Config
@@ -164,7 +166,7 @@ func (s *apiV2) GetRoutes() []api.Route {
 		// 200: PluginConfigResponse
 		// 400: ErrorResponse
 		// 401: UnauthResponse
-		api.Route{Method: "PUT", Path: prefix + "/plugins/:type/:name/:version/config", Handle: s.setPluginConfigItem},
+		api.Route{Method: "PUT", Path: prefix + "/plugins/:type/:name/:version/config", Handle: s.setPluginConfigItem, Role: api.RolePluginAdmin, Audit: "plugin.config.set"},
 		// swagger:route DELETE /plugins/{ptype}/{pname}/{pversion}/config plugins deletePluginConfigItem
 		//
 		// Delete Config
@@ -183,7 +185,7 @@ func (s *apiV2) GetRoutes() []api.Route {
 		// 200: PluginConfigResponse
 		// 400: ErrorResponse
 		// 401: UnauthResponse
-		api.Route{Method: "DELETE", Path: prefix + "/plugins/:type/:name/:version/config", Handle: s.deletePluginConfigItem},
+		api.Route{Method: "DELETE", Path: prefix + "/plugins/:type/:name/:version/config", Handle: s.deletePluginConfigItem, Role: api.RolePluginAdmin, Audit: "plugin.config.delete"},
 		// swagger:route GET /metrics plugins getMetrics
 		//
 		// Get Metrics
@@ -200,12 +202,13 @@ func (s *apiV2) GetRoutes() []api.Route {
 		// 404: ErrorResponse
 		// 500: ErrorResponse
 		// 401: UnauthResponse
-		api.Route{Method: "GET", Path: prefix + "/metrics", Handle: s.getMetrics},
+		api.Route{Method: "GET", Path: prefix + "/metrics", Handle: s.getMetrics, Role: api.RoleReadOnly},
 		// swagger:route GET /tasks tasks getTasks
 		//
 		// Get All
 		//
-		// An empty list returns if no tasks exist.
+		// An empty list returns if no tasks exist. The tasks can be filtered
+		// by their labels with a selector.
 		//
 		// Produces:
 		// application/json
@@ -214,8 +217,43 @@ func (s *apiV2) GetRoutes() []api.Route {
 		//
 		// Responses:
 		// 200: TasksResponse
+		// 400: ErrorResponse
+		// 401: UnauthResponse
+		api.Route{Method: "GET", Path: prefix + "/tasks", Handle: s.getTasks, Role: api.RoleReadOnly},
+		// swagger:route PUT /tasks tasks updateTasksState
+		//
+		// Start/Stop by labels
+		//
+		// A selector is required. The outcome of the action is returned for
+		// each task selected.
+		//
+		// Produces:
+		// application/json
+		//
+		// Schemes: http, https
+		//
+		// Responses:
+		// 200: TasksActionResponse
+		// 400: ErrorResponse
 		// 401: UnauthResponse
-		api.Route{Method: "GET", Path: prefix + "/tasks", Handle: s.getTasks},
+		api.Route{Method: "PUT", Path: prefix + "/tasks", Handle: s.updateTasksState, Role: api.RoleTaskOperator, Audit: "task.{action}"},
+		// swagger:route DELETE /tasks tasks removeTasks
+		//
+		// Remove by labels
+		//
+		// A selector is required. The outcome of the removal is returned for
+		// each task selected.
+		//
+		// Produces:
+		// application/json
+		//
+		// Schemes: http, https
+		//
+		// Responses:
+		// 200: TasksActionResponse
+		// 400: ErrorResponse
+		// 401: UnauthResponse
+		api.Route{Method: "DELETE", Path: prefix + "/tasks", Handle: s.removeTasks, Role: api.RoleTaskOperator, Audit: "task.remove"},
 		// swagger:route GET /tasks/{id} tasks getTask
 		//
 		// Get
@@ -231,7 +269,7 @@ func (s *apiV2) GetRoutes() []api.Route {
 		// 200: TaskResponse
 		// 404: ErrorResponse
 		// 401: UnauthResponse
-		api.Route{Method: "GET", Path: prefix + "/tasks/:id", Handle: s.getTask},
+		api.Route{Method: "GET", Path: prefix + "/tasks/:id", Handle: s.getTask, Role: api.RoleReadOnly},
 		// swagger:route GET /tasks/{id}/watch tasks watchTask
 		//
 		// Watch
@@ -248,12 +286,13 @@ func (s *apiV2) GetRoutes() []api.Route {
 		// 404: ErrorResponse
 		// 500: ErrorResponse
 		// 401: UnauthResponse
-		api.Route{Method: "GET", Path: prefix + "/tasks/:id/watch", Handle: s.watchTask},
+		api.Route{Method: "GET", Path: prefix + "/tasks/:id/watch", Handle: s.watchTask, Role: api.RoleReadOnly},
 		// swagger:route POST /tasks tasks addTask
 		//
 		// Add
 		//
 		// A string representation of Snap task manifest is required.
+		// With dry_run=true, the manifest is validated without creating the task.
 		//
 		// Consumes:
 		// application/json
@@ -264,15 +303,39 @@ func (s *apiV2) GetRoutes() []api.Route {
 		// Schemes: http, https
 		//
 		// Responses:
+		// 200: TaskValidationResponse
 		// 201: TaskResponse
+		// 400: TaskValidationResponse
 		// 500: ErrorResponse
 		// 401: UnauthResponse
-		api.Route{Method: "POST", Path: prefix + "/tasks", Handle: s.addTask},
+		api.Route{Method: "POST", Path: prefix + "/tasks", Handle: s.addTask, Role: api.RoleTaskOperator, Audit: "task.create"},
+		// swagger:route POST /tasks/batch tasks runTaskBatch
+		//
+		// Batch
+		//
+		// Runs a list of create, start, stop and remove operations in order,
+		// returning the outcome of each. The tasks created by an atomic batch
+		// are removed when one of its operations fails.
+		//
+		// Consumes:
+		// application/json
+		//
+		// Produces:
+		// application/json
+		//
+		// Schemes: http, https
+		//
+		// Responses:
+		// 200: TaskBatchResponse
+		// 400: ErrorResponse
+		// 401: UnauthResponse
+		api.Route{Method: "POST", Path: prefix + "/tasks/batch", Handle: s.runTaskBatch, Role: api.RoleTaskOperator, Audit: "task.batch"},
 		// swagger:route PUT /tasks/{id} tasks updateTaskState
 		//
-		// Enable/Start/Stop
+		// Enable/Start/Stop/Replay
 		//
-		// The task ID is required.
+		// The task ID is required. Replaying a task publishes again the metrics
+		// held in its dead-letter spool.
 		//
 		// Consumes:
 		// application/json
@@ -283,12 +346,36 @@ func (s *apiV2) GetRoutes() []api.Route {
 		// Schemes: http, https
 		//
 		// Responses:
+		// 200: TaskReplayResponse
 		// 204: TaskResponse
 		// 400: ErrorResponse
 		// 409: ErrorResponse
 		// 500: ErrorResponse
 		// 401: UnauthResponse
-		api.Route{Method: "PUT", Path: prefix + "/tasks/:id", Handle: s.updateTaskState},
+		api.Route{Method: "PUT", Path: prefix + "/tasks/:id", Handle: s.updateTaskState, Role: api.RoleTaskOperator, Audit: "task.{action}"},
+		// swagger:route PATCH /tasks/{id} tasks updateTask
+		//
+		// Update
+		//
+		// The task ID is required. The schedule, workflow, deadline, max-failures
+		// and name given replace the ones of the task, a running task being
+		// switched over between two of its runs.
+		//
+		// Consumes:
+		// application/json
+		//
+		// Produces:
+		// application/json
+		//
+		// Schemes: http, https
+		//
+		// Responses:
+		// 200: TaskResponse
+		// 404: ErrorResponse
+		// 409: ErrorResponse
+		// 500: ErrorResponse
+		// 401: UnauthResponse
+		api.Route{Method: "PATCH", Path: prefix + "/tasks/:id", Handle: s.updateTask, Role: api.RoleTaskOperator, Audit: "task.update"},
 		// swagger:route DELETE /tasks/{id} tasks removeTask
 		//
 		// Remove
@@ -305,7 +392,98 @@ func (s *apiV2) GetRoutes() []api.Route {
 		// 404: ErrorResponse
 		// 500: TaskErrorResponse
 		// 401: UnauthResponse
-		api.Route{Method: "DELETE", Path: prefix + "/tasks/:id", Handle: s.removeTask},
+		api.Route{Method: "DELETE", Path: prefix + "/tasks/:id", Handle: s.removeTask, Role: api.RoleTaskOperator, Audit: "task.remove"},
+		// swagger:route GET /templates templates getTemplates
+		//
+		// List
+		//
+		// An empty list returns if no templates exist.
+		//
+		// Produces:
+		// application/json
+		//
+		// Schemes: http, https
+		//
+		// Responses:
+		// 200: TemplatesResponse
+		// 401: UnauthResponse
+		api.Route{Method: "GET", Path: prefix + "/templates", Handle: s.getTemplates, Role: api.RoleReadOnly},
+		// swagger:route GET /templates/{name} templates getTemplate
+		//
+		// Get
+		//
+		// The template name is required.
+		//
+		// Produces:
+		// application/json
+		//
+		// Schemes: http, https
+		//
+		// Responses:
+		// 200: TemplateResponse
+		// 404: ErrorResponse
+		// 401: UnauthResponse
+		api.Route{Method: "GET", Path: prefix + "/templates/:name", Handle: s.getTemplate, Role: api.RoleReadOnly},
+		// swagger:route POST /templates templates addTemplate
+		//
+		// Add
+		//
+		// A task manifest with ${param} placeholders and the parameters it
+		// declares is required.
+		//
+		// Consumes:
+		// application/json
+		//
+		// Produces:
+		// application/json
+		//
+		// Schemes: http, https
+		//
+		// Responses:
+		// 201: TemplateResponse
+		// 400: ErrorResponse
+		// 409: ErrorResponse
+		// 401: UnauthResponse
+		api.Route{Method: "POST", Path: prefix + "/templates", Handle: s.addTemplate, Role: api.RoleTaskOperator, Audit: "template.create"},
+		// swagger:route POST /templates/{name}/instantiate templates instantiateTemplate
+		//
+		// Instantiate
+		//
+		// Creates a task from the template with the values of its parameters.
+		// The parameters not given take their default value.
+		//
+		// Consumes:
+		// application/json
+		//
+		// Produces:
+		// application/json
+		//
+		// Schemes: http, https
+		//
+		// Responses:
+		// 201: TaskResponse
+		// 400: ErrorResponse
+		// 404: ErrorResponse
+		// 401: UnauthResponse
+		api.Route{Method: "POST", Path: prefix + "/templates/:name/instantiate", Handle: s.instantiateTemplate, Role: api.RoleTaskOperator, Audit: "template.instantiate"},
+		// swagger:route DELETE /templates/{name} templates removeTemplate
+		//
+		// Remove
+		//
+		// The template name is required. The tasks created from the template
+		// are not affected.
+		//
+		// Produces:
+		// application/json
+		//
+		// Schemes: http, https
+		//
+		// Responses:
+		// 204: TemplateResponse
+		// 404: ErrorResponse
+		// 500: ErrorResponse
+		// 401: UnauthResponse
+		api.Route{Method: "DELETE", Path: prefix + "/templates/:name", Handle: s.removeTemplate, Role: api.RoleTaskOperator, Audit: "template.remove"},
 	}
 	return routes
 }
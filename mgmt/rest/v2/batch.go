@@ -0,0 +1,224 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/serror"
+	"github.com/julienschmidt/httprouter"
+)
+
+const (
+	// BatchCreate creates a task from the manifest of the operation
+	BatchCreate = "create"
+	// BatchStart starts the task with the ID of the operation
+	BatchStart = "start"
+	// BatchStop stops the task with the ID of the operation
+	BatchStop = "stop"
+	// BatchRemove removes the task with the ID of the operation
+	BatchRemove = "remove"
+
+	// BatchSucceeded is the status of an operation which succeeded
+	BatchSucceeded = "succeeded"
+	// BatchFailed is the status of an operation which failed
+	BatchFailed = "failed"
+	// BatchSkipped is the status of an operation not run as an earlier one
+	// of an atomic batch failed
+	BatchSkipped = "skipped"
+	// BatchRolledBack is the status of a task creation undone as an
+	// operation of an atomic batch failed
+	BatchRolledBack = "rolled_back"
+)
+
+// TaskBatchParams defines the operations of a batch.
+//
+// swagger:parameters runTaskBatch
+type TaskBatchParams struct {
+	// in: body
+	//
+	// required: true
+	Batch TaskBatchRequest `json:"batch"`
+}
+
+// TaskBatchRequest is a list of operations on tasks run in order. When the
+// batch is atomic, the tasks it created are removed once one of its
+// operations fails, and the operations following it are skipped.
+type TaskBatchRequest struct {
+	Atomic     bool                 `json:"atomic"`
+	Operations []TaskBatchOperation `json:"operations"`
+}
+
+// TaskBatchOperation is an action on a task, given by its manifest when it is
+// created and by its ID otherwise.
+type TaskBatchOperation struct {
+	Action string          `json:"action"`
+	ID     string          `json:"id,omitempty"`
+	Task   json.RawMessage `json:"task,omitempty"`
+}
+
+// TaskBatchResponse returns the outcome of each operation of a batch.
+//
+// swagger:response TaskBatchResponse
+type TaskBatchResp struct {
+	// in: body
+	Body TaskBatchResponse
+}
+
+type TaskBatchResponse struct {
+	Results    []TaskBatchResult `json:"results"`
+	RolledBack bool              `json:"rolled_back,omitempty"`
+}
+
+// TaskBatchResult is the outcome of an operation of a batch, in the order of
+// the request.
+type TaskBatchResult struct {
+	Action string `json:"action"`
+	ID     string `json:"id,omitempty"`
+	Name   string `json:"name,omitempty"`
+	Href   string `json:"href,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+func (s *apiV2) runTaskBatch(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var batch TaskBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+		Write(400, FromError(err), w)
+		return
+	}
+	if err := validateTaskBatch(batch); err != nil {
+		Write(400, FromError(err), w)
+		return
+	}
+
+	resp := TaskBatchResponse{Results: make([]TaskBatchResult, len(batch.Operations))}
+	// the tasks created by an atomic batch are started once all its
+	// operations succeeded, so they can be removed right away otherwise
+	var toStart []int
+	failed := false
+	for i, op := range batch.Operations {
+		res := &resp.Results[i]
+		res.Action, res.ID = op.Action, op.ID
+		if failed && batch.Atomic {
+			res.Status = BatchSkipped
+			continue
+		}
+		var err error
+		switch op.Action {
+		case BatchCreate:
+			var start bool
+			start, err = s.createBatchTask(op, batch.Atomic, res, r.Host)
+			if start {
+				toStart = append(toStart, i)
+			}
+		case BatchStart:
+			err = firstError(s.taskManager.StartTask(op.ID))
+		case BatchStop:
+			err = firstError(s.taskManager.StopTask(op.ID))
+		case BatchRemove:
+			err = s.taskManager.RemoveTask(op.ID)
+		}
+		if err != nil {
+			res.Status, res.Error = BatchFailed, err.Error()
+			failed = true
+			continue
+		}
+		res.Status = BatchSucceeded
+	}
+
+	if failed && batch.Atomic {
+		for i := range resp.Results {
+			res := &resp.Results[i]
+			if res.Action != BatchCreate || res.Status != BatchSucceeded {
+				continue
+			}
+			if err := s.taskManager.RemoveTask(res.ID); err != nil {
+				res.Error = fmt.Sprintf("rollback failed: %v", err)
+				continue
+			}
+			res.Status = BatchRolledBack
+		}
+		resp.RolledBack = true
+		Write(200, resp, w)
+		return
+	}
+	for _, i := range toStart {
+		res := &resp.Results[i]
+		if err := firstError(s.taskManager.StartTask(res.ID)); err != nil {
+			res.Error = fmt.Sprintf("task created but not started: %v", err)
+		}
+	}
+	Write(200, resp, w)
+}
+
+// createBatchTask creates the task of an operation, returning whether it
+// must be started once the batch succeeded when the batch is atomic
+func (s *apiV2) createBatchTask(op TaskBatchOperation, atomic bool, res *TaskBatchResult, host string) (bool, error) {
+	var manifest struct {
+		Start bool `json:"start"`
+	}
+	if err := json.Unmarshal(op.Task, &manifest); err != nil {
+		return false, err
+	}
+	var mode *bool
+	if atomic {
+		mode = new(bool)
+	}
+	t, err := core.CreateTaskFromContent(ioutil.NopCloser(bytes.NewReader(op.Task)), mode, s.taskManager.CreateTask)
+	if err != nil {
+		return false, err
+	}
+	res.ID, res.Name, res.Href = t.ID(), t.GetName(), taskURI(host, t)
+	return atomic && manifest.Start, nil
+}
+
+func validateTaskBatch(batch TaskBatchRequest) error {
+	if len(batch.Operations) == 0 {
+		return ErrEmptyBatch
+	}
+	for i, op := range batch.Operations {
+		switch op.Action {
+		case BatchCreate:
+			if len(op.Task) == 0 {
+				return fmt.Errorf("operation %d: a task manifest is required to create a task", i)
+			}
+		case BatchStart, BatchStop, BatchRemove:
+			if op.ID == "" {
+				return fmt.Errorf("operation %d: a task ID is required to %s a task", i, op.Action)
+			}
+		default:
+			return fmt.Errorf("operation %d: %v '%s'", i, ErrWrongAction, op.Action)
+		}
+	}
+	return nil
+}
+
+func firstError(errs []serror.SnapError) error {
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
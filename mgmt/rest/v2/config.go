@@ -45,6 +45,32 @@ type PluginConfigResponse struct {
 	Body cdata.ConfigDataNode
 }
 
+// PluginConfigSourcesItem represents a plugin config along with the source of
+// each of its items: "file" when the value was read from the config file and
+// "runtime" when it was set through the API.
+type PluginConfigSourcesItem struct {
+	Config  *cdata.ConfigDataNode `json:"config"`
+	Sources map[string]string     `json:"sources"`
+}
+
+// PluginConfigSourcesResponse represents the response of a plugin config items with their sources.
+//
+// swagger:response PluginConfigSourcesResponse
+type PluginConfigSourcesResponse struct {
+	// in: body
+	Body PluginConfigSourcesItem
+}
+
+// PluginConfigGetParams defines the query parameters for getting a config.
+//
+// swagger:parameters getPluginConfigItem
+type PluginConfigGetParams struct {
+	// Includes the source of each config item in the response.
+	//
+	// in: query
+	Sources bool `json:"sources"`
+}
+
 // PluginConfigParam defines the string representation of a config.
 //
 //swagger:parameters setPluginConfigItem
@@ -74,9 +100,14 @@ type PluginConfigDeleteParams struct {
 
 func (s *apiV2) getPluginConfigItem(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
 	var err error
+	_, sources := r.URL.Query()["sources"]
 	styp := p.ByName("type")
 	if styp == "" {
 		cfg := s.configManager.GetPluginConfigDataNodeAll()
+		if sources {
+			Write(200, &PluginConfigSourcesItem{&cfg, s.configManager.GetPluginConfigDataNodeAllSources()}, w)
+			return
+		}
 		item := &PluginConfigItem{cfg}
 		Write(200, item, w)
 		return
@@ -99,6 +130,10 @@ func (s *apiV2) getPluginConfigItem(w http.ResponseWriter, r *http.Request, p ht
 	}
 
 	cfg := s.configManager.GetPluginConfigDataNode(typ, name, iver)
+	if sources {
+		Write(200, &PluginConfigSourcesItem{&cfg, s.configManager.GetPluginConfigDataNodeSources(typ, name, iver)}, w)
+		return
+	}
 	item := &PluginConfigItem{cfg}
 	Write(200, item, w)
 }
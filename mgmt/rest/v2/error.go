@@ -31,6 +31,9 @@ const (
 	ErrPluginAlreadyLoaded     = "plugin is already loaded"
 	ErrTaskNotFound            = "task not found"
 	ErrTaskDisabledNotRunnable = "task is disabled"
+	ErrTaskMustBeStopped       = "task must be stopped"
+	ErrTemplateNotFound        = "template not found"
+	ErrTemplateAlreadyExists   = "a template with this name already exists"
 )
 
 var (
@@ -38,6 +41,8 @@ var (
 	ErrStreamingUnsupported = errors.New("streaming unsupported")
 	ErrNoActionSpecified    = errors.New("no action was specified in the request")
 	ErrWrongAction          = errors.New("wrong action requested")
+	ErrNoSelectorSpecified  = errors.New("no selector was specified in the request")
+	ErrEmptyBatch           = errors.New("the batch has no operations")
 )
 
 // ErrorResponse represents the Snap error response type.
@@ -66,6 +66,22 @@ func (MockConfigManager) DeletePluginConfigDataNodeFieldAll(fields ...string) cd
 	return *mockConfig
 }
 
+func (MockConfigManager) GetPluginConfigDataNodeSources(core.PluginType, string, int) map[string]string {
+	return mockConfigSources()
+}
+
+func (MockConfigManager) GetPluginConfigDataNodeAllSources() map[string]string {
+	return mockConfigSources()
+}
+
+func mockConfigSources() map[string]string {
+	sources := map[string]string{}
+	for k := range mockConfig.Table() {
+		sources[k] = "file"
+	}
+	return sources
+}
+
 // These constants are the expected plugin config responses from running
 // rest_v2_test.go on the plugin config routes found in mgmt/rest/server.go
 const (
@@ -78,6 +94,18 @@ const (
   "Port": 2,
   "User": "KELLY"
 }
+`
+
+	GET_PLUGIN_CONFIG_ITEM_SOURCES = `{
+  "config": {
+    "Port": 2,
+    "User": "KELLY"
+  },
+  "sources": {
+    "Port": "file",
+    "User": "file"
+  }
+}
 `
 
 	DELETE_PLUGIN_CONFIG_ITEM = `{
@@ -21,9 +21,14 @@ limitations under the License.
 package mock
 
 import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/cdata"
 	"github.com/intelsdi-x/snap/core/serror"
 	"github.com/intelsdi-x/snap/pkg/schedule"
 	"github.com/intelsdi-x/snap/scheduler/wmap"
@@ -39,7 +44,8 @@ var taskCatalog map[string]core.Task = map[string]core.Task{
 		MyHitCount:          44,
 		MyMissCount:         8,
 		MyState:             "failed",
-		MyHref:              "http://localhost:8181/v2/tasks/qwertyuiop"},
+		MyHref:              "http://localhost:8181/v2/tasks/qwertyuiop",
+		MyLabels:            map[string]string{"team": "infra", "env": "prod"}},
 	"Task2": &mockTask{
 		MyID:                "asdfghjkl",
 		MyName:              "TASK2.0",
@@ -49,7 +55,8 @@ var taskCatalog map[string]core.Task = map[string]core.Task{
 		MyHitCount:          33,
 		MyMissCount:         7,
 		MyState:             "passed",
-		MyHref:              "http://localhost:8181/v2/tasks/asdfghjkl"}}
+		MyHref:              "http://localhost:8181/v2/tasks/asdfghjkl",
+		MyLabels:            map[string]string{"team": "infra", "env": "dev"}}}
 
 type mockTask struct {
 	MyID                 string            `json:"id"`
@@ -65,6 +72,7 @@ type mockTask struct {
 	MyLastFailureMessage string            `json:"last_failure_message,omitempty"`
 	MyState              string            `json:"task_state"`
 	MyHref               string            `json:"href"`
+	MyLabels             map[string]string `json:"labels,omitempty"`
 }
 
 func (t *mockTask) ID() string                          { return t.MyID }
@@ -74,6 +82,7 @@ func (t *mockTask) GetName() string                     { return t.MyName }
 func (t *mockTask) SetName(string)                      { return }
 func (t *mockTask) SetID(string)                        { return }
 func (t *mockTask) MissedCount() uint                   { return 0 }
+func (t *mockTask) SkippedCount() uint                  { return 0 }
 func (t *mockTask) FailedCount() uint                   { return 0 }
 func (t *mockTask) LastFailureMessage() string          { return "" }
 func (t *mockTask) LastRunTime() *time.Time             { return &time.Time{} }
@@ -87,6 +96,20 @@ func (t *mockTask) MaxCollectDuration() time.Duration   { return time.Second }
 func (t *mockTask) SetMaxCollectDuration(time.Duration) {}
 func (t *mockTask) MaxMetricsBuffer() int64             { return 0 }
 func (t *mockTask) SetMaxMetricsBuffer(int64)           {}
+func (t *mockTask) PublishBuffers() []core.PublishBufferStatus {
+	return nil
+}
+func (t *mockTask) Labels() map[string]string     { return t.MyLabels }
+func (t *mockTask) SetLabels(l map[string]string) { t.MyLabels = l }
+func (t *mockTask) Dependencies() []core.TaskDependency {
+	return nil
+}
+func (t *mockTask) SetDependencies([]core.TaskDependency) {}
+func (t *mockTask) AdaptiveInterval() bool                { return false }
+func (t *mockTask) SetAdaptiveInterval(bool)              {}
+func (t *mockTask) EffectiveInterval() time.Duration      { return 0 }
+func (t *mockTask) Priority() string                      { return "" }
+func (t *mockTask) SetPriority(string)                    {}
 func (t *mockTask) Option(...core.TaskOption) core.TaskOption {
 	return core.TaskDeadlineDuration(0)
 }
@@ -129,12 +152,106 @@ func (m *MockTaskManager) CreateTask(
 		MyState:             "failed",
 		MyHref:              "http://localhost:8181/v2/tasks/MyTaskID"}, nil
 }
+func (m *MockTaskManager) UpdateTask(
+	id string,
+	sch schedule.Schedule,
+	wmap *wmap.WorkflowMap,
+	opts ...core.TaskOption) (core.Task, core.TaskErrors) {
+	return &mockTask{
+		MyID:                id,
+		MyName:              "TaskUpdated",
+		MySchedule:          &core.Schedule{},
+		MyCreationTimestamp: time.Now().Unix(),
+		MyLastRunTimestamp:  time.Now().Unix(),
+		MyHitCount:          99,
+		MyMissCount:         5,
+		MyState:             "Running",
+		MyHref:              "http://localhost:8181/v2/tasks/" + id}, nil
+}
+
+// ValidateTask resolves the metrics of the workflow with their config in the
+// workflow and rejects the publishers named "unknown"
+func (m *MockTaskManager) ValidateTask(sch schedule.Schedule, wmap *wmap.WorkflowMap) *core.TaskValidation {
+	v := &core.TaskValidation{}
+	if wmap.Collect == nil {
+		return v
+	}
+	namespaces := make([]string, 0, len(wmap.Collect.Metrics))
+	for ns := range wmap.Collect.Metrics {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+	cdt, _ := wmap.Collect.GetConfigTree()
+	for _, ns := range namespaces {
+		path := fmt.Sprintf("workflow.collect.metrics['%s']", ns)
+		cfg := cdt.Get(strings.Split(strings.TrimPrefix(ns, "/"), "/"))
+		v.Metrics = append(v.Metrics, core.NewResolvedConfig(path, ns, "", 1, cfg))
+	}
+	for i, pu := range wmap.Collect.Publish {
+		path := fmt.Sprintf("workflow.collect.publish[%d]", i)
+		if pu.PluginName == "unknown" {
+			v.AddError(path, serror.New(errors.New("Plugin not found"), map[string]interface{}{"name": pu.PluginName}))
+			continue
+		}
+		cfg, _ := pu.GetConfigNode()
+		if cfg == nil {
+			cfg = cdata.NewNode()
+		}
+		v.Plugins = append(v.Plugins, core.NewResolvedConfig(path, pu.PluginName, "publisher", pu.PluginVersion, cfg))
+	}
+	return v
+}
+
+// mockTemplate is the only template of the mock task manager
+var mockTemplate = &core.TaskTemplate{
+	Name:        "collect-mock",
+	Description: "Collects /one/two/three",
+	Parameters: []core.TemplateParameter{
+		{Name: "interval", Type: core.TemplateParamDuration, Default: "1s"},
+		{Name: "deadline", Type: core.TemplateParamDuration},
+	},
+	Task: []byte(`{"version": 1, "deadline": "${deadline}", "schedule": {"type": "simple", "interval": "${interval}"}, "workflow": {"collect": {"metrics": {"/one/two/three": {}}}}}`),
+}
+
+func (m *MockTaskManager) AddTemplate(t *core.TaskTemplate) error {
+	if err := t.Validate(); err != nil {
+		return err
+	}
+	if t.Name == mockTemplate.Name {
+		return errors.New("A template with this name already exists")
+	}
+	return nil
+}
+func (m *MockTaskManager) GetTemplates() []*core.TaskTemplate {
+	return []*core.TaskTemplate{mockTemplate}
+}
+func (m *MockTaskManager) GetTemplate(name string) (*core.TaskTemplate, error) {
+	if name != mockTemplate.Name {
+		return nil, errors.New("Template not found")
+	}
+	return mockTemplate, nil
+}
+func (m *MockTaskManager) RemoveTemplate(name string) error {
+	_, err := m.GetTemplate(name)
+	return err
+}
+
+// InstantiateTemplate instantiates the mock template, so that the values are
+// checked against its parameters
+func (m *MockTaskManager) InstantiateTemplate(name string, values map[string]interface{}, start *bool) (core.Task, error) {
+	t, err := m.GetTemplate(name)
+	if err != nil {
+		return nil, err
+	}
+	return core.CreateTaskFromTemplate(t, values, start, m.CreateTask)
+}
 func (m *MockTaskManager) GetTasks() map[string]core.Task {
 	return taskCatalog
 }
 func (m *MockTaskManager) StartTask(id string) []serror.SnapError { return nil }
 func (m *MockTaskManager) StopTask(id string) []serror.SnapError  { return nil }
 func (m *MockTaskManager) RemoveTask(id string) error             { return nil }
+func (m *MockTaskManager) ReplayTask(id string) (int, error)      { return 2, nil }
 func (m *MockTaskManager) WatchTask(id string, handler core.TaskWatcherHandler) (core.TaskWatcherCloser, error) {
 	return nil, nil
 }
@@ -176,6 +293,10 @@ const (
     {
       "id": "qwertyuiop",
       "name": "TASK1.0",
+      "labels": {
+        "env": "prod",
+        "team": "infra"
+      },
       "deadline": "4ns",
       "creation_timestamp": -62135596800,
       "last_run_timestamp": -1,
@@ -185,6 +306,10 @@ const (
     {
       "id": "asdfghjkl",
       "name": "TASK2.0",
+      "labels": {
+        "env": "dev",
+        "team": "infra"
+      },
       "deadline": "4ns",
       "creation_timestamp": -62135596800,
       "last_run_timestamp": -1,
@@ -200,6 +325,10 @@ const (
     {
       "id": "asdfghjkl",
       "name": "TASK2.0",
+      "labels": {
+        "env": "dev",
+        "team": "infra"
+      },
       "deadline": "4ns",
       "creation_timestamp": -62135596800,
       "last_run_timestamp": -1,
@@ -209,6 +338,10 @@ const (
     {
       "id": "qwertyuiop",
       "name": "TASK1.0",
+      "labels": {
+        "env": "prod",
+        "team": "infra"
+      },
       "deadline": "4ns",
       "creation_timestamp": -62135596800,
       "last_run_timestamp": -1,
@@ -217,6 +350,39 @@ const (
     }
   ]
 }
+`
+
+	GET_TASKS_SELECTOR_RESPONSE = `{
+  "tasks": [
+    {
+      "id": "qwertyuiop",
+      "name": "TASK1.0",
+      "labels": {
+        "env": "prod",
+        "team": "infra"
+      },
+      "deadline": "4ns",
+      "creation_timestamp": -62135596800,
+      "last_run_timestamp": -1,
+      "task_state": "Running",
+      "href": "http://localhost:%d/v2/tasks/qwertyuiop"
+    }
+  ]
+}
+`
+
+	STOP_TASKS_SELECTOR_RESPONSE = `{
+  "tasks": [
+    {
+      "id": "asdfghjkl",
+      "name": "TASK2.0"
+    },
+    {
+      "id": "qwertyuiop",
+      "name": "TASK1.0"
+    }
+  ]
+}
 `
 
 	GET_TASK_RESPONSE = `{
@@ -257,6 +423,35 @@ const (
   "task_state": "Running",
   "href": "http://localhost:%d/v2/tasks/MyTaskID"
 }
+`
+
+	UPDATE_TASK = `{
+  "name": "TaskUpdated",
+  "schedule": {
+    "type": "simple",
+    "interval": "2s"
+  },
+  "max-failures": 5
+}`
+
+	UPDATE_TASK_RESPONSE = `{
+  "id": "MockTask1234",
+  "name": "TaskUpdated",
+  "deadline": "4ns",
+  "workflow": {
+    "collect": {
+      "metrics": {}
+    }
+  },
+  "schedule": {
+    "type": "windowed",
+    "interval": "1s"
+  },
+  "creation_timestamp": -62135596800,
+  "last_run_timestamp": -1,
+  "task_state": "Running",
+  "href": "http://localhost:%d/v2/tasks/MockTask1234"
+}
 `
 
 	START_TASK_RESPONSE_ID_START = ``
@@ -266,4 +461,9 @@ const (
 	ENABLE_TASK_RESPONSE_ID_ENABLE = ``
 
 	REMOVE_TASK_RESPONSE_ID = ``
+
+	REPLAY_TASK_RESPONSE = `{
+  "replayed": 2
+}
+`
 )
@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"net/http"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -51,6 +52,15 @@ type TaskResp struct {
 	Task Task `json:"task"`
 }
 
+// TaskValidationResponse returns the errors of a task manifest and the config
+// its metrics and plugins resolve to.
+//
+// swagger:response TaskValidationResponse
+type TaskValidationResp struct {
+	// in: body
+	Validation core.TaskValidation `json:"validation"`
+}
+
 // TaskErrorResponse returns removing a task error.
 //
 // swagger:response TaskErrorResponse
@@ -63,15 +73,66 @@ type TasksResponse struct {
 	Tasks Tasks `json:"tasks"`
 }
 
+// TasksActionResponse returns the outcome of an action on each of the tasks
+// selected by their labels.
+//
+// swagger:response TasksActionResponse
+type TasksActionResp struct {
+	// in: body
+	Body TasksActionResponse
+}
+
+type TasksActionResponse struct {
+	Tasks []TaskActionResult `json:"tasks"`
+}
+
+// TaskActionResult is the outcome of an action on a task, which failed when
+// Error is set.
+type TaskActionResult struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Error string `json:"error,omitempty"`
+}
+
+type taskActionResults []TaskActionResult
+
+func (s taskActionResults) Len() int           { return len(s) }
+func (s taskActionResults) Less(i, j int) bool { return s[i].ID < s[j].ID }
+func (s taskActionResults) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// TaskReplayResponse returns the number of batches of metrics published again
+// when replaying the dead letters of a task.
+//
+// swagger:response TaskReplayResponse
+type TaskReplayResp struct {
+	// in: body
+	Body TaskReplayResponse
+}
+
+type TaskReplayResponse struct {
+	Replayed int `json:"replayed"`
+}
+
 // TaskParam defines the API path task id.
 //
-// swagger:parameters getTask watchTask updateTaskState removeTask
+// swagger:parameters getTask watchTask updateTaskState updateTask removeTask
 type TaskParam struct {
 	// in: path
 	// required: true
 	ID string `json:"id"`
 }
 
+// TaskSelectorParams defines the labels selecting the tasks listed or acted on.
+//
+// swagger:parameters getTasks updateTasksState removeTasks
+type TaskSelectorParams struct {
+	// Comma separated requirements on the labels of the tasks, like
+	// team=infra,env!=dev. It is required to act on several tasks.
+	//
+	// in: query
+	Selector string `json:"selector"`
+}
+
 // TaskPostParams defines task POST and PUT string representation content.
 //
 // swagger:parameters addTask
@@ -84,9 +145,32 @@ type TaskPostParams struct {
 	Task Task `json:"task"yaml:"task"`
 }
 
+// TaskDryRunParams defines whether a task is only validated.
+//
+// swagger:parameters addTask
+type TaskDryRunParams struct {
+	// Validate the task manifest against the plugins and metrics loaded
+	// without creating the task.
+	//
+	// in: query
+	DryRun bool `json:"dry_run"`
+}
+
+// TaskPatchParams defines the changes to a task.
+//
+// swagger:parameters updateTask
+type TaskPatchParams struct {
+	// Update the schedule, workflow, deadline, max-failures or name of a task
+	//
+	// in: body
+	//
+	// required: true
+	Task core.TaskUpdateRequest `json:"task"`
+}
+
 // TaskPutParams defines a task state
 //
-// swagger:parameters updateTaskState
+// swagger:parameters updateTaskState updateTasksState
 type TaskPutParams struct {
 	// Update the state of a task
 	//
@@ -98,22 +182,67 @@ type TaskPutParams struct {
 
 // Task represents Snap task definition.
 type Task struct {
-	ID                 string            `json:"id,omitempty"`
-	Name               string            `json:"name,omitempty"`
-	Version            int               `json:"version,omitempty"`
-	Deadline           string            `json:"deadline,omitempty"`
-	Workflow           *wmap.WorkflowMap `json:"workflow,omitempty"`
-	Schedule           *core.Schedule    `json:"schedule,omitempty"`
-	CreationTimestamp  int64             `json:"creation_timestamp,omitempty"`
-	LastRunTimestamp   int64             `json:"last_run_timestamp,omitempty"`
-	HitCount           int               `json:"hit_count,omitempty"`
-	MissCount          int               `json:"miss_count,omitempty"`
-	FailedCount        int               `json:"failed_count,omitempty"`
-	LastFailureMessage string            `json:"last_failure_message,omitempty"`
-	TaskState          string            `json:"task_state,omitempty"`
-	Href               string            `json:"href,omitempty"`
-	Start              bool              `json:"start,omitempty"`
-	MaxFailures        int               `json:"max-failures,omitempty"`
+	ID                 string                `json:"id,omitempty"`
+	Name               string                `json:"name,omitempty"`
+	Labels             map[string]string     `json:"labels,omitempty"`
+	Dependencies       []core.TaskDependency `json:"dependencies,omitempty"`
+	Version            int                   `json:"version,omitempty"`
+	Deadline           string                `json:"deadline,omitempty"`
+	Workflow           *wmap.WorkflowMap     `json:"workflow,omitempty"`
+	Schedule           *core.Schedule        `json:"schedule,omitempty"`
+	CreationTimestamp  int64                 `json:"creation_timestamp,omitempty"`
+	LastRunTimestamp   int64                 `json:"last_run_timestamp,omitempty"`
+	HitCount           int                   `json:"hit_count,omitempty"`
+	MissCount          int                   `json:"miss_count,omitempty"`
+	SkipCount          int                   `json:"skip_count,omitempty"`
+	FailedCount        int                   `json:"failed_count,omitempty"`
+	LastFailureMessage string                `json:"last_failure_message,omitempty"`
+	TaskState          string                `json:"task_state,omitempty"`
+	Href               string                `json:"href,omitempty"`
+	Start              bool                  `json:"start,omitempty"`
+	MaxFailures        int                   `json:"max-failures,omitempty"`
+	AdaptiveInterval   bool                  `json:"adaptive-interval,omitempty"`
+	Priority           string                `json:"priority,omitempty"`
+	// EffectiveInterval the interval the task fires on, stretched while the
+	// collection of an adaptive task cannot keep up with its schedule
+	EffectiveInterval string `json:"effective_interval,omitempty"`
+	// PublishBuffers the status of the buffers of the publishers of the task
+	PublishBuffers []core.PublishBufferStatus `json:"publish_buffers,omitempty"`
+	// Trigger the state of the trigger of a task with a triggered schedule
+	Trigger *TaskTrigger `json:"trigger,omitempty"`
+}
+
+// TaskTrigger is the state of the trigger of a task with a triggered schedule
+type TaskTrigger struct {
+	Triggered              bool     `json:"triggered"`
+	Value                  *float64 `json:"value,omitempty"`
+	Count                  uint     `json:"count"`
+	LastObservedTimestamp  int64    `json:"last_observed_timestamp,omitempty"`
+	LastTriggeredTimestamp int64    `json:"last_triggered_timestamp,omitempty"`
+	CooldownUntilTimestamp int64    `json:"cooldown_until_timestamp,omitempty"`
+}
+
+func taskTrigger(s schedule.Schedule) *TaskTrigger {
+	sch, ok := s.(*schedule.TriggeredSchedule)
+	if !ok {
+		return nil
+	}
+	ts := sch.TriggerState()
+	tr := &TaskTrigger{
+		Triggered: ts.Triggered,
+		Value:     ts.Value,
+		Count:     ts.Count,
+	}
+	if !ts.LastObservedTime.IsZero() {
+		tr.LastObservedTimestamp = ts.LastObservedTime.Unix()
+	}
+	if !ts.LastTriggeredTime.IsZero() {
+		tr.LastTriggeredTimestamp = ts.LastTriggeredTime.Unix()
+	}
+	if ts.CooldownUntil.After(time.Now()) {
+		tr.CooldownUntilTimestamp = ts.CooldownUntil.Unix()
+	}
+	return tr
 }
 
 type Tasks []Task
@@ -135,6 +264,17 @@ func (s *Task) CreationTime() time.Time {
 }
 
 func (s *apiV2) addTask(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	if q := r.URL.Query().Get("dry_run"); q != "" {
+		dryRun, err := strconv.ParseBool(q)
+		if err != nil {
+			Write(400, FromError(err), w)
+			return
+		}
+		if dryRun {
+			s.validateTask(w, r)
+			return
+		}
+	}
 	task, err := core.CreateTaskFromContent(r.Body, nil, s.taskManager.CreateTask)
 	if err != nil {
 		Write(500, FromError(err), w)
@@ -145,23 +285,114 @@ func (s *apiV2) addTask(w http.ResponseWriter, r *http.Request, _ httprouter.Par
 	Write(201, taskB, w)
 }
 
+// validateTask validates the task manifest of a request without creating the
+// task, responding 400 when the manifest is not valid
+func (s *apiV2) validateTask(w http.ResponseWriter, r *http.Request) {
+	v := core.ValidateTaskFromContent(r.Body, s.taskManager.ValidateTask)
+	if !v.Valid {
+		Write(400, v, w)
+		return
+	}
+	Write(200, v, w)
+}
+
 func (s *apiV2) getTasks(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	selector, err := core.ParseLabelSelector(r.URL.Query().Get("selector"))
+	if err != nil {
+		Write(400, FromError(err), w)
+		return
+	}
+
 	// get tasks from the task manager
 	sts := s.taskManager.GetTasks()
 
 	// create the task list response
-	tasks := make(Tasks, len(sts))
-	i := 0
+	tasks := make(Tasks, 0, len(sts))
 	for _, t := range sts {
-		tasks[i] = SchedulerTaskFromTask(t)
-		tasks[i].Href = taskURI(r.Host, t)
-		i++
+		if !selector.Matches(t.Labels()) {
+			continue
+		}
+		task := SchedulerTaskFromTask(t)
+		task.Href = taskURI(r.Host, t)
+		tasks = append(tasks, task)
 	}
 	sort.Sort(tasks)
 
 	Write(200, TasksResponse{Tasks: tasks}, w)
 }
 
+// selectTasks returns the tasks matching the selector of the request, which
+// must not be empty as it is used to act on several tasks at once
+func (s *apiV2) selectTasks(r *http.Request) ([]core.Task, error) {
+	q := r.URL.Query().Get("selector")
+	if strings.TrimSpace(q) == "" {
+		return nil, ErrNoSelectorSpecified
+	}
+	selector, err := core.ParseLabelSelector(q)
+	if err != nil {
+		return nil, err
+	}
+	var tasks []core.Task
+	for _, t := range s.taskManager.GetTasks() {
+		if selector.Matches(t.Labels()) {
+			tasks = append(tasks, t)
+		}
+	}
+	return tasks, nil
+}
+
+// updateTasksState starts or stops the tasks selected by their labels
+func (s *apiV2) updateTasksState(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	action := r.URL.Query().Get("action")
+	var fn func(string) []serror.SnapError
+	switch action {
+	case "start":
+		fn = s.taskManager.StartTask
+	case "stop":
+		fn = s.taskManager.StopTask
+	case "":
+		Write(400, FromError(ErrNoActionSpecified), w)
+		return
+	default:
+		Write(400, FromError(ErrWrongAction), w)
+		return
+	}
+	tasks, err := s.selectTasks(r)
+	if err != nil {
+		Write(400, FromError(err), w)
+		return
+	}
+	results := make(taskActionResults, 0, len(tasks))
+	for _, t := range tasks {
+		res := TaskActionResult{ID: t.ID(), Name: t.GetName()}
+		if errs := fn(t.ID()); len(errs) > 0 {
+			res.Error = errs[0].Error()
+		}
+		results = append(results, res)
+	}
+	sort.Sort(results)
+	Write(200, TasksActionResponse{Tasks: results}, w)
+}
+
+// removeTasks removes the tasks selected by their labels
+func (s *apiV2) removeTasks(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	tasks, err := s.selectTasks(r)
+	if err != nil {
+		Write(400, FromError(err), w)
+		return
+	}
+	results := make(taskActionResults, 0, len(tasks))
+	for _, t := range tasks {
+		res := TaskActionResult{ID: t.ID(), Name: t.GetName()}
+		if err := s.taskManager.RemoveTask(t.ID()); err != nil {
+			res.Error = err.Error()
+		}
+		results = append(results, res)
+	}
+	sort.Sort(results)
+	Write(200, TasksActionResponse{Tasks: results}, w)
+}
+
 func (s *apiV2) getTask(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
 	id := p.ByName("id")
 	t, err := s.taskManager.GetTask(id)
@@ -191,6 +422,9 @@ func (s *apiV2) updateTaskState(w http.ResponseWriter, r *http.Request, p httpro
 			errs = s.taskManager.StartTask(id)
 		case "stop":
 			errs = s.taskManager.StopTask(id)
+		case "replay":
+			s.replayTask(id, w)
+			return
 		default:
 			errs = append(errs, serror.New(ErrWrongAction))
 		}
@@ -214,6 +448,40 @@ func (s *apiV2) updateTaskState(w http.ResponseWriter, r *http.Request, p httpro
 	Write(204, nil, w)
 }
 
+func (s *apiV2) updateTask(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	id := p.ByName("id")
+	t, err := core.UpdateTaskFromContent(id, r.Body, s.taskManager.UpdateTask)
+	if err != nil {
+		statusCode := 500
+		switch {
+		case strings.Contains(strings.ToLower(err.Error()), ErrTaskNotFound):
+			statusCode = 404
+		case strings.Contains(strings.ToLower(err.Error()), ErrTaskMustBeStopped):
+			statusCode = 409
+		}
+		Write(statusCode, FromError(err), w)
+		return
+	}
+	task := AddSchedulerTaskFromTask(t)
+	task.Href = taskURI(r.Host, t)
+	Write(200, task, w)
+}
+
+func (s *apiV2) replayTask(id string, w http.ResponseWriter) {
+	replayed, err := s.taskManager.ReplayTask(id)
+	if err != nil {
+		statusCode := 500
+		if strings.Contains(strings.ToLower(err.Error()), ErrTaskNotFound) {
+			statusCode = 404
+		}
+		e := FromError(err)
+		e.Fields["replayed"] = fmt.Sprint(replayed)
+		Write(statusCode, e, w)
+		return
+	}
+	Write(200, TaskReplayResponse{Replayed: replayed}, w)
+}
+
 func (s *apiV2) removeTask(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
 	id := p.ByName("id")
 	err := s.taskManager.RemoveTask(id)
@@ -237,6 +505,7 @@ func AddSchedulerTaskFromTask(t core.Task) Task {
 	st := SchedulerTaskFromTask(t)
 	(&st).assertSchedule(t.Schedule())
 	st.Workflow = t.WMap()
+	st.PublishBuffers = t.PublishBuffers()
 	return st
 }
 
@@ -249,25 +518,29 @@ func SchedulerTaskFromTask(t core.Task) Task {
 		LastRunTimestamp:   t.LastRunTime().Unix(),
 		HitCount:           int(t.HitCount()),
 		MissCount:          int(t.MissedCount()),
+		SkipCount:          int(t.SkippedCount()),
 		FailedCount:        int(t.FailedCount()),
 		LastFailureMessage: t.LastFailureMessage(),
 		TaskState:          t.State().String(),
+		Labels:             t.Labels(),
+		Dependencies:       t.Dependencies(),
+		Trigger:            taskTrigger(t.Schedule()),
+		AdaptiveInterval:   t.AdaptiveInterval(),
+		Priority:           t.Priority(),
 	}
 	if st.LastRunTimestamp < 0 {
 		st.LastRunTimestamp = -1
 	}
+	if d := t.EffectiveInterval(); d > 0 {
+		st.EffectiveInterval = d.String()
+	}
 	return st
 }
 
 func (t *Task) assertSchedule(s schedule.Schedule) {
 	switch v := s.(type) {
 	case *schedule.WindowedSchedule:
-		t.Schedule = &core.Schedule{
-			Type:           "windowed",
-			Interval:       v.Interval.String(),
-			StartTimestamp: v.StartTime,
-			StopTimestamp:  v.StopTime,
-		}
+		t.Schedule = core.ScheduleFromSchedule(v)
 		return
 	case *schedule.CronSchedule:
 		t.Schedule = &core.Schedule{
@@ -275,5 +548,8 @@ func (t *Task) assertSchedule(s schedule.Schedule) {
 			Interval: v.Entry(),
 		}
 		return
+	case *schedule.TriggeredSchedule:
+		t.Schedule = core.ScheduleFromSchedule(v)
+		return
 	}
 }
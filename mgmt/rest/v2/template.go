@@ -0,0 +1,169 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/julienschmidt/httprouter"
+)
+
+// TemplatesResponse returns a list of task templates.
+//
+// swagger:response TemplatesResponse
+type TemplatesResp struct {
+	// in: body
+	Body TemplatesResponse
+}
+
+type TemplatesResponse struct {
+	Templates []Template `json:"templates"`
+}
+
+// TemplateResponse returns a task template.
+//
+// swagger:response TemplateResponse
+type TemplateResp struct {
+	// in: body
+	Template Template `json:"template"`
+}
+
+// Template represents a task template.
+type Template struct {
+	core.TaskTemplate
+	Href string `json:"href"`
+}
+
+// TemplateParam defines the API path template name.
+//
+// swagger:parameters getTemplate removeTemplate instantiateTemplate
+type TemplateParam struct {
+	// in: path
+	// required: true
+	Name string `json:"name"`
+}
+
+// TemplatePostParams defines a task template.
+//
+// swagger:parameters addTemplate
+type TemplatePostParams struct {
+	// in: body
+	//
+	// required: true
+	Template core.TaskTemplate `json:"template"`
+}
+
+// TemplateInstantiateParams defines the values a template is instantiated with.
+//
+// swagger:parameters instantiateTemplate
+type TemplateInstantiateParams struct {
+	// in: body
+	//
+	// required: true
+	Instantiate TemplateInstantiateRequest `json:"instantiate"`
+}
+
+// TemplateInstantiateRequest holds the values of the parameters of a template.
+// The task created is started according to Start, or to the start field of
+// the template when Start is not set.
+type TemplateInstantiateRequest struct {
+	Values map[string]interface{} `json:"values"`
+	Start  *bool                  `json:"start,omitempty"`
+}
+
+func (s *apiV2) getTemplates(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	templates := s.taskManager.GetTemplates()
+	resp := TemplatesResponse{Templates: make([]Template, len(templates))}
+	for i, t := range templates {
+		resp.Templates[i] = Template{TaskTemplate: *t, Href: templateURI(r.Host, t.Name)}
+	}
+	Write(200, resp, w)
+}
+
+func (s *apiV2) getTemplate(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	name := p.ByName("name")
+	t, err := s.taskManager.GetTemplate(name)
+	if err != nil {
+		Write(404, FromError(err), w)
+		return
+	}
+	Write(200, Template{TaskTemplate: *t, Href: templateURI(r.Host, t.Name)}, w)
+}
+
+func (s *apiV2) addTemplate(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	t := &core.TaskTemplate{}
+	d := json.NewDecoder(r.Body)
+	d.UseNumber()
+	if err := d.Decode(t); err != nil {
+		Write(400, FromError(err), w)
+		return
+	}
+	if err := s.taskManager.AddTemplate(t); err != nil {
+		code := 400
+		if strings.Contains(strings.ToLower(err.Error()), ErrTemplateAlreadyExists) {
+			code = 409
+		}
+		Write(code, FromError(err), w)
+		return
+	}
+	Write(201, Template{TaskTemplate: *t, Href: templateURI(r.Host, t.Name)}, w)
+}
+
+func (s *apiV2) removeTemplate(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	if err := s.taskManager.RemoveTemplate(p.ByName("name")); err != nil {
+		if strings.Contains(strings.ToLower(err.Error()), ErrTemplateNotFound) {
+			Write(404, FromError(err), w)
+			return
+		}
+		Write(500, FromError(err), w)
+		return
+	}
+	Write(204, nil, w)
+}
+
+func (s *apiV2) instantiateTemplate(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	var req TemplateInstantiateRequest
+	d := json.NewDecoder(r.Body)
+	d.UseNumber()
+	if err := d.Decode(&req); err != nil {
+		Write(400, FromError(err), w)
+		return
+	}
+	task, err := s.taskManager.InstantiateTemplate(p.ByName("name"), req.Values, req.Start)
+	if err != nil {
+		code := 400
+		if strings.Contains(strings.ToLower(err.Error()), ErrTemplateNotFound) {
+			code = 404
+		}
+		Write(code, FromError(err), w)
+		return
+	}
+	taskB := AddSchedulerTaskFromTask(task)
+	taskB.Href = taskURI(r.Host, task)
+	Write(201, taskB, w)
+}
+
+func templateURI(host, name string) string {
+	return fmt.Sprintf("%s://%s/%s/templates/%s", protocolPrefix, host, version, name)
+}
@@ -60,6 +60,7 @@ func (t *mockTask) GetName() string                           { return "" }
 func (t *mockTask) SetName(string)                            { return }
 func (t *mockTask) SetID(string)                              { return }
 func (t *mockTask) MissedCount() uint                         { return 0 }
+func (t *mockTask) SkippedCount() uint                        { return 0 }
 func (t *mockTask) FailedCount() uint                         { return 0 }
 func (t *mockTask) LastFailureMessage() string                { return "" }
 func (t *mockTask) LastRunTime() *time.Time                   { return nil }
@@ -77,6 +78,18 @@ func (t *mockTask) MaxMetricsBuffer() int64                   { return 0 }
 func (t *mockTask) SetMaxMetricsBuffer(int64)                 {}
 func (t *mockTask) MaxCollectDuration() time.Duration         { return time.Second }
 func (t *mockTask) SetMaxCollectDuration(time.Duration)       {}
+func (t *mockTask) PublishBuffers() []core.PublishBufferStatus {
+	return nil
+}
+func (t *mockTask) Labels() map[string]string             { return nil }
+func (t *mockTask) SetLabels(map[string]string)           {}
+func (t *mockTask) Dependencies() []core.TaskDependency   { return nil }
+func (t *mockTask) SetDependencies([]core.TaskDependency) {}
+func (t *mockTask) AdaptiveInterval() bool                { return false }
+func (t *mockTask) SetAdaptiveInterval(bool)              {}
+func (t *mockTask) EffectiveInterval() time.Duration      { return 0 }
+func (t *mockTask) Priority() string                      { return "" }
+func (t *mockTask) SetPriority(string)                    {}
 
 func getTestConfig() *Config {
 	cfg := GetDefaultConfig()
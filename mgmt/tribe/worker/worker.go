@@ -34,6 +34,7 @@ import (
 	"github.com/intelsdi-x/snap/core"
 	"github.com/intelsdi-x/snap/core/serror"
 	"github.com/intelsdi-x/snap/mgmt/rest/client"
+	"github.com/intelsdi-x/snap/pkg/audit"
 	"github.com/intelsdi-x/snap/pkg/schedule"
 	"github.com/intelsdi-x/snap/scheduler"
 	"github.com/intelsdi-x/snap/scheduler/wmap"
@@ -294,7 +295,9 @@ func (w worker) unloadPlugin(plugin core.Plugin) error {
 	if !w.isPluginLoaded(plugin.Name(), plugin.TypeName(), plugin.Version()) {
 		return nil
 	}
-	if _, err := w.pluginManager.Unload(plugin); err != nil {
+	_, err := w.pluginManager.Unload(plugin)
+	w.audit("plugin.unload", pluginTarget(plugin), "", err)
+	if err != nil {
 		logger.WithField("err", err).Info("failed to unload plugin")
 		return err
 	}
@@ -337,15 +340,19 @@ func (w worker) loadPlugin(plugin core.Plugin) error {
 			logger.Error(err)
 			return err
 		}
-		_, err = w.pluginManager.Load(rp)
-		if err != nil {
-			logger.Error(err)
-			return err
+		_, serr := w.pluginManager.Load(rp)
+		if serr != nil {
+			w.audit("plugin.load", pluginTarget(plugin), member.GetAddr().String(), serr)
+			logger.Error(serr)
+			return serr
 		}
 		if w.isPluginLoaded(plugin.Name(), plugin.TypeName(), plugin.Version()) {
+			w.audit("plugin.load", pluginTarget(plugin), member.GetAddr().String(), nil)
 			return nil
 		}
-		return errors.New("failed to load plugin")
+		err = errors.New("failed to load plugin")
+		w.audit("plugin.load", pluginTarget(plugin), member.GetAddr().String(), err)
+		return err
 	}
 	return errors.New("failed to find a member with the plugin")
 }
@@ -423,7 +430,9 @@ func (w worker) createTask(taskID string, startOnCreate bool) {
 			if startOnCreate {
 				if _, err := w.taskManager.GetTask(taskID); err == nil {
 					logger.Debug("starting task")
-					if errs := w.taskManager.StartTaskTribe(taskID); errs != nil {
+					errs := w.taskManager.StartTaskTribe(taskID)
+					w.audit("task.start", taskID, member.GetAddr().String(), firstError(errs))
+					if errs != nil {
 						fields := log.Fields{}
 						for idx, e := range errs {
 							fields[fmt.Sprintf("err-%d", idx)] = e.Error()
@@ -443,6 +452,7 @@ func (w worker) createTask(taskID string, startOnCreate bool) {
 				startOnCreate,
 				opt)
 			if errs != nil && len(errs.Errors()) > 0 {
+				w.audit("task.create", taskID, member.GetAddr().String(), errs.Errors()[0])
 				fields := log.Fields{}
 				for idx, e := range errs.Errors() {
 					fields[fmt.Sprintf("err-%d", idx)] = e
@@ -450,6 +460,7 @@ func (w worker) createTask(taskID string, startOnCreate bool) {
 				logger.WithFields(fields).Debug("error creating task")
 				continue
 			}
+			w.audit("task.create", taskID, member.GetAddr().String(), nil)
 			logger.Debugf("task created")
 			done = true
 			break
@@ -469,8 +480,10 @@ func (w worker) startTask(taskID string) error {
 	logger.Debug("starting task")
 	errs := w.taskManager.StartTaskTribe(taskID)
 	if errs == nil || len(errs) == 0 {
+		w.audit("task.start", taskID, "", nil)
 		return nil
 	}
+	w.audit("task.start", taskID, "", errs[0])
 	if errs != nil {
 		for _, err := range errs {
 			if err.Error() == scheduler.ErrTaskAlreadyRunning.Error() {
@@ -491,8 +504,10 @@ func (w worker) stopTask(taskID string) error {
 	})
 	errs := w.taskManager.StopTaskTribe(taskID)
 	if errs == nil || len(errs) == 0 {
+		w.audit("task.stop", taskID, "", nil)
 		return nil
 	}
+	w.audit("task.stop", taskID, "", errs[0])
 	for _, err := range errs {
 		if err.Error() == scheduler.ErrTaskAlreadyStopped.Error() {
 			logger.WithFields(err.Fields()).Info(err)
@@ -510,6 +525,7 @@ func (w worker) removeTask(taskID string) error {
 		"_block":  "remove-task",
 	})
 	err := w.taskManager.RemoveTaskTribe(taskID)
+	w.audit("task.remove", taskID, "", err)
 	if err == nil {
 		return nil
 	}
@@ -517,6 +533,32 @@ func (w worker) removeTask(taskID string) error {
 	return err
 }
 
+// audit records an operation of the worker, applying a change agreed with the
+// other members of the tribe, in the audit log. source is the address of the
+// member the plugin or task was fetched from, if any.
+func (w worker) audit(operation, target, source string, err error) {
+	outcome, msg := audit.Outcome(err)
+	audit.Record(audit.Entry{
+		Actor:     "tribe",
+		Source:    source,
+		Operation: operation,
+		Target:    target,
+		Outcome:   outcome,
+		Error:     msg,
+	})
+}
+
+func pluginTarget(plugin core.Plugin) string {
+	return fmt.Sprintf("%s:%s:%d", plugin.TypeName(), plugin.Name(), plugin.Version())
+}
+
+func firstError(errs []serror.SnapError) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs[0]
+}
+
 func shuffle(m []Member) []Member {
 	result := make([]Member, len(m))
 	perm := rand.Perm(len(m))
@@ -550,37 +592,12 @@ func getSchedule(s *core.Schedule) schedule.Schedule {
 		"schedule-type": s.Type,
 	})
 	switch s.Type {
-	case "simple", "windowed":
-		if s.Interval == "" {
-			logger.Error(core.ErrMissingScheduleInterval)
-			return nil
-		}
-		d, err := time.ParseDuration(s.Interval)
+	case "simple", "windowed", "cron", "triggered":
+		sch, err := core.MakeSchedule(*s)
 		if err != nil {
 			logger.Error(err)
 			return nil
 		}
-		sch := schedule.NewWindowedSchedule(
-			d,
-			s.StartTimestamp,
-			s.StopTimestamp,
-			s.Count,
-		)
-		if err = sch.Validate(); err != nil {
-			logger.Error(err)
-			return nil
-		}
-		return sch
-	case "cron":
-		if s.Interval == "" {
-			logger.Error(core.ErrMissingScheduleInterval)
-			return nil
-		}
-		sch := schedule.NewCronSchedule(s.Interval)
-		if err := sch.Validate(); err != nil {
-			logger.Error(err)
-			return nil
-		}
 		return sch
 	case "streaming":
 		logger.Error("streaming is not yet available for tribe")
@@ -0,0 +1,59 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package worker
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/pkg/schedule"
+)
+
+func TestGetSchedule(t *testing.T) {
+	Convey("Given the cron schedule of a task shared through tribe", t, func() {
+		s := &core.Schedule{
+			Type:     "cron",
+			Interval: "0 * * * * *",
+			Entries:  []string{"30 * * * * *"},
+			Timezone: "Europe/Paris",
+			Blackouts: []core.BlackoutWindow{
+				{Start: "0 0 2 * * *", Duration: "1h"},
+			},
+		}
+		Convey("the schedule of the tribe members keeps its entries, time zone and blackouts", func() {
+			sch, ok := getSchedule(s).(*schedule.CronSchedule)
+			So(ok, ShouldBeTrue)
+			So(sch.Entries(), ShouldResemble, []string{"0 * * * * *", "30 * * * * *"})
+			So(sch.Calendar.Location.String(), ShouldEqual, "Europe/Paris")
+			So(sch.Calendar.Blackouts, ShouldHaveLength, 1)
+			So(sch.Calendar.Blackouts[0].Start, ShouldEqual, "0 0 2 * * *")
+			So(sch.Calendar.Blackouts[0].Duration, ShouldEqual, time.Hour)
+		})
+		Convey("an invalid blackout is refused", func() {
+			s.Blackouts[0].Duration = "soon"
+			So(getSchedule(s), ShouldBeNil)
+		})
+	})
+}
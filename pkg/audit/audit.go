@@ -0,0 +1,308 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package audit records the operations changing the state of snapteld, like
+// loading a plugin or creating a task, in an append-only log of JSON lines.
+// The log is rotated when it reaches its maximum size, keeping a number of
+// backups named after it with the suffixes .1 (the most recent) to .N.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// OutcomeSuccess is the outcome of an operation which succeeded
+	OutcomeSuccess = "success"
+	// OutcomeFailure is the outcome of an operation which failed
+	OutcomeFailure = "failure"
+)
+
+var (
+	// ErrNotEnabled - The error message for querying the audit log when it is not enabled
+	ErrNotEnabled = errors.New("Audit log is not enabled")
+	// ErrClosed - The error message for writing to an audit log which is closed
+	ErrClosed = errors.New("Audit log is closed")
+
+	auditLogger = log.WithField("_module", "audit")
+
+	mutex sync.RWMutex
+	std   *Logger
+)
+
+// Entry is an operation recorded in the audit log
+type Entry struct {
+	Time time.Time `json:"time"`
+	// Actor is who made the call: the name of an API token, "password" for the
+	// REST API password, "anonymous" when authentication is disabled, "tribe"
+	// or "signal"
+	Actor string `json:"actor"`
+	Role  string `json:"role,omitempty"`
+	// Source is the address the call came from
+	Source string `json:"source,omitempty"`
+	// Operation is what was done, like task.create or plugin.load
+	Operation string `json:"operation"`
+	// Target identifies the task, plugin, agreement or setting changed
+	Target string `json:"target,omitempty"`
+	// PayloadHash is the SHA-256 of the payload of the request
+	PayloadHash string `json:"payload_hash,omitempty"`
+	Outcome     string `json:"outcome"`
+	Status      int    `json:"status,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// Query selects entries of the audit log. Empty fields match every entry.
+type Query struct {
+	Since time.Time
+	Until time.Time
+	// Operation matches an operation or, when it ends with a dot, the
+	// operations starting with it, like "task."
+	Operation string
+	Actor     string
+	Target    string
+	// Limit keeps the given number of most recent entries
+	Limit int
+}
+
+func (q Query) matches(e Entry) bool {
+	if !q.Since.IsZero() && e.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && e.Time.After(q.Until) {
+		return false
+	}
+	if q.Operation != "" {
+		if strings.HasSuffix(q.Operation, ".") {
+			if !strings.HasPrefix(e.Operation, q.Operation) {
+				return false
+			}
+		} else if e.Operation != q.Operation {
+			return false
+		}
+	}
+	if q.Actor != "" && e.Actor != q.Actor {
+		return false
+	}
+	if q.Target != "" && e.Target != q.Target {
+		return false
+	}
+	return true
+}
+
+// Logger appends entries to an audit log file
+type Logger struct {
+	sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+// New opens the audit log at path, which is rotated when it grows beyond
+// maxSize bytes. maxBackups rotated files are kept. A maxSize of 0 disables
+// the rotation.
+func New(path string, maxSize int64, maxBackups int) (*Logger, error) {
+	l := &Logger{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *Logger) open() error {
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	l.file, l.size = f, fi.Size()
+	return nil
+}
+
+// Write appends an entry to the log, rotating it first when needed. Entries
+// without a time are recorded at the current time.
+func (l *Logger) Write(e Entry) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	l.Lock()
+	defer l.Unlock()
+	if l.file == nil {
+		return ErrClosed
+	}
+	if l.maxSize > 0 && l.size > 0 && l.size+int64(len(b)) > l.maxSize {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := l.file.Write(b)
+	l.size += int64(n)
+	return err
+}
+
+// rotate renames the log to .1 after shifting the backups, dropping the oldest
+func (l *Logger) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+	l.file = nil
+	if l.maxBackups > 0 {
+		os.Remove(l.backup(l.maxBackups))
+		for i := l.maxBackups - 1; i > 0; i-- {
+			if err := os.Rename(l.backup(i), l.backup(i+1)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		if err := os.Rename(l.path, l.backup(1)); err != nil {
+			return err
+		}
+	} else if err := os.Remove(l.path); err != nil {
+		return err
+	}
+	return l.open()
+}
+
+func (l *Logger) backup(i int) string {
+	return fmt.Sprintf("%s.%d", l.path, i)
+}
+
+// Query returns the entries of the log and of its backups matching q, the
+// oldest first
+func (l *Logger) Query(q Query) ([]Entry, error) {
+	l.Lock()
+	defer l.Unlock()
+	entries := []Entry{}
+	files := []string{}
+	for i := l.maxBackups; i > 0; i-- {
+		files = append(files, l.backup(i))
+	}
+	files = append(files, l.path)
+	for _, path := range files {
+		f, err := os.Open(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			var e Entry
+			if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+				continue
+			}
+			if q.matches(e) {
+				entries = append(entries, e)
+			}
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if q.Limit > 0 && len(entries) > q.Limit {
+		entries = entries[len(entries)-q.Limit:]
+	}
+	return entries, nil
+}
+
+// Close closes the log
+func (l *Logger) Close() error {
+	l.Lock()
+	defer l.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// SetLogger sets the log the entries of snapteld are recorded to. A nil
+// logger disables the audit log.
+func SetLogger(l *Logger) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	std = l
+}
+
+// Enabled returns true when the entries are recorded
+func Enabled() bool {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return std != nil
+}
+
+// Record appends an entry to the audit log of snapteld, if it is enabled.
+// Errors are logged since they should not fail the operation.
+func Record(e Entry) {
+	mutex.RLock()
+	l := std
+	mutex.RUnlock()
+	if l == nil {
+		return
+	}
+	if err := l.Write(e); err != nil {
+		auditLogger.WithFields(log.Fields{
+			"_block":    "record",
+			"operation": e.Operation,
+			"error":     err,
+		}).Error("error writing to the audit log")
+	}
+}
+
+// Search returns the entries of the audit log of snapteld matching q
+func Search(q Query) ([]Entry, error) {
+	mutex.RLock()
+	l := std
+	mutex.RUnlock()
+	if l == nil {
+		return nil, ErrNotEnabled
+	}
+	return l.Query(q)
+}
+
+// Outcome returns the outcome of an operation which returned err
+func Outcome(err error) (string, string) {
+	if err != nil {
+		return OutcomeFailure, err.Error()
+	}
+	return OutcomeSuccess, ""
+}
@@ -0,0 +1,125 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAuditLog(t *testing.T) {
+	Convey("Given an audit log", t, func() {
+		dir, err := ioutil.TempDir("", "snap-audit")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "audit.log")
+		l, err := New(path, 0, 0)
+		So(err, ShouldBeNil)
+		defer l.Close()
+
+		start := time.Now()
+		So(l.Write(Entry{Time: start, Actor: "ops", Operation: "task.create", Target: "t1", Outcome: OutcomeSuccess}), ShouldBeNil)
+		So(l.Write(Entry{Time: start.Add(time.Second), Actor: "deployer", Operation: "plugin.load", Outcome: OutcomeSuccess}), ShouldBeNil)
+		So(l.Write(Entry{Time: start.Add(2 * time.Second), Actor: "ops", Operation: "task.remove", Target: "t1", Outcome: OutcomeFailure, Error: "Not Found"}), ShouldBeNil)
+
+		Convey("entries are appended as JSON lines", func() {
+			b, err := ioutil.ReadFile(path)
+			So(err, ShouldBeNil)
+			So(string(b), ShouldContainSubstring, `"operation":"plugin.load"`)
+		})
+		Convey("entries can be queried", func() {
+			entries, err := l.Query(Query{})
+			So(err, ShouldBeNil)
+			So(entries, ShouldHaveLength, 3)
+			So(entries[0].Operation, ShouldEqual, "task.create")
+
+			entries, err = l.Query(Query{Operation: "task."})
+			So(err, ShouldBeNil)
+			So(entries, ShouldHaveLength, 2)
+
+			entries, err = l.Query(Query{Actor: "ops", Since: start.Add(time.Second)})
+			So(err, ShouldBeNil)
+			So(entries, ShouldHaveLength, 1)
+			So(entries[0].Error, ShouldEqual, "Not Found")
+
+			entries, err = l.Query(Query{Limit: 1})
+			So(err, ShouldBeNil)
+			So(entries, ShouldHaveLength, 1)
+			So(entries[0].Operation, ShouldEqual, "task.remove")
+		})
+		Convey("the log is reopened in append mode", func() {
+			So(l.Close(), ShouldBeNil)
+			l, err = New(path, 0, 0)
+			So(err, ShouldBeNil)
+			So(l.Write(Entry{Operation: "task.start"}), ShouldBeNil)
+			entries, err := l.Query(Query{})
+			So(err, ShouldBeNil)
+			So(entries, ShouldHaveLength, 4)
+		})
+	})
+
+	Convey("Given an audit log rotated at a small size", t, func() {
+		dir, err := ioutil.TempDir("", "snap-audit")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "audit.log")
+		l, err := New(path, 200, 2)
+		So(err, ShouldBeNil)
+		defer l.Close()
+
+		for i := 0; i < 10; i++ {
+			So(l.Write(Entry{Actor: "ops", Operation: "task.create", Target: fmt.Sprintf("task-%d", i), Outcome: OutcomeSuccess}), ShouldBeNil)
+		}
+		Convey("only the given number of backups is kept", func() {
+			_, err := os.Stat(path + ".1")
+			So(err, ShouldBeNil)
+			_, err = os.Stat(path + ".2")
+			So(err, ShouldBeNil)
+			_, err = os.Stat(path + ".3")
+			So(os.IsNotExist(err), ShouldBeTrue)
+		})
+		Convey("the most recent entries are queried across the backups", func() {
+			entries, err := l.Query(Query{})
+			So(err, ShouldBeNil)
+			So(len(entries), ShouldBeLessThan, 10)
+			So(entries[len(entries)-1].Target, ShouldEqual, "task-9")
+			for i := 1; i < len(entries); i++ {
+				So(entries[i].Time.Before(entries[i-1].Time), ShouldBeFalse)
+			}
+		})
+	})
+
+	Convey("Given no audit log", t, func() {
+		SetLogger(nil)
+		Convey("entries are not recorded", func() {
+			Record(Entry{Operation: "task.create"})
+			_, err := Search(Query{})
+			So(err, ShouldEqual, ErrNotEnabled)
+		})
+	})
+}
@@ -0,0 +1,326 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metricfilter implements the filter expressions which select the
+// metrics flowing into a node of a task workflow.
+//
+// An expression compares a field of a metric with a literal:
+//
+//	namespace == "/intel/procfs/cpu/*/user_percentage"
+//	tags.plugin_running_on =~ "^web-[0-9]+$"
+//	value >= 90
+//
+// and comparisons are combined with and, or, not and parentheses.
+package metricfilter
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/intelsdi-x/snap/core"
+)
+
+// Filter is a parsed filter expression
+type Filter struct {
+	expr string
+	root node
+}
+
+// Parse parses a filter expression
+func Parse(expr string) (*Filter, error) {
+	p := &parser{lexer: newLexer(expr)}
+	if err := p.next(); err != nil {
+		return nil, p.wrap(err)
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, p.wrap(err)
+	}
+	if p.tok.kind != tokEOF {
+		return nil, p.wrap(fmt.Errorf("unexpected '%s'", p.tok.text))
+	}
+	return &Filter{expr: expr, root: root}, nil
+}
+
+// Match returns true if the metric matches the filter
+func (f *Filter) Match(m core.Metric) bool {
+	return f.root.eval(m)
+}
+
+// Apply returns the metrics which match the filter, in order
+func (f *Filter) Apply(mts []core.Metric) []core.Metric {
+	matched := make([]core.Metric, 0, len(mts))
+	for _, m := range mts {
+		if f.root.eval(m) {
+			matched = append(matched, m)
+		}
+	}
+	return matched
+}
+
+// String returns the expression of the filter
+func (f *Filter) String() string {
+	return f.expr
+}
+
+type node interface {
+	eval(core.Metric) bool
+}
+
+type andNode struct{ left, right node }
+
+func (n *andNode) eval(m core.Metric) bool { return n.left.eval(m) && n.right.eval(m) }
+
+type orNode struct{ left, right node }
+
+func (n *orNode) eval(m core.Metric) bool { return n.left.eval(m) || n.right.eval(m) }
+
+type notNode struct{ expr node }
+
+func (n *notNode) eval(m core.Metric) bool { return !n.expr.eval(m) }
+
+// Comparison operators
+const (
+	opEq       = "=="
+	opNe       = "!="
+	opMatch    = "=~"
+	opNotMatch = "!~"
+	opLt       = "<"
+	opLe       = "<="
+	opGt       = ">"
+	opGe       = ">="
+)
+
+// namespaceNode compares the namespace of a metric with a glob or a regular expression
+type namespaceNode struct {
+	op   string
+	glob []string
+	re   *regexp.Regexp
+}
+
+func newNamespaceNode(op string, lit token) (node, error) {
+	if lit.kind != tokString {
+		return nil, fmt.Errorf("namespace must be compared with a string")
+	}
+	n := &namespaceNode{op: op}
+	switch op {
+	case opEq, opNe:
+		n.glob = strings.Split(strings.TrimPrefix(lit.text, "/"), "/")
+		for _, e := range n.glob {
+			if _, err := path.Match(e, ""); err != nil {
+				return nil, fmt.Errorf("invalid namespace glob \"%s\"", lit.text)
+			}
+		}
+	case opMatch, opNotMatch:
+		re, err := regexp.Compile(lit.text)
+		if err != nil {
+			return nil, err
+		}
+		n.re = re
+	default:
+		return nil, fmt.Errorf("namespace cannot be compared with '%s'", op)
+	}
+	return n, nil
+}
+
+func (n *namespaceNode) eval(m core.Metric) bool {
+	ns := m.Namespace().Strings()
+	switch n.op {
+	case opEq:
+		return matchGlob(n.glob, ns)
+	case opNe:
+		return !matchGlob(n.glob, ns)
+	case opMatch:
+		return n.re.MatchString("/" + strings.Join(ns, "/"))
+	default:
+		return !n.re.MatchString("/" + strings.Join(ns, "/"))
+	}
+}
+
+// matchGlob matches namespace elements against glob elements. Each glob
+// element matches one namespace element, except "**" which matches any number of them.
+func matchGlob(glob, ns []string) bool {
+	for i, g := range glob {
+		if g == "**" {
+			for j := i; j <= len(ns); j++ {
+				if matchGlob(glob[i+1:], ns[j:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if i >= len(ns) {
+			return false
+		}
+		if ok, _ := path.Match(g, ns[i]); !ok {
+			return false
+		}
+	}
+	return len(glob) == len(ns)
+}
+
+// stringNode compares a string field of a metric, a tag or its unit
+type stringNode struct {
+	op    string
+	field func(core.Metric) (string, bool)
+	value string
+	re    *regexp.Regexp
+}
+
+func newStringNode(name, op string, lit token, field func(core.Metric) (string, bool)) (node, error) {
+	if lit.kind != tokString {
+		return nil, fmt.Errorf("%s must be compared with a string", name)
+	}
+	n := &stringNode{op: op, field: field, value: lit.text}
+	switch op {
+	case opEq, opNe:
+	case opMatch, opNotMatch:
+		re, err := regexp.Compile(lit.text)
+		if err != nil {
+			return nil, err
+		}
+		n.re = re
+	default:
+		return nil, fmt.Errorf("%s cannot be compared with '%s'", name, op)
+	}
+	return n, nil
+}
+
+func (n *stringNode) eval(m core.Metric) bool {
+	v, ok := n.field(m)
+	switch n.op {
+	case opEq:
+		return ok && v == n.value
+	case opNe:
+		return !ok || v != n.value
+	case opMatch:
+		return ok && n.re.MatchString(v)
+	default:
+		return !ok || !n.re.MatchString(v)
+	}
+}
+
+// valueNode compares the data of a metric with a number, or with a string
+// when the data is not numeric
+type valueNode struct {
+	op     string
+	number float64
+	str    *stringNode
+}
+
+func newValueNode(op string, lit token) (node, error) {
+	if lit.kind == tokString {
+		str, err := newStringNode("value", op, lit, func(m core.Metric) (string, bool) {
+			if m.Data() == nil {
+				return "", false
+			}
+			return fmt.Sprintf("%v", m.Data()), true
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &valueNode{op: op, str: str.(*stringNode)}, nil
+	}
+	switch op {
+	case opEq, opNe, opLt, opLe, opGt, opGe:
+	default:
+		return nil, fmt.Errorf("value cannot be compared with a number using '%s'", op)
+	}
+	return &valueNode{op: op, number: lit.number}, nil
+}
+
+func (n *valueNode) eval(m core.Metric) bool {
+	if n.str != nil {
+		return n.str.eval(m)
+	}
+	v, ok := ToFloat(m.Data())
+	if !ok {
+		// data which is not a number only differs from a number
+		return n.op == opNe
+	}
+	switch n.op {
+	case opEq:
+		return v == n.number
+	case opNe:
+		return v != n.number
+	case opLt:
+		return v < n.number
+	case opLe:
+		return v <= n.number
+	case opGt:
+		return v > n.number
+	default:
+		return v >= n.number
+	}
+}
+
+// ToFloat returns the value of numeric metric data as a float64, and false
+// if the data is not a number
+func ToFloat(data interface{}) (float64, bool) {
+	switch v := data.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// newComparison returns the node comparing the given field with a literal
+func newComparison(field, op string, lit token) (node, error) {
+	switch {
+	case field == "namespace":
+		return newNamespaceNode(op, lit)
+	case field == "value":
+		return newValueNode(op, lit)
+	case field == "unit":
+		return newStringNode(field, op, lit, func(m core.Metric) (string, bool) {
+			return m.Unit(), true
+		})
+	case strings.HasPrefix(field, "tags.") && len(field) > len("tags."):
+		key := strings.TrimPrefix(field, "tags.")
+		return newStringNode(field, op, lit, func(m core.Metric) (string, bool) {
+			v, ok := m.Tags()[key]
+			return v, ok
+		})
+	}
+	return nil, fmt.Errorf("unknown field '%s'", field)
+}
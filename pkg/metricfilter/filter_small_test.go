@@ -0,0 +1,133 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metricfilter
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/intelsdi-x/snap/control/plugin"
+	"github.com/intelsdi-x/snap/core"
+)
+
+func newMetric(ns string, data interface{}, tags map[string]string) core.Metric {
+	return plugin.MetricType{
+		Namespace_: core.NewNamespace(strings.Split(strings.TrimPrefix(ns, "/"), "/")...),
+		Data_:      data,
+		Tags_:      tags,
+	}
+}
+
+func match(expr string, m core.Metric) bool {
+	f, err := Parse(expr)
+	So(err, ShouldBeNil)
+	return f.Match(m)
+}
+
+func TestFilterMatch(t *testing.T) {
+	cpu := newMetric("/intel/procfs/cpu/0/user_percentage", 92.5, map[string]string{"host": "web-01"})
+	disk := newMetric("/intel/procfs/disk/sda/octets_read", 1024, map[string]string{"host": "db-01"})
+	state := newMetric("/intel/docker/abc/state", "running", nil)
+
+	Convey("Namespace globs", t, func() {
+		So(match(`namespace == "/intel/procfs/cpu/*/user_percentage"`, cpu), ShouldBeTrue)
+		So(match(`namespace == "/intel/procfs/cpu/*"`, cpu), ShouldBeFalse)
+		So(match(`namespace == "/intel/procfs/**"`, cpu), ShouldBeTrue)
+		So(match(`namespace == "/intel/**/octets_*"`, disk), ShouldBeTrue)
+		So(match(`namespace != "/intel/procfs/cpu/**"`, disk), ShouldBeTrue)
+	})
+	Convey("Namespace regular expressions", t, func() {
+		So(match(`namespace =~ "^/intel/procfs/(cpu|disk)/"`, cpu), ShouldBeTrue)
+		So(match(`namespace !~ "cpu"`, disk), ShouldBeTrue)
+	})
+	Convey("Tags", t, func() {
+		So(match(`tags.host == "web-01"`, cpu), ShouldBeTrue)
+		So(match(`tags.host =~ "^db-"`, cpu), ShouldBeFalse)
+		So(match(`tags.host != "web-01"`, disk), ShouldBeTrue)
+		Convey("a missing tag is never equal to a value", func() {
+			So(match(`tags.rack == ""`, cpu), ShouldBeFalse)
+			So(match(`tags.rack != "r1"`, cpu), ShouldBeTrue)
+			So(match(`tags.host == "web-01"`, state), ShouldBeFalse)
+		})
+	})
+	Convey("Values", t, func() {
+		So(match(`value > 90`, cpu), ShouldBeTrue)
+		So(match(`value <= 90`, cpu), ShouldBeFalse)
+		So(match(`value == 1024`, disk), ShouldBeTrue)
+		So(match(`value >= 1e3`, disk), ShouldBeTrue)
+		So(match(`value == "running"`, state), ShouldBeTrue)
+		Convey("data which is not a number does not compare with numbers", func() {
+			So(match(`value > 0`, state), ShouldBeFalse)
+			So(match(`value != 0`, state), ShouldBeTrue)
+		})
+	})
+	Convey("Boolean operators", t, func() {
+		expr := `namespace == "/intel/procfs/**" and (tags.host =~ "^web" or value > 1000)`
+		So(match(expr, cpu), ShouldBeTrue)
+		So(match(expr, disk), ShouldBeTrue)
+		So(match(expr, state), ShouldBeFalse)
+		So(match(`not tags.host == "web-01"`, cpu), ShouldBeFalse)
+		So(match(`!(value < 0) && value < 100 || value > 1000`, disk), ShouldBeTrue)
+	})
+	Convey("Apply keeps the matching metrics in order", t, func() {
+		f, err := Parse(`namespace == "/intel/procfs/**"`)
+		So(err, ShouldBeNil)
+		So(f.Apply([]core.Metric{state, disk, cpu}), ShouldResemble, []core.Metric{disk, cpu})
+		So(f.String(), ShouldEqual, `namespace == "/intel/procfs/**"`)
+	})
+}
+
+func TestFilterParse(t *testing.T) {
+	Convey("Invalid expressions are rejected", t, func() {
+		for _, expr := range []string{
+			``,
+			`namespace`,
+			`namespace ==`,
+			`namespace == 1`,
+			`namespace > "/intel"`,
+			`tags.host < "a"`,
+			`value =~ 1`,
+			`host == "a"`,
+			`tags. == "a"`,
+			`value > "a"`,
+			`tags.host == "a" and`,
+			`(value > 1`,
+			`value > 1)`,
+			`tags.host == "a`,
+			`tags.host =~ "("`,
+			`namespace == "/intel/[cpu"`,
+			`value $ 1`,
+		} {
+			_, err := Parse(expr)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldStartWith, "Invalid filter")
+		}
+	})
+	Convey("Strings can be quoted with single quotes and escaped", t, func() {
+		m := newMetric("/intel/mock/foo", `say "hi"`, map[string]string{"name": "it's"})
+		So(match(`tags.name == 'it\'s'`, m), ShouldBeTrue)
+		So(match(`value == "say \"hi\""`, m), ShouldBeTrue)
+		So(match(`value =~ "\w+ \"hi\""`, m), ShouldBeTrue)
+	})
+}
@@ -0,0 +1,289 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metricfilter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokOp
+	tokAnd
+	tokOr
+	tokNot
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind   tokenKind
+	text   string
+	number float64
+	pos    int
+}
+
+type lexer struct {
+	input string
+	pos   int
+}
+
+func newLexer(input string) *lexer {
+	return &lexer{input: input}
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || unicode.IsLetter(rune(c))
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || unicode.IsDigit(rune(c)) || c == '.' || c == '-'
+}
+
+// next returns the next token of the input
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.input) && unicode.IsSpace(rune(l.input[l.pos])) {
+		l.pos++
+	}
+	start := l.pos
+	if l.pos >= len(l.input) {
+		return token{kind: tokEOF, pos: start}, nil
+	}
+	c := l.input[l.pos]
+	switch {
+	case c == '(':
+		l.pos++
+		return token{kind: tokLParen, text: "(", pos: start}, nil
+	case c == ')':
+		l.pos++
+		return token{kind: tokRParen, text: ")", pos: start}, nil
+	case c == '"' || c == '\'':
+		return l.string(c)
+	case c == '-' || c == '+' || c == '.' || unicode.IsDigit(rune(c)):
+		return l.number()
+	case isIdentStart(c):
+		for l.pos < len(l.input) && isIdentChar(l.input[l.pos]) {
+			l.pos++
+		}
+		text := l.input[start:l.pos]
+		switch strings.ToLower(text) {
+		case "and":
+			return token{kind: tokAnd, text: text, pos: start}, nil
+		case "or":
+			return token{kind: tokOr, text: text, pos: start}, nil
+		case "not":
+			return token{kind: tokNot, text: text, pos: start}, nil
+		}
+		return token{kind: tokIdent, text: text, pos: start}, nil
+	}
+	for _, op := range []string{"&&", "||", opEq, opNe, opMatch, opNotMatch, opLe, opGe, opLt, opGt} {
+		if strings.HasPrefix(l.input[l.pos:], op) {
+			l.pos += len(op)
+			switch op {
+			case "&&":
+				return token{kind: tokAnd, text: op, pos: start}, nil
+			case "||":
+				return token{kind: tokOr, text: op, pos: start}, nil
+			}
+			return token{kind: tokOp, text: op, pos: start}, nil
+		}
+	}
+	if c == '!' {
+		l.pos++
+		return token{kind: tokNot, text: "!", pos: start}, nil
+	}
+	return token{}, fmt.Errorf("unexpected character '%c' at position %d", c, start)
+}
+
+func (l *lexer) string(quote byte) (token, error) {
+	start := l.pos
+	l.pos++
+	var buf []byte
+	for l.pos < len(l.input) {
+		c := l.input[l.pos]
+		switch c {
+		case quote:
+			l.pos++
+			return token{kind: tokString, text: string(buf), pos: start}, nil
+		case '\\':
+			// only the quote and the backslash are escaped so that regular
+			// expressions can be written as they are
+			if l.pos+1 < len(l.input) && (l.input[l.pos+1] == quote || l.input[l.pos+1] == '\\') {
+				l.pos++
+				c = l.input[l.pos]
+			}
+		}
+		buf = append(buf, c)
+		l.pos++
+	}
+	return token{}, fmt.Errorf("unterminated string at position %d", start)
+}
+
+func (l *lexer) number() (token, error) {
+	start := l.pos
+	l.pos++
+	for l.pos < len(l.input) {
+		c := l.input[l.pos]
+		if !unicode.IsDigit(rune(c)) && c != '.' && c != 'e' && c != 'E' &&
+			!((c == '-' || c == '+') && (l.input[l.pos-1] == 'e' || l.input[l.pos-1] == 'E')) {
+			break
+		}
+		l.pos++
+	}
+	text := l.input[start:l.pos]
+	n, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return token{}, fmt.Errorf("invalid number '%s' at position %d", text, start)
+	}
+	return token{kind: tokNumber, text: text, number: n, pos: start}, nil
+}
+
+// parser is a recursive descent parser of the grammar:
+//
+//	or         = and { ("or" | "||") and }
+//	and        = unary { ("and" | "&&") unary }
+//	unary      = ("not" | "!") unary | "(" or ")" | comparison
+//	comparison = field operator (string | number)
+type parser struct {
+	*lexer
+	tok token
+}
+
+func (p *parser) next() error {
+	tok, err := p.lexer.next()
+	if err != nil {
+		return err
+	}
+	p.tok = tok
+	return nil
+}
+
+func (p *parser) wrap(err error) error {
+	return fmt.Errorf("Invalid filter '%s': %v", p.input, err)
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok.kind == tokOr {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok.kind == tokAnd {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &andNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	switch p.tok.kind {
+	case tokNot:
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{expr: expr}, nil
+	case tokLParen:
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.kind != tokRParen {
+			return nil, p.unexpected("')'")
+		}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		return expr, nil
+	case tokIdent:
+		return p.parseComparison()
+	}
+	return nil, p.unexpected("a comparison")
+}
+
+func (p *parser) parseComparison() (node, error) {
+	field := p.tok
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokOp {
+		return nil, p.unexpected("an operator")
+	}
+	op := p.tok
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokString && p.tok.kind != tokNumber {
+		return nil, p.unexpected("a string or a number")
+	}
+	lit := p.tok
+	n, err := newComparison(field.text, op.text, lit)
+	if err != nil {
+		return nil, fmt.Errorf("%v at position %d", err, field.pos)
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+func (p *parser) unexpected(expected string) error {
+	if p.tok.kind == tokEOF {
+		return fmt.Errorf("expected %s at end of filter", expected)
+	}
+	return fmt.Errorf("expected %s at position %d, found '%s'", expected, p.tok.pos, p.tok.text)
+}
@@ -21,11 +21,14 @@ package rpcutil
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+
+	"github.com/intelsdi-x/snap/pkg/telemetry"
 )
 
 // grpcDialDefaultTimeout is the default timeout for initial gRPC dial
@@ -41,6 +44,7 @@ func GetClientConnection(ctx context.Context, addr string, port int) (*grpc.Clie
 func GetClientConnectionWithCreds(ctx context.Context, addr string, port int, creds credentials.TransportCredentials) (*grpc.ClientConn, error) {
 	grpcDialOpts := []grpc.DialOption{
 		grpc.WithTimeout(grpcDialDefaultTimeout),
+		grpc.WithUnaryInterceptor(observeCall),
 	}
 	if creds != nil {
 		grpcDialOpts = append(grpcDialOpts, grpc.WithTransportCredentials(creds))
@@ -54,3 +58,16 @@ func GetClientConnectionWithCreds(ctx context.Context, addr string, port int, cr
 	}
 	return conn, nil
 }
+
+// observeCall records the duration of the gRPC calls made by snapteld, which
+// are named by their method, like rpc.Collector.CollectMetrics
+func observeCall(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	name := strings.Replace(strings.TrimPrefix(method, "/"), "/", ".", -1)
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	telemetry.Observe(time.Since(start), "grpc", name)
+	if err != nil {
+		telemetry.Add(1, "grpc", name, "errors")
+	}
+	return err
+}
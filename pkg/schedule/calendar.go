@@ -0,0 +1,108 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron"
+)
+
+// ErrInvalidBlackout - The error message for a blackout window which is neither recurring nor bounded by two times
+var ErrInvalidBlackout = errors.New("Blackout window must have either a start cron entry and a positive duration, or a start and a stop time")
+
+// Blackout is a window of time during which a schedule does not fire. It is
+// either recurring, starting on each firing of a cron entry for a duration, or
+// a single window between a start and a stop time.
+type Blackout struct {
+	Start     string
+	Duration  time.Duration
+	StartTime *time.Time
+	StopTime  *time.Time
+}
+
+// Calendar holds the location in which a schedule is evaluated, which
+// defaults to the local time, and the blackout windows in which its firings
+// are skipped.
+type Calendar struct {
+	Location  *time.Location
+	Blackouts []Blackout
+	// starts are the parsed start entries of the recurring blackouts
+	starts []cron.Schedule
+}
+
+// location returns the location the schedule is evaluated in
+func (c *Calendar) location() *time.Location {
+	if c.Location == nil {
+		return time.Local
+	}
+	return c.Location
+}
+
+// validate checks and parses the blackout windows
+func (c *Calendar) validate() error {
+	starts := make([]cron.Schedule, len(c.Blackouts))
+	for i, b := range c.Blackouts {
+		switch {
+		case b.Start != "" && b.Duration > 0 && b.StartTime == nil && b.StopTime == nil:
+			s, err := cron.Parse(b.Start)
+			if err != nil {
+				return fmt.Errorf("Invalid start of blackout window '%s': %v", b.Start, err)
+			}
+			starts[i] = s
+		case b.Start == "" && b.StartTime != nil && b.StopTime != nil:
+			if b.StopTime.Before(*b.StartTime) {
+				return ErrStopBeforeStart
+			}
+		default:
+			return ErrInvalidBlackout
+		}
+	}
+	c.starts = starts
+	return nil
+}
+
+// blackedOut returns whether t is within a blackout window
+func (c *Calendar) blackedOut(t time.Time) bool {
+	if len(c.Blackouts) == 0 {
+		return false
+	}
+	if len(c.starts) != len(c.Blackouts) && c.validate() != nil {
+		return false
+	}
+	t = t.In(c.location())
+	for i, b := range c.Blackouts {
+		if c.starts[i] == nil {
+			if !t.Before(*b.StartTime) && t.Before(*b.StopTime) {
+				return true
+			}
+			continue
+		}
+		// the window containing t, if any, is the first one starting after
+		// the duration of a window before t
+		start := c.starts[i].Next(t.Add(-b.Duration))
+		if !start.IsZero() && !start.After(t) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCalendar(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skip("time zone database unavailable")
+	}
+
+	Convey("Validating blackout windows", t, func() {
+		start, stop := time.Now(), time.Now().Add(time.Hour)
+		So((&Calendar{Blackouts: []Blackout{{Start: "0 0 9 * * MON-FRI", Duration: 8 * time.Hour}}}).validate(), ShouldBeNil)
+		So((&Calendar{Blackouts: []Blackout{{StartTime: &start, StopTime: &stop}}}).validate(), ShouldBeNil)
+		So((&Calendar{Blackouts: []Blackout{{StartTime: &stop, StopTime: &start}}}).validate(), ShouldEqual, ErrStopBeforeStart)
+		So((&Calendar{Blackouts: []Blackout{{Start: "0 0 9 * * MON-FRI"}}}).validate(), ShouldEqual, ErrInvalidBlackout)
+		So((&Calendar{Blackouts: []Blackout{{Start: "at nine", Duration: time.Hour}}}).validate(), ShouldNotBeNil)
+	})
+
+	Convey("Given business hours and a maintenance window in Paris", t, func() {
+		mStart := time.Date(2017, 6, 10, 2, 0, 0, 0, paris)
+		mStop := mStart.Add(2 * time.Hour)
+		c := &Calendar{
+			Location: paris,
+			Blackouts: []Blackout{
+				{Start: "0 0 9 * * MON-FRI", Duration: 8 * time.Hour},
+				{StartTime: &mStart, StopTime: &mStop},
+			},
+		}
+		So(c.validate(), ShouldBeNil)
+
+		Convey("the business hours are blacked out in the time zone", func() {
+			// Wednesday June 7th 2017
+			So(c.blackedOut(time.Date(2017, 6, 7, 9, 0, 0, 0, paris)), ShouldBeTrue)
+			So(c.blackedOut(time.Date(2017, 6, 7, 16, 59, 59, 0, paris)), ShouldBeTrue)
+			So(c.blackedOut(time.Date(2017, 6, 7, 17, 0, 0, 0, paris)), ShouldBeFalse)
+			So(c.blackedOut(time.Date(2017, 6, 7, 8, 59, 59, 0, paris)), ShouldBeFalse)
+			// 8:30 UTC is 10:30 in Paris
+			So(c.blackedOut(time.Date(2017, 6, 7, 8, 30, 0, 0, time.UTC)), ShouldBeTrue)
+			// Saturday
+			So(c.blackedOut(time.Date(2017, 6, 10, 10, 0, 0, 0, paris)), ShouldBeFalse)
+		})
+		Convey("the maintenance window is blacked out", func() {
+			So(c.blackedOut(time.Date(2017, 6, 10, 3, 0, 0, 0, paris)), ShouldBeTrue)
+			So(c.blackedOut(mStop), ShouldBeFalse)
+		})
+	})
+
+	Convey("Given a cron schedule with several entries and a blackout window", t, func() {
+		c := NewCronSchedule("@every 1s", "@every 1h")
+		So(c.Validate(), ShouldBeNil)
+		So(c.Entries(), ShouldResemble, []string{"@every 1s", "@every 1h"})
+		now := time.Now()
+		stop := now.Add(2500 * time.Millisecond)
+		c.Blackouts = []Blackout{{StartTime: &now, StopTime: &stop}}
+		So(c.Validate(), ShouldBeNil)
+
+		Convey("the firings within the window are skipped and counted apart from misses", func() {
+			r := c.Wait(now)
+			So(r.Error(), ShouldBeNil)
+			So(r.Missed(), ShouldEqual, 0)
+			So(r.Skipped(), ShouldBeBetweenOrEqual, 2, 3)
+			So(r.LastTime(), ShouldHappenAfter, stop)
+		})
+	})
+
+	Convey("Given a cron schedule never firing outside of its blackout window", t, func() {
+		c := NewCronSchedule("0 0 10 * * *")
+		c.Blackouts = []Blackout{{Start: "0 0 9 * * *", Duration: 2 * time.Hour}}
+		So(c.Validate(), ShouldBeNil)
+		r := c.Wait(time.Now())
+		So(r.State(), ShouldEqual, Error)
+		So(r.Error(), ShouldEqual, ErrNoCronFiring)
+	})
+}
@@ -25,30 +25,37 @@ import (
 	"github.com/robfig/cron"
 )
 
-// ErrMissingCronEntry indicates missing cron entry
-var ErrMissingCronEntry = errors.New("Cron entry is missing")
+var (
+	// ErrMissingCronEntry indicates missing cron entry
+	ErrMissingCronEntry = errors.New("Cron entry is missing")
+	// ErrNoCronFiring - The error message for cron entries never firing outside of the blackout windows
+	ErrNoCronFiring = errors.New("Cron entries do not fire outside of the blackout windows within a year")
+)
 
-// CronSchedule is a schedule that waits as long as specified in cron entry
+// CronSchedule is a schedule that waits as long as specified in cron entries,
+// firing on each firing of any of them
 type CronSchedule struct {
-	entry    string
-	enabled  bool
-	state    ScheduleState
-	schedule *cron.Cron
+	entries   []string
+	state     ScheduleState
+	schedules []cron.Schedule
+	Calendar
 }
 
-// NewCronSchedule creates and starts new cron schedule and returns an instance of CronSchedule
-func NewCronSchedule(entry string) *CronSchedule {
-	schedule := cron.New()
+// NewCronSchedule returns an instance of CronSchedule firing on the given cron entries
+func NewCronSchedule(entry string, entries ...string) *CronSchedule {
 	return &CronSchedule{
-		entry:    entry,
-		schedule: schedule,
-		enabled:  false,
+		entries: append([]string{entry}, entries...),
 	}
 }
 
 // Entry returns the cron schedule entry
 func (c *CronSchedule) Entry() string {
-	return c.entry
+	return c.entries[0]
+}
+
+// Entries returns all the cron entries of the schedule
+func (c *CronSchedule) Entries() []string {
+	return c.entries
 }
 
 // GetState returns state of CronSchedule
@@ -56,59 +63,95 @@ func (c *CronSchedule) GetState() ScheduleState {
 	return c.state
 }
 
-// Validate returns error if cron entry doesn't match crontab format
+// Validate returns error if cron entries don't match crontab format or if
+// the blackout windows are invalid
 func (c *CronSchedule) Validate() error {
-	if c.entry == "" {
-		return ErrMissingCronEntry
-	}
-	_, err := cron.Parse(c.entry)
-	if err != nil {
+	if err := c.parse(); err != nil {
 		return err
 	}
+	return c.Calendar.validate()
+}
+
+func (c *CronSchedule) parse() error {
+	schedules := make([]cron.Schedule, len(c.entries))
+	for i, entry := range c.entries {
+		if entry == "" {
+			return ErrMissingCronEntry
+		}
+		s, err := cron.Parse(entry)
+		if err != nil {
+			return err
+		}
+		schedules[i] = s
+	}
+	c.schedules = schedules
 	return nil
 }
 
-// Wait waits as long as specified in cron entry
+// next returns the first firing of any of the entries after t
+func (c *CronSchedule) next(t time.Time) time.Time {
+	var next time.Time
+	for _, s := range c.schedules {
+		if n := s.Next(t); !n.IsZero() && (next.IsZero() || n.Before(next)) {
+			next = n
+		}
+	}
+	return next
+}
+
+// Wait waits as long as specified in cron entries. The firings within
+// blackout windows are skipped and counted apart from the missed ones.
 func (c *CronSchedule) Wait(last time.Time) Response {
 	var err error
-	now := time.Now()
-
-	// first run
-	if (last == time.Time{}) {
-		last = now
-	}
-	// schedule not enabled, either due to first run or invalid cron entry
-	if !c.enabled {
-		err = c.schedule.AddFunc(c.entry, func() {})
-		if err != nil {
+	// entries not parsed yet, either due to first run or invalid cron entry
+	if c.schedules == nil {
+		if err = c.parse(); err != nil {
 			c.state = Error
-		} else {
-			c.enabled = true
 		}
 	}
 
-	var misses uint
-	if c.enabled {
-		s := c.schedule.Entries()[0].Schedule
+	var misses, skipped uint
+	if err == nil {
+		now := time.Now().In(c.location())
+		// first run
+		if (last == time.Time{}) {
+			last = now
+		}
 
 		// calculate misses
-		for next := last; next.Before(now); {
-			next = s.Next(next)
-			if next.After(now) {
-				break
+		for next := c.next(last.In(c.location())); !next.IsZero() && !next.After(now); next = c.next(next) {
+			if c.blackedOut(next) {
+				skipped++
+			} else {
+				misses++
 			}
-			misses++
 		}
 
-		// wait
-		waitTime := s.Next(now)
-		time.Sleep(waitTime.Sub(now))
+		// skip the firings within blackout windows
+		next := c.next(now)
+		horizon := now.AddDate(1, 0, 0)
+		for !next.IsZero() && c.blackedOut(next) {
+			if next.After(horizon) {
+				next = time.Time{}
+				break
+			}
+			skipped++
+			next = c.next(next)
+		}
+		if next.IsZero() {
+			c.state = Error
+			err = ErrNoCronFiring
+		} else {
+			// wait
+			time.Sleep(next.Sub(time.Now()))
+		}
 	}
 
 	return &CronScheduleResponse{
 		state:    c.GetState(),
 		err:      err,
 		missed:   misses,
+		skipped:  skipped,
 		lastTime: time.Now(),
 	}
 }
@@ -118,6 +161,7 @@ type CronScheduleResponse struct {
 	state    ScheduleState
 	err      error
 	missed   uint
+	skipped  uint
 	lastTime time.Time
 }
 
@@ -136,6 +180,11 @@ func (c *CronScheduleResponse) Missed() uint {
 	return c.missed
 }
 
+// Skipped returns the firings skipped within blackout windows
+func (c *CronScheduleResponse) Skipped() uint {
+	return c.skipped
+}
+
 // LastTime returns the last response time
 func (c *CronScheduleResponse) LastTime() time.Time {
 	return c.lastTime
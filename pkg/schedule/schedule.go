@@ -21,6 +21,7 @@ package schedule
 
 import (
 	"errors"
+	"hash/fnv"
 	"time"
 )
 
@@ -31,6 +32,14 @@ var (
 	ErrInvalidStopTime = errors.New("Stop time is in the past")
 	// ErrStopBeforeStart - Error message for the stop time cannot occur before start time
 	ErrStopBeforeStart = errors.New("Stop time cannot occur before start time")
+	// ErrInvalidAlignment - Error message for an unknown alignment of the firings of a schedule
+	ErrInvalidAlignment = errors.New("Alignment must be one of unaligned, aligned or offset")
+	// ErrInvalidOffset - Error message for an offset out of the interval or without the offset alignment
+	ErrInvalidOffset = errors.New("Offset must be less than the interval and is only allowed with the offset alignment")
+	// ErrInvalidJitter - Error message for a jitter out of the interval
+	ErrInvalidJitter = errors.New("Jitter cannot be negative nor exceed the interval")
+	// ErrInvalidCatchUp - Error message for an unknown catch-up policy of a schedule
+	ErrInvalidCatchUp = errors.New("Catch-up must be one of skip, fire-once or fire-all")
 )
 
 // ScheduleState int type
@@ -45,6 +54,74 @@ const (
 	Error
 )
 
+// Alignment is how the firings of an interval schedule are placed in time
+type Alignment string
+
+const (
+	// Unaligned - the intervals are counted from the last firing, the first one firing right away
+	Unaligned Alignment = "unaligned"
+	// Aligned - the firings are aligned on the multiples of the interval since the Unix epoch
+	Aligned Alignment = "aligned"
+	// OffsetAligned - the firings are aligned like Aligned, shifted by an offset
+	OffsetAligned Alignment = "offset"
+)
+
+// CatchUp is what an interval schedule does about the intervals missed while
+// a task was busy
+type CatchUp string
+
+const (
+	// CatchUpSkip - the missed intervals are counted and skipped
+	CatchUpSkip CatchUp = "skip"
+	// CatchUpFireOnce - the schedule fires once right away for all the missed intervals
+	CatchUpFireOnce CatchUp = "fire-once"
+	// CatchUpFireAll - the schedule fires right away once for each missed interval
+	CatchUpFireAll CatchUp = "fire-all"
+)
+
+// IntervalOptions tune the firings of an interval schedule
+type IntervalOptions struct {
+	Alignment Alignment
+	// Offset shifts the aligned firings of the OffsetAligned alignment
+	Offset time.Duration
+	// Jitter is the maximum splay of the firings. The splay of a schedule is
+	// derived from its jitter key, so that it is the same on each firing and
+	// on each restart of a task, while differing between tasks.
+	Jitter  time.Duration
+	CatchUp CatchUp
+}
+
+// Validate checks the options for the given interval
+func (o IntervalOptions) Validate(i time.Duration) error {
+	switch o.Alignment {
+	case "", Unaligned, Aligned, OffsetAligned:
+	default:
+		return ErrInvalidAlignment
+	}
+	if o.Offset < 0 || o.Offset >= i || (o.Offset != 0 && o.Alignment != OffsetAligned) {
+		return ErrInvalidOffset
+	}
+	if o.Jitter < 0 || o.Jitter > i {
+		return ErrInvalidJitter
+	}
+	switch o.CatchUp {
+	case "", CatchUpSkip, CatchUpFireOnce, CatchUpFireAll:
+	default:
+		return ErrInvalidCatchUp
+	}
+	return nil
+}
+
+// splay returns the delay within the jitter given by the key
+func (o IntervalOptions) splay(key string) time.Duration {
+	if o.Jitter <= 0 {
+		return 0
+	}
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return time.Duration(h.Sum64() % uint64(o.Jitter))
+}
+
 // Schedule interface
 type Schedule interface {
 	// Returns the current state of the schedule
@@ -63,27 +140,8 @@ type Response interface {
 	State() ScheduleState
 	// Returns any intervals that were missed since the call to Wait()
 	Missed() uint
+	// Returns any firings that were skipped within blackout windows since the call to Wait()
+	Skipped() uint
 	// The time the interval fired
 	LastTime() time.Time
 }
-
-func waitOnInterval(last time.Time, i time.Duration) (uint, time.Time) {
-	// first run
-	if (last == time.Time{}) {
-		// for the first run, do not wait on interval
-		// and schedule workflow execution immediately
-		return uint(0), time.Now()
-	}
-	// Get the difference in time.Duration since last in nanoseconds (int64)
-	timeDiff := time.Since(last).Nanoseconds()
-	// cache our schedule interval in nanoseconds
-	nanoInterval := i.Nanoseconds()
-	// use modulo operation to obtain the remainder of time over last interval
-	remainder := timeDiff % nanoInterval
-	// subtract remainder from
-	missed := (timeDiff - remainder) / nanoInterval // timeDiff.Nanoseconds() % s.Interval.Nanoseconds()
-	waitDuration := nanoInterval - remainder
-	// Wait until predicted interval fires
-	time.Sleep(time.Duration(waitDuration))
-	return uint(missed), time.Now()
-}
@@ -65,6 +65,11 @@ func (s *StreamingScheduleResponse) Missed() uint {
 	return 0
 }
 
+// Skipped returns any skipped firings
+func (s *StreamingScheduleResponse) Skipped() uint {
+	return 0
+}
+
 // LastTime returns the last response time
 func (s *StreamingScheduleResponse) LastTime() time.Time {
 	return time.Time{}
@@ -0,0 +1,296 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schedule
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrMissingTriggerTask - The error message for a trigger not naming the task it watches
+	ErrMissingTriggerTask = errors.New("Trigger task is missing")
+	// ErrInvalidTriggerMetric - The error message for a trigger without the namespace of the metric it watches
+	ErrInvalidTriggerMetric = errors.New("Trigger metric must be a namespace starting with '/'")
+	// ErrInvalidHysteresis - The error message for a negative hysteresis
+	ErrInvalidHysteresis = errors.New("Trigger hysteresis cannot be negative")
+	// ErrInvalidCooldown - The error message for a negative cooldown
+	ErrInvalidCooldown = errors.New("Trigger cooldown cannot be negative")
+	// ErrInvalidTriggerInterval - The error message for a negative interval of a triggered schedule
+	ErrInvalidTriggerInterval = errors.New("Interval of a triggered schedule cannot be negative")
+)
+
+// Trigger describes the metric watched by a TriggeredSchedule and the
+// threshold it must cross for the schedule to fire.
+type Trigger struct {
+	// Task is the ID or the name of the task collecting the metric
+	Task string
+	// Metric is the namespace of the metric, where `*` matches any element
+	Metric string
+	// Threshold is crossed when the metric goes above it, or below it if Below is set
+	Threshold float64
+	Below     bool
+	// Hysteresis is how far back past the threshold the metric must go to
+	// release the trigger, so that a metric hovering around the threshold does
+	// not flap the trigger
+	Hysteresis float64
+	// Cooldown is how long the trigger stays released before it can trigger again
+	Cooldown time.Duration
+}
+
+// TriggerState is the state of the trigger of a TriggeredSchedule
+type TriggerState struct {
+	// Triggered is whether the metric is past the threshold
+	Triggered bool
+	// Value is the last value of the metric observed
+	Value *float64
+	// LastObservedTime is when the metric was last observed
+	LastObservedTime time.Time
+	// LastTriggeredTime is when the threshold was last crossed
+	LastTriggeredTime time.Time
+	// CooldownUntil is when the trigger can trigger again after being released
+	CooldownUntil time.Time
+	// Count is the number of times the threshold was crossed
+	Count uint
+}
+
+// TriggeredSchedule is a schedule that fires while a metric collected by
+// another task is past a threshold. With an interval, it fires on the interval
+// for as long as the trigger holds. Without one, it fires once each time the
+// threshold is crossed.
+type TriggeredSchedule struct {
+	Interval time.Duration
+	Trigger  Trigger
+	state    ScheduleState
+
+	mutex   sync.Mutex
+	trigger TriggerState
+	// pending is set when the threshold is crossed and cleared on the firing
+	// following it
+	pending bool
+	// wake is closed, and replaced, to wake up the waits on any change
+	wake chan struct{}
+	// releases counts the calls to Release, which end the pending waits
+	releases uint
+}
+
+// NewTriggeredSchedule returns an instance of TriggeredSchedule with the given
+// interval and trigger
+func NewTriggeredSchedule(i time.Duration, tr Trigger) *TriggeredSchedule {
+	return &TriggeredSchedule{
+		Interval: i,
+		Trigger:  tr,
+		wake:     make(chan struct{}),
+	}
+}
+
+// GetState returns ScheduleState of TriggeredSchedule
+func (s *TriggeredSchedule) GetState() ScheduleState {
+	return s.state
+}
+
+// Validate validates the trigger and the interval of TriggeredSchedule
+func (s *TriggeredSchedule) Validate() error {
+	if s.Trigger.Task == "" {
+		return ErrMissingTriggerTask
+	}
+	if !strings.HasPrefix(s.Trigger.Metric, "/") || len(s.Trigger.Metric) < 2 {
+		return ErrInvalidTriggerMetric
+	}
+	if s.Trigger.Hysteresis < 0 {
+		return ErrInvalidHysteresis
+	}
+	if s.Trigger.Cooldown < 0 {
+		return ErrInvalidCooldown
+	}
+	if s.Interval < 0 {
+		return ErrInvalidTriggerInterval
+	}
+	s.state = Active
+	return nil
+}
+
+// TriggerState returns the current state of the trigger
+func (s *TriggeredSchedule) TriggerState() TriggerState {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.trigger
+}
+
+// Observe updates the trigger with values of the watched metric collected at
+// the same time. The values are reduced to the one nearest to crossing the
+// threshold, so that any of them past the threshold triggers.
+func (s *TriggeredSchedule) Observe(values ...float64) {
+	if len(values) == 0 {
+		return
+	}
+	v := values[0]
+	for _, value := range values[1:] {
+		if (s.Trigger.Below && value < v) || (!s.Trigger.Below && value > v) {
+			v = value
+		}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := time.Now()
+	s.trigger.Value = &v
+	s.trigger.LastObservedTime = now
+	if !s.trigger.Triggered {
+		crossed := v > s.Trigger.Threshold
+		if s.Trigger.Below {
+			crossed = v < s.Trigger.Threshold
+		}
+		if !crossed || now.Before(s.trigger.CooldownUntil) {
+			return
+		}
+		s.trigger.Triggered = true
+		s.trigger.LastTriggeredTime = now
+		s.trigger.Count++
+		s.pending = true
+		logger.WithFields(log.Fields{
+			"_block": "triggered-observe",
+			"task":   s.Trigger.Task,
+			"metric": s.Trigger.Metric,
+			"value":  v,
+		}).Debug("schedule triggered")
+	} else {
+		released := v < s.Trigger.Threshold-s.Trigger.Hysteresis
+		if s.Trigger.Below {
+			released = v > s.Trigger.Threshold+s.Trigger.Hysteresis
+		}
+		if !released {
+			return
+		}
+		s.trigger.Triggered = false
+		s.trigger.CooldownUntil = now.Add(s.Trigger.Cooldown)
+		logger.WithFields(log.Fields{
+			"_block": "triggered-observe",
+			"task":   s.Trigger.Task,
+			"metric": s.Trigger.Metric,
+			"value":  v,
+		}).Debug("schedule released")
+	}
+	s.wakeUp()
+}
+
+// Release wakes up the waits on the schedule, for instance when the task
+// using it is stopped while the schedule is not triggered.
+func (s *TriggeredSchedule) Release() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.releases++
+	s.wakeUp()
+}
+
+// wakeUp must be called with the mutex held
+func (s *TriggeredSchedule) wakeUp() {
+	close(s.wake)
+	s.wake = make(chan struct{})
+}
+
+// Wait blocks until the trigger fires, either on being triggered or on the
+// interval while it holds. It returns early when the schedule is released.
+func (s *TriggeredSchedule) Wait(last time.Time) Response {
+	var missed uint
+	s.mutex.Lock()
+	releases := s.releases
+	for {
+		if s.releases != releases {
+			break
+		}
+		if s.pending {
+			s.pending = false
+			break
+		}
+		now := time.Now()
+		var next time.Time
+		if s.trigger.Triggered && s.Interval > 0 {
+			from := last
+			if from.Before(s.trigger.LastTriggeredTime) {
+				// the intervals are counted from the time the trigger holds
+				from = s.trigger.LastTriggeredTime
+			}
+			next = from.Add(s.Interval)
+			if !now.Before(next) {
+				if from == last {
+					missed = uint(now.Sub(last)/s.Interval) - 1
+				}
+				break
+			}
+		}
+		wake := s.wake
+		s.mutex.Unlock()
+		if next.IsZero() {
+			<-wake
+		} else {
+			timer := time.NewTimer(next.Sub(now))
+			select {
+			case <-wake:
+			case <-timer.C:
+			}
+			timer.Stop()
+		}
+		s.mutex.Lock()
+	}
+	s.mutex.Unlock()
+	return &TriggeredScheduleResponse{
+		state:    s.GetState(),
+		missed:   missed,
+		lastTime: time.Now(),
+	}
+}
+
+// TriggeredScheduleResponse is the response from TriggeredSchedule
+// conforming to ScheduleResponse interface
+type TriggeredScheduleResponse struct {
+	state    ScheduleState
+	missed   uint
+	lastTime time.Time
+}
+
+// State returns the state of the Schedule
+func (s *TriggeredScheduleResponse) State() ScheduleState {
+	return s.state
+}
+
+// Error returns last error
+func (s *TriggeredScheduleResponse) Error() error {
+	return nil
+}
+
+// Missed returns any missed intervals
+func (s *TriggeredScheduleResponse) Missed() uint {
+	return s.missed
+}
+
+// Skipped returns any skipped firings
+func (s *TriggeredScheduleResponse) Skipped() uint {
+	return 0
+}
+
+// LastTime returns the last triggered schedule response time
+func (s *TriggeredScheduleResponse) LastTime() time.Time {
+	return s.lastTime
+}
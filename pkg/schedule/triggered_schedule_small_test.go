@@ -0,0 +1,118 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// waitFor returns the response of the wait on s, or nil if it is still
+// waiting after the timeout
+func waitFor(s *TriggeredSchedule, last time.Time, timeout time.Duration) Response {
+	ch := make(chan Response, 1)
+	go func() { ch <- s.Wait(last) }()
+	select {
+	case r := <-ch:
+		return r
+	case <-time.After(timeout):
+		s.Release()
+		<-ch
+		return nil
+	}
+}
+
+func TestTriggeredSchedule(t *testing.T) {
+	trigger := Trigger{Task: "host-cpu", Metric: "/intel/procfs/cpu/*/utilization", Threshold: 90, Hysteresis: 10}
+
+	Convey("Validating a triggered schedule", t, func() {
+		So(NewTriggeredSchedule(0, trigger).Validate(), ShouldBeNil)
+		So(NewTriggeredSchedule(-time.Second, trigger).Validate(), ShouldEqual, ErrInvalidTriggerInterval)
+		invalid := trigger
+		invalid.Task = ""
+		So(NewTriggeredSchedule(0, invalid).Validate(), ShouldEqual, ErrMissingTriggerTask)
+		invalid = trigger
+		invalid.Metric = "intel/procfs"
+		So(NewTriggeredSchedule(0, invalid).Validate(), ShouldEqual, ErrInvalidTriggerMetric)
+		invalid = trigger
+		invalid.Hysteresis = -1
+		So(NewTriggeredSchedule(0, invalid).Validate(), ShouldEqual, ErrInvalidHysteresis)
+	})
+
+	Convey("Given a triggered schedule without interval", t, func() {
+		s := NewTriggeredSchedule(0, trigger)
+		So(s.Validate(), ShouldBeNil)
+
+		Convey("it waits while the threshold is not crossed", func() {
+			s.Observe(50, 89)
+			So(waitFor(s, time.Time{}, 50*time.Millisecond), ShouldBeNil)
+			So(s.TriggerState().Triggered, ShouldBeFalse)
+			So(*s.TriggerState().Value, ShouldEqual, 89)
+		})
+		Convey("it fires once when the threshold is crossed", func() {
+			go func() {
+				time.Sleep(10 * time.Millisecond)
+				s.Observe(50, 95)
+			}()
+			r := waitFor(s, time.Time{}, time.Second)
+			So(r, ShouldNotBeNil)
+			So(r.State(), ShouldEqual, Active)
+			So(s.TriggerState().Triggered, ShouldBeTrue)
+			So(s.TriggerState().Count, ShouldEqual, 1)
+			So(waitFor(s, r.LastTime(), 50*time.Millisecond), ShouldBeNil)
+
+			Convey("and is only released past the hysteresis", func() {
+				s.Observe(85)
+				So(s.TriggerState().Triggered, ShouldBeTrue)
+				s.Observe(79)
+				So(s.TriggerState().Triggered, ShouldBeFalse)
+				s.Observe(95)
+				So(s.TriggerState().Count, ShouldEqual, 2)
+			})
+		})
+	})
+
+	Convey("Given a triggered schedule with an interval and a cooldown", t, func() {
+		cooled := trigger
+		cooled.Cooldown = time.Hour
+		s := NewTriggeredSchedule(20*time.Millisecond, cooled)
+		So(s.Validate(), ShouldBeNil)
+
+		Convey("it fires on the interval while the trigger holds", func() {
+			s.Observe(95)
+			r := waitFor(s, time.Time{}, time.Second)
+			So(r, ShouldNotBeNil)
+			r = waitFor(s, r.LastTime(), time.Second)
+			So(r, ShouldNotBeNil)
+
+			Convey("and not during the cooldown once released", func() {
+				s.Observe(10)
+				s.Observe(95)
+				So(s.TriggerState().Triggered, ShouldBeFalse)
+				So(s.TriggerState().CooldownUntil, ShouldHappenAfter, time.Now())
+				So(waitFor(s, r.LastTime(), 50*time.Millisecond), ShouldBeNil)
+			})
+		})
+	})
+}
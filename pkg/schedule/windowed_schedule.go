@@ -37,6 +37,13 @@ type WindowedSchedule struct {
 	Count      uint
 	state      ScheduleState
 	stopOnTime *time.Time
+	IntervalOptions
+	Calendar
+	// jitterKey derives the splay of the firings, see SetJitterKey
+	jitterKey string
+	// backlog is the number of missed intervals still to fire under the
+	// CatchUpFireAll policy
+	backlog uint
 }
 
 // NewWindowedSchedule returns an instance of WindowedSchedule with given interval, start and stop timestamp
@@ -60,6 +67,12 @@ func NewWindowedSchedule(i time.Duration, start *time.Time, stop *time.Time, cou
 	}
 }
 
+// SetJitterKey sets the key the splay of the firings is derived from, which is
+// the ID of the task using the schedule
+func (w *WindowedSchedule) SetJitterKey(key string) {
+	w.jitterKey = key
+}
+
 // setStopOnTime calculates and set the value of the windowed `stopOnTime` which is the right window boundary.
 // `stopOnTime` is determined by `StopTime` or, if it is not provided, calculated based on count and interval.
 func (w *WindowedSchedule) setStopOnTime() {
@@ -105,6 +118,12 @@ func (w *WindowedSchedule) Validate() error {
 	if w.Interval <= 0 {
 		return ErrInvalidInterval
 	}
+	if err := w.IntervalOptions.Validate(w.Interval); err != nil {
+		return err
+	}
+	if err := w.Calendar.validate(); err != nil {
+		return err
+	}
 
 	// the schedule passed validation, set as active
 	w.state = Active
@@ -116,7 +135,7 @@ func (w *WindowedSchedule) Validate() error {
 func (w *WindowedSchedule) Wait(last time.Time) Response {
 	// If within the window we wait our interval and return
 	// otherwise we exit with a completed state.
-	var m uint
+	var m, skipped uint
 
 	if (last == time.Time{}) {
 		// the first waiting in cycles, so
@@ -145,7 +164,7 @@ func (w *WindowedSchedule) Wait(last time.Time) Response {
 				"time-before-stop": w.stopOnTime.Sub(time.Now()),
 			}).Debug("Within window, calling interval")
 
-			m, _ = waitOnInterval(last, w.Interval)
+			m, skipped = w.waitOutsideBlackouts(last)
 
 			// check if the schedule should be ended after waiting on interval
 			if time.Now().After(*w.stopOnTime) {
@@ -163,21 +182,102 @@ func (w *WindowedSchedule) Wait(last time.Time) Response {
 		}
 	} else {
 		// This has no end like a simple schedule
-		m, _ = waitOnInterval(last, w.Interval)
+		m, skipped = w.waitOutsideBlackouts(last)
 
 	}
 	return &WindowedScheduleResponse{
 		state:    w.GetState(),
 		missed:   m,
+		skipped:  skipped,
 		lastTime: time.Now(),
 	}
 }
 
+// waitOnInterval waits for the firing following last and returns the number
+// of intervals missed since last. Depending on the catch-up policy, missed
+// intervals make it return right away instead of waiting for the next one.
+func (w *WindowedSchedule) waitOnInterval(last time.Time) uint {
+	now := time.Now()
+	// first run
+	if (last == time.Time{}) {
+		w.backlog = 0
+		if w.Alignment == Aligned || w.Alignment == OffsetAligned {
+			time.Sleep(w.boundaryAfter(now).Sub(now))
+			return 0
+		}
+		// for the first run, do not wait on interval
+		// and schedule workflow execution immediately, or after the splay
+		time.Sleep(w.splay(w.jitterKey))
+		return 0
+	}
+	if w.backlog > 0 {
+		w.backlog--
+		return 0
+	}
+
+	var missed uint
+	var next time.Time
+	if w.Alignment == Aligned || w.Alignment == OffsetAligned {
+		first := w.boundaryAfter(last)
+		next = first
+		if !now.Before(first) {
+			missed = uint(now.Sub(first)/w.Interval) + 1
+			next = first.Add(time.Duration(missed) * w.Interval)
+		}
+	} else {
+		// the intervals are counted from the last firing, which is ahead of
+		// now when the task delays its next firing
+		if now.After(last) {
+			missed = uint(now.Sub(last) / w.Interval)
+		}
+		next = last.Add(time.Duration(missed+1) * w.Interval)
+	}
+	if missed > 0 {
+		switch w.CatchUp {
+		case CatchUpFireOnce:
+			return missed - 1
+		case CatchUpFireAll:
+			w.backlog = missed - 1
+			return 0
+		}
+	}
+	// Wait until predicted interval fires
+	time.Sleep(next.Sub(time.Now()))
+	return missed
+}
+
+// waitOutsideBlackouts waits on the interval until a firing falls outside of
+// the blackout windows or the window of the schedule ends, and returns the
+// intervals missed and the firings skipped within blackout windows.
+func (w *WindowedSchedule) waitOutsideBlackouts(last time.Time) (missed, skipped uint) {
+	for {
+		missed += w.waitOnInterval(last)
+		now := time.Now()
+		if !w.blackedOut(now) || (w.stopOnTime != nil && now.After(*w.stopOnTime)) {
+			return missed, skipped
+		}
+		skipped++
+		last = now
+	}
+}
+
+// boundaryAfter returns the first aligned firing after t
+func (w *WindowedSchedule) boundaryAfter(t time.Time) time.Time {
+	phase := w.splay(w.jitterKey)
+	if w.Alignment == OffsetAligned {
+		phase += w.Offset
+	}
+	i := w.Interval.Nanoseconds()
+	n := t.UnixNano() - phase.Nanoseconds()
+	return time.Unix(0, (n/i+1)*i+phase.Nanoseconds())
+}
+
 // WindowedScheduleResponse is the response from SimpleSchedule
 // conforming to ScheduleResponse interface
 type WindowedScheduleResponse struct {
 	state    ScheduleState
 	missed   uint
+	skipped  uint
 	lastTime time.Time
 }
 
@@ -196,6 +296,11 @@ func (w *WindowedScheduleResponse) Missed() uint {
 	return w.missed
 }
 
+// Skipped returns the firings skipped within blackout windows
+func (w *WindowedScheduleResponse) Skipped() uint {
+	return w.skipped
+}
+
 // LastTime returns the last windowed schedule response time
 func (w *WindowedScheduleResponse) LastTime() time.Time {
 	return w.lastTime
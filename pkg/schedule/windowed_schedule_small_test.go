@@ -91,3 +91,84 @@ func TestWindowedScheduleValidation(t *testing.T) {
 		So(afterMS, ShouldBeLessThan, shouldWait+10)
 	})
 }
+
+func TestWindowedScheduleIntervalOptions(t *testing.T) {
+	interval := 100 * time.Millisecond
+	Convey("invalid interval options", t, func() {
+		w := NewWindowedSchedule(interval, nil, nil, 0)
+		w.Alignment = "hourly"
+		So(w.Validate(), ShouldEqual, ErrInvalidAlignment)
+		w = NewWindowedSchedule(interval, nil, nil, 0)
+		w.Offset = 10 * time.Millisecond
+		So(w.Validate(), ShouldEqual, ErrInvalidOffset)
+		w.Alignment = OffsetAligned
+		So(w.Validate(), ShouldBeNil)
+		w.Offset = interval
+		So(w.Validate(), ShouldEqual, ErrInvalidOffset)
+		w = NewWindowedSchedule(interval, nil, nil, 0)
+		w.Jitter = 2 * interval
+		So(w.Validate(), ShouldEqual, ErrInvalidJitter)
+		w = NewWindowedSchedule(interval, nil, nil, 0)
+		w.CatchUp = "fire-some"
+		So(w.Validate(), ShouldEqual, ErrInvalidCatchUp)
+	})
+	Convey("the splay is fixed for a key and within the jitter", t, func() {
+		o := IntervalOptions{Jitter: interval}
+		So(o.splay("a"), ShouldEqual, o.splay("a"))
+		So(o.splay("a"), ShouldNotEqual, o.splay("b"))
+		So(o.splay("a"), ShouldBeLessThan, interval)
+		So(IntervalOptions{}.splay("a"), ShouldEqual, 0)
+	})
+	Convey("aligned firings are on the multiples of the interval plus the offset", t, func() {
+		w := NewWindowedSchedule(interval, nil, nil, 0)
+		w.Alignment = OffsetAligned
+		w.Offset = 30 * time.Millisecond
+		So(w.Validate(), ShouldBeNil)
+		last := time.Unix(1000, int64(50*time.Millisecond))
+		So(w.boundaryAfter(last), ShouldResemble, time.Unix(1000, int64(130*time.Millisecond)))
+		So(w.boundaryAfter(time.Unix(1000, int64(130*time.Millisecond))), ShouldResemble, time.Unix(1000, int64(230*time.Millisecond)))
+
+		r := w.Wait(time.Time{})
+		So(r.LastTime().UnixNano()%int64(interval), ShouldBeBetween, int64(30*time.Millisecond), int64(40*time.Millisecond))
+	})
+	Convey("missed intervals", t, func() {
+		// the last firing was three and a half intervals ago
+		ago := func() time.Time { return time.Now().Add(-350 * time.Millisecond) }
+		Convey("are skipped by default", func() {
+			w := NewWindowedSchedule(interval, nil, nil, 0)
+			So(w.Validate(), ShouldBeNil)
+			before := time.Now()
+			So(w.Wait(ago()).Missed(), ShouldEqual, 3)
+			So(time.Since(before), ShouldBeGreaterThan, 40*time.Millisecond)
+		})
+		Convey("fire once right away", func() {
+			w := NewWindowedSchedule(interval, nil, nil, 0)
+			w.CatchUp = CatchUpFireOnce
+			So(w.Validate(), ShouldBeNil)
+			before := time.Now()
+			So(w.Wait(ago()).Missed(), ShouldEqual, 2)
+			So(time.Since(before), ShouldBeLessThan, 10*time.Millisecond)
+		})
+		Convey("all fire right away", func() {
+			w := NewWindowedSchedule(interval, nil, nil, 0)
+			w.CatchUp = CatchUpFireAll
+			So(w.Validate(), ShouldBeNil)
+			before, last := time.Now(), ago()
+			for i := 0; i < 3; i++ {
+				So(w.Wait(last).Missed(), ShouldEqual, 0)
+				last = time.Now()
+			}
+			So(time.Since(before), ShouldBeLessThan, 10*time.Millisecond)
+			// caught up, the next firing is on the interval
+			w.Wait(last)
+			So(time.Since(before), ShouldBeGreaterThan, 90*time.Millisecond)
+		})
+	})
+	Convey("a delayed firing is on the interval after the delay without misses", t, func() {
+		w := NewWindowedSchedule(interval, nil, nil, 0)
+		So(w.Validate(), ShouldBeNil)
+		before := time.Now()
+		So(w.Wait(before.Add(2*interval)).Missed(), ShouldEqual, 0)
+		So(time.Since(before), ShouldBeGreaterThan, 290*time.Millisecond)
+	})
+}
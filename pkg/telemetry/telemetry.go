@@ -0,0 +1,154 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package telemetry records the metrics snapteld exposes about itself under
+// the /intel/snap namespace. Counters are incremented by the modules of
+// snapteld as things happen, while gauges are read from their sources when
+// the metrics are collected.
+package telemetry
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/intelsdi-x/snap/core"
+)
+
+// Prefix is the namespace reserved for the metrics of snapteld
+var Prefix = []string{"intel", "snap"}
+
+// Sample is the value of a metric, identified by its namespace below Prefix
+type Sample struct {
+	Namespace []string
+	Value     float64
+}
+
+// Definition describes a metric in the metric catalog. The dynamic elements
+// of its namespace take the value of the corresponding element of the samples.
+type Definition struct {
+	Namespace   core.Namespace
+	Description string
+	Unit        string
+}
+
+// Definitions are the metrics recorded by snapteld
+var Definitions = []Definition{
+	define("scheduler/tasks/[task_id]/hits", "Number of runs of the task", ""),
+	define("scheduler/tasks/[task_id]/misses", "Number of intervals missed by the task", ""),
+	define("scheduler/tasks/[task_id]/skips", "Number of runs of the task skipped within blackout windows", ""),
+	define("scheduler/tasks/[task_id]/failures", "Number of failed runs of the task", ""),
+	define("scheduler/work_manager/[queue]/depth", "Number of jobs waiting in the work manager queue", ""),
+	define("scheduler/work_manager/[queue]/priorities/[priority]/depth", "Number of jobs of the task priority class waiting in the work manager queue", ""),
+	define("scheduler/work_manager/[queue]/priorities/[priority]/dropped", "Number of jobs of the task priority class refused or shed by the full work manager queue", ""),
+	define("scheduler/jobs/[job_type]/count", "Number of jobs run", ""),
+	define("scheduler/jobs/[job_type]/failures", "Number of jobs which returned errors", ""),
+	define("scheduler/jobs/[job_type]/wait_seconds_total", "Total time jobs waited before being run", "s"),
+	define("scheduler/jobs/[job_type]/run_seconds_total", "Total time spent running jobs", "s"),
+	define("control/plugins/[plugin_name]/restarts", "Number of restarts of the plugin", ""),
+	define("control/cache/hits", "Number of metrics served from the collector cache", ""),
+	define("control/cache/misses", "Number of metrics not found in the collector cache", ""),
+	define("grpc/[method]/count", "Number of gRPC calls", ""),
+	define("grpc/[method]/errors", "Number of gRPC calls which returned errors", ""),
+	define("grpc/[method]/seconds_total", "Total duration of gRPC calls", "s"),
+}
+
+// define parses a namespace below Prefix, where dynamic elements are
+// written [name]
+func define(ns, description, unit string) Definition {
+	namespace := core.NewNamespace(Prefix...)
+	for _, e := range strings.Split(ns, "/") {
+		if strings.HasPrefix(e, "[") && strings.HasSuffix(e, "]") {
+			namespace = namespace.AddDynamicElement(e[1:len(e)-1], "")
+			continue
+		}
+		namespace = namespace.AddStaticElement(e)
+	}
+	return Definition{Namespace: namespace, Description: description, Unit: unit}
+}
+
+var (
+	mutex    sync.Mutex
+	counters = map[string]*Sample{}
+	sources  = map[string]func() []Sample{}
+)
+
+// Add adds delta to the counter with the given namespace below Prefix
+func Add(delta float64, ns ...string) {
+	key := strings.Join(ns, core.Separator)
+	mutex.Lock()
+	defer mutex.Unlock()
+	s, ok := counters[key]
+	if !ok {
+		s = &Sample{Namespace: append([]string{}, ns...)}
+		counters[key] = s
+	}
+	s.Value += delta
+}
+
+// Observe counts a call which took the given duration, under the count and
+// seconds_total elements of the given namespace
+func Observe(d time.Duration, ns ...string) {
+	Add(1, append(ns, "count")...)
+	Add(d.Seconds(), append(ns, "seconds_total")...)
+}
+
+// RegisterSource sets the function which returns the gauges of a module.
+// Registering a source again replaces the previous one.
+func RegisterSource(name string, fn func() []Sample) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	sources[name] = fn
+}
+
+// UnregisterSource removes the gauges of a module
+func UnregisterSource(name string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	delete(sources, name)
+}
+
+// Snapshot returns the current value of all the metrics, sorted by namespace
+func Snapshot() []Sample {
+	mutex.Lock()
+	samples := make([]Sample, 0, len(counters))
+	for _, s := range counters {
+		samples = append(samples, *s)
+	}
+	fns := make([]func() []Sample, 0, len(sources))
+	for _, fn := range sources {
+		fns = append(fns, fn)
+	}
+	mutex.Unlock()
+	// sources are called without holding the lock since they may take their own
+	for _, fn := range fns {
+		samples = append(samples, fn()...)
+	}
+	sort.Sort(byNamespace(samples))
+	return samples
+}
+
+type byNamespace []Sample
+
+func (s byNamespace) Len() int      { return len(s) }
+func (s byNamespace) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byNamespace) Less(i, j int) bool {
+	return strings.Join(s[i].Namespace, core.Separator) < strings.Join(s[j].Namespace, core.Separator)
+}
@@ -0,0 +1,56 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package telemetry
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTelemetry(t *testing.T) {
+	Convey("Telemetry", t, func() {
+		Convey("defines namespaces below /intel/snap", func() {
+			d := Definitions[0]
+			So(d.Namespace.String(), ShouldEqual, "/intel/snap/scheduler/tasks/*/hits")
+			So(d.Namespace[4].IsDynamic(), ShouldBeTrue)
+			So(d.Namespace[4].Name, ShouldEqual, "task_id")
+		})
+		Convey("records counters and gauges", func() {
+			Add(1, "control", "cache", "hits")
+			Add(2, "control", "cache", "hits")
+			Observe(1500*time.Millisecond, "grpc", "rpc.Collector.CollectMetrics")
+			RegisterSource("test", func() []Sample {
+				return []Sample{{Namespace: []string{"scheduler", "work_manager", "collect", "depth"}, Value: 4}}
+			})
+			So(Snapshot(), ShouldResemble, []Sample{
+				{Namespace: []string{"control", "cache", "hits"}, Value: 3},
+				{Namespace: []string{"grpc", "rpc.Collector.CollectMetrics", "count"}, Value: 1},
+				{Namespace: []string{"grpc", "rpc.Collector.CollectMetrics", "seconds_total"}, Value: 1.5},
+				{Namespace: []string{"scheduler", "work_manager", "collect", "depth"}, Value: 4},
+			})
+			UnregisterSource("test")
+			So(len(Snapshot()), ShouldEqual, 3)
+		})
+	})
+}
@@ -0,0 +1,272 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/intelsdi-x/snap/control"
+	"github.com/intelsdi-x/snap/mgmt/rest"
+	"github.com/intelsdi-x/snap/pkg/audit"
+	"github.com/intelsdi-x/snap/pkg/cfgfile"
+	"github.com/intelsdi-x/snap/scheduler"
+)
+
+// reloadableSettings lists the settings which can be changed without restarting
+// snapteld. A setting is reloadable when it, or one of its parents, is listed.
+var reloadableSettings = []string{
+	"log_level",
+	"control.plugins",
+	"control.tags",
+	"control.plugin_rate_limits",
+	"scheduler.work_manager_queue_size",
+	"scheduler.work_manager_pool_size",
+	"scheduler.priority_weights",
+	"restapi.allowed_origins",
+	"restapi.rest_auth",
+	"restapi.rest_auth_password",
+	"restapi.tokens",
+	"restapi.client_certs",
+}
+
+type reloadsControlConfig interface {
+	ReloadConfig(*control.Config) error
+}
+
+type reloadsSchedulerConfig interface {
+	ReloadConfig(*scheduler.Config)
+}
+
+// configReloader re-reads the configuration of snapteld and applies the
+// reloadable settings to the running modules
+type configReloader struct {
+	ctx runtimeFlagsContext
+	cfg *Config
+	// settings holds the configuration last read, before any runtime change
+	settings  map[string]interface{}
+	control   reloadsControlConfig
+	scheduler reloadsSchedulerConfig
+	rest      *rest.Server
+}
+
+// configChange describes the change of a single setting
+type configChange struct {
+	setting string
+	old     interface{}
+	new     interface{}
+}
+
+func (c configChange) reloadable() bool {
+	for _, s := range reloadableSettings {
+		if c.setting == s || strings.HasPrefix(c.setting, s+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func (c configChange) fields() log.Fields {
+	f := log.Fields{
+		"_block":  "reload-config",
+		"_module": logModule,
+		"setting": c.setting,
+		"old":     c.old,
+		"new":     c.new,
+	}
+	if strings.Contains(c.setting, "password") || c.setting == "restapi.tokens" {
+		f["old"], f["new"] = "********", "********"
+	}
+	return f
+}
+
+// reload reads the configuration again and applies it when all the changed
+// settings can be changed at runtime. The whole configuration is rejected
+// otherwise and the running configuration is left untouched.
+func (r *configReloader) reload() {
+	cfg := getDefaultConfig()
+	if err := loadConfig(cfg, r.ctx.String("config")); err != nil {
+		r.reject(err.Error())
+		return
+	}
+	applyCmdLineFlags(cfg, r.ctx)
+	if cfg.Control.PluginConfigWriteBack && cfg.Control.PluginConfigOverlayPath == "" {
+		cfg.Control.PluginConfigOverlayPath = pluginConfigOverlayPath(r.ctx.String("config"))
+	}
+	jb, _ := json.Marshal(cfg)
+	if serrs := cfgfile.ValidateSchema(CONFIG_CONSTRAINTS, string(jb)); serrs != nil {
+		for _, serr := range serrs {
+			log.WithFields(serr.Fields()).Error(serr.Error())
+		}
+		r.reject("errors found in the configuration")
+		return
+	}
+
+	settings, err := flattenConfig(cfg)
+	if err != nil {
+		r.reject(err.Error())
+		return
+	}
+	changes := diffSettings(r.settings, settings)
+	if len(changes) == 0 {
+		log.WithFields(log.Fields{
+			"_block":  "reload-config",
+			"_module": logModule,
+		}).Info("configuration unchanged")
+		return
+	}
+	restartRequired := false
+	for _, c := range changes {
+		if !c.reloadable() {
+			restartRequired = true
+			log.WithFields(c.fields()).Error("setting cannot be changed without restarting snapteld")
+			continue
+		}
+		log.WithFields(c.fields()).Info("setting changed")
+	}
+	if restartRequired {
+		r.reject("some of the changed settings require a restart")
+		return
+	}
+
+	// keep the password given interactively when snapteld started
+	if cfg.RestAPI.RestAuth && cfg.RestAPI.RestAuthPassword == "" {
+		cfg.RestAPI.RestAuthPassword = r.cfg.RestAPI.RestAuthPassword
+	}
+	if cfg.RestAPI.RestAuth && cfg.RestAPI.RestAuthPassword == "" && len(cfg.RestAPI.Tokens) == 0 && len(cfg.RestAPI.ClientCerts) == 0 {
+		r.reject("REST API authentication requires a password, tokens or client certificates")
+		return
+	}
+	if err := r.apply(cfg); err != nil {
+		r.reject(err.Error())
+		return
+	}
+	r.settings = settings
+	for _, c := range changes {
+		audit.Record(audit.Entry{
+			Actor:     "signal",
+			Operation: "config.reload",
+			Target:    c.setting,
+			Outcome:   audit.OutcomeSuccess,
+		})
+	}
+	log.WithFields(log.Fields{
+		"_block":  "reload-config",
+		"_module": logModule,
+		"changes": len(changes),
+	}).Info("configuration reloaded")
+}
+
+// apply changes the reloadable settings of the running modules
+func (r *configReloader) apply(cfg *Config) error {
+	if r.rest != nil {
+		if err := r.rest.ReloadConfig(cfg.RestAPI); err != nil {
+			return err
+		}
+		if cfg.RestAPI.RestAuth && !cfg.RestAPI.HTTPS {
+			log.Warning("Using REST API authentication without HTTPS enabled.")
+		}
+		r.cfg.RestAPI.Corsd = cfg.RestAPI.Corsd
+		r.cfg.RestAPI.RestAuth = cfg.RestAPI.RestAuth
+		r.cfg.RestAPI.RestAuthPassword = cfg.RestAPI.RestAuthPassword
+		r.cfg.RestAPI.Tokens = cfg.RestAPI.Tokens
+		r.cfg.RestAPI.ClientCerts = cfg.RestAPI.ClientCerts
+	}
+	// the control module updates its config, which is also r.cfg.Control, in place
+	if err := r.control.ReloadConfig(cfg.Control); err != nil {
+		return err
+	}
+	r.scheduler.ReloadConfig(cfg.Scheduler)
+	r.cfg.Scheduler.WorkManagerQueueSize = cfg.Scheduler.WorkManagerQueueSize
+	r.cfg.Scheduler.WorkManagerPoolSize = cfg.Scheduler.WorkManagerPoolSize
+
+	log.SetLevel(getLevel(cfg.LogLevel))
+	r.cfg.LogLevel = cfg.LogLevel
+	return nil
+}
+
+func (r *configReloader) reject(reason string) {
+	audit.Record(audit.Entry{
+		Actor:     "signal",
+		Operation: "config.reload",
+		Target:    r.ctx.String("config"),
+		Outcome:   audit.OutcomeFailure,
+		Error:     reason,
+	})
+	log.WithFields(log.Fields{
+		"_block":  "reload-config",
+		"_module": logModule,
+		"reason":  reason,
+	}).Error("configuration not reloaded")
+}
+
+// diffSettings returns the settings whose values differ between two configurations
+func diffSettings(oldSettings, newSettings map[string]interface{}) []configChange {
+	keys := []string{}
+	for k := range oldSettings {
+		keys = append(keys, k)
+	}
+	for k := range newSettings {
+		if _, ok := oldSettings[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	changes := []configChange{}
+	for _, k := range keys {
+		if !reflect.DeepEqual(oldSettings[k], newSettings[k]) {
+			changes = append(changes, configChange{setting: k, old: oldSettings[k], new: newSettings[k]})
+		}
+	}
+	return changes
+}
+
+// flattenConfig returns the settings of the configuration keyed by their
+// dotted path, e.g. restapi.port
+func flattenConfig(cfg *Config) (map[string]interface{}, error) {
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	settings := map[string]interface{}{}
+	flattenSettings("", v, settings)
+	return settings, nil
+}
+
+func flattenSettings(prefix string, v interface{}, settings map[string]interface{}) {
+	if m, ok := v.(map[string]interface{}); ok && len(m) > 0 {
+		for k, val := range m {
+			if prefix != "" {
+				k = prefix + "." + k
+			}
+			flattenSettings(k, val, settings)
+		}
+		return
+	}
+	settings[prefix] = v
+}
@@ -0,0 +1,134 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/intelsdi-x/snap/pkg/schedule"
+)
+
+const (
+	// adaptiveBusyRatio is the share of the effective interval a firing may
+	// take before the interval is stretched
+	adaptiveBusyRatio = 0.8
+	// adaptiveSaturation is the share of the collect queue filled above which
+	// the queue is saturated
+	adaptiveSaturation = 0.8
+	// adaptiveMaxFactor caps the effective interval to a multiple of the interval
+	adaptiveMaxFactor = 8
+	// adaptiveRecovery is the inverse of the share of the stretch given back
+	// on each firing keeping up with the interval
+	adaptiveRecovery = 4
+)
+
+// reportsSaturation is implemented by the work managers able to tell how full
+// their collect queue is
+type reportsSaturation interface {
+	collectSaturation() float64
+}
+
+// scheduleInterval returns the interval of the schedules firing on one, or 0
+func scheduleInterval(s schedule.Schedule) time.Duration {
+	if sch, ok := s.(*schedule.WindowedSchedule); ok {
+		return sch.Interval
+	}
+	return 0
+}
+
+// AdaptiveInterval returns whether the task stretches its interval while its
+// collection cannot keep up with it
+func (t *task) AdaptiveInterval() bool {
+	return t.adaptiveInterval
+}
+
+func (t *task) SetAdaptiveInterval(v bool) {
+	t.adaptiveInterval = v
+	if !v {
+		t.intervalStretch = 0
+	}
+}
+
+// EffectiveInterval returns the interval the task fires on, stretched while
+// its collection cannot keep up, or 0 when its schedule has no interval
+func (t *task) EffectiveInterval() time.Duration {
+	interval := scheduleInterval(t.schedule)
+	if interval <= 0 {
+		return 0
+	}
+	return interval + t.intervalStretch
+}
+
+// saturated returns whether the collect queue of the work manager is
+// saturated, or the last firing was refused or shed by it
+func (t *task) saturated() bool {
+	if t.lastFailureTime == t.lastFireTime && (t.lastFailureMessage == errLimitExceeded.Error() || t.lastFailureMessage == errJobShed.Error()) {
+		return true
+	}
+	if m, ok := t.manager.(reportsSaturation); ok {
+		return m.collectSaturation() >= adaptiveSaturation
+	}
+	return false
+}
+
+// adaptInterval updates the stretch of the interval of an adaptive task after
+// a firing which took d. The interval doubles, up to adaptiveMaxFactor times
+// the interval of the schedule, while the firings take most of it or the
+// collect queue is saturated, and shrinks back gradually once they keep up.
+func (t *task) adaptInterval(d time.Duration) {
+	t.Lock()
+	defer t.Unlock()
+	interval := scheduleInterval(t.schedule)
+	if !t.adaptiveInterval || interval <= 0 {
+		t.intervalStretch = 0
+		return
+	}
+	stretch := t.intervalStretch
+	saturated := t.saturated()
+	if saturated || d >= time.Duration(float64(interval+stretch)*adaptiveBusyRatio) {
+		stretch = interval + 2*stretch
+		if max := (adaptiveMaxFactor - 1) * interval; stretch > max {
+			stretch = max
+		}
+	} else if stretch > 0 {
+		shrunk := stretch - stretch/adaptiveRecovery
+		if shrunk < interval/10 {
+			shrunk = 0
+		}
+		// hold the stretch while the firings would take most of the shrunk interval
+		if d < time.Duration(float64(interval+shrunk)*adaptiveBusyRatio) {
+			stretch = shrunk
+		}
+	}
+	if stretch == t.intervalStretch {
+		return
+	}
+	taskLogger.WithFields(log.Fields{
+		"_block":             "adapt-interval",
+		"task-id":            t.id,
+		"task-name":          t.name,
+		"duration":           d,
+		"saturated":          saturated,
+		"effective-interval": interval + stretch,
+	}).Debug("effective interval changed")
+	t.intervalStretch = stretch
+}
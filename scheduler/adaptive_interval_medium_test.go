@@ -0,0 +1,101 @@
+// +build medium
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/intelsdi-x/snap/pkg/schedule"
+)
+
+type mockSaturatedManager struct {
+	saturation float64
+}
+
+func (m *mockSaturatedManager) Work(j job) queuedJob {
+	return newQueuedJob(j)
+}
+
+func (m *mockSaturatedManager) collectSaturation() float64 {
+	return m.saturation
+}
+
+func TestAdaptiveInterval(t *testing.T) {
+	Convey("Given an adaptive task with an interval of a second", t, func() {
+		m := &mockSaturatedManager{}
+		tsk := &task{
+			schedule:         schedule.NewWindowedSchedule(time.Second, nil, nil, 0),
+			manager:          m,
+			adaptiveInterval: true,
+		}
+		So(tsk.EffectiveInterval(), ShouldEqual, time.Second)
+
+		Convey("runs keeping up with the interval do not stretch it", func() {
+			tsk.adaptInterval(500 * time.Millisecond)
+			So(tsk.EffectiveInterval(), ShouldEqual, time.Second)
+		})
+		Convey("slow runs double the interval up to its cap", func() {
+			tsk.adaptInterval(900 * time.Millisecond)
+			So(tsk.EffectiveInterval(), ShouldEqual, 2*time.Second)
+			tsk.adaptInterval(1800 * time.Millisecond)
+			So(tsk.EffectiveInterval(), ShouldEqual, 4*time.Second)
+			for i := 0; i < 3; i++ {
+				tsk.adaptInterval(10 * time.Second)
+			}
+			So(tsk.EffectiveInterval(), ShouldEqual, adaptiveMaxFactor*time.Second)
+
+			Convey("and it shrinks back gradually once they keep up", func() {
+				tsk.adaptInterval(100 * time.Millisecond)
+				So(tsk.EffectiveInterval(), ShouldEqual, 1000*time.Millisecond+5250*time.Millisecond)
+				for i := 0; i < 20; i++ {
+					tsk.adaptInterval(100 * time.Millisecond)
+				}
+				So(tsk.EffectiveInterval(), ShouldEqual, time.Second)
+			})
+			Convey("but not while the runs would take most of the shrunk interval", func() {
+				tsk.adaptInterval(5500 * time.Millisecond)
+				So(tsk.EffectiveInterval(), ShouldEqual, adaptiveMaxFactor*time.Second)
+			})
+		})
+		Convey("a saturated collect queue stretches the interval", func() {
+			m.saturation = 0.9
+			tsk.adaptInterval(100 * time.Millisecond)
+			So(tsk.EffectiveInterval(), ShouldEqual, 2*time.Second)
+		})
+		Convey("a run refused by the collect queue stretches the interval", func() {
+			tsk.lastFireTime = time.Now()
+			tsk.RecordFailure([]error{&queuingError{Err: errLimitExceeded}})
+			tsk.adaptInterval(time.Millisecond)
+			So(tsk.EffectiveInterval(), ShouldEqual, 2*time.Second)
+		})
+		Convey("the interval is not stretched once the task is not adaptive", func() {
+			tsk.adaptInterval(2 * time.Second)
+			tsk.SetAdaptiveInterval(false)
+			So(tsk.EffectiveInterval(), ShouldEqual, time.Second)
+			tsk.adaptInterval(2 * time.Second)
+			So(tsk.EffectiveInterval(), ShouldEqual, time.Second)
+		})
+	})
+}
@@ -0,0 +1,322 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/intelsdi-x/snap/control/plugin"
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/cdata"
+	"github.com/intelsdi-x/snap/pkg/metricfilter"
+	"github.com/intelsdi-x/snap/scheduler/wmap"
+)
+
+var (
+	// ErrAggregateWindowInvalid - The error message for an aggregate node without a window
+	ErrAggregateWindowInvalid = errors.New("Aggregate window must be greater than 0")
+	// ErrAggregateSlideInvalid - The error message for a sliding window which slides by more than its length
+	ErrAggregateSlideInvalid = errors.New("Aggregate slide must be greater than 0 and less than or equal to the window")
+	// ErrAggregateNoFunctions - The error message for an aggregate node without functions
+	ErrAggregateNoFunctions = errors.New("Aggregate node requires at least one function")
+)
+
+// aggregateFunc computes an aggregate of the sorted values of a window
+type aggregateFunc struct {
+	name string
+	fn   func(values []float64) float64
+	// keepUnit is false for aggregates whose unit is not the unit of the values
+	keepUnit bool
+}
+
+var aggregateFuncs = map[string]aggregateFunc{
+	"min":   {"min", func(v []float64) float64 { return v[0] }, true},
+	"max":   {"max", func(v []float64) float64 { return v[len(v)-1] }, true},
+	"sum":   {"sum", sumValues, true},
+	"mean":  {"mean", func(v []float64) float64 { return sumValues(v) / float64(len(v)) }, true},
+	"count": {"count", func(v []float64) float64 { return float64(len(v)) }, false},
+}
+
+func sumValues(values []float64) float64 {
+	var s float64
+	for _, v := range values {
+		s += v
+	}
+	return s
+}
+
+// parseAggregateFunc returns the aggregate function of the given name.
+// Percentiles are named pNN, like p95, and use the nearest-rank method.
+func parseAggregateFunc(name string) (aggregateFunc, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if f, ok := aggregateFuncs[name]; ok {
+		return f, nil
+	}
+	if strings.HasPrefix(name, "p") {
+		p, err := strconv.ParseFloat(name[1:], 64)
+		if err == nil && p > 0 && p <= 100 {
+			return aggregateFunc{
+				name: name,
+				fn: func(v []float64) float64 {
+					rank := int(math.Ceil(p / 100 * float64(len(v))))
+					return v[rank-1]
+				},
+				keepUnit: true,
+			}, nil
+		}
+	}
+	return aggregateFunc{}, fmt.Errorf("Unknown aggregate function '%s'", name)
+}
+
+type aggregateSample struct {
+	value float64
+	at    time.Time
+}
+
+// aggregateSeries holds the samples of the metrics sharing a namespace and tags
+type aggregateSeries struct {
+	metric  core.Metric
+	samples []aggregateSample
+}
+
+// aggregator buffers the numeric metrics of its parent node across the runs
+// of a task and emits their aggregates once a window is complete. A tumbling
+// window (no slide) emits the aggregates of each consecutive window, while a
+// sliding window emits the aggregates of the last window every slide.
+// Aggregates are emitted on the first run after a window is complete.
+type aggregator struct {
+	sync.Mutex
+	window    time.Duration
+	slide     time.Duration
+	functions []aggregateFunc
+	series    map[string]*aggregateSeries
+	// start of the current window (tumbling) or time of the last emission (sliding)
+	start time.Time
+}
+
+// newAggregator validates an aggregate node of a workflow map
+func newAggregator(a wmap.AggregateWorkflowMapNode) (*aggregator, error) {
+	ag := &aggregator{series: map[string]*aggregateSeries{}}
+	var err error
+	if ag.window, err = time.ParseDuration(a.Window); err != nil {
+		return nil, fmt.Errorf("%v (while parsing aggregate 'window')", err)
+	}
+	if ag.window <= 0 {
+		return nil, ErrAggregateWindowInvalid
+	}
+	if a.Slide != "" {
+		if ag.slide, err = time.ParseDuration(a.Slide); err != nil {
+			return nil, fmt.Errorf("%v (while parsing aggregate 'slide')", err)
+		}
+		if ag.slide <= 0 || ag.slide > ag.window {
+			return nil, ErrAggregateSlideInvalid
+		}
+	}
+	if len(a.Functions) == 0 {
+		return nil, ErrAggregateNoFunctions
+	}
+	for _, name := range a.Functions {
+		f, err := parseAggregateFunc(name)
+		if err != nil {
+			return nil, err
+		}
+		ag.functions = append(ag.functions, f)
+	}
+	return ag, nil
+}
+
+func (a *aggregator) String() string {
+	names := make([]string, len(a.functions))
+	for i, f := range a.functions {
+		names[i] = f.name
+	}
+	out := fmt.Sprintf("window=%v", a.window)
+	if a.slide > 0 {
+		out += fmt.Sprintf(" slide=%v", a.slide)
+	}
+	return out + fmt.Sprintf(" functions=%s", strings.Join(names, ","))
+}
+
+// add buffers the given metrics received at the given time and returns the
+// aggregates of the window which is complete, if any
+func (a *aggregator) add(metrics []core.Metric, now time.Time) []core.Metric {
+	a.Lock()
+	defer a.Unlock()
+	if a.start.IsZero() {
+		a.start = now
+	}
+	var aggregates []core.Metric
+	if a.slide == 0 {
+		// the new metrics belong to the next window
+		if end := a.start.Add(a.window); !now.Before(end) {
+			aggregates = a.aggregate(end)
+			a.series = map[string]*aggregateSeries{}
+			a.start = end.Add(now.Sub(end) / a.window * a.window)
+		}
+		a.append(metrics, now)
+		return aggregates
+	}
+	a.append(metrics, now)
+	if now.Sub(a.start) >= a.slide {
+		a.prune(now)
+		aggregates = a.aggregate(now)
+		a.start = now
+	}
+	return aggregates
+}
+
+func (a *aggregator) append(metrics []core.Metric, now time.Time) {
+	for _, m := range metrics {
+		v, ok := metricfilter.ToFloat(m.Data())
+		if !ok {
+			continue
+		}
+		key := seriesKey(m)
+		s, ok := a.series[key]
+		if !ok {
+			s = &aggregateSeries{}
+			a.series[key] = s
+		}
+		s.metric = m
+		s.samples = append(s.samples, aggregateSample{value: v, at: now})
+	}
+}
+
+// prune drops the samples which are out of the sliding window ending now
+func (a *aggregator) prune(now time.Time) {
+	for key, s := range a.series {
+		i := 0
+		for i < len(s.samples) && now.Sub(s.samples[i].at) >= a.window {
+			i++
+		}
+		s.samples = s.samples[i:]
+		if len(s.samples) == 0 {
+			delete(a.series, key)
+		}
+	}
+}
+
+// aggregate computes the aggregates of each series, timestamped with the end
+// of the window. The namespace of an aggregate is the namespace of its series
+// followed by the name of the function.
+func (a *aggregator) aggregate(end time.Time) []core.Metric {
+	keys := make([]string, 0, len(a.series))
+	for key := range a.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	aggregates := make([]core.Metric, 0, len(keys)*len(a.functions))
+	for _, key := range keys {
+		s := a.series[key]
+		values := make([]float64, len(s.samples))
+		for i, sample := range s.samples {
+			values[i] = sample.value
+		}
+		sort.Float64s(values)
+		for _, f := range a.functions {
+			ns := make(core.Namespace, len(s.metric.Namespace()), len(s.metric.Namespace())+1)
+			copy(ns, s.metric.Namespace())
+			mt := plugin.MetricType{
+				Namespace_:   ns.AddStaticElement(f.name),
+				Version_:     s.metric.Version(),
+				Data_:        f.fn(values),
+				Tags_:        s.metric.Tags(),
+				Description_: s.metric.Description(),
+				Timestamp_:   end,
+			}
+			if f.keepUnit {
+				mt.Unit_ = s.metric.Unit()
+			}
+			aggregates = append(aggregates, mt)
+		}
+	}
+	return aggregates
+}
+
+// seriesKey identifies a metric by its namespace and tags
+func seriesKey(m core.Metric) string {
+	var buf bytes.Buffer
+	buf.WriteString(m.Namespace().String())
+	keys := make([]string, 0, len(m.Tags()))
+	for k := range m.Tags() {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&buf, ";%s=%s", k, m.Tags()[k])
+	}
+	return buf.String()
+}
+
+// convertAggregateNode converts the aggregate nodes of a workflow map into
+// process nodes which aggregate the metrics instead of calling a plugin
+func convertAggregateNode(ag []wmap.AggregateWorkflowMapNode) ([]*processNode, error) {
+	agNodes := make([]*processNode, len(ag))
+	for i, a := range ag {
+		aggregator, err := newAggregator(a)
+		if err != nil {
+			return nil, err
+		}
+		prC, err := convertProcessNode(a.Process)
+		if err != nil {
+			return nil, err
+		}
+		puC, err := convertPublishNode(a.Publish)
+		if err != nil {
+			return nil, err
+		}
+		filter, err := newFilter(a.Filter)
+		if err != nil {
+			return nil, err
+		}
+		agNodes[i] = &processNode{
+			name:         "aggregate",
+			version:      -1,
+			config:       cdata.NewNode(),
+			ProcessNodes: prC,
+			PublishNodes: puC,
+			filter:       filter,
+			aggregator:   aggregator,
+		}
+	}
+	return agNodes, nil
+}
+
+// aggregateJob is the parent job of the children of an aggregate node
+type aggregateJob struct {
+	job
+	metrics []core.Metric
+}
+
+func (a *aggregateJob) Metrics() []core.Metric {
+	return a.metrics
+}
+
+func (a *aggregateJob) TypeString() string {
+	return "aggregate"
+}
@@ -0,0 +1,147 @@
+// +build medium
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/intelsdi-x/snap/control/plugin"
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/pkg/schedule"
+	"github.com/intelsdi-x/snap/scheduler/wmap"
+)
+
+func newSample(data interface{}, host string) core.Metric {
+	return plugin.MetricType{
+		Namespace_: core.NewNamespace("intel", "load"),
+		Data_:      data,
+		Tags_:      map[string]string{"host": host},
+		Unit_:      "%",
+	}
+}
+
+// aggregates maps the namespace and host of aggregates to their data
+func aggregates(mts []core.Metric) map[string]interface{} {
+	out := map[string]interface{}{}
+	for _, m := range mts {
+		out[m.Tags()["host"]+m.Namespace().String()] = m.Data()
+	}
+	return out
+}
+
+func TestAggregator(t *testing.T) {
+	Convey("Aggregator", t, func() {
+		start := time.Now()
+		Convey("emits the aggregates of tumbling windows", func() {
+			ag, err := newAggregator(wmap.AggregateWorkflowMapNode{Window: "1m", Functions: []string{"min", "max", "mean", "sum", "count", "p50"}})
+			So(err, ShouldBeNil)
+			So(ag.add([]core.Metric{newSample(1, "a"), newSample(10, "b")}, start), ShouldBeEmpty)
+			So(ag.add([]core.Metric{newSample(3, "a"), newSample("n/a", "b")}, start.Add(20*time.Second)), ShouldBeEmpty)
+			So(ag.add([]core.Metric{newSample(8, "a")}, start.Add(40*time.Second)), ShouldBeEmpty)
+			mts := ag.add([]core.Metric{newSample(100, "a")}, start.Add(time.Minute))
+			So(aggregates(mts), ShouldResemble, map[string]interface{}{
+				"a/intel/load/min": 1.0, "a/intel/load/max": 8.0, "a/intel/load/mean": 4.0,
+				"a/intel/load/sum": 12.0, "a/intel/load/count": 3.0, "a/intel/load/p50": 3.0,
+				"b/intel/load/min": 10.0, "b/intel/load/max": 10.0, "b/intel/load/mean": 10.0,
+				"b/intel/load/sum": 10.0, "b/intel/load/count": 1.0, "b/intel/load/p50": 10.0,
+			})
+			So(mts[0].Timestamp(), ShouldResemble, start.Add(time.Minute))
+			So(mts[0].Unit(), ShouldEqual, "%")
+			So(mts[4].Namespace().String(), ShouldEqual, "/intel/load/count")
+			So(mts[4].Unit(), ShouldEqual, "")
+
+			Convey("starting the next window with the metrics which complete a window", func() {
+				mts := ag.add(nil, start.Add(2*time.Minute))
+				So(aggregates(mts), ShouldContainKey, "a/intel/load/max")
+				So(aggregates(mts)["a/intel/load/max"], ShouldEqual, 100.0)
+				So(aggregates(mts), ShouldNotContainKey, "b/intel/load/max")
+			})
+		})
+		Convey("emits the aggregates of the last window every slide", func() {
+			ag, err := newAggregator(wmap.AggregateWorkflowMapNode{Window: "30s", Slide: "10s", Functions: []string{"max", "count"}})
+			So(err, ShouldBeNil)
+			So(ag.add([]core.Metric{newSample(5, "a")}, start), ShouldBeEmpty)
+			So(ag.add([]core.Metric{newSample(2, "a")}, start.Add(5*time.Second)), ShouldBeEmpty)
+			mts := ag.add([]core.Metric{newSample(3, "a")}, start.Add(10*time.Second))
+			So(aggregates(mts), ShouldResemble, map[string]interface{}{"a/intel/load/max": 5.0, "a/intel/load/count": 3.0})
+			mts = ag.add([]core.Metric{newSample(1, "a")}, start.Add(30*time.Second))
+			So(aggregates(mts), ShouldResemble, map[string]interface{}{"a/intel/load/max": 3.0, "a/intel/load/count": 3.0})
+		})
+		Convey("rejects invalid aggregate nodes", func() {
+			_, err := newAggregator(wmap.AggregateWorkflowMapNode{Functions: []string{"max"}})
+			So(err, ShouldNotBeNil)
+			_, err = newAggregator(wmap.AggregateWorkflowMapNode{Window: "0s", Functions: []string{"max"}})
+			So(err, ShouldEqual, ErrAggregateWindowInvalid)
+			_, err = newAggregator(wmap.AggregateWorkflowMapNode{Window: "10s", Slide: "1m", Functions: []string{"max"}})
+			So(err, ShouldEqual, ErrAggregateSlideInvalid)
+			_, err = newAggregator(wmap.AggregateWorkflowMapNode{Window: "10s"})
+			So(err, ShouldEqual, ErrAggregateNoFunctions)
+			_, err = newAggregator(wmap.AggregateWorkflowMapNode{Window: "10s", Functions: []string{"p101"}})
+			So(err, ShouldNotBeNil)
+			_, err = newAggregator(wmap.AggregateWorkflowMapNode{Window: "10s", Functions: []string{"median"}})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestWorkflowAggregate(t *testing.T) {
+	Convey("Given a scheduler", t, func() {
+		s := New(GetDefaultConfig())
+		mm := &mockRoutingPublisher{mockMetricManager: newMockMetricManager(), published: map[string][]string{}}
+		s.SetMetricManager(mm)
+		So(s.Start(), ShouldBeNil)
+		sch := schedule.NewWindowedSchedule(20*time.Millisecond, nil, nil, 0)
+
+		Convey("aggregates are published once a window is complete", func() {
+			w := wmap.NewWorkflowMap()
+			w.Collect.AddMetric("/intel/*", 1)
+			ag := wmap.NewAggregateNode("100ms", "max")
+			ag.Filter = `namespace == "/intel/cpu/**"`
+			ag.Add(wmap.NewPublishNode("aggregate-publisher", -1))
+			w.Collect.Add(ag)
+
+			tsk, errs := s.CreateTask(sch, w, false)
+			So(errs.Errors(), ShouldBeEmpty)
+			task := s.tasks.Get(tsk.ID())
+			task.Spin()
+			time.Sleep(300 * time.Millisecond)
+			task.Stop()
+
+			published := mm.get("aggregate-publisher")
+			So(published, ShouldNotBeEmpty)
+			So(published, ShouldContain, "/intel/cpu/0/idle/max")
+			So(published, ShouldNotContain, "/intel/disk/sda/reads/max")
+			So(tsk.FailedCount(), ShouldEqual, 0)
+		})
+		Convey("a task with an invalid aggregate node is rejected", func() {
+			w := wmap.NewWorkflowMap()
+			w.Collect.AddMetric("/intel/*", 1)
+			w.Collect.Add(wmap.NewAggregateNode("1m", "mode"))
+			_, errs := s.CreateTask(sch, w, false)
+			So(errs.Errors(), ShouldNotBeEmpty)
+			So(errs.Errors()[0].Error(), ShouldStartWith, "Unknown aggregate function")
+		})
+	})
+}
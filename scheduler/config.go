@@ -22,21 +22,41 @@ package scheduler
 import (
 	"encoding/json"
 	"fmt"
+
+	"github.com/intelsdi-x/snap/core"
 )
 
 // default configuration values
 const (
-	defaultWorkManagerQueueSize uint = 25
-	defaultWorkManagerPoolSize  uint = 4
+	defaultWorkManagerQueueSize uint   = 25
+	defaultWorkManagerPoolSize  uint   = 4
+	defaultTaskStorePath        string = ""
+	defaultDeadLetterPath       string = ""
+	defaultPublishBufferPath    string = ""
 )
 
+// defaultPriorityWeights returns the default weights of the task priority classes
+func defaultPriorityWeights() map[string]uint {
+	return map[string]uint{
+		core.TaskPriorityHigh:   4,
+		core.TaskPriorityNormal: 2,
+		core.TaskPriorityLow:    1,
+	}
+}
+
 // holds the configuration passed in through the SNAP config file
 //   Note: if this struct is modified, then the switch statement in the
 //         UnmarshalJSON method in this same file needs to be modified to
 //         match the field mapping that is defined here
 type Config struct {
-	WorkManagerQueueSize uint `json:"work_manager_queue_size"yaml:"work_manager_queue_size"`
-	WorkManagerPoolSize  uint `json:"work_manager_pool_size"yaml:"work_manager_pool_size"`
+	WorkManagerQueueSize uint   `json:"work_manager_queue_size"yaml:"work_manager_queue_size"`
+	WorkManagerPoolSize  uint   `json:"work_manager_pool_size"yaml:"work_manager_pool_size"`
+	TaskStorePath        string `json:"task_store_path"yaml:"task_store_path"`
+	DeadLetterPath       string `json:"dead_letter_path"yaml:"dead_letter_path"`
+	PublishBufferPath    string `json:"publish_buffer_path"yaml:"publish_buffer_path"`
+	// PriorityWeights are the weights of the task priority classes in the
+	// work manager queues
+	PriorityWeights map[string]uint `json:"priority_weights"yaml:"priority_weights"`
 }
 
 const (
@@ -51,6 +71,33 @@ const (
 					"work_manager_pool_size" : {
 						"type": "integer",
 						"minimum": 1
+					},
+					"task_store_path" : {
+						"type": "string"
+					},
+					"dead_letter_path" : {
+						"type": "string"
+					},
+					"publish_buffer_path" : {
+						"type": "string"
+					},
+					"priority_weights" : {
+						"type": ["object", "null"],
+						"properties" : {
+							"high" : {
+								"type": "integer",
+								"minimum": 1
+							},
+							"normal" : {
+								"type": "integer",
+								"minimum": 1
+							},
+							"low" : {
+								"type": "integer",
+								"minimum": 1
+							}
+						},
+						"additionalProperties": false
 					}
 				},
 				"additionalProperties": false
@@ -63,6 +110,10 @@ func GetDefaultConfig() *Config {
 	return &Config{
 		WorkManagerQueueSize: defaultWorkManagerQueueSize,
 		WorkManagerPoolSize:  defaultWorkManagerPoolSize,
+		TaskStorePath:        defaultTaskStorePath,
+		DeadLetterPath:       defaultDeadLetterPath,
+		PublishBufferPath:    defaultPublishBufferPath,
+		PriorityWeights:      defaultPriorityWeights(),
 	}
 }
 
@@ -88,6 +139,26 @@ func (c *Config) UnmarshalJSON(data []byte) error {
 			if err := json.Unmarshal(v, &(c.WorkManagerPoolSize)); err != nil {
 				return fmt.Errorf("%v (while parsing 'scheduler::work_manager_pool_size')", err)
 			}
+		case "task_store_path":
+			if err := json.Unmarshal(v, &(c.TaskStorePath)); err != nil {
+				return fmt.Errorf("%v (while parsing 'scheduler::task_store_path')", err)
+			}
+		case "dead_letter_path":
+			if err := json.Unmarshal(v, &(c.DeadLetterPath)); err != nil {
+				return fmt.Errorf("%v (while parsing 'scheduler::dead_letter_path')", err)
+			}
+		case "publish_buffer_path":
+			if err := json.Unmarshal(v, &(c.PublishBufferPath)); err != nil {
+				return fmt.Errorf("%v (while parsing 'scheduler::publish_buffer_path')", err)
+			}
+		case "priority_weights":
+			// the weights given are merged into the default ones
+			if c.PriorityWeights == nil {
+				c.PriorityWeights = defaultPriorityWeights()
+			}
+			if err := json.Unmarshal(v, &(c.PriorityWeights)); err != nil {
+				return fmt.Errorf("%v (while parsing 'scheduler::priority_weights')", err)
+			}
 		default:
 			return fmt.Errorf("Unrecognized key '%v' in global config file while parsing 'scheduler'", k)
 		}
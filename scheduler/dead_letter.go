@@ -0,0 +1,226 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pborman/uuid"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/intelsdi-x/snap/control/plugin"
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/cdata"
+	"github.com/intelsdi-x/snap/core/ctypes"
+)
+
+const (
+	deadLetterExt = ".json"
+)
+
+var (
+	deadLetterLogger = schedulerLogger.WithField("_module", "scheduler-dead-letter")
+
+	// ErrDeadLetterSpoolNotSet - The error message for replaying a task when no dead-letter spool is configured
+	ErrDeadLetterSpoolNotSet = errors.New("Dead-letter spool is not configured")
+)
+
+// DeadLetterSpool keeps the metrics which could not be published once all
+// the attempts allowed by the retry policy of a publisher failed, so they
+// can be published again later. Implementations must be safe for concurrent use.
+type DeadLetterSpool interface {
+	// Add spools a dead letter
+	Add(*DeadLetter) error
+	// List returns the dead letters of a task, oldest first
+	List(taskID string) ([]*DeadLetter, error)
+	// Remove deletes a dead letter from the spool
+	Remove(*DeadLetter) error
+}
+
+// DeadLetter is a batch of metrics a publisher failed to publish.
+type DeadLetter struct {
+	ID            string                `json:"id"`
+	TaskID        string                `json:"task_id"`
+	PluginName    string                `json:"plugin_name"`
+	PluginVersion int                   `json:"plugin_version"`
+	Target        string                `json:"target,omitempty"`
+	Config        *cdata.ConfigDataNode `json:"config"`
+	Metrics       []plugin.MetricType   `json:"metrics"`
+	Attempts      int                   `json:"attempts"`
+	Errors        []string              `json:"errors"`
+	Timestamp     time.Time             `json:"timestamp"`
+}
+
+// newDeadLetter returns the dead letter for the metrics of the parent job
+// the publish node failed to publish
+func newDeadLetter(pj job, t *task, pu *publishNode, attempts int, errs []error) *DeadLetter {
+	now := time.Now()
+	dl := &DeadLetter{
+		// IDs sort in the order the dead letters were created
+		ID:            fmt.Sprintf("%020d-%s", now.UnixNano(), uuid.New()),
+		TaskID:        t.id,
+		PluginName:    pu.Name(),
+		PluginVersion: pu.Version(),
+		Target:        pu.Target,
+		Config:        pu.Config(),
+		Metrics:       toMetricTypes(pj.Metrics()),
+		Attempts:      attempts,
+		Timestamp:     now,
+	}
+	for _, e := range errs {
+		dl.Errors = append(dl.Errors, e.Error())
+	}
+	return dl
+}
+
+// replayDeadLetter publishes the metrics of a dead letter again, once, with
+// the publisher which failed to publish them
+func replayDeadLetter(t *task, dl *DeadLetter) []error {
+	mgr, err := t.RemoteManagers.Get(dl.Target)
+	if err != nil {
+		return []error{err}
+	}
+	config := map[string]ctypes.ConfigValue{}
+	if dl.Config != nil {
+		config = dl.Config.Table()
+	}
+	return publishOnce(t, mgr, dl.PluginName, dl.PluginVersion, config, fromMetricTypes(dl.Metrics))
+}
+
+// toMetricTypes converts metrics to a type which can be saved as JSON
+func toMetricTypes(mts []core.Metric) []plugin.MetricType {
+	metrics := make([]plugin.MetricType, len(mts))
+	for i, m := range mts {
+		metrics[i] = plugin.MetricType{
+			Namespace_:          m.Namespace(),
+			LastAdvertisedTime_: m.LastAdvertisedTime(),
+			Version_:            m.Version(),
+			Config_:             m.Config(),
+			Data_:               m.Data(),
+			Tags_:               m.Tags(),
+			Unit_:               m.Unit(),
+			Description_:        m.Description(),
+			Timestamp_:          m.Timestamp(),
+		}
+	}
+	return metrics
+}
+
+func fromMetricTypes(mts []plugin.MetricType) []core.Metric {
+	metrics := make([]core.Metric, len(mts))
+	for i := range mts {
+		metrics[i] = mts[i]
+	}
+	return metrics
+}
+
+// fileDeadLetterSpool is the default DeadLetterSpool. It keeps one JSON
+// document per dead letter in a directory per task.
+type fileDeadLetterSpool struct {
+	sync.Mutex
+	path string
+}
+
+// NewFileDeadLetterSpool returns a DeadLetterSpool keeping its dead letters
+// in the given directory. The directory is created on first use if it does not exist.
+func NewFileDeadLetterSpool(path string) DeadLetterSpool {
+	return &fileDeadLetterSpool{path: path}
+}
+
+func (f *fileDeadLetterSpool) Add(dl *DeadLetter) error {
+	f.Lock()
+	defer f.Unlock()
+	dir := filepath.Join(f.path, dl.TaskID)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	b, err := json.Marshal(dl)
+	if err != nil {
+		return err
+	}
+	// write to a hidden file first so a partially written dead letter is never replayed
+	tmp, err := ioutil.TempFile(dir, "."+dl.ID)
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, dl.ID+deadLetterExt))
+}
+
+func (f *fileDeadLetterSpool) List(taskID string) ([]*DeadLetter, error) {
+	f.Lock()
+	defer f.Unlock()
+	dir := filepath.Join(f.path, taskID)
+	// ReadDir sorts the files by name, which is the order the dead letters were spooled in
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*DeadLetter{}, nil
+		}
+		return nil, err
+	}
+	letters := []*DeadLetter{}
+	for _, file := range files {
+		if file.IsDir() || strings.HasPrefix(file.Name(), ".") || !strings.HasSuffix(file.Name(), deadLetterExt) {
+			continue
+		}
+		b, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		dl := &DeadLetter{}
+		if err := json.Unmarshal(b, dl); err != nil {
+			deadLetterLogger.WithFields(log.Fields{
+				"_block":      "list",
+				"dead-letter": file.Name(),
+				"_error":      err.Error(),
+			}).Error("unable to parse dead letter")
+			continue
+		}
+		letters = append(letters, dl)
+	}
+	return letters, nil
+}
+
+func (f *fileDeadLetterSpool) Remove(dl *DeadLetter) error {
+	f.Lock()
+	defer f.Unlock()
+	err := os.Remove(filepath.Join(f.path, dl.TaskID, dl.ID+deadLetterExt))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
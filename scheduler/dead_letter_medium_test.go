@@ -0,0 +1,222 @@
+// +build medium
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/intelsdi-x/snap/control/plugin"
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/ctypes"
+	"github.com/intelsdi-x/snap/pkg/schedule"
+	"github.com/intelsdi-x/snap/scheduler/wmap"
+)
+
+// mockFlakyPublisher fails the given number of publish calls before succeeding
+type mockFlakyPublisher struct {
+	*mockMetricManager
+	sync.Mutex
+	failures  int
+	attempts  int
+	published [][]core.Metric
+}
+
+func (m *mockFlakyPublisher) CollectMetrics(string, map[string]map[string]string) ([]core.Metric, []error) {
+	return []core.Metric{
+		plugin.MetricType{Namespace_: core.NewNamespace("foo", "bar"), Version_: 1, Data_: 1.5},
+	}, nil
+}
+
+func (m *mockFlakyPublisher) PublishMetrics(mts []core.Metric, _ map[string]ctypes.ConfigValue, _, _ string, _ int) []error {
+	m.Lock()
+	defer m.Unlock()
+	m.attempts++
+	if m.failures > 0 {
+		m.failures--
+		return []error{errors.New("backend unavailable")}
+	}
+	m.published = append(m.published, mts)
+	return nil
+}
+
+func (m *mockFlakyPublisher) setFailures(n int) {
+	m.Lock()
+	defer m.Unlock()
+	m.failures = n
+}
+
+func (m *mockFlakyPublisher) counts() (int, int) {
+	m.Lock()
+	defer m.Unlock()
+	return m.attempts, len(m.published)
+}
+
+func newRetryWorkflowMap(maxAttempts int) *wmap.WorkflowMap {
+	w := wmap.NewWorkflowMap()
+	w.Collect.AddMetric("/foo/bar", 1)
+	pu := wmap.NewPublishNode("file", -1)
+	pu.Retry = &wmap.RetryPolicy{MaxAttempts: maxAttempts, InitialInterval: "10ms", MaxInterval: "20ms"}
+	w.Collect.Add(pu)
+	return w
+}
+
+func TestRetryPolicy(t *testing.T) {
+	Convey("Given a workflow map retry policy", t, func() {
+		Convey("no policy means a single attempt", func() {
+			p, err := newRetryPolicy(nil)
+			So(err, ShouldBeNil)
+			So(p.maxAttempts, ShouldEqual, 1)
+		})
+		Convey("the delay grows up to the max interval", func() {
+			p, err := newRetryPolicy(&wmap.RetryPolicy{MaxAttempts: 5, InitialInterval: "1s", MaxInterval: "3s"})
+			So(err, ShouldBeNil)
+			So(p.backoff(2), ShouldEqual, time.Second)
+			So(p.backoff(3), ShouldEqual, 2*time.Second)
+			So(p.backoff(4), ShouldEqual, 3*time.Second)
+			So(p.backoff(5), ShouldEqual, 3*time.Second)
+		})
+		Convey("the delay stays within the jitter", func() {
+			p, err := newRetryPolicy(&wmap.RetryPolicy{MaxAttempts: 2, InitialInterval: "1s", Jitter: 0.5})
+			So(err, ShouldBeNil)
+			for i := 0; i < 10; i++ {
+				So(p.backoff(2), ShouldBeBetweenOrEqual, 500*time.Millisecond, 1500*time.Millisecond)
+			}
+		})
+		Convey("invalid policies are rejected", func() {
+			_, err := newRetryPolicy(&wmap.RetryPolicy{})
+			So(err, ShouldEqual, ErrRetryMaxAttemptsInvalid)
+			_, err = newRetryPolicy(&wmap.RetryPolicy{MaxAttempts: 2, Multiplier: 0.5})
+			So(err, ShouldEqual, ErrRetryMultiplierInvalid)
+			_, err = newRetryPolicy(&wmap.RetryPolicy{MaxAttempts: 2, Jitter: 2})
+			So(err, ShouldEqual, ErrRetryJitterInvalid)
+			_, err = newRetryPolicy(&wmap.RetryPolicy{MaxAttempts: 2, InitialInterval: "soon"})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestPublishRetry(t *testing.T) {
+	Convey("Given a scheduler with a dead-letter spool", t, func() {
+		dir, err := ioutil.TempDir("", "snap-dead-letter")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		cfg := GetDefaultConfig()
+		cfg.DeadLetterPath = dir
+		s := New(cfg)
+		mm := &mockFlakyPublisher{mockMetricManager: newMockMetricManager()}
+		s.SetMetricManager(mm)
+		So(s.Start(), ShouldBeNil)
+		sch := schedule.NewWindowedSchedule(time.Hour, nil, nil, 0)
+
+		Convey("a publish which fails less than max_attempts times succeeds", func() {
+			mm.setFailures(2)
+			tsk, errs := s.CreateTask(sch, newRetryWorkflowMap(3), false)
+			So(errs.Errors(), ShouldBeEmpty)
+			task := s.tasks.Get(tsk.ID())
+			task.Spin()
+			time.Sleep(500 * time.Millisecond)
+			task.Stop()
+			attempts, published := mm.counts()
+			So(attempts, ShouldEqual, 3)
+			So(published, ShouldEqual, 1)
+			So(tsk.FailedCount(), ShouldEqual, 0)
+			letters, err := s.deadLetters.List(tsk.ID())
+			So(err, ShouldBeNil)
+			So(letters, ShouldBeEmpty)
+		})
+		Convey("a publish which keeps failing is spooled", func() {
+			mm.setFailures(10)
+			tsk, errs := s.CreateTask(sch, newRetryWorkflowMap(2), false)
+			So(errs.Errors(), ShouldBeEmpty)
+			task := s.tasks.Get(tsk.ID())
+			task.Spin()
+			time.Sleep(500 * time.Millisecond)
+			task.Stop()
+			attempts, published := mm.counts()
+			So(attempts, ShouldEqual, 2)
+			So(published, ShouldEqual, 0)
+			So(tsk.FailedCount(), ShouldEqual, 1)
+			letters, err := s.deadLetters.List(tsk.ID())
+			So(err, ShouldBeNil)
+			So(len(letters), ShouldEqual, 1)
+			So(letters[0].PluginName, ShouldEqual, "file")
+			So(letters[0].Attempts, ShouldEqual, 2)
+			So(len(letters[0].Metrics), ShouldEqual, 1)
+			So(letters[0].Metrics[0].Namespace().String(), ShouldEqual, "/foo/bar")
+
+			Convey("and replayed once the publisher recovers", func() {
+				mm.setFailures(0)
+				replayed, err := s.ReplayTask(tsk.ID())
+				So(err, ShouldBeNil)
+				So(replayed, ShouldEqual, 1)
+				_, published := mm.counts()
+				So(published, ShouldEqual, 1)
+				letters, err := s.deadLetters.List(tsk.ID())
+				So(err, ShouldBeNil)
+				So(letters, ShouldBeEmpty)
+			})
+			Convey("and kept when the replay fails", func() {
+				replayed, err := s.ReplayTask(tsk.ID())
+				So(err, ShouldNotBeNil)
+				So(replayed, ShouldEqual, 0)
+				letters, err := s.deadLetters.List(tsk.ID())
+				So(err, ShouldBeNil)
+				So(len(letters), ShouldEqual, 1)
+			})
+		})
+		Convey("a publish waiting to be retried gives up when the task is stopped", func() {
+			mm.setFailures(10)
+			w := newRetryWorkflowMap(2)
+			w.Collect.Publish[0].Retry.InitialInterval = "1h"
+			w.Collect.Publish[0].Retry.MaxInterval = "1h"
+			tsk, errs := s.CreateTask(sch, w, false)
+			So(errs.Errors(), ShouldBeEmpty)
+			task := s.tasks.Get(tsk.ID())
+			task.Spin()
+			time.Sleep(500 * time.Millisecond)
+			stopped := make(chan struct{})
+			go func() {
+				task.Stop()
+				close(stopped)
+			}()
+			select {
+			case <-stopped:
+			case <-time.After(5 * time.Second):
+				t.Fatal("task did not stop while a publish was waiting to be retried")
+			}
+			attempts, _ := mm.counts()
+			So(attempts, ShouldEqual, 1)
+		})
+		Convey("replaying an unknown task fails", func() {
+			_, err := s.ReplayTask("1234")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,82 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"github.com/intelsdi-x/snap/core"
+)
+
+// dependencySource is the source of the state changes of tasks run in reaction
+// to an event of a task they depend on
+const dependencySource = "dependency"
+
+// handleTaskDependencies runs the actions of the tasks depending on the event
+// of the task with the given ID. The actions are run apart from the handling
+// of the event, as they emit events of their own.
+func (s *scheduler) handleTaskDependencies(id, event string) {
+	if s.state != schedulerStarted {
+		return
+	}
+	src := s.tasks.Get(id)
+	if src == nil {
+		return
+	}
+	name := src.GetName()
+	for _, t := range s.tasks.Table() {
+		if t.ID() == id {
+			continue
+		}
+		for _, d := range t.Dependencies() {
+			if d.Matches(id, name, event) {
+				go s.runDependencyAction(t.ID(), id, d)
+			}
+		}
+	}
+}
+
+func (s *scheduler) runDependencyAction(id, sourceID string, d core.TaskDependency) {
+	logger := schedulerLogger.WithFields(log.Fields{
+		"_block":         "task-dependency",
+		"task-id":        id,
+		"source-task-id": sourceID,
+		"event":          d.Event,
+		"action":         d.Action,
+	})
+	var err error
+	switch d.Action {
+	case core.DependencyActionStart:
+		if errs := s.startTask(id, dependencySource); len(errs) > 0 {
+			err = errs[0]
+		}
+	case core.DependencyActionStop:
+		if errs := s.stopTask(id, dependencySource); len(errs) > 0 {
+			err = errs[0]
+		}
+	case core.DependencyActionEnable:
+		_, err = s.EnableTask(id)
+	}
+	if err != nil {
+		logger.WithField("_error", err.Error()).Warn("unable to run the action of a task dependency")
+		return
+	}
+	logger.Info("ran the action of a task dependency")
+}
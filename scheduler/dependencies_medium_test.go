@@ -0,0 +1,77 @@
+// +build medium
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/pkg/schedule"
+)
+
+// waitForState waits up to a second for a task to reach a state
+func waitForState(t core.Task, state core.TaskState) core.TaskState {
+	for i := 0; i < 100 && t.State() != state; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	return t.State()
+}
+
+func TestTaskDependencies(t *testing.T) {
+	s := newScheduler()
+	s.Start()
+	defer s.Stop()
+	w := newMockWorkflowMap()
+
+	Convey("Given a task depending on the end of another task", t, func() {
+		baseline, errs := s.CreateTask(schedule.NewWindowedSchedule(interval, nil, nil, 1), w, false, core.SetTaskName("baseline"))
+		So(errs.Errors(), ShouldBeEmpty)
+		cleanup, errs := s.CreateTask(schedule.NewWindowedSchedule(interval, nil, nil, 0), w, false,
+			core.SetTaskDependencies([]core.TaskDependency{{Task: "baseline", Event: core.DependencyOnEnded, Action: core.DependencyActionStart}}))
+		So(errs.Errors(), ShouldBeEmpty)
+		watcher, errs := s.CreateTask(schedule.NewWindowedSchedule(interval, nil, nil, 0), w, false,
+			core.SetTaskDependencies([]core.TaskDependency{{Task: baseline.ID(), Event: core.DependencyOnDisabled, Action: core.DependencyActionStart}}))
+		So(errs.Errors(), ShouldBeEmpty)
+
+		Convey("the dependent task is started once the task ends", func() {
+			So(s.StartTask(baseline.ID()), ShouldBeEmpty)
+			So(waitForState(baseline, core.TaskEnded), ShouldEqual, core.TaskEnded)
+			So(waitForState(cleanup, core.TaskSpinning), ShouldNotEqual, core.TaskStopped)
+
+			Convey("while the tasks depending on other events are not", func() {
+				So(watcher.State(), ShouldEqual, core.TaskStopped)
+			})
+		})
+		Convey("the dependent task can be stopped by another task stopping", func() {
+			stopper, errs := s.CreateTask(schedule.NewWindowedSchedule(interval, nil, nil, 0), w, false,
+				core.SetTaskDependencies([]core.TaskDependency{{Task: watcher.ID(), Event: core.DependencyOnStopped, Action: core.DependencyActionStop}}))
+			So(errs.Errors(), ShouldBeEmpty)
+			So(s.StartTask(watcher.ID()), ShouldBeEmpty)
+			So(s.StartTask(stopper.ID()), ShouldBeEmpty)
+			So(s.StopTask(watcher.ID()), ShouldBeEmpty)
+			So(waitForState(stopper, core.TaskStopped), ShouldEqual, core.TaskStopped)
+		})
+	})
+}
@@ -38,6 +38,24 @@ var (
 		EnvVar: "WORK_MANAGER_POOL_SIZE",
 	}
 
+	flTaskStorePath = cli.StringFlag{
+		Name:   "task-store-path",
+		Usage:  "Path to a directory where tasks are persisted so they survive restarts (disabled if empty)",
+		EnvVar: "SNAP_TASK_STORE_PATH",
+	}
+
+	flDeadLetterPath = cli.StringFlag{
+		Name:   "dead-letter-path",
+		Usage:  "Path to a directory where metrics which could not be published are spooled for replay (disabled if empty)",
+		EnvVar: "SNAP_DEAD_LETTER_PATH",
+	}
+
+	flPublishBufferPath = cli.StringFlag{
+		Name:   "publish-buffer-path",
+		Usage:  "Path to a directory where publishers with a buffer keep metrics while they fail (buffers disabled if empty)",
+		EnvVar: "SNAP_PUBLISH_BUFFER_PATH",
+	}
+
 	// Flags consumed by snapteld
-	Flags = []cli.Flag{flSchedulerQueueSize, flSchedulerPoolSize}
+	Flags = []cli.Flag{flSchedulerQueueSize, flSchedulerPoolSize, flTaskStorePath, flDeadLetterPath, flPublishBufferPath}
 )
@@ -24,6 +24,7 @@ import (
 	"time"
 
 	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
 
 	"github.com/intelsdi-x/snap/core"
 	"github.com/intelsdi-x/snap/core/cdata"
@@ -86,6 +87,8 @@ type job interface {
 	Type() jobType
 	TypeString() string
 	TaskID() string
+	Priority() string
+	Context() context.Context
 	Run()
 	Metrics() []core.Metric
 }
@@ -97,6 +100,8 @@ type coreJob struct {
 	name      string
 	version   int
 	taskID    string
+	priority  string
+	ctx       context.Context
 	jtype     jobType
 	deadline  time.Time
 	starttime time.Time
@@ -163,6 +168,11 @@ func (c *coreJob) TaskID() string {
 	return c.taskID
 }
 
+// Priority returns the priority class of the task of the job
+func (c *coreJob) Priority() string {
+	return c.priority
+}
+
 type collectorJob struct {
 	*coreJob
 	collector      collectsMetrics
@@ -240,7 +250,19 @@ func (c *collectorJob) Run() {
 		}
 	}
 
-	ret, errs := c.collector.CollectMetrics(c.TaskID(), c.tags)
+	ctx, cancel := c.runContext()
+	defer cancel()
+	if err := contextError(ctx); err != nil {
+		c.AddErrors(err)
+		return
+	}
+	var ret []core.Metric
+	var errs []error
+	if cc, ok := c.collector.(collectsMetricsWithContext); ok {
+		ret, errs = cc.CollectMetricsContext(ctx, c.TaskID(), c.tags)
+	} else {
+		ret, errs = c.collector.CollectMetrics(c.TaskID(), c.tags)
+	}
 
 	log.WithFields(log.Fields{
 		"_module":      "scheduler-job",
@@ -259,6 +281,9 @@ func (c *collectorJob) Run() {
 				"error":    e,
 			}).Error("collector run error")
 		}
+		if err := contextError(ctx); err != nil {
+			errs = append(errs, err)
+		}
 		c.AddErrors(errs...)
 	}
 }
@@ -276,10 +301,14 @@ func (pr *processJob) Metrics() []core.Metric {
 }
 
 func newProcessJob(parentJob job, pluginName string, pluginVersion int, contentType string, config map[string]ctypes.ConfigValue, processor processesMetrics, taskID string) job {
+	cj := newCoreJob(processJobType, parentJob.Deadline(), taskID, pluginName, pluginVersion)
+	// the jobs of a workflow inherit the priority and context of its collect job
+	cj.priority = parentJob.Priority()
+	cj.ctx = parentJob.Context()
 	return &processJob{
 		parentJob: parentJob,
 		metrics:   []core.Metric{},
-		coreJob:   newCoreJob(processJobType, parentJob.Deadline(), taskID, pluginName, pluginVersion),
+		coreJob:   cj,
 		config:    config,
 		processor: processor,
 	}
@@ -295,7 +324,19 @@ func (p *processJob) Run() {
 		"plugin-config":  p.config,
 	}).Debug("starting processor job")
 
-	mts, errs := p.processor.ProcessMetrics(p.parentJob.Metrics(), p.config, p.taskID, p.name, p.version)
+	ctx, cancel := p.runContext()
+	defer cancel()
+	if err := contextError(ctx); err != nil {
+		p.AddErrors(err)
+		return
+	}
+	var mts []core.Metric
+	var errs []error
+	if pc, ok := p.processor.(processesMetricsWithContext); ok {
+		mts, errs = pc.ProcessMetricsContext(ctx, p.parentJob.Metrics(), p.config, p.taskID, p.name, p.version)
+	} else {
+		mts, errs = p.processor.ProcessMetrics(p.parentJob.Metrics(), p.config, p.taskID, p.name, p.version)
+	}
 	if errs != nil {
 		for _, e := range errs {
 			log.WithFields(log.Fields{
@@ -308,6 +349,9 @@ func (p *processJob) Run() {
 				"error":          e.Error(),
 			}).Error("error with processor job")
 		}
+		if err := contextError(ctx); err != nil {
+			errs = append(errs, err)
+		}
 		p.AddErrors(errs...)
 	}
 	p.metrics = mts
@@ -325,10 +369,13 @@ func (pu *publisherJob) Metrics() []core.Metric {
 }
 
 func newPublishJob(parentJob job, pluginName string, pluginVersion int, contentType string, config map[string]ctypes.ConfigValue, publisher publishesMetrics, taskID string) job {
+	cj := newCoreJob(publishJobType, parentJob.Deadline(), taskID, pluginName, pluginVersion)
+	cj.priority = parentJob.Priority()
+	cj.ctx = parentJob.Context()
 	return &publisherJob{
 		parentJob: parentJob,
 		publisher: publisher,
-		coreJob:   newCoreJob(publishJobType, parentJob.Deadline(), taskID, pluginName, pluginVersion),
+		coreJob:   cj,
 		config:    config,
 	}
 }
@@ -343,7 +390,18 @@ func (p *publisherJob) Run() {
 		"plugin-config":  p.config,
 	}).Debug("starting publisher job")
 
-	errs := p.publisher.PublishMetrics(p.parentJob.Metrics(), p.config, p.taskID, p.name, p.version)
+	ctx, cancel := p.runContext()
+	defer cancel()
+	if err := contextError(ctx); err != nil {
+		p.AddErrors(err)
+		return
+	}
+	var errs []error
+	if pc, ok := p.publisher.(publishesMetricsWithContext); ok {
+		errs = pc.PublishMetricsContext(ctx, p.parentJob.Metrics(), p.config, p.taskID, p.name, p.version)
+	} else {
+		errs = p.publisher.PublishMetrics(p.parentJob.Metrics(), p.config, p.taskID, p.name, p.version)
+	}
 	if errs != nil {
 		for _, e := range errs {
 			log.WithFields(log.Fields{
@@ -356,6 +414,9 @@ func (p *publisherJob) Run() {
 				"error":          e.Error(),
 			}).Error("error with publisher job")
 		}
+		if err := contextError(ctx); err != nil {
+			errs = append(errs, err)
+		}
 		p.AddErrors(errs...)
 	}
 }
@@ -0,0 +1,112 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"errors"
+
+	"golang.org/x/net/context"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/ctypes"
+)
+
+var (
+	// ErrJobDeadlineExceeded - The error message for a job whose plugin call ran past its deadline
+	ErrJobDeadlineExceeded = errors.New("Job deadline exceeded, plugin call timed out")
+	// ErrJobCanceled - The error message for a job canceled because its task was stopped
+	ErrJobCanceled = errors.New("Job canceled, task stopped")
+)
+
+// collectsMetricsWithContext, processesMetricsWithContext and
+// publishesMetricsWithContext are implemented by the metric managers passing
+// the deadline and cancellation of a job on to the plugin calls it makes
+type collectsMetricsWithContext interface {
+	CollectMetricsContext(context.Context, string, map[string]map[string]string) ([]core.Metric, []error)
+}
+
+type processesMetricsWithContext interface {
+	ProcessMetricsContext(context.Context, []core.Metric, map[string]ctypes.ConfigValue, string, string, int) ([]core.Metric, []error)
+}
+
+type publishesMetricsWithContext interface {
+	PublishMetricsContext(context.Context, []core.Metric, map[string]ctypes.ConfigValue, string, string, int) []error
+}
+
+// Context returns the context of the runs of the task of the job, which is
+// canceled once the task stops
+func (c *coreJob) Context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// runContext returns the context the job makes its plugin calls in, which
+// expires at the deadline of the job
+func (c *coreJob) runContext() (context.Context, context.CancelFunc) {
+	if c.deadline.IsZero() {
+		return context.WithCancel(c.Context())
+	}
+	return context.WithDeadline(c.Context(), c.deadline)
+}
+
+// contextError returns the error reported for a job whose context is done,
+// telling a job which timed out from one whose task was stopped
+func contextError(ctx context.Context) error {
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		return ErrJobDeadlineExceeded
+	case context.Canceled:
+		return ErrJobCanceled
+	}
+	return nil
+}
+
+// startRuns gives the runs of the task a new context, canceled by cancelRuns
+func (t *task) startRuns() {
+	t.runMutex.Lock()
+	defer t.runMutex.Unlock()
+	if t.cancelRuns != nil {
+		t.cancelRuns()
+	}
+	t.runCtx, t.cancelRuns = context.WithCancel(context.Background())
+}
+
+// stopRuns cancels the context of the runs of the task, aborting the plugin
+// calls in flight. It does not need the lock of the task, which is held for
+// the whole of a firing.
+func (t *task) stopRuns() {
+	t.runMutex.Lock()
+	defer t.runMutex.Unlock()
+	if t.cancelRuns != nil {
+		t.cancelRuns()
+	}
+}
+
+// runContext returns the context of the runs of the task
+func (t *task) runContext() context.Context {
+	t.runMutex.Lock()
+	defer t.runMutex.Unlock()
+	if t.runCtx == nil {
+		return context.Background()
+	}
+	return t.runCtx
+}
@@ -0,0 +1,83 @@
+// +build medium
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"golang.org/x/net/context"
+
+	"github.com/intelsdi-x/snap/core"
+)
+
+// mockContextCollector blocks its collect calls until their context is done
+type mockContextCollector struct {
+	calls    int
+	deadline time.Time
+}
+
+func (m *mockContextCollector) CollectMetrics(string, map[string]map[string]string) ([]core.Metric, []error) {
+	return m.CollectMetricsContext(context.Background(), "", nil)
+}
+
+func (m *mockContextCollector) CollectMetricsContext(ctx context.Context, _ string, _ map[string]map[string]string) ([]core.Metric, []error) {
+	m.calls++
+	m.deadline, _ = ctx.Deadline()
+	<-ctx.Done()
+	return nil, []error{ctx.Err()}
+}
+
+func TestJobContext(t *testing.T) {
+	Convey("Given a collector blocking until its call is done", t, func() {
+		mc := &mockContextCollector{}
+
+		Convey("the deadline of the job bounds the plugin call", func() {
+			j := newCollectorJob(nil, 50*time.Millisecond, mc, nil, "taskID", nil)
+			j.Run()
+			So(mc.calls, ShouldEqual, 1)
+			So(mc.deadline, ShouldResemble, j.Deadline())
+			errs := j.Errors()
+			So(errs[len(errs)-1], ShouldEqual, ErrJobDeadlineExceeded)
+		})
+		Convey("stopping the task cancels the plugin call in flight", func() {
+			tsk := &task{}
+			tsk.startRuns()
+			j := newCollectorJob(nil, time.Minute, mc, nil, "taskID", nil)
+			j.(*collectorJob).ctx = tsk.runContext()
+			go func() {
+				time.Sleep(50 * time.Millisecond)
+				tsk.stopRuns()
+			}()
+			j.Run()
+			errs := j.Errors()
+			So(errs[len(errs)-1], ShouldEqual, ErrJobCanceled)
+
+			Convey("and the jobs of the task still queued are not run", func() {
+				pj := newPublishJob(j, "publisher", 1, "", nil, nil, "taskID")
+				pj.Run()
+				So(pj.Errors(), ShouldResemble, []error{ErrJobCanceled})
+			})
+		})
+	})
+}
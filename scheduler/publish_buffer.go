@@ -0,0 +1,283 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/intelsdi-x/snap/control/plugin"
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/scheduler/wmap"
+)
+
+// Overflow policies of a publish buffer
+const (
+	// BufferOverflowDropOldest drops the oldest metrics to make room for new ones
+	BufferOverflowDropOldest = "drop_oldest"
+	// BufferOverflowDropNewest drops the new metrics when the buffer is full
+	BufferOverflowDropNewest = "drop_newest"
+)
+
+const (
+	defaultBufferMaxSize int64 = 100 * 1024 * 1024
+	bufferedBatchExt           = ".json"
+)
+
+var (
+	// ErrPublishBufferPathNotSet - The error message for a task with a publish buffer when buffering is not configured
+	ErrPublishBufferPathNotSet = errors.New("Publish buffers require the scheduler publish_buffer_path to be set")
+	// ErrBufferMaxSizeInvalid - The error message for a publish buffer with a negative size
+	ErrBufferMaxSizeInvalid = errors.New("Buffer max_size must be greater than 0")
+	// ErrBufferOverflowInvalid - The error message for an unknown overflow policy
+	ErrBufferOverflowInvalid = fmt.Errorf("Buffer overflow must be either %s or %s", BufferOverflowDropOldest, BufferOverflowDropNewest)
+)
+
+// bufferPolicy bounds the metrics a publish buffer keeps
+type bufferPolicy struct {
+	maxSize  int64
+	maxAge   time.Duration
+	overflow string
+}
+
+// newBufferPolicy validates the buffer policy of a workflow map publish node.
+// A nil policy means that the node is not buffered.
+func newBufferPolicy(b *wmap.BufferPolicy) (*bufferPolicy, error) {
+	if b == nil {
+		return nil, nil
+	}
+	p := &bufferPolicy{
+		maxSize:  defaultBufferMaxSize,
+		overflow: BufferOverflowDropOldest,
+	}
+	if b.MaxSize < 0 {
+		return nil, ErrBufferMaxSizeInvalid
+	}
+	if b.MaxSize > 0 {
+		p.maxSize = b.MaxSize
+	}
+	if b.MaxAge != "" {
+		d, err := time.ParseDuration(b.MaxAge)
+		if err != nil {
+			return nil, fmt.Errorf("%v (while parsing buffer 'max_age')", err)
+		}
+		p.maxAge = d
+	}
+	switch b.Overflow {
+	case "":
+	case BufferOverflowDropOldest, BufferOverflowDropNewest:
+		p.overflow = b.Overflow
+	default:
+		return nil, ErrBufferOverflowInvalid
+	}
+	return p, nil
+}
+
+type bufferedBatch struct {
+	name      string
+	size      int64
+	timestamp time.Time
+}
+
+// publishBuffer keeps, in order, the batches of metrics a publish node failed
+// to publish. Each batch is saved in its own file so buffered metrics survive
+// a restart of snapteld.
+type publishBuffer struct {
+	sync.Mutex
+	dir     string
+	policy  *bufferPolicy
+	batches []bufferedBatch
+	size    int64
+	dropped uint64
+	seq     uint64
+}
+
+// openPublishBuffer returns the buffer kept in the given directory, loading
+// the batches left over from a previous run
+func openPublishBuffer(dir string, policy *bufferPolicy) (*publishBuffer, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	// ReadDir sorts the files by name, which is the order the batches were buffered in
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	b := &publishBuffer{dir: dir, policy: policy}
+	for _, file := range files {
+		if file.IsDir() || strings.HasPrefix(file.Name(), ".") || !strings.HasSuffix(file.Name(), bufferedBatchExt) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(file.Name(), bufferedBatchExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		if seq > b.seq {
+			b.seq = seq
+		}
+		b.batches = append(b.batches, bufferedBatch{name: file.Name(), size: file.Size(), timestamp: file.ModTime()})
+		b.size += file.Size()
+	}
+	return b, nil
+}
+
+// add buffers a batch of metrics, dropping metrics as the policy of the
+// buffer requires. It returns the number of batches dropped.
+func (b *publishBuffer) add(metrics []core.Metric) (int, error) {
+	b.Lock()
+	defer b.Unlock()
+	dropped := b.expire()
+	data, err := json.Marshal(toMetricTypes(metrics))
+	if err != nil {
+		return dropped, err
+	}
+	size := int64(len(data))
+	if size > b.policy.maxSize {
+		b.dropped++
+		return dropped + 1, nil
+	}
+	for b.size+size > b.policy.maxSize {
+		if b.policy.overflow == BufferOverflowDropNewest {
+			b.dropped++
+			return dropped + 1, nil
+		}
+		if err := b.removeOldest(); err != nil {
+			return dropped, err
+		}
+		b.dropped++
+		dropped++
+	}
+	b.seq++
+	name := fmt.Sprintf("%020d%s", b.seq, bufferedBatchExt)
+	// write to a hidden file first so a partially written batch is never published
+	tmp, err := ioutil.TempFile(b.dir, "."+name)
+	if err != nil {
+		return dropped, err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return dropped, err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return dropped, err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(b.dir, name)); err != nil {
+		return dropped, err
+	}
+	b.batches = append(b.batches, bufferedBatch{name: name, size: size, timestamp: time.Now()})
+	b.size += size
+	return dropped, nil
+}
+
+// drain publishes the buffered batches, oldest first, until the buffer is
+// empty or a batch fails to be published. It returns the number of batches published.
+func (b *publishBuffer) drain(publish func([]core.Metric) []error) (int, []error) {
+	b.Lock()
+	defer b.Unlock()
+	b.expire()
+	published := 0
+	for len(b.batches) > 0 {
+		data, err := ioutil.ReadFile(filepath.Join(b.dir, b.batches[0].name))
+		if err != nil {
+			return published, []error{err}
+		}
+		mts := []plugin.MetricType{}
+		if err := json.Unmarshal(data, &mts); err != nil {
+			// a batch which cannot be read back would block the buffer forever
+			b.dropped++
+			if err := b.removeOldest(); err != nil {
+				return published, []error{err}
+			}
+			continue
+		}
+		if errs := publish(fromMetricTypes(mts)); len(errs) > 0 {
+			return published, errs
+		}
+		if err := b.removeOldest(); err != nil {
+			return published, []error{err}
+		}
+		published++
+	}
+	return published, nil
+}
+
+// expire drops the batches older than the max age of the buffer
+func (b *publishBuffer) expire() int {
+	if b.policy.maxAge == 0 {
+		return 0
+	}
+	expired := 0
+	for len(b.batches) > 0 && time.Since(b.batches[0].timestamp) > b.policy.maxAge {
+		if err := b.removeOldest(); err != nil {
+			break
+		}
+		b.dropped++
+		expired++
+	}
+	return expired
+}
+
+func (b *publishBuffer) removeOldest() error {
+	if err := os.Remove(filepath.Join(b.dir, b.batches[0].name)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	b.size -= b.batches[0].size
+	b.batches = b.batches[1:]
+	return nil
+}
+
+// depth returns the number of buffered batches
+func (b *publishBuffer) depth() int {
+	b.Lock()
+	defer b.Unlock()
+	return len(b.batches)
+}
+
+func (b *publishBuffer) status() (int, int64, uint64) {
+	b.Lock()
+	defer b.Unlock()
+	return len(b.batches), b.size, b.dropped
+}
+
+// setPolicy changes the policy applied to the next batches added to the buffer
+func (b *publishBuffer) setPolicy(policy *bufferPolicy) {
+	b.Lock()
+	defer b.Unlock()
+	b.policy = policy
+}
+
+// remove deletes the buffer and the metrics it holds
+func (b *publishBuffer) remove() error {
+	b.Lock()
+	defer b.Unlock()
+	b.batches = nil
+	b.size = 0
+	return os.RemoveAll(b.dir)
+}
@@ -0,0 +1,189 @@
+// +build medium
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/intelsdi-x/snap/control/plugin"
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/pkg/schedule"
+	"github.com/intelsdi-x/snap/scheduler/wmap"
+)
+
+func newBatch(v float64) []core.Metric {
+	return []core.Metric{plugin.MetricType{Namespace_: core.NewNamespace("foo", "bar"), Data_: v}}
+}
+
+func TestPublishBuffer(t *testing.T) {
+	Convey("Given a publish buffer", t, func() {
+		dir, err := ioutil.TempDir("", "snap-publish-buffer")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		policy, err := newBufferPolicy(&wmap.BufferPolicy{})
+		So(err, ShouldBeNil)
+		b, err := openPublishBuffer(filepath.Join(dir, "1-file"), policy)
+		So(err, ShouldBeNil)
+		for i := 1; i <= 3; i++ {
+			dropped, err := b.add(newBatch(float64(i)))
+			So(err, ShouldBeNil)
+			So(dropped, ShouldEqual, 0)
+		}
+		published := []interface{}{}
+		publish := func(mts []core.Metric) []error {
+			published = append(published, mts[0].Data())
+			return nil
+		}
+
+		Convey("batches are drained in order", func() {
+			n, errs := b.drain(publish)
+			So(errs, ShouldBeEmpty)
+			So(n, ShouldEqual, 3)
+			So(published, ShouldResemble, []interface{}{1.0, 2.0, 3.0})
+			So(b.depth(), ShouldEqual, 0)
+		})
+		Convey("draining stops at the first failure", func() {
+			calls := 0
+			n, errs := b.drain(func(mts []core.Metric) []error {
+				calls++
+				if calls == 2 {
+					return []error{errors.New("backend unavailable")}
+				}
+				return nil
+			})
+			So(errs, ShouldNotBeEmpty)
+			So(n, ShouldEqual, 1)
+			So(b.depth(), ShouldEqual, 2)
+		})
+		Convey("batches are reloaded when the buffer is opened again", func() {
+			reopened, err := openPublishBuffer(filepath.Join(dir, "1-file"), policy)
+			So(err, ShouldBeNil)
+			depth, size, _ := reopened.status()
+			So(depth, ShouldEqual, 3)
+			So(size, ShouldBeGreaterThan, 0)
+			_, err = reopened.add(newBatch(4))
+			So(err, ShouldBeNil)
+			reopened.drain(publish)
+			So(published, ShouldResemble, []interface{}{1.0, 2.0, 3.0, 4.0})
+		})
+		Convey("the oldest batches are dropped when the buffer is full", func() {
+			_, size, _ := b.status()
+			b.policy = &bufferPolicy{maxSize: size, overflow: BufferOverflowDropOldest}
+			dropped, err := b.add(newBatch(4))
+			So(err, ShouldBeNil)
+			So(dropped, ShouldEqual, 1)
+			b.drain(publish)
+			So(published, ShouldResemble, []interface{}{2.0, 3.0, 4.0})
+		})
+		Convey("the newest batch is dropped when the buffer is full", func() {
+			_, size, _ := b.status()
+			b.policy = &bufferPolicy{maxSize: size, overflow: BufferOverflowDropNewest}
+			dropped, err := b.add(newBatch(4))
+			So(err, ShouldBeNil)
+			So(dropped, ShouldEqual, 1)
+			_, _, total := b.status()
+			So(total, ShouldEqual, 1)
+			b.drain(publish)
+			So(published, ShouldResemble, []interface{}{1.0, 2.0, 3.0})
+		})
+		Convey("batches older than the max age are dropped", func() {
+			b.policy = &bufferPolicy{maxSize: defaultBufferMaxSize, maxAge: time.Millisecond, overflow: BufferOverflowDropOldest}
+			time.Sleep(10 * time.Millisecond)
+			n, errs := b.drain(publish)
+			So(errs, ShouldBeEmpty)
+			So(n, ShouldEqual, 0)
+			_, _, dropped := b.status()
+			So(dropped, ShouldEqual, 3)
+		})
+	})
+	Convey("Invalid buffer policies are rejected", t, func() {
+		_, err := newBufferPolicy(&wmap.BufferPolicy{Overflow: "drop_all"})
+		So(err, ShouldEqual, ErrBufferOverflowInvalid)
+		_, err = newBufferPolicy(&wmap.BufferPolicy{MaxSize: -1})
+		So(err, ShouldEqual, ErrBufferMaxSizeInvalid)
+		_, err = newBufferPolicy(&wmap.BufferPolicy{MaxAge: "forever"})
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestSchedulerPublishBuffer(t *testing.T) {
+	Convey("Given a scheduler with publish buffers", t, func() {
+		dir, err := ioutil.TempDir("", "snap-publish-buffer")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		cfg := GetDefaultConfig()
+		cfg.PublishBufferPath = dir
+		s := New(cfg)
+		mm := &mockFlakyPublisher{mockMetricManager: newMockMetricManager()}
+		s.SetMetricManager(mm)
+		So(s.Start(), ShouldBeNil)
+
+		w := wmap.NewWorkflowMap()
+		w.Collect.AddMetric("/foo/bar", 1)
+		pu := wmap.NewPublishNode("file", -1)
+		pu.Buffer = &wmap.BufferPolicy{}
+		w.Collect.Add(pu)
+
+		Convey("metrics are buffered while the publisher fails and drained once it recovers", func() {
+			mm.setFailures(3)
+			sch := schedule.NewWindowedSchedule(50*time.Millisecond, nil, nil, 0)
+			tsk, errs := s.CreateTask(sch, w, false)
+			So(errs.Errors(), ShouldBeEmpty)
+			task := s.tasks.Get(tsk.ID())
+			task.Spin()
+			time.Sleep(120 * time.Millisecond)
+
+			buffers := tsk.PublishBuffers()
+			So(len(buffers), ShouldEqual, 1)
+			So(buffers[0].PluginName, ShouldEqual, "file")
+			So(buffers[0].Depth, ShouldBeGreaterThan, 0)
+			So(tsk.FailedCount(), ShouldEqual, 0)
+
+			time.Sleep(300 * time.Millisecond)
+			task.Stop()
+			So(tsk.PublishBuffers()[0].Depth, ShouldEqual, 0)
+			attempts, published := mm.counts()
+			So(published, ShouldEqual, attempts-3)
+
+			Convey("and removed with the task", func() {
+				So(s.RemoveTask(tsk.ID()), ShouldBeNil)
+				_, err := os.Stat(filepath.Join(dir, tsk.ID()))
+				So(os.IsNotExist(err), ShouldBeTrue)
+			})
+		})
+		Convey("a task with a buffer cannot be created without a buffer path", func() {
+			s.bufferPath = ""
+			sch := schedule.NewWindowedSchedule(time.Hour, nil, nil, 0)
+			_, errs := s.CreateTask(sch, w, false)
+			So(errs.Errors(), ShouldNotBeEmpty)
+			So(errs.Errors()[0].Error(), ShouldEqual, ErrPublishBufferPathNotSet.Error())
+		})
+	})
+}
@@ -22,11 +22,14 @@ package scheduler
 import (
 	"errors"
 	"sync"
+
+	"github.com/intelsdi-x/snap/core"
 )
 
 var (
 	errQueueEmpty    = errors.New("queue empty")
 	errLimitExceeded = errors.New("limit exceeded")
+	errJobShed       = errors.New("job shed for a job of higher priority")
 )
 
 type jobHandler func(queuedJob)
@@ -38,7 +41,7 @@ type queue struct {
 	handler jobHandler
 	limit   uint
 	kill    chan struct{}
-	items   []queuedJob
+	classes map[string]*classQueue
 	mutex   *sync.Mutex
 	status  queueStatus
 }
@@ -68,7 +71,7 @@ func newQueue(limit uint, handler jobHandler) *queue {
 		handler: handler,
 		limit:   limit,
 		kill:    make(chan struct{}),
-		items:   []queuedJob{},
+		classes: newClassQueues(),
 		mutex:   &sync.Mutex{},
 		status:  queueStopped,
 	}
@@ -99,6 +102,48 @@ func (q *queue) Stop() {
 	}
 }
 
+// SetLimit changes the maximum number of jobs held by the queue. Jobs already
+// queued above a lowered limit are still handled.
+func (q *queue) SetLimit(limit uint) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.limit = limit
+}
+
+// SetWeights changes the weights of the priority classes of the queue, which
+// set the share of the jobs served from each class while several hold jobs
+func (q *queue) SetWeights(weights map[string]uint) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	for p, c := range q.classes {
+		if w := weights[p]; w > 0 {
+			c.weight = w
+		}
+	}
+}
+
+// Len returns the number of jobs waiting in the queue
+func (q *queue) Len() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.length()
+}
+
+// ClassLen returns the number of jobs of a priority class waiting in the queue
+func (q *queue) ClassLen(priority string) int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.class(priority).length
+}
+
+// Dropped returns the number of jobs of a priority class refused or shed by
+// the queue because it was full
+func (q *queue) Dropped(priority string) uint64 {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.class(priority).dropped
+}
+
 /*
    Below is the private, internal functionality of the queue.
    These functions are not thread-safe, and should not be used
@@ -111,7 +156,8 @@ func (q *queue) start() {
 	for {
 		select {
 		case e := <-q.Event:
-			if err := q.push(e); err != nil {
+			shed, err := q.push(e)
+			if err != nil {
 				qe := &queuingError{
 					Err: err,
 					Job: e.Job(),
@@ -120,6 +166,14 @@ func (q *queue) start() {
 				e.Promise().Complete([]error{qe}) // Signal job termination.
 				continue
 			}
+			if shed != nil {
+				qe := &queuingError{
+					Err: errJobShed,
+					Job: shed.Job(),
+				}
+				q.Err <- qe
+				shed.Promise().Complete([]error{qe})
+			}
 
 			q.mutex.Lock()
 			if q.status == queueRunning {
@@ -157,19 +211,48 @@ func (q *queue) handle() {
 }
 
 func (q *queue) length() int {
-	return len(q.items)
+	n := 0
+	for _, c := range q.classes {
+		n += c.length
+	}
+	return n
+}
+
+// class returns the class of the given priority, jobs without a known
+// priority being of normal priority
+func (q *queue) class(priority string) *classQueue {
+	if c, ok := q.classes[priority]; ok {
+		return c
+	}
+	return q.classes[core.TaskPriorityNormal]
 }
 
-func (q *queue) push(j queuedJob) error {
+// push queues j. When the queue is full, a job of the lowest class below the
+// class of j is shed and returned to make room for j, and j is refused when
+// there is no such job.
+func (q *queue) push(j queuedJob) (queuedJob, error) {
 
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
 
+	c := q.class(j.Job().Priority())
 	if q.limit == 0 || uint(q.length())+1 <= q.limit {
-		q.items = append(q.items, j)
-		return nil
+		c.push(j)
+		return nil, nil
+	}
+	for i := len(core.TaskPriorities) - 1; i >= 0; i-- {
+		lower := q.classes[core.TaskPriorities[i]]
+		if lower == c {
+			break
+		}
+		if lower.length > 0 {
+			shed := lower.shed()
+			c.push(j)
+			return shed, nil
+		}
 	}
-	return errLimitExceeded
+	c.dropped++
+	return nil, errLimitExceeded
 }
 
 func (q *queue) pop() (queuedJob, error) {
@@ -179,12 +262,97 @@ func (q *queue) pop() (queuedJob, error) {
 
 	var j queuedJob
 
-	if q.length() == 0 {
+	// smooth weighted round robin: each class holding jobs earns its weight,
+	// and the richest class is served and pays for the others
+	var next *classQueue
+	total := 0
+	for _, p := range core.TaskPriorities {
+		c := q.classes[p]
+		if c.length == 0 {
+			c.credit = 0
+			continue
+		}
+		c.credit += int(c.weight)
+		total += int(c.weight)
+		if next == nil || c.credit > next.credit {
+			next = c
+		}
+	}
+	if next == nil {
 		return j, errQueueEmpty
 	}
+	next.credit -= total
+
+	return next.pop(), nil
+}
+
+// classQueue holds the jobs of a priority class. The jobs of each task are
+// kept in order and the tasks take turns, so that a task queuing many jobs
+// does not hold up the other tasks of its class.
+type classQueue struct {
+	weight uint
+	credit int
+	// tasks holds the IDs of the tasks with queued jobs, in turn order
+	tasks   []string
+	jobs    map[string][]queuedJob
+	length  int
+	dropped uint64
+}
+
+func newClassQueues() map[string]*classQueue {
+	weights := defaultPriorityWeights()
+	classes := make(map[string]*classQueue, len(core.TaskPriorities))
+	for _, p := range core.TaskPriorities {
+		classes[p] = &classQueue{
+			weight: weights[p],
+			jobs:   map[string][]queuedJob{},
+		}
+	}
+	return classes
+}
 
-	j = q.items[0]
-	q.items = q.items[1:]
+func (c *classQueue) push(j queuedJob) {
+	id := j.Job().TaskID()
+	if len(c.jobs[id]) == 0 {
+		c.tasks = append(c.tasks, id)
+	}
+	c.jobs[id] = append(c.jobs[id], j)
+	c.length++
+}
 
-	return j, nil
+// pop removes the oldest job of the task whose turn it is
+func (c *classQueue) pop() queuedJob {
+	id := c.tasks[0]
+	jobs := c.jobs[id]
+	c.tasks = c.tasks[1:]
+	if len(jobs) > 1 {
+		c.jobs[id] = jobs[1:]
+		c.tasks = append(c.tasks, id)
+	} else {
+		delete(c.jobs, id)
+	}
+	c.length--
+	return jobs[0]
+}
+
+// shed removes the newest job of the task with the most queued jobs, the last
+// to be served of them
+func (c *classQueue) shed() queuedJob {
+	at := 0
+	for i, id := range c.tasks {
+		if len(c.jobs[id]) >= len(c.jobs[c.tasks[at]]) {
+			at = i
+		}
+	}
+	id := c.tasks[at]
+	jobs := c.jobs[id]
+	if len(jobs) > 1 {
+		c.jobs[id] = jobs[:len(jobs)-1]
+	} else {
+		delete(c.jobs, id)
+		c.tasks = append(c.tasks[:at], c.tasks[at+1:]...)
+	}
+	c.length--
+	c.dropped++
+	return jobs[len(jobs)-1]
 }
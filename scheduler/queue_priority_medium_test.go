@@ -0,0 +1,108 @@
+// +build medium
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/intelsdi-x/snap/core"
+)
+
+// newPriorityJob returns a queued job of the given task and priority class
+func newPriorityJob(taskID, priority string) queuedJob {
+	cj := newCoreJob(collectJobType, time.Now().Add(time.Second), taskID, "", 0)
+	cj.priority = priority
+	return newQueuedJob(&collectorJob{coreJob: cj})
+}
+
+// popTasks pops n jobs off the queue and returns the IDs of their tasks
+func popTasks(q *queue, n int) []string {
+	var ids []string
+	for i := 0; i < n; i++ {
+		j, err := q.pop()
+		if err != nil {
+			break
+		}
+		ids = append(ids, j.Job().TaskID())
+	}
+	return ids
+}
+
+func TestQueuePriorities(t *testing.T) {
+	Convey("Given a queue", t, func() {
+		q := newQueue(0, func(queuedJob) {})
+
+		Convey("the tasks of a class take turns", func() {
+			for i := 0; i < 3; i++ {
+				q.push(newPriorityJob("busy", core.TaskPriorityNormal))
+			}
+			q.push(newPriorityJob("quiet", core.TaskPriorityNormal))
+			So(popTasks(q, 4), ShouldResemble, []string{"busy", "quiet", "busy", "busy"})
+		})
+		Convey("the classes are served in proportion to their weights", func() {
+			q.SetWeights(map[string]uint{core.TaskPriorityHigh: 3, core.TaskPriorityLow: 1})
+			for i := 0; i < 8; i++ {
+				q.push(newPriorityJob("high", core.TaskPriorityHigh))
+				q.push(newPriorityJob("low", core.TaskPriorityLow))
+			}
+			counts := map[string]int{}
+			for _, id := range popTasks(q, 8) {
+				counts[id]++
+			}
+			So(counts, ShouldResemble, map[string]int{"high": 6, "low": 2})
+			So(q.ClassLen(core.TaskPriorityHigh), ShouldEqual, 2)
+			So(q.ClassLen(core.TaskPriorityLow), ShouldEqual, 6)
+		})
+		Convey("jobs without a known class are of normal priority", func() {
+			q.push(newPriorityJob("other", ""))
+			So(q.ClassLen(core.TaskPriorityNormal), ShouldEqual, 1)
+		})
+	})
+	Convey("Given a full queue", t, func() {
+		q := newQueue(3, func(queuedJob) {})
+		q.push(newPriorityJob("normal", core.TaskPriorityNormal))
+		q.push(newPriorityJob("low-1", core.TaskPriorityLow))
+		q.push(newPriorityJob("low-2", core.TaskPriorityLow))
+
+		Convey("a job of a higher class sheds a job of the lowest class", func() {
+			shed, err := q.push(newPriorityJob("high", core.TaskPriorityHigh))
+			So(err, ShouldBeNil)
+			So(shed.Job().TaskID(), ShouldEqual, "low-2")
+			So(q.Len(), ShouldEqual, 3)
+			So(q.Dropped(core.TaskPriorityLow), ShouldEqual, 1)
+
+			shed, err = q.push(newPriorityJob("normal", core.TaskPriorityNormal))
+			So(err, ShouldBeNil)
+			So(shed.Job().TaskID(), ShouldEqual, "low-1")
+		})
+		Convey("a job finding no lower class job is refused", func() {
+			shed, err := q.push(newPriorityJob("low-3", core.TaskPriorityLow))
+			So(err, ShouldEqual, errLimitExceeded)
+			So(shed, ShouldBeNil)
+			So(q.Dropped(core.TaskPriorityLow), ShouldEqual, 1)
+			So(q.Len(), ShouldEqual, 3)
+		})
+	})
+}
@@ -84,7 +84,7 @@ func TestQueue(t *testing.T) {
 		q := newQueue(3, func(queuedJob) { time.Sleep(1 * time.Second) })
 		q.Start()
 		for i := 0; i < 5; i++ {
-			q.Event <- newQueuedJob(&collectorJob{})
+			q.Event <- newQueuedJob(&collectorJob{coreJob: &coreJob{}})
 		}
 		err := <-q.Err
 		So(err, ShouldNotBeNil)
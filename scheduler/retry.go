@@ -0,0 +1,113 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/intelsdi-x/snap/scheduler/wmap"
+)
+
+// default values of a publisher retry policy
+const (
+	defaultRetryInitialInterval = time.Second
+	defaultRetryMaxInterval     = 30 * time.Second
+	defaultRetryMultiplier      = 2.0
+)
+
+var (
+	// ErrRetryMaxAttemptsInvalid - The error message for a retry policy without attempts
+	ErrRetryMaxAttemptsInvalid = errors.New("Retry policy max_attempts must be greater than 0")
+	// ErrRetryMultiplierInvalid - The error message for a retry policy which shortens the delay between attempts
+	ErrRetryMultiplierInvalid = errors.New("Retry policy multiplier must be greater than or equal to 1")
+	// ErrRetryJitterInvalid - The error message for a retry policy with a jitter out of range
+	ErrRetryJitterInvalid = errors.New("Retry policy jitter must be between 0 and 1")
+)
+
+// retryPolicy controls how many times and how often a failed publish job is retried
+type retryPolicy struct {
+	maxAttempts     int
+	initialInterval time.Duration
+	maxInterval     time.Duration
+	multiplier      float64
+	jitter          float64
+}
+
+// newRetryPolicy validates the retry policy of a workflow map publish node.
+// A nil policy means that a publish job is attempted only once.
+func newRetryPolicy(r *wmap.RetryPolicy) (*retryPolicy, error) {
+	if r == nil {
+		return &retryPolicy{maxAttempts: 1}, nil
+	}
+	p := &retryPolicy{
+		maxAttempts:     r.MaxAttempts,
+		initialInterval: defaultRetryInitialInterval,
+		maxInterval:     defaultRetryMaxInterval,
+		multiplier:      defaultRetryMultiplier,
+		jitter:          r.Jitter,
+	}
+	if p.maxAttempts < 1 {
+		return nil, ErrRetryMaxAttemptsInvalid
+	}
+	var err error
+	if r.InitialInterval != "" {
+		if p.initialInterval, err = time.ParseDuration(r.InitialInterval); err != nil {
+			return nil, fmt.Errorf("%v (while parsing retry policy 'initial_interval')", err)
+		}
+	}
+	if r.MaxInterval != "" {
+		if p.maxInterval, err = time.ParseDuration(r.MaxInterval); err != nil {
+			return nil, fmt.Errorf("%v (while parsing retry policy 'max_interval')", err)
+		}
+	}
+	if p.maxInterval < p.initialInterval {
+		p.maxInterval = p.initialInterval
+	}
+	if r.Multiplier != 0 {
+		p.multiplier = r.Multiplier
+	}
+	if p.multiplier < 1 {
+		return nil, ErrRetryMultiplierInvalid
+	}
+	if p.jitter < 0 || p.jitter > 1 {
+		return nil, ErrRetryJitterInvalid
+	}
+	return p, nil
+}
+
+// backoff returns how long to wait before the given attempt, the first
+// retry being attempt 2.
+func (r *retryPolicy) backoff(attempt int) time.Duration {
+	d := float64(r.initialInterval)
+	for i := 2; i < attempt && d < float64(r.maxInterval); i++ {
+		d *= r.multiplier
+	}
+	if d > float64(r.maxInterval) {
+		d = float64(r.maxInterval)
+	}
+	if r.jitter > 0 {
+		// spread the delay evenly within +/- jitter of its value
+		d += d * r.jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(d)
+}
@@ -42,6 +42,7 @@ import (
 	"github.com/intelsdi-x/snap/core/scheduler_event"
 	"github.com/intelsdi-x/snap/core/serror"
 	"github.com/intelsdi-x/snap/pkg/schedule"
+	"github.com/intelsdi-x/snap/pkg/telemetry"
 	"github.com/intelsdi-x/snap/scheduler/wmap"
 )
 
@@ -126,6 +127,11 @@ type scheduler struct {
 	state           schedulerState
 	eventManager    *gomit.EventController
 	taskWatcherColl *taskWatcherCollection
+	store           TaskStore
+	templates       *templateCollection
+	templateStore   TemplateStore
+	deadLetters     DeadLetterSpool
+	bufferPath      string
 }
 
 type managesWork interface {
@@ -256,9 +262,11 @@ func New(cfg *Config) *scheduler {
 		PublishWkrSizeOption(cfg.WorkManagerPoolSize),
 		ProcessQSizeOption(cfg.WorkManagerQueueSize),
 		ProcessWkrSizeOption(cfg.WorkManagerPoolSize),
+		PriorityWeightsOption(cfg.PriorityWeights),
 	}
 	s := &scheduler{
 		tasks:           newTaskCollection(),
+		templates:       newTemplateCollection(),
 		eventManager:    gomit.NewEventController(),
 		taskWatcherColl: newTaskWatcherCollection(),
 	}
@@ -269,6 +277,29 @@ func New(cfg *Config) *scheduler {
 	s.workManager.Start()
 	s.eventManager.RegisterHandler(HandlerRegistrationName, s)
 
+	if cfg.TaskStorePath != "" {
+		schedulerLogger.WithFields(log.Fields{
+			"_block": "New",
+			"value":  cfg.TaskStorePath,
+		}).Info("Setting task store path")
+		s.store = NewFileTaskStore(cfg.TaskStorePath)
+		s.templateStore = NewFileTemplateStore(filepath.Join(cfg.TaskStorePath, templateStoreDir))
+	}
+	if cfg.DeadLetterPath != "" {
+		schedulerLogger.WithFields(log.Fields{
+			"_block": "New",
+			"value":  cfg.DeadLetterPath,
+		}).Info("Setting dead-letter spool path")
+		s.deadLetters = NewFileDeadLetterSpool(cfg.DeadLetterPath)
+	}
+	if cfg.PublishBufferPath != "" {
+		schedulerLogger.WithFields(log.Fields{
+			"_block": "New",
+			"value":  cfg.PublishBufferPath,
+		}).Info("Setting publish buffer path")
+		s.bufferPath = cfg.PublishBufferPath
+	}
+
 	return s
 }
 
@@ -288,11 +319,42 @@ func (s *scheduler) RegisterEventHandler(name string, h gomit.Handler) error {
 	return s.eventManager.RegisterHandler(name, h)
 }
 
+// SetTaskStore sets the store used to persist tasks across restarts.
+// It must be called before the scheduler is started.
+func (s *scheduler) SetTaskStore(ts TaskStore) {
+	s.store = ts
+}
+
+// SetDeadLetterSpool sets the spool keeping the metrics which could not be published.
+// It must be called before tasks are created.
+func (s *scheduler) SetDeadLetterSpool(dls DeadLetterSpool) {
+	s.deadLetters = dls
+}
+
+// ReloadConfig applies the work manager queue and pool sizes and the priority
+// weights of cfg to the running scheduler. Changing the other settings of cfg
+// requires a restart.
+func (s *scheduler) ReloadConfig(cfg *Config) {
+	schedulerLogger.WithFields(log.Fields{
+		"_block":     "reload-config",
+		"queue-size": cfg.WorkManagerQueueSize,
+		"pool-size":  cfg.WorkManagerPoolSize,
+	}).Info("Resizing work manager")
+	s.workManager.Resize(cfg.WorkManagerQueueSize, cfg.WorkManagerPoolSize)
+	s.workManager.SetPriorityWeights(cfg.PriorityWeights)
+}
+
 // CreateTask creates and returns task
 func (s *scheduler) CreateTask(sch schedule.Schedule, wfMap *wmap.WorkflowMap, startOnCreate bool, opts ...core.TaskOption) (core.Task, core.TaskErrors) {
 	return s.createTask(sch, wfMap, startOnCreate, "user", opts...)
 }
 
+// createAutoDiscoveredTask creates a task from a manifest of an auto discover
+// path. These tasks are created anew on each start and are not persisted.
+func (s *scheduler) createAutoDiscoveredTask(sch schedule.Schedule, wfMap *wmap.WorkflowMap, startOnCreate bool, opts ...core.TaskOption) (core.Task, core.TaskErrors) {
+	return s.createTask(sch, wfMap, startOnCreate, "autodiscover", opts...)
+}
+
 func (s *scheduler) CreateTaskTribe(sch schedule.Schedule, wfMap *wmap.WorkflowMap, startOnCreate bool, opts ...core.TaskOption) (core.Task, core.TaskErrors) {
 	return s.createTask(sch, wfMap, startOnCreate, "tribe", opts...)
 }
@@ -341,7 +403,63 @@ func (s *scheduler) createTask(sch schedule.Schedule, wfMap *wmap.WorkflowMap, s
 		f.Error("Unable to create task")
 		return nil, te
 	}
+	task.deadLetters = s.deadLetters
+	task.autoDiscovered = source == "autodiscover"
+
+	if errs := validateWorkflowDeps(sch, wf, &task.RemoteManagers); len(errs) > 0 {
+		te.errs = append(te.errs, errs...)
+		return nil, te
+	}
+
+	// Open the buffers of the publish nodes, reloading the metrics they held
+	// when the task is restored
+	if err := task.openPublishBuffers(task.workflow, s.bufferPath); err != nil {
+		te.errs = append(te.errs, serror.New(err))
+		f := buildErrorsLog(te.Errors(), logger)
+		f.Error("Unable to open publish buffers")
+		return nil, te
+	}
 
+	// Add task to taskCollection
+	if err := s.tasks.add(task); err != nil {
+		te.errs = append(te.errs, serror.New(err))
+		f := buildErrorsLog(te.Errors(), logger)
+		f.Error("errors during task creation")
+		return nil, te
+	}
+
+	logger.WithFields(log.Fields{
+		"task-id":    task.ID(),
+		"task-state": task.State(),
+	}).Info("task created")
+
+	s.persistTask(task)
+
+	event := &scheduler_event.TaskCreatedEvent{
+		TaskID:        task.id,
+		StartOnCreate: startOnCreate,
+		Source:        source,
+	}
+	defer s.eventManager.Emit(event)
+
+	if startOnCreate {
+		logger.WithFields(log.Fields{
+			"task-id": task.ID(),
+			"source":  source,
+		}).Info("starting task on creation")
+
+		errs := s.StartTask(task.id)
+		if errs != nil {
+			te.errs = append(te.errs, errs...)
+		}
+	}
+
+	return task, te
+}
+
+// validateWorkflowDeps validates the plugins and metrics of a workflow, and
+// their compatibility with the schedule, against the managers of the task
+func validateWorkflowDeps(sch schedule.Schedule, wf *schedulerWorkflow, mgrs *managers) []serror.SnapError {
 	// subscribedPluginAsserts includes rules that need to be evaluated once we
 	// have mapped the metrics to specific collector plugins.  Examples include
 	// asserting that streaming tasks don't reference non-streaming collectors.
@@ -402,53 +520,97 @@ func (s *scheduler) createTask(sch schedule.Schedule, wfMap *wmap.WorkflowMap, s
 			})
 		}
 
-		manager, err := task.RemoteManagers.Get(k)
+		manager, err := mgrs.Get(k)
 		if err != nil {
-			te.errs = append(te.errs, serror.New(err))
-			return nil, te
+			return []serror.SnapError{serror.New(err)}
 		}
-		var errs []serror.SnapError
-		errs = manager.ValidateDeps(group.requestedMetrics, group.subscribedPlugins, wf.configTree, subscribedPluginAsserts...)
-
-		if len(errs) > 0 {
-			te.errs = append(te.errs, errs...)
-			return nil, te
+		if errs := manager.ValidateDeps(group.requestedMetrics, group.subscribedPlugins, wf.configTree, subscribedPluginAsserts...); len(errs) > 0 {
+			return errs
 		}
 	}
+	return nil
+}
 
-	// Add task to taskCollection
-	if err := s.tasks.add(task); err != nil {
+// UpdateTask changes the schedule, workflow and options of an existing task
+// in place. A nil schedule or workflow map keeps the current one. A running
+// task is switched over between two firings, and only the plugins which differ
+// between its current and new workflow are subscribed and unsubscribed.
+func (s *scheduler) UpdateTask(id string, sch schedule.Schedule, wfMap *wmap.WorkflowMap, opts ...core.TaskOption) (core.Task, core.TaskErrors) {
+	logger := schedulerLogger.WithFields(log.Fields{
+		"_block":  "update-task",
+		"task-id": id,
+	})
+	te := &taskErrors{
+		errs: make([]serror.SnapError, 0),
+	}
+
+	t, err := s.getTask(id)
+	if err != nil {
 		te.errs = append(te.errs, serror.New(err))
 		f := buildErrorsLog(te.Errors(), logger)
-		f.Error("errors during task creation")
+		f.Error("error updating task")
 		return nil, te
 	}
 
-	logger.WithFields(log.Fields{
-		"task-id":    task.ID(),
-		"task-state": task.State(),
-	}).Info("task created")
-
-	event := &scheduler_event.TaskCreatedEvent{
-		TaskID:        task.id,
-		StartOnCreate: startOnCreate,
-		Source:        source,
+	if sch != nil {
+		if err := sch.Validate(); err != nil {
+			te.errs = append(te.errs, serror.New(err))
+			f := buildErrorsLog(te.Errors(), logger)
+			f.Error("schedule passed not valid")
+			return nil, te
+		}
 	}
-	defer s.eventManager.Emit(event)
-
-	if startOnCreate {
-		logger.WithFields(log.Fields{
-			"task-id": task.ID(),
-			"source":  source,
-		}).Info("starting task on creation")
 
-		errs := s.StartTask(task.id)
-		if errs != nil {
+	// the schedule and workflow the task ends up with are validated together
+	// as the schedule type restricts the plugins of the workflow
+	t.Lock()
+	vsch, vwf, mgrs := t.schedule, t.workflow, t.RemoteManagers
+	t.Unlock()
+	var wf *schedulerWorkflow
+	if wfMap != nil {
+		wf, err = wmapToWorkflow(wfMap)
+		if err != nil {
+			te.errs = append(te.errs, serror.New(err))
+			f := buildErrorsLog(te.Errors(), logger)
+			f.Error("Unable to generate workflow from workflow map")
+			return nil, te
+		}
+		wf.eventEmitter = s.eventManager
+		mgrs = newManagers(s.metricManager)
+		if err := createTaskClients(&mgrs, wf); err != nil {
+			te.errs = append(te.errs, serror.New(err))
+			f := buildErrorsLog(te.Errors(), logger)
+			f.Error("Unable to create task clients")
+			return nil, te
+		}
+		vwf = wf
+	}
+	if sch != nil {
+		vsch = sch
+	}
+	if sch != nil || wf != nil {
+		if errs := validateWorkflowDeps(vsch, vwf, &mgrs); len(errs) > 0 {
 			te.errs = append(te.errs, errs...)
+			f := buildErrorsLog(te.Errors(), logger)
+			f.Error("task update not valid")
+			return nil, te
 		}
 	}
 
-	return task, te
+	if errs := t.update(sch, wf, mgrs, s.bufferPath, opts...); len(errs) > 0 {
+		te.errs = append(te.errs, errs...)
+		f := buildErrorsLog(te.Errors(), logger)
+		f.Error("error updating task")
+		return nil, te
+	}
+
+	logger.WithFields(log.Fields{
+		"task-state":       t.State(),
+		"schedule-changed": sch != nil,
+		"workflow-changed": wf != nil,
+	}).Info("task updated")
+	s.persistTask(t)
+	return t, te
 }
 
 // RemoveTask given a tasks id.  The task must be stopped.
@@ -479,7 +641,12 @@ func (s *scheduler) removeTask(id, source string) error {
 	}
 
 	defer s.eventManager.Emit(event)
-	return s.tasks.remove(t)
+	if err := s.tasks.remove(t); err != nil {
+		return err
+	}
+	t.removePublishBuffers()
+	s.forgetTask(t.id)
+	return nil
 }
 
 // GetTasks returns a copy of the tasks in a map where the task id is the key
@@ -666,9 +833,55 @@ func (s *scheduler) EnableTask(id string) (core.Task, error) {
 		"task-id":    t.ID(),
 		"task-state": t.State(),
 	}).Info("task enabled")
+	s.persistTask(t)
 	return t, nil
 }
 
+// ReplayTask publishes again, in the order they were spooled, the metrics of
+// a task which were sent to the dead-letter spool. Replaying stops at the first
+// batch which still cannot be published. It returns the number of batches published.
+func (s *scheduler) ReplayTask(id string) (int, error) {
+	logger := schedulerLogger.WithFields(log.Fields{
+		"_block":  "replay-task",
+		"task-id": id,
+	})
+	t, err := s.getTask(id)
+	if err != nil {
+		logger.WithField("_error", err.Error()).Error("error replaying task")
+		return 0, err
+	}
+	if s.deadLetters == nil {
+		logger.WithField("_error", ErrDeadLetterSpoolNotSet.Error()).Error("error replaying task")
+		return 0, ErrDeadLetterSpoolNotSet
+	}
+	letters, err := s.deadLetters.List(id)
+	if err != nil {
+		logger.WithField("_error", err.Error()).Error("error replaying task")
+		return 0, err
+	}
+	replayed := 0
+	for _, dl := range letters {
+		if errs := replayDeadLetter(t, dl); len(errs) > 0 {
+			logger.WithFields(log.Fields{
+				"_error":      errs[len(errs)-1].Error(),
+				"dead-letter": dl.ID,
+				"replayed":    replayed,
+			}).Error("error replaying task")
+			return replayed, errs[len(errs)-1]
+		}
+		if err := s.deadLetters.Remove(dl); err != nil {
+			logger.WithFields(log.Fields{
+				"_error":      err.Error(),
+				"dead-letter": dl.ID,
+			}).Error("unable to remove replayed dead letter")
+			return replayed, err
+		}
+		replayed++
+	}
+	logger.WithField("replayed", replayed).Info("task replayed")
+	return replayed, nil
+}
+
 // Start starts the scheduler
 func (s *scheduler) Start() error {
 	if s.metricManager == nil {
@@ -682,6 +895,29 @@ func (s *scheduler) Start() error {
 	schedulerLogger.WithFields(log.Fields{
 		"_block": "start-scheduler",
 	}).Info("scheduler started")
+	telemetry.RegisterSource("tasks", s.taskCounts)
+
+	// Restore the templates and the tasks saved in the stores
+	if s.templateStore != nil {
+		if err := s.restoreTemplates(); err != nil {
+			schedulerLogger.WithFields(log.Fields{
+				"_block": "start-scheduler",
+				"_error": err.Error(),
+			}).Error("error restoring templates from template store")
+			s.state = schedulerStopped
+			return err
+		}
+	}
+	if s.store != nil {
+		if err := s.restoreTasks(); err != nil {
+			schedulerLogger.WithFields(log.Fields{
+				"_block": "start-scheduler",
+				"_error": err.Error(),
+			}).Error("error restoring tasks from task store")
+			s.state = schedulerStopped
+			return err
+		}
+	}
 
 	//Autodiscover
 	autoDiscoverPaths := s.metricManager.GetAutodiscoverPaths()
@@ -689,6 +925,7 @@ func (s *scheduler) Start() error {
 		schedulerLogger.WithFields(log.Fields{
 			"_block": "start-scheduler",
 		}).Info("auto discover path is enabled")
+		var discovered []autoDiscovered
 		for _, pa := range autoDiscoverPaths {
 			fullPath, err := filepath.Abs(pa)
 			if err != nil {
@@ -707,7 +944,7 @@ func (s *scheduler) Start() error {
 					"autodiscoverpath": pa,
 				}).Fatal(err)
 			}
-			var taskFiles []os.FileInfo
+			var taskFiles, templateFiles, valuesFiles []os.FileInfo
 			for _, file := range files {
 				if file.IsDir() {
 					schedulerLogger.WithFields(log.Fields{
@@ -721,9 +958,24 @@ func (s *scheduler) Start() error {
 				if !strings.HasSuffix(fname, ".json") && !strings.HasSuffix(fname, ".yaml") && !strings.HasSuffix(fname, ".yml") {
 					continue
 				}
-				taskFiles = append(taskFiles, file)
+				// templates and the values to instantiate them with
+				// are told apart from tasks by their extension
+				switch {
+				case isAutoDiscoverFile(fname, templateFileExt):
+					templateFiles = append(templateFiles, file)
+				case isAutoDiscoverFile(fname, valuesFileExt):
+					valuesFiles = append(valuesFiles, file)
+				default:
+					taskFiles = append(taskFiles, file)
+				}
 			}
-			autoDiscoverTasks(taskFiles, fullPath, s.CreateTask)
+			s.autoDiscoverTemplates(templateFiles, fullPath)
+			discovered = append(discovered, autoDiscovered{fullPath, taskFiles, valuesFiles})
+		}
+		// tasks are created once the templates of every path are loaded
+		for _, d := range discovered {
+			autoDiscoverTasks(d.taskFiles, d.path, s.createAutoDiscoveredTask)
+			s.autoDiscoverTemplateValues(d.valuesFiles, d.path)
 		}
 	} else {
 		schedulerLogger.WithFields(log.Fields{
@@ -736,6 +988,7 @@ func (s *scheduler) Start() error {
 
 func (s *scheduler) Stop() {
 	s.state = schedulerStopped
+	telemetry.UnregisterSource("tasks")
 	// stop all tasks that are not already stopped
 	for _, t := range s.tasks.table {
 		// Kill ensure another task can't turn it back on while we are shutting down
@@ -746,6 +999,20 @@ func (s *scheduler) Stop() {
 	}).Info("scheduler stopped")
 }
 
+// taskCounts returns the number of hits, misses, skips and failures of each task
+func (s *scheduler) taskCounts() []telemetry.Sample {
+	var samples []telemetry.Sample
+	for id, t := range s.tasks.Table() {
+		samples = append(samples,
+			telemetry.Sample{Namespace: []string{"scheduler", "tasks", id, "hits"}, Value: float64(t.HitCount())},
+			telemetry.Sample{Namespace: []string{"scheduler", "tasks", id, "misses"}, Value: float64(t.MissedCount())},
+			telemetry.Sample{Namespace: []string{"scheduler", "tasks", id, "skips"}, Value: float64(t.SkippedCount())},
+			telemetry.Sample{Namespace: []string{"scheduler", "tasks", id, "failures"}, Value: float64(t.FailedCount())},
+		)
+	}
+	return samples
+}
+
 // Set metricManager for scheduler
 func (s *scheduler) SetMetricManager(mm managesMetrics) {
 	s.metricManager = mm
@@ -781,6 +1048,7 @@ func (s *scheduler) HandleGomitEvent(e gomit.Event) {
 			"metric-count":    len(v.Metrics),
 		}).Debug("event received")
 		s.taskWatcherColl.handleMetricCollected(v.TaskID, v.Metrics)
+		s.handleTaskTriggers(v.TaskID, v.Metrics)
 	case *scheduler_event.MetricCollectionFailedEvent:
 		log.WithFields(log.Fields{
 			"_module":         "scheduler-events",
@@ -796,6 +1064,7 @@ func (s *scheduler) HandleGomitEvent(e gomit.Event) {
 			"event-namespace": e.Namespace(),
 			"task-id":         v.TaskID,
 		}).Debug("event received")
+		s.persistTaskByID(v.TaskID)
 		s.taskWatcherColl.handleTaskStarted(v.TaskID)
 	case *scheduler_event.TaskStoppedEvent:
 		log.WithFields(log.Fields{
@@ -807,7 +1076,10 @@ func (s *scheduler) HandleGomitEvent(e gomit.Event) {
 		// We need to unsubscribe from deps when a task has stopped
 		task, _ := s.getTask(v.TaskID)
 		task.UnsubscribePlugins()
+		releaseTrigger(task)
+		s.persistTaskByID(v.TaskID)
 		s.taskWatcherColl.handleTaskStopped(v.TaskID)
+		s.handleTaskDependencies(v.TaskID, core.DependencyOnStopped)
 	case *scheduler_event.TaskEndedEvent:
 		log.WithFields(log.Fields{
 			"_module":         "scheduler-events",
@@ -818,7 +1090,9 @@ func (s *scheduler) HandleGomitEvent(e gomit.Event) {
 		// We need to unsubscribe from deps when a task has ended
 		task, _ := s.getTask(v.TaskID)
 		task.UnsubscribePlugins()
+		s.persistTaskByID(v.TaskID)
 		s.taskWatcherColl.handleTaskEnded(v.TaskID)
+		s.handleTaskDependencies(v.TaskID, core.DependencyOnEnded)
 	case *scheduler_event.TaskDisabledEvent:
 		log.WithFields(log.Fields{
 			"_module":         "scheduler-events",
@@ -830,7 +1104,9 @@ func (s *scheduler) HandleGomitEvent(e gomit.Event) {
 		// We need to unsubscribe from deps when a task goes disabled
 		task, _ := s.getTask(v.TaskID)
 		task.UnsubscribePlugins()
+		s.persistTaskByID(v.TaskID)
 		s.taskWatcherColl.handleTaskDisabled(v.TaskID, v.Why)
+		s.handleTaskDependencies(v.TaskID, core.DependencyOnDisabled)
 	case *scheduler_event.PluginsUnsubscribedEvent:
 		log.WithFields(log.Fields{
 			"_module":         "scheduler-events",
@@ -847,6 +1123,105 @@ func (s *scheduler) HandleGomitEvent(e gomit.Event) {
 	}
 }
 
+// restoreTasks recreates the tasks held in the task store and starts the
+// ones that were running when snapteld was stopped.
+func (s *scheduler) restoreTasks() error {
+	records, err := s.store.Load()
+	if err != nil {
+		return err
+	}
+	logger := schedulerLogger.WithField("_block", "restore-tasks")
+	for _, r := range records {
+		tlog := logger.WithFields(log.Fields{
+			"task-id":    r.ID,
+			"task-name":  r.Name,
+			"task-state": r.State,
+		})
+		if s.tasks.Get(r.ID) != nil {
+			continue
+		}
+		if r.Schedule == nil || r.Workflow == nil {
+			tlog.Error(ErrTaskStoreRecordInvalid)
+			continue
+		}
+		sch, err := core.MakeSchedule(*r.Schedule)
+		if err != nil {
+			tlog.WithField("_error", err.Error()).Error("unable to restore task schedule")
+			continue
+		}
+		opts, err := r.options()
+		if err != nil {
+			tlog.WithField("_error", err.Error()).Error("unable to restore task options")
+			continue
+		}
+		// The record is kept in the store when the task cannot be created
+		// (e.g. a plugin it depends on is not loaded yet) so that it will be
+		// restored on a later start.
+		if _, te := s.createTask(sch, r.Workflow, false, "store", opts...); te != nil && len(te.Errors()) > 0 {
+			tlog = buildErrorsLog(te.Errors(), tlog)
+			tlog.Error("unable to restore task")
+			continue
+		}
+		t, err := s.getTask(r.ID)
+		if err != nil {
+			continue
+		}
+		t.creationTime = time.Unix(r.CreationTimestamp, 0)
+		switch r.State {
+		case core.TaskSpinning.String():
+			if errs := s.startTask(t.id, "store"); errs != nil {
+				tlog = buildErrorsLog(errs, tlog)
+				tlog.Error("unable to resume task")
+				continue
+			}
+		case core.TaskDisabled.String():
+			t.state = core.TaskDisabled
+		case core.TaskEnded.String():
+			t.state = core.TaskEnded
+		}
+		s.persistTask(t)
+		tlog.Info("task restored")
+	}
+	return nil
+}
+
+// persistTask saves the current definition and state of a task in the task store
+func (s *scheduler) persistTask(t *task) {
+	// tasks are stopped as part of shutting down the scheduler; their state
+	// at that point must not overwrite the one they had while running.
+	// Auto discovered tasks are created again from their manifest instead.
+	if s.store == nil || s.state != schedulerStarted || t.autoDiscovered {
+		return
+	}
+	if err := s.store.Save(newTaskRecord(t)); err != nil {
+		schedulerLogger.WithFields(log.Fields{
+			"_block":  "persist-task",
+			"_error":  err.Error(),
+			"task-id": t.ID(),
+		}).Error("error saving task in task store")
+	}
+}
+
+func (s *scheduler) persistTaskByID(id string) {
+	if t, err := s.getTask(id); err == nil {
+		s.persistTask(t)
+	}
+}
+
+// forgetTask removes a task from the task store
+func (s *scheduler) forgetTask(id string) {
+	if s.store == nil {
+		return
+	}
+	if err := s.store.Delete(id); err != nil {
+		schedulerLogger.WithFields(log.Fields{
+			"_block":  "forget-task",
+			"_error":  err.Error(),
+			"task-id": id,
+		}).Error("error removing task from task store")
+	}
+}
+
 func (s *scheduler) getTask(id string) (*task, error) {
 	task := s.tasks.Get(id)
 	if task == nil {
@@ -869,6 +1244,11 @@ func getWorkflowPlugins(prnodes []*processNode, pbnodes []*publishNode, requeste
 
 func walkWorkflowForDeps(prnodes []*processNode, pbnodes []*publishNode, requestedMetrics []core.RequestedMetric, depGroup depGroupMap) depGroupMap {
 	for _, pr := range prnodes {
+		// aggregate nodes do not subscribe to a plugin
+		if pr.aggregator != nil {
+			walkWorkflowForDeps(pr.ProcessNodes, pr.PublishNodes, requestedMetrics, depGroup)
+			continue
+		}
 		processors := depGroup[pr.Target]
 		if _, ok := depGroup[pr.Target]; ok {
 			processors.subscribedPlugins = append(processors.subscribedPlugins, pr)
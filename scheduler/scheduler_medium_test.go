@@ -668,3 +668,128 @@ func TestEnableTask(t *testing.T) {
 
 	s.Stop()
 }
+
+func TestUpdateTask(t *testing.T) {
+	s := newScheduler()
+	s.Start()
+	w := newMockWorkflowMap()
+
+	Convey("Calling UpdateTask on a stopped task", t, func() {
+		sch := schedule.NewWindowedSchedule(interval, nil, nil, 0)
+		tsk, errs := s.CreateTask(sch, w, false)
+		So(errs.Errors(), ShouldBeEmpty)
+		So(tsk, ShouldNotBeNil)
+
+		Convey("changes the schedule and options of the task in place", func() {
+			newSch := schedule.NewWindowedSchedule(time.Second, nil, nil, 0)
+			updated, errs := s.UpdateTask(tsk.ID(), newSch, nil, core.SetTaskName("updated"), core.TaskDeadlineDuration(time.Second*6))
+			So(errs.Errors(), ShouldBeEmpty)
+			So(updated.ID(), ShouldEqual, tsk.ID())
+			So(updated.Schedule(), ShouldEqual, newSch)
+			So(updated.GetName(), ShouldEqual, "updated")
+			So(updated.DeadlineDuration(), ShouldEqual, time.Second*6)
+			So(updated.WMap(), ShouldEqual, w)
+		})
+		Convey("changes the workflow of the task", func() {
+			newW := newMockWorkflowMap()
+			newW.Collect.AddMetric("/foo/qux", 1)
+			updated, errs := s.UpdateTask(tsk.ID(), nil, newW)
+			So(errs.Errors(), ShouldBeEmpty)
+			So(updated.WMap(), ShouldEqual, newW)
+			So(updated.Schedule(), ShouldEqual, sch)
+		})
+		Convey("returns an error when the schedule does not validate", func() {
+			_, errs := s.UpdateTask(tsk.ID(), schedule.NewWindowedSchedule(0, nil, nil, 0), nil)
+			So(errs.Errors(), ShouldNotBeEmpty)
+			So(errs.Errors()[0].Error(), ShouldEqual, schedule.ErrInvalidInterval.Error())
+			So(tsk.Schedule(), ShouldEqual, sch)
+		})
+		Convey("returns an error when the workflow does not validate", func() {
+			s.metricManager.(*mockMetricManager).failValidatingMetrics = true
+			defer func() { s.metricManager.(*mockMetricManager).failValidatingMetrics = false }()
+			_, errs := s.UpdateTask(tsk.ID(), nil, newMockWorkflowMap())
+			So(errs.Errors(), ShouldNotBeEmpty)
+			So(tsk.WMap(), ShouldEqual, w)
+		})
+	})
+	Convey("Calling UpdateTask on a task that does not exist", t, func() {
+		_, errs := s.UpdateTask("1234", schedule.NewWindowedSchedule(interval, nil, nil, 0), nil)
+		So(errs.Errors(), ShouldNotBeEmpty)
+		So(errs.Errors()[0].Error(), ShouldEqual, ErrTaskNotFound.Error())
+	})
+	Convey("Calling UpdateTask on a running task", t, func() {
+		sch := schedule.NewWindowedSchedule(interval, nil, nil, 0)
+		tsk, _ := s.CreateTask(sch, w, false)
+		So(tsk, ShouldNotBeNil)
+		task := s.tasks.Get(tsk.ID())
+		task.Spin()
+		defer task.Stop()
+
+		Convey("switches the schedule between two firings", func() {
+			newSch := schedule.NewWindowedSchedule(interval*2, nil, nil, 0)
+			_, errs := s.UpdateTask(tsk.ID(), newSch, nil)
+			So(errs.Errors(), ShouldBeEmpty)
+			So(tsk.Schedule(), ShouldEqual, newSch)
+			So(tsk.State(), ShouldNotEqual, core.TaskStopped)
+		})
+		Convey("keeps its workflow when the new plugins cannot be subscribed", func() {
+			_, errs := s.UpdateTask(tsk.ID(), nil, newMockWorkflowMap())
+			So(errs.Errors(), ShouldNotBeEmpty)
+			So(tsk.WMap(), ShouldEqual, w)
+		})
+	})
+
+	s.Stop()
+}
+
+func TestValidateTask(t *testing.T) {
+	s := newScheduler()
+	s.Start()
+	w := newMockWorkflowMap()
+	sch := schedule.NewWindowedSchedule(interval, nil, nil, 0)
+
+	Convey("Calling ValidateTask with a valid workflow", t, func() {
+		v := s.ValidateTask(sch, w)
+		So(v.Valid, ShouldBeTrue)
+		So(v.Errors, ShouldBeEmpty)
+		So(len(s.tasks.Table()), ShouldEqual, 0)
+
+		Convey("returns the path and config of the plugins", func() {
+			paths := []string{}
+			for _, p := range v.Plugins {
+				paths = append(paths, p.Path)
+			}
+			So(paths, ShouldResemble, []string{
+				"workflow.collect.process[0]",
+				"workflow.collect.process[0].process[0]",
+				"workflow.collect.process[0].process[0].publish[0]",
+				"workflow.collect.publish[0]",
+			})
+			So(v.Plugins[3].Name, ShouldEqual, "rmq")
+			So(v.Plugins[3].Config["birthplace"], ShouldEqual, "dallas")
+		})
+	})
+	Convey("Calling ValidateTask with an invalid schedule and workflow", t, func() {
+		s.metricManager.(*mockMetricManager).failValidatingMetrics = true
+		defer func() { s.metricManager.(*mockMetricManager).failValidatingMetrics = false }()
+		v := s.ValidateTask(schedule.NewWindowedSchedule(0, nil, nil, 0), w)
+		So(v.Valid, ShouldBeFalse)
+
+		Convey("returns every error with its path", func() {
+			paths := []string{}
+			for _, e := range v.Errors {
+				paths = append(paths, e.Path)
+			}
+			So(paths, ShouldContain, "schedule")
+			So(paths, ShouldContain, "workflow.collect.metrics['/foo/bar']")
+			So(paths, ShouldContain, "workflow.collect.metrics['/foo/baz']")
+			So(paths, ShouldContain, "workflow.collect.process[0].process[0].publish[0]")
+			So(paths, ShouldContain, "workflow.collect.publish[0]")
+		})
+	})
+	Convey("Calling ValidateTask with a workflow without metrics", t, func() {
+		v := s.ValidateTask(sch, wmap.NewWorkflowMap())
+		So(v.Valid, ShouldBeFalse)
+		So(v.Errors[0].Path, ShouldEqual, "workflow")
+	})
+}
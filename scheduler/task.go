@@ -23,6 +23,8 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"os"
+	"path/filepath"
 	"strconv"
 	"sync"
 	"time"
@@ -30,6 +32,7 @@ import (
 	"github.com/intelsdi-x/gomit"
 	"github.com/pborman/uuid"
 	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
 
 	"github.com/intelsdi-x/snap/core"
 	"github.com/intelsdi-x/snap/core/scheduler_event"
@@ -59,6 +62,8 @@ var (
 	ErrTaskDisabledOnFailures = errors.New("Task disabled due to consecutive failures")
 	// ErrTaskNotDisabled - The error message for task must be disabled
 	ErrTaskNotDisabled = errors.New("Task must be disabled")
+	// ErrTaskUpdateStreaming - The error message for changing the schedule or workflow of a running streaming task
+	ErrTaskUpdateStreaming = errors.New("Task must be stopped to change the schedule or workflow of a streaming task")
 )
 
 type task struct {
@@ -78,6 +83,7 @@ type task struct {
 	deadlineDuration   time.Duration
 	hitCount           uint
 	missedIntervals    uint
+	skippedIntervals   uint
 	failureMutex       sync.Mutex
 	failedRuns         uint
 	lastFailureMessage string
@@ -86,9 +92,25 @@ type task struct {
 	eventEmitter       gomit.Emitter
 	RemoteManagers     managers
 	isStream           bool
+	labels             map[string]string
+	dependencies       []core.TaskDependency
+	adaptiveInterval   bool
+	priority           string
+	// intervalStretch is added to the interval of an adaptive task while its
+	// collection cannot keep up with it
+	intervalStretch time.Duration
+	// runMutex protects the context of the runs of the task, canceled by
+	// cancelRuns when the task stops
+	runMutex   sync.Mutex
+	runCtx     context.Context
+	cancelRuns context.CancelFunc
+	// autoDiscovered tasks are created from the manifests of the auto
+	// discover paths on each start, so they are not kept in the task store
+	autoDiscovered bool
 
 	maxCollectDuration time.Duration
 	maxMetricsBuffer   int64
+	deadLetters        DeadLetterSpool
 }
 
 //NewTask creates a Task
@@ -122,14 +144,25 @@ func newTask(s schedule.Schedule, wf *schedulerWorkflow, m *workManager, mm mana
 		eventEmitter:     emitter,
 		RemoteManagers:   mgrs,
 		isStream:         stream,
+		priority:         core.TaskPriorityNormal,
 	}
 	//set options
 	for _, opt := range opts {
 		opt(task)
 	}
+	// the splay of the firings is derived from the ID, which may be set by the options
+	setJitterKey(s, task.id)
 	return task, nil
 }
 
+// setJitterKey keys the splay of the firings of an interval schedule to the
+// ID of the task using it
+func setJitterKey(s schedule.Schedule, id string) {
+	if w, ok := s.(*schedule.WindowedSchedule); ok {
+		w.SetJitterKey(id)
+	}
+}
+
 // Option sets the options specified.
 // Returns an option to optionally restore the last arg's previous value.
 func (t *task) Option(opts ...core.TaskOption) core.TaskOption {
@@ -156,6 +189,117 @@ func (t *task) SetMaxMetricsBuffer(i int64) {
 	t.maxMetricsBuffer = i
 }
 
+// PublishBuffers returns the status of the buffers of the publish nodes of the task
+func (t *task) PublishBuffers() []core.PublishBufferStatus {
+	statuses := []core.PublishBufferStatus{}
+	walkPublishNodes(t.workflow.processNodes, t.workflow.publishNodes, func(pu *publishNode) {
+		if pu.buffer == nil {
+			return
+		}
+		depth, size, dropped := pu.buffer.status()
+		statuses = append(statuses, core.PublishBufferStatus{
+			PluginName:    pu.Name(),
+			PluginVersion: pu.Version(),
+			Depth:         depth,
+			Size:          size,
+			Dropped:       dropped,
+		})
+	})
+	return statuses
+}
+
+// openPublishBuffers opens the buffers of the publish nodes of wf which have
+// a buffer policy. Each buffer is kept in its own directory under path, and
+// the buffer the task already holds in a directory is reused.
+func (t *task) openPublishBuffers(wf *schedulerWorkflow, path string) error {
+	held := map[string]*publishBuffer{}
+	walkPublishNodes(t.workflow.processNodes, t.workflow.publishNodes, func(pu *publishNode) {
+		if pu.buffer != nil {
+			held[pu.buffer.dir] = pu.buffer
+		}
+	})
+	var err error
+	index := 0
+	walkPublishNodes(wf.processNodes, wf.publishNodes, func(pu *publishNode) {
+		index++
+		if err != nil || pu.bufferPolicy == nil {
+			return
+		}
+		if path == "" {
+			err = ErrPublishBufferPathNotSet
+			return
+		}
+		dir := filepath.Join(path, t.id, fmt.Sprintf("%d-%s", index, pu.Name()))
+		if b, ok := held[dir]; ok {
+			pu.buffer = b
+			return
+		}
+		pu.buffer, err = openPublishBuffer(dir, pu.bufferPolicy)
+	})
+	return err
+}
+
+// removePublishBuffers deletes the buffers of the task and the metrics they hold
+func (t *task) removePublishBuffers() {
+	walkPublishNodes(t.workflow.processNodes, t.workflow.publishNodes, func(pu *publishNode) {
+		if pu.buffer == nil {
+			return
+		}
+		t.removePublishBuffer(pu)
+		// the directory of the task is removed once it is empty
+		os.Remove(filepath.Dir(pu.buffer.dir))
+	})
+}
+
+// removeUnusedPublishBuffers deletes the buffers of the publish nodes of from
+// which are not reused by the publish nodes of to
+func (t *task) removeUnusedPublishBuffers(from, to *schedulerWorkflow) {
+	used := map[*publishBuffer]bool{}
+	walkPublishNodes(to.processNodes, to.publishNodes, func(pu *publishNode) {
+		used[pu.buffer] = true
+	})
+	walkPublishNodes(from.processNodes, from.publishNodes, func(pu *publishNode) {
+		if pu.buffer != nil && !used[pu.buffer] {
+			t.removePublishBuffer(pu)
+		}
+	})
+}
+
+func (t *task) removePublishBuffer(pu *publishNode) {
+	if err := pu.buffer.remove(); err != nil {
+		taskLogger.WithFields(log.Fields{
+			"_block":       "remove-publish-buffers",
+			"task-id":      t.id,
+			"publish-name": pu.Name(),
+			"_error":       err.Error(),
+		}).Error("unable to remove publish buffer")
+	}
+}
+
+// Labels returns the labels of the task
+func (t *task) Labels() map[string]string {
+	return t.labels
+}
+
+// SetLabels replaces the labels of the task
+func (t *task) SetLabels(labels map[string]string) {
+	l := make(map[string]string, len(labels))
+	for k, v := range labels {
+		l[k] = v
+	}
+	t.labels = l
+}
+
+// Dependencies returns the tasks the task depends on
+func (t *task) Dependencies() []core.TaskDependency {
+	return t.dependencies
+}
+
+// SetDependencies replaces the tasks the task depends on
+func (t *task) SetDependencies(deps []core.TaskDependency) {
+	t.dependencies = append([]core.TaskDependency(nil), deps...)
+}
+
 //Returns the name of the task
 func (t *task) GetName() string {
 	return t.name
@@ -202,6 +346,11 @@ func (t *task) MissedCount() uint {
 	return t.missedIntervals
 }
 
+// SkippedCount returns the number of firings skipped within blackout windows.
+func (t *task) SkippedCount() uint {
+	return t.skippedIntervals
+}
+
 // FailedRuns returns the number of intervals missed.
 func (t *task) FailedCount() uint {
 	return t.failedRuns
@@ -234,6 +383,15 @@ func (t *task) GetStopOnFailure() int {
 	return t.stopOnFailure
 }
 
+// Priority returns the priority class of the jobs of the task.
+func (t *task) Priority() string {
+	return t.priority
+}
+
+func (t *task) SetPriority(p string) {
+	t.priority = p
+}
+
 // Spin will start a task spinning in its own routine while it waits for its
 // schedule.
 func (t *task) Spin() {
@@ -254,10 +412,12 @@ func (t *task) Spin() {
 	// waiting a period of time, and starting the task won't show
 	// misses for the interval while stopped.
 	t.lastFireTime = time.Time{}
+	t.intervalStretch = 0
 
 	if t.state == core.TaskStopped || t.state == core.TaskEnded {
 		t.state = core.TaskSpinning
 		t.killChan = make(chan struct{})
+		t.startRuns()
 		// spin in a goroutine
 		go t.spin()
 	}
@@ -355,6 +515,8 @@ func (t *task) stream() {
 }
 
 func (t *task) Stop() {
+	// abort the firing in flight, which holds the lock of the task
+	t.stopRuns()
 	t.Lock()
 	defer t.Unlock()
 	if t.state == core.TaskFiring || t.state == core.TaskSpinning {
@@ -398,26 +560,31 @@ func (t *task) UnsubscribePlugins() []serror.SnapError {
 // If there are errors with subscribing any deps, manage unsubscribing all other deps that may have already been subscribed
 // and then return the errors.
 func (t *task) SubscribePlugins() ([]string, []serror.SnapError) {
-	depGroups := getWorkflowPlugins(t.workflow.processNodes, t.workflow.publishNodes, t.workflow.metrics)
+	return subscribeDeps(t.ID(), t.workflow, &t.RemoteManagers)
+}
+
+// subscribeDeps subscribes the dependencies of a workflow under the given id
+func subscribeDeps(id string, wf *schedulerWorkflow, mgrs *managers) ([]string, []serror.SnapError) {
+	depGroups := getWorkflowPlugins(wf.processNodes, wf.publishNodes, wf.metrics)
 	var subbedDeps []string
 	for k := range depGroups {
 		var errs []serror.SnapError
-		mgr, err := t.RemoteManagers.Get(k)
+		mgr, err := mgrs.Get(k)
 		if err != nil {
 			errs = append(errs, serror.New(err))
 		} else {
-			errs = mgr.SubscribeDeps(t.ID(), depGroups[k].requestedMetrics, depGroups[k].subscribedPlugins, t.workflow.configTree)
+			errs = mgr.SubscribeDeps(id, depGroups[k].requestedMetrics, depGroups[k].subscribedPlugins, wf.configTree)
 		}
 		// If there are errors with subscribing any deps, go through and unsubscribe all other
 		// deps that may have already been subscribed then return the errors.
 		if len(errs) > 0 {
 			for _, key := range subbedDeps {
-				mgr, err := t.RemoteManagers.Get(key)
+				mgr, err := mgrs.Get(key)
 				if err != nil {
 					errs = append(errs, serror.New(err))
 				} else {
 					// sending empty mts to unsubscribe to indicate task should not start
-					uerrs := mgr.UnsubscribeDeps(t.ID())
+					uerrs := mgr.UnsubscribeDeps(id)
 					errs = append(errs, uerrs...)
 				}
 			}
@@ -430,6 +597,83 @@ func (t *task) SubscribePlugins() ([]string, []serror.SnapError) {
 	return subbedDeps, nil
 }
 
+// unsubscribeDeps unsubscribes the dependencies of a workflow subscribed under the given id
+func unsubscribeDeps(id string, wf *schedulerWorkflow, mgrs *managers) []serror.SnapError {
+	var errs []serror.SnapError
+	for k := range getWorkflowPlugins(wf.processNodes, wf.publishNodes, wf.metrics) {
+		mgr, err := mgrs.Get(k)
+		if err != nil {
+			errs = append(errs, serror.New(err))
+			continue
+		}
+		errs = append(errs, mgr.UnsubscribeDeps(id)...)
+	}
+	return errs
+}
+
+// update switches the task over to the given schedule and workflow, either of
+// which may be nil to keep the current one, and applies the options. The task
+// is locked during the switch, so a running task changes between two firings
+// and its new schedule applies from the firing following the update.
+func (t *task) update(sch schedule.Schedule, wf *schedulerWorkflow, mgrs managers, bufferPath string, opts ...core.TaskOption) []serror.SnapError {
+	t.Lock()
+	running := t.state == core.TaskFiring || t.state == core.TaskSpinning
+	_, stream := sch.(*schedule.StreamingSchedule)
+	if running && (t.isStream || stream) && (sch != nil || wf != nil) {
+		t.Unlock()
+		return []serror.SnapError{serror.New(ErrTaskUpdateStreaming)}
+	}
+	t.Unlock()
+
+	// The dependencies of the new workflow are first subscribed under a
+	// staging id, so the plugins shared with the current workflow stay
+	// subscribed and only the changed ones are loaded or released.
+	if running && wf != nil {
+		staging := t.id + "-update"
+		if _, errs := subscribeDeps(staging, wf, &mgrs); len(errs) > 0 {
+			return errs
+		}
+		defer unsubscribeDeps(staging, wf, &mgrs)
+	}
+
+	t.Lock()
+	defer t.Unlock()
+	if wf != nil {
+		if err := t.openPublishBuffers(wf, bufferPath); err != nil {
+			t.removeUnusedPublishBuffers(wf, t.workflow)
+			return []serror.SnapError{serror.New(err)}
+		}
+		if t.state == core.TaskSpinning {
+			unsubscribeDeps(t.id, t.workflow, &t.RemoteManagers)
+			if _, errs := subscribeDeps(t.id, wf, &mgrs); len(errs) > 0 {
+				subscribeDeps(t.id, t.workflow, &t.RemoteManagers)
+				t.removeUnusedPublishBuffers(wf, t.workflow)
+				return errs
+			}
+		}
+		walkPublishNodes(wf.processNodes, wf.publishNodes, func(pu *publishNode) {
+			if pu.buffer != nil {
+				pu.buffer.setPolicy(pu.bufferPolicy)
+			}
+		})
+		t.removeUnusedPublishBuffers(t.workflow, wf)
+		t.workflow, t.RemoteManagers = wf, mgrs
+	}
+	if sch != nil {
+		// a triggered schedule replaced is no longer fed, so its wait is ended
+		// and the task waits on the new schedule instead
+		if old, ok := t.schedule.(*schedule.TriggeredSchedule); ok {
+			defer old.Release()
+		}
+		setJitterKey(sch, t.id)
+		t.schedule, t.isStream = sch, stream
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return nil
+}
+
 //Enable changes the state from Disabled to Stopped
 func (t *task) Enable() error {
 	t.Lock()
@@ -444,6 +688,7 @@ func (t *task) Enable() error {
 }
 
 func (t *task) Kill() {
+	t.stopRuns()
 	t.Lock()
 	defer t.Unlock()
 	if t.state == core.TaskFiring || t.state == core.TaskSpinning {
@@ -475,7 +720,9 @@ func (t *task) spin() {
 			// If response show this schedule is still active we fire
 			case schedule.Active:
 				t.missedIntervals += sr.Missed()
+				t.skippedIntervals += sr.Skipped()
 				t.fire()
+				t.adaptInterval(time.Since(t.lastFireTime))
 				if t.lastFailureTime == t.lastFireTime {
 					consecutiveFailures++
 					taskLogger.WithFields(log.Fields{
@@ -562,10 +809,34 @@ func (t *task) disable(failureMsg string) {
 }
 
 func (t *task) waitForSchedule() {
-	select {
-	case <-t.killChan:
+	// the schedule may be switched by an update while the task is waiting
+	for {
+		t.Lock()
+		sch, lastFireTime := t.schedule, t.lastFireTime
+		if !lastFireTime.IsZero() {
+			// the stretched interval of an adaptive task delays the next firing
+			lastFireTime = lastFireTime.Add(t.intervalStretch)
+		}
+		t.Unlock()
+		sr := sch.Wait(lastFireTime)
+		if _, ok := sch.(*schedule.TriggeredSchedule); ok {
+			t.Lock()
+			replaced := t.schedule != sch
+			t.Unlock()
+			if replaced {
+				select {
+				case <-t.killChan:
+					return
+				default:
+					continue
+				}
+			}
+		}
+		select {
+		case <-t.killChan:
+		case t.schResponseChan <- sr:
+		}
 		return
-	case t.schResponseChan <- t.schedule.Wait(t.lastFireTime):
 	}
 }
 
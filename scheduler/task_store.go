@@ -0,0 +1,240 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/scheduler/wmap"
+)
+
+const (
+	taskRecordExt = ".json"
+)
+
+var (
+	storeLogger = schedulerLogger.WithField("_module", "scheduler-task-store")
+
+	// ErrTaskStoreRecordInvalid - The error message for a task record which cannot be restored
+	ErrTaskStoreRecordInvalid = errors.New("Task record is missing a schedule or workflow")
+)
+
+// TaskStore persists tasks so they can be restored when snapteld restarts.
+// Implementations must be safe for concurrent use.
+type TaskStore interface {
+	// Save creates or replaces the record of a task
+	Save(*TaskRecord) error
+	// Delete removes the record of the task with the given ID
+	Delete(id string) error
+	// Load returns all the records held by the store
+	Load() ([]*TaskRecord, error)
+}
+
+// TaskRecord is the durable representation of a task held by a TaskStore.
+type TaskRecord struct {
+	ID                 string                `json:"id"`
+	Name               string                `json:"name"`
+	Schedule           *core.Schedule        `json:"schedule"`
+	Workflow           *wmap.WorkflowMap     `json:"workflow"`
+	Deadline           string                `json:"deadline"`
+	MaxFailures        int                   `json:"max-failures"`
+	MaxCollectDuration string                `json:"max-collect-duration,omitempty"`
+	MaxMetricsBuffer   int64                 `json:"max-metrics-buffer,omitempty"`
+	CreationTimestamp  int64                 `json:"creation_timestamp"`
+	State              string                `json:"state"`
+	Labels             map[string]string     `json:"labels,omitempty"`
+	Dependencies       []core.TaskDependency `json:"dependencies,omitempty"`
+	AdaptiveInterval   bool                  `json:"adaptive-interval,omitempty"`
+	Priority           string                `json:"priority,omitempty"`
+}
+
+// newTaskRecord returns the record describing the current definition and state of a task
+func newTaskRecord(t *task) *TaskRecord {
+	r := &TaskRecord{
+		ID:                t.ID(),
+		Name:              t.GetName(),
+		Schedule:          core.ScheduleFromSchedule(t.Schedule()),
+		Workflow:          t.WMap(),
+		Deadline:          t.DeadlineDuration().String(),
+		MaxFailures:       t.GetStopOnFailure(),
+		MaxMetricsBuffer:  t.MaxMetricsBuffer(),
+		CreationTimestamp: t.CreationTime().Unix(),
+		State:             t.State().String(),
+		Labels:            t.Labels(),
+		Dependencies:      t.Dependencies(),
+		AdaptiveInterval:  t.AdaptiveInterval(),
+		Priority:          t.Priority(),
+	}
+	if t.MaxCollectDuration() != 0 {
+		r.MaxCollectDuration = t.MaxCollectDuration().String()
+	}
+	return r
+}
+
+// options returns the task options needed to recreate the task described by the record
+func (r *TaskRecord) options() ([]core.TaskOption, error) {
+	opts := []core.TaskOption{
+		core.SetTaskID(r.ID),
+		core.OptionStopOnFailure(r.MaxFailures),
+	}
+	if r.Name != "" {
+		opts = append(opts, core.SetTaskName(r.Name))
+	}
+	if r.Deadline != "" {
+		dl, err := time.ParseDuration(r.Deadline)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, core.TaskDeadlineDuration(dl))
+	}
+	if r.MaxCollectDuration != "" {
+		d, err := time.ParseDuration(r.MaxCollectDuration)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, core.SetMaxCollectDuration(d))
+	}
+	if r.MaxMetricsBuffer != 0 {
+		opts = append(opts, core.SetMaxMetricsBuffer(r.MaxMetricsBuffer))
+	}
+	if len(r.Labels) > 0 {
+		opts = append(opts, core.SetTaskLabels(r.Labels))
+	}
+	if len(r.Dependencies) > 0 {
+		opts = append(opts, core.SetTaskDependencies(r.Dependencies))
+	}
+	if r.AdaptiveInterval {
+		opts = append(opts, core.SetAdaptiveInterval(true))
+	}
+	if r.Priority != "" {
+		opts = append(opts, core.SetTaskPriority(r.Priority))
+	}
+	return opts, nil
+}
+
+// fileTaskStore is the default TaskStore. It keeps one JSON document per
+// task in a local directory and replaces documents atomically so that a
+// crash while saving never leaves a partially written record behind.
+type fileTaskStore struct {
+	sync.Mutex
+	path string
+}
+
+// NewFileTaskStore returns a TaskStore keeping its records in the given directory.
+// The directory is created on first use if it does not exist.
+func NewFileTaskStore(path string) TaskStore {
+	return &fileTaskStore{path: path}
+}
+
+func (f *fileTaskStore) Save(r *TaskRecord) error {
+	f.Lock()
+	defer f.Unlock()
+	if err := os.MkdirAll(f.path, 0700); err != nil {
+		return err
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(f.path, r.ID+taskRecordExt, b)
+}
+
+func (f *fileTaskStore) Delete(id string) error {
+	f.Lock()
+	defer f.Unlock()
+	err := os.Remove(f.recordPath(id))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (f *fileTaskStore) Load() ([]*TaskRecord, error) {
+	f.Lock()
+	defer f.Unlock()
+	if err := os.MkdirAll(f.path, 0700); err != nil {
+		return nil, err
+	}
+	files, err := ioutil.ReadDir(f.path)
+	if err != nil {
+		return nil, err
+	}
+	records := []*TaskRecord{}
+	for _, file := range files {
+		// skip directories and temporary files left over from an interrupted save
+		if file.IsDir() || strings.HasPrefix(file.Name(), ".") || !strings.HasSuffix(file.Name(), taskRecordExt) {
+			continue
+		}
+		b, err := ioutil.ReadFile(filepath.Join(f.path, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		r := &TaskRecord{}
+		if err := json.Unmarshal(b, r); err != nil {
+			storeLogger.WithFields(log.Fields{
+				"_block":      "load",
+				"task-record": file.Name(),
+				"_error":      err.Error(),
+			}).Error("unable to parse task record")
+			continue
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+// writeFileAtomic replaces the named file of dir with a file holding b. The
+// content is written to a hidden temporary file first, so that readers never
+// see a partially written file.
+func writeFileAtomic(dir, name string, b []byte) error {
+	tmp, err := ioutil.TempFile(dir, "."+name)
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, name))
+}
+
+func (f *fileTaskStore) recordPath(id string) string {
+	return filepath.Join(f.path, id+taskRecordExt)
+}
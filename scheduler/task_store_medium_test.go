@@ -0,0 +1,174 @@
+// +build medium
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/pkg/schedule"
+)
+
+func TestFileTaskStore(t *testing.T) {
+	Convey("Given a file task store", t, func() {
+		dir, err := ioutil.TempDir("", "snap-task-store")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		ts := NewFileTaskStore(filepath.Join(dir, "tasks"))
+
+		Convey("Load returns no records from an empty store", func() {
+			records, err := ts.Load()
+			So(err, ShouldBeNil)
+			So(records, ShouldBeEmpty)
+		})
+		Convey("Saved records are loaded back", func() {
+			r := &TaskRecord{
+				ID:       "1234",
+				Name:     "my-task",
+				Schedule: &core.Schedule{Type: "simple", Interval: "1s"},
+				Workflow: newMockWorkflowMap(),
+				Deadline: "5s",
+				State:    "Running",
+			}
+			So(ts.Save(r), ShouldBeNil)
+			r.State = "Stopped"
+			So(ts.Save(r), ShouldBeNil)
+
+			records, err := ts.Load()
+			So(err, ShouldBeNil)
+			So(len(records), ShouldEqual, 1)
+			So(records[0].ID, ShouldEqual, "1234")
+			So(records[0].Name, ShouldEqual, "my-task")
+			So(records[0].State, ShouldEqual, "Stopped")
+			So(records[0].Workflow.Collect.Metrics, ShouldContainKey, "/foo/bar")
+
+			Convey("and deleted records are gone", func() {
+				So(ts.Delete("1234"), ShouldBeNil)
+				records, err := ts.Load()
+				So(err, ShouldBeNil)
+				So(records, ShouldBeEmpty)
+				So(ts.Delete("1234"), ShouldBeNil)
+			})
+		})
+		Convey("Corrupted records are skipped", func() {
+			So(os.MkdirAll(filepath.Join(dir, "tasks"), 0700), ShouldBeNil)
+			So(ioutil.WriteFile(filepath.Join(dir, "tasks", "bad.json"), []byte("{"), 0600), ShouldBeNil)
+			records, err := ts.Load()
+			So(err, ShouldBeNil)
+			So(records, ShouldBeEmpty)
+		})
+	})
+}
+
+func TestSchedulerTaskStore(t *testing.T) {
+	Convey("Given a scheduler with a task store", t, func() {
+		dir, err := ioutil.TempDir("", "snap-task-store")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		cfg := GetDefaultConfig()
+		cfg.TaskStorePath = dir
+		s := New(cfg)
+		s.SetMetricManager(newMockMetricManager())
+		So(s.Start(), ShouldBeNil)
+
+		sch := schedule.NewWindowedSchedule(time.Second, nil, nil, 0)
+		tsk, errs := s.CreateTask(sch, newMockWorkflowMap(), false, core.SetTaskName("persisted"), core.OptionStopOnFailure(3))
+		So(errs.Errors(), ShouldBeEmpty)
+
+		Convey("the task is saved in the store", func() {
+			records, err := s.store.Load()
+			So(err, ShouldBeNil)
+			So(len(records), ShouldEqual, 1)
+			So(records[0].ID, ShouldEqual, tsk.ID())
+			So(records[0].State, ShouldEqual, core.TaskStopped.String())
+		})
+		Convey("the task is restored by a new scheduler", func() {
+			s.Stop()
+			s2 := New(cfg)
+			s2.SetMetricManager(newMockMetricManager())
+			So(s2.Start(), ShouldBeNil)
+			rt, err := s2.GetTask(tsk.ID())
+			So(err, ShouldBeNil)
+			So(rt.GetName(), ShouldEqual, "persisted")
+			So(rt.GetStopOnFailure(), ShouldEqual, 3)
+			So(rt.CreationTime().Unix(), ShouldEqual, tsk.CreationTime().Unix())
+			So(rt.Schedule().(*schedule.WindowedSchedule).Interval, ShouldEqual, time.Second)
+		})
+		Convey("the task is removed from the store when removed", func() {
+			So(s.RemoveTask(tsk.ID()), ShouldBeNil)
+			records, err := s.store.Load()
+			So(err, ShouldBeNil)
+			So(records, ShouldBeEmpty)
+		})
+	})
+}
+
+func TestSchedulerTaskStoreAutoDiscover(t *testing.T) {
+	Convey("Given a scheduler with a task store and an auto discover path", t, func() {
+		dir, err := ioutil.TempDir("", "snap-task-store")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		autoload := filepath.Join(dir, "autoload")
+		So(os.MkdirAll(autoload, 0700), ShouldBeNil)
+		manifest := `{
+			"version": 1,
+			"schedule": {"type": "simple", "interval": "1s"},
+			"workflow": {"collect": {"metrics": {"/foo/bar": {}}}}
+		}`
+		So(ioutil.WriteFile(filepath.Join(autoload, "task.json"), []byte(manifest), 0600), ShouldBeNil)
+
+		cfg := GetDefaultConfig()
+		cfg.TaskStorePath = filepath.Join(dir, "store")
+		start := func() *scheduler {
+			s := New(cfg)
+			mm := newMockMetricManager()
+			mm.SetAutodiscoverPaths([]string{autoload})
+			s.SetMetricManager(mm)
+			So(s.Start(), ShouldBeNil)
+			return s
+		}
+		s := start()
+		So(len(s.GetTasks()), ShouldEqual, 1)
+
+		Convey("the auto discovered task is not saved in the store", func() {
+			records, err := s.store.Load()
+			So(err, ShouldBeNil)
+			So(records, ShouldBeEmpty)
+		})
+		Convey("restarting does not duplicate the auto discovered task", func() {
+			s.Stop()
+			s2 := start()
+			So(len(s2.GetTasks()), ShouldEqual, 1)
+			s2.Stop()
+			s3 := start()
+			defer s3.Stop()
+			So(len(s3.GetTasks()), ShouldEqual, 1)
+		})
+	})
+}
@@ -0,0 +1,143 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/cdata"
+	"github.com/intelsdi-x/snap/core/serror"
+	"github.com/intelsdi-x/snap/pkg/schedule"
+	"github.com/intelsdi-x/snap/scheduler/wmap"
+)
+
+// resolvesConfig is implemented by the metric managers which resolve the
+// config the metrics and plugins of a workflow are given once subscribed,
+// like control
+type resolvesConfig interface {
+	ResolveConfig([]core.RequestedMetric, []core.SubscribedPlugin, *cdata.ConfigDataTree) ([]core.Metric, []*cdata.ConfigDataNode)
+}
+
+// ValidateTask validates a schedule and a workflow map against the plugins and
+// metrics loaded without creating a task. The metrics and the plugins of the
+// workflow are validated one by one, so that every error is located by the
+// JSON path of the element of the task manifest it applies to. The schedule is
+// only validated when it is not nil.
+func (s *scheduler) ValidateTask(sch schedule.Schedule, wfMap *wmap.WorkflowMap) *core.TaskValidation {
+	v := &core.TaskValidation{}
+	if s.state != schedulerStarted {
+		v.AddError("", serror.New(ErrSchedulerNotStarted))
+		return v
+	}
+	if sch != nil {
+		if err := sch.Validate(); err != nil {
+			v.AddError("schedule", serror.New(err))
+		}
+	}
+
+	wf, err := wmapToWorkflow(wfMap)
+	if err != nil {
+		v.AddError("workflow", serror.New(err))
+		return v
+	}
+	mgrs := newManagers(s.metricManager)
+	if err := createTaskClients(&mgrs, wf); err != nil {
+		v.AddError("workflow", serror.New(err))
+		return v
+	}
+
+	errCount := len(v.Errors)
+	local, _ := mgrs.Get("")
+	for _, mt := range wf.metrics {
+		path := fmt.Sprintf("workflow.collect.metrics['%s']", mt.Namespace().String())
+		requested := []core.RequestedMetric{mt}
+		v.AddErrors(path, local.ValidateDeps(requested, nil, wf.configTree))
+		if r, ok := local.(resolvesConfig); ok {
+			mts, _ := r.ResolveConfig(requested, nil, wf.configTree)
+			sort.Sort(metricsByNamespace(mts))
+			for _, m := range mts {
+				v.Metrics = append(v.Metrics, core.NewResolvedConfig(path, m.Namespace().String(), "", m.Version(), m.Config()))
+			}
+		}
+	}
+	validateWorkflowNodes(v, "workflow.collect", wf.processNodes, wf.publishNodes, wf.configTree, &mgrs)
+
+	// the rules on the collectors the schedule allows apply to the workflow
+	// as a whole
+	if sch != nil && len(v.Errors) == errCount {
+		v.AddErrors("workflow.collect.metrics", validateWorkflowDeps(sch, wf, &mgrs))
+	}
+	v.Valid = len(v.Errors) == 0
+	return v
+}
+
+// validateWorkflowNodes validates the plugins of process and publish nodes one
+// by one, recording their errors and their resolved config at their path
+func validateWorkflowNodes(v *core.TaskValidation, path string, prnodes []*processNode, pbnodes []*publishNode, configTree *cdata.ConfigDataTree, mgrs *managers) {
+	// aggregate nodes follow the process nodes, and do not call a plugin
+	var processed, aggregated int
+	for _, pr := range prnodes {
+		var p string
+		if pr.aggregator != nil {
+			p = fmt.Sprintf("%s.aggregate[%d]", path, aggregated)
+			aggregated++
+		} else {
+			p = fmt.Sprintf("%s.process[%d]", path, processed)
+			processed++
+			validateWorkflowPlugin(v, p, pr, pr.Target, configTree, mgrs)
+		}
+		validateWorkflowNodes(v, p, pr.ProcessNodes, pr.PublishNodes, configTree, mgrs)
+	}
+	for i, pb := range pbnodes {
+		validateWorkflowPlugin(v, fmt.Sprintf("%s.publish[%d]", path, i), pb, pb.Target, configTree, mgrs)
+	}
+}
+
+func validateWorkflowPlugin(v *core.TaskValidation, path string, plg core.SubscribedPlugin, target string, configTree *cdata.ConfigDataTree, mgrs *managers) {
+	manager, err := mgrs.Get(target)
+	if err != nil {
+		v.AddError(path, serror.New(err))
+		return
+	}
+	plugins := []core.SubscribedPlugin{plg}
+	v.AddErrors(path, manager.ValidateDeps(nil, plugins, configTree))
+	cfg := plg.Config()
+	if r, ok := manager.(resolvesConfig); ok {
+		_, cfgs := r.ResolveConfig(nil, plugins, configTree)
+		cfg = cfgs[0]
+	}
+	v.Plugins = append(v.Plugins, core.NewResolvedConfig(path, plg.Name(), plg.TypeName(), plg.Version(), cfg))
+}
+
+type metricsByNamespace []core.Metric
+
+func (m metricsByNamespace) Len() int {
+	return len(m)
+}
+
+func (m metricsByNamespace) Less(i, j int) bool {
+	return m[i].Namespace().String() < m[j].Namespace().String()
+}
+
+func (m metricsByNamespace) Swap(i, j int) {
+	m[i], m[j] = m[j], m[i]
+}
@@ -0,0 +1,394 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ghodss/yaml"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/pkg/schedule"
+	"github.com/intelsdi-x/snap/scheduler/wmap"
+)
+
+const (
+	templateRecordExt = ".json"
+	templateStoreDir  = "templates"
+
+	// the extensions, before .json, .yaml or .yml, of the template and
+	// values files of the auto discover paths
+	templateFileExt = ".template"
+	valuesFileExt   = ".values"
+)
+
+var (
+	// ErrTemplateNotFound - The error message for a template which does not exist
+	ErrTemplateNotFound = errors.New("Template not found")
+	// ErrTemplateAlreadyExists - The error message for a template whose name is already taken
+	ErrTemplateAlreadyExists = errors.New("A template with this name already exists")
+)
+
+// TemplateStore persists task templates so they can be restored when
+// snapteld restarts. Implementations must be safe for concurrent use.
+type TemplateStore interface {
+	// Save creates or replaces a template
+	Save(*core.TaskTemplate) error
+	// Delete removes the template with the given name
+	Delete(name string) error
+	// Load returns all the templates held by the store
+	Load() ([]*core.TaskTemplate, error)
+}
+
+// fileTemplateStore is the default TemplateStore. It keeps one JSON document
+// per template in a local directory, like the fileTaskStore does for tasks.
+type fileTemplateStore struct {
+	sync.Mutex
+	path string
+}
+
+// NewFileTemplateStore returns a TemplateStore keeping its templates in the given directory.
+// The directory is created on first use if it does not exist.
+func NewFileTemplateStore(path string) TemplateStore {
+	return &fileTemplateStore{path: path}
+}
+
+func (f *fileTemplateStore) Save(t *core.TaskTemplate) error {
+	f.Lock()
+	defer f.Unlock()
+	if err := os.MkdirAll(f.path, 0700); err != nil {
+		return err
+	}
+	b, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(f.path, t.Name+templateRecordExt, b)
+}
+
+func (f *fileTemplateStore) Delete(name string) error {
+	f.Lock()
+	defer f.Unlock()
+	err := os.Remove(filepath.Join(f.path, name+templateRecordExt))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (f *fileTemplateStore) Load() ([]*core.TaskTemplate, error) {
+	f.Lock()
+	defer f.Unlock()
+	if err := os.MkdirAll(f.path, 0700); err != nil {
+		return nil, err
+	}
+	files, err := ioutil.ReadDir(f.path)
+	if err != nil {
+		return nil, err
+	}
+	templates := []*core.TaskTemplate{}
+	for _, file := range files {
+		// skip directories and temporary files left over from an interrupted save
+		if file.IsDir() || strings.HasPrefix(file.Name(), ".") || !strings.HasSuffix(file.Name(), templateRecordExt) {
+			continue
+		}
+		b, err := ioutil.ReadFile(filepath.Join(f.path, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		t := &core.TaskTemplate{}
+		if err := json.Unmarshal(b, t); err != nil {
+			storeLogger.WithFields(log.Fields{
+				"_block":   "load-templates",
+				"template": file.Name(),
+				"_error":   err.Error(),
+			}).Error("unable to parse template")
+			continue
+		}
+		templates = append(templates, t)
+	}
+	return templates, nil
+}
+
+type templateCollection struct {
+	sync.Mutex
+	table map[string]*core.TaskTemplate
+}
+
+func newTemplateCollection() *templateCollection {
+	return &templateCollection{table: map[string]*core.TaskTemplate{}}
+}
+
+// add adds a template to the collection, replacing the template of the same
+// name when replace is true
+func (c *templateCollection) add(t *core.TaskTemplate, replace bool) error {
+	c.Lock()
+	defer c.Unlock()
+	if _, ok := c.table[t.Name]; ok && !replace {
+		return ErrTemplateAlreadyExists
+	}
+	c.table[t.Name] = t
+	return nil
+}
+
+func (c *templateCollection) get(name string) (*core.TaskTemplate, error) {
+	c.Lock()
+	defer c.Unlock()
+	t, ok := c.table[name]
+	if !ok {
+		return nil, ErrTemplateNotFound
+	}
+	return t, nil
+}
+
+func (c *templateCollection) remove(name string) error {
+	c.Lock()
+	defer c.Unlock()
+	if _, ok := c.table[name]; !ok {
+		return ErrTemplateNotFound
+	}
+	delete(c.table, name)
+	return nil
+}
+
+// all returns the templates of the collection sorted by name
+func (c *templateCollection) all() []*core.TaskTemplate {
+	c.Lock()
+	defer c.Unlock()
+	templates := make([]*core.TaskTemplate, 0, len(c.table))
+	for _, t := range c.table {
+		templates = append(templates, t)
+	}
+	sort.Sort(templatesByName(templates))
+	return templates
+}
+
+type templatesByName []*core.TaskTemplate
+
+func (t templatesByName) Len() int {
+	return len(t)
+}
+
+func (t templatesByName) Less(i, j int) bool {
+	return t[i].Name < t[j].Name
+}
+
+func (t templatesByName) Swap(i, j int) {
+	t[i], t[j] = t[j], t[i]
+}
+
+// SetTemplateStore sets the store used to persist templates across restarts.
+// It must be called before the scheduler is started.
+func (s *scheduler) SetTemplateStore(ts TemplateStore) {
+	s.templateStore = ts
+}
+
+// AddTemplate validates a template and adds it to the templates of the
+// scheduler, saving it in the template store if one is set
+func (s *scheduler) AddTemplate(t *core.TaskTemplate) error {
+	if err := t.Validate(); err != nil {
+		return err
+	}
+	if err := s.templates.add(t, false); err != nil {
+		return err
+	}
+	if s.templateStore != nil {
+		if err := s.templateStore.Save(t); err != nil {
+			s.templates.remove(t.Name)
+			return err
+		}
+	}
+	schedulerLogger.WithFields(log.Fields{
+		"_block":   "add-template",
+		"template": t.Name,
+	}).Info("template added")
+	return nil
+}
+
+// GetTemplates returns the templates of the scheduler sorted by name
+func (s *scheduler) GetTemplates() []*core.TaskTemplate {
+	return s.templates.all()
+}
+
+// GetTemplate returns the template with the given name
+func (s *scheduler) GetTemplate(name string) (*core.TaskTemplate, error) {
+	return s.templates.get(name)
+}
+
+// RemoveTemplate removes the template with the given name. The tasks created
+// from the template are not affected.
+func (s *scheduler) RemoveTemplate(name string) error {
+	if err := s.templates.remove(name); err != nil {
+		return err
+	}
+	if s.templateStore != nil {
+		if err := s.templateStore.Delete(name); err != nil {
+			schedulerLogger.WithFields(log.Fields{
+				"_block":   "remove-template",
+				"template": name,
+				"_error":   err.Error(),
+			}).Error("unable to remove template from template store")
+		}
+	}
+	schedulerLogger.WithFields(log.Fields{
+		"_block":   "remove-template",
+		"template": name,
+	}).Info("template removed")
+	return nil
+}
+
+// InstantiateTemplate creates a task from the template with the given name
+// and the values of its parameters. The task is started on creation according
+// to startOnCreate, or to the start field of the template when it is nil.
+func (s *scheduler) InstantiateTemplate(name string, values map[string]interface{}, startOnCreate *bool) (core.Task, error) {
+	return s.instantiateTemplate(name, values, startOnCreate, s.CreateTask)
+}
+
+func (s *scheduler) instantiateTemplate(name string, values map[string]interface{}, startOnCreate *bool,
+	fp func(sch schedule.Schedule,
+		wfMap *wmap.WorkflowMap,
+		startOnCreate bool,
+		opts ...core.TaskOption) (core.Task, core.TaskErrors)) (core.Task, error) {
+	t, err := s.templates.get(name)
+	if err != nil {
+		return nil, err
+	}
+	return core.CreateTaskFromTemplate(t, values, startOnCreate, fp)
+}
+
+// restoreTemplates adds the templates held in the template store to the
+// templates of the scheduler
+func (s *scheduler) restoreTemplates() error {
+	templates, err := s.templateStore.Load()
+	if err != nil {
+		return err
+	}
+	for _, t := range templates {
+		if err := t.Validate(); err != nil {
+			schedulerLogger.WithFields(log.Fields{
+				"_block":   "restore-templates",
+				"template": t.Name,
+				"_error":   err.Error(),
+			}).Error("unable to restore template")
+			continue
+		}
+		s.templates.add(t, true)
+	}
+	return nil
+}
+
+// autoDiscovered holds the files of an auto discover path which are loaded
+// once the templates of every path are known
+type autoDiscovered struct {
+	path        string
+	taskFiles   []os.FileInfo
+	valuesFiles []os.FileInfo
+}
+
+// isAutoDiscoverFile returns whether the lower case name of a JSON or YAML file
+// has the given extension before its own, like foo.template.yaml
+func isAutoDiscoverFile(fname, ext string) bool {
+	fname = strings.TrimSuffix(fname, filepath.Ext(fname))
+	return strings.HasSuffix(fname, ext)
+}
+
+// readAutoDiscoverFile reads a JSON or YAML file, returning its content as JSON
+func readAutoDiscoverFile(fpath string) ([]byte, error) {
+	b, err := ioutil.ReadFile(fpath)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasSuffix(strings.ToLower(fpath), ".json") {
+		return b, nil
+	}
+	return yaml.YAMLToJSON(b)
+}
+
+// autoDiscoverTemplates loads the templates of an auto discover path. They
+// replace the templates of the same name, and are not saved in the template
+// store since they are loaded again on every start.
+func (s *scheduler) autoDiscoverTemplates(templateFiles []os.FileInfo, fullPath string) {
+	for _, file := range templateFiles {
+		flog := schedulerLogger.WithFields(log.Fields{
+			"_block":           "autoDiscoverTemplates",
+			"autodiscoverpath": fullPath,
+			"template-file":    file.Name(),
+		})
+		b, err := readAutoDiscoverFile(filepath.Join(fullPath, file.Name()))
+		if err != nil {
+			flog.Error("Reading template file ", err)
+			continue
+		}
+		t := &core.TaskTemplate{}
+		if err := json.Unmarshal(b, t); err != nil {
+			flog.Error("Parsing template file ", err)
+			continue
+		}
+		if err := t.Validate(); err != nil {
+			flog.Error(err)
+			continue
+		}
+		s.templates.add(t, true)
+		flog.WithField("template", t.Name).Info("Loading template")
+	}
+}
+
+// autoDiscoverTemplateValues creates a task from each values file of an auto
+// discover path, naming the template to instantiate and the values of its
+// parameters
+func (s *scheduler) autoDiscoverTemplateValues(valuesFiles []os.FileInfo, fullPath string) {
+	for _, file := range valuesFiles {
+		flog := schedulerLogger.WithFields(log.Fields{
+			"_block":           "autoDiscoverTemplateValues",
+			"autodiscoverpath": fullPath,
+			"task-file-name":   file.Name(),
+		})
+		b, err := readAutoDiscoverFile(filepath.Join(fullPath, file.Name()))
+		if err != nil {
+			flog.Error("Reading values file ", err)
+			continue
+		}
+		tv, err := core.ReadTemplateValues(bytes.NewReader(b))
+		if err != nil {
+			flog.Error("Parsing values file ", err)
+			continue
+		}
+		mode := true
+		task, err := s.instantiateTemplate(tv.Template, tv.Values, &mode, s.createAutoDiscoveredTask)
+		if err != nil {
+			flog.WithField("template", tv.Template).Error(err)
+			continue
+		}
+		flog.WithFields(log.Fields{
+			"template": tv.Template,
+			"task-ID":  task.ID(),
+		}).Info("Loading task")
+	}
+}
@@ -0,0 +1,128 @@
+// +build medium
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/pkg/schedule"
+)
+
+const templateTask = `{
+	"name": "collect-${metric}",
+	"schedule": {"type": "simple", "interval": "${interval}"},
+	"workflow": {"collect": {"metrics": {"/foo/${metric}": {}}}}
+}`
+
+func newTestTemplate() *core.TaskTemplate {
+	return &core.TaskTemplate{
+		Name: "collect-foo",
+		Parameters: []core.TemplateParameter{
+			{Name: "metric"},
+			{Name: "interval", Type: core.TemplateParamDuration, Default: "1s"},
+		},
+		Task: json.RawMessage(templateTask),
+	}
+}
+
+func TestSchedulerTemplates(t *testing.T) {
+	Convey("Given a scheduler with a template store", t, func() {
+		dir, err := ioutil.TempDir("", "snap-template-store")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		cfg := GetDefaultConfig()
+		cfg.TaskStorePath = dir
+		s := New(cfg)
+		s.SetMetricManager(newMockMetricManager())
+		So(s.Start(), ShouldBeNil)
+		So(s.AddTemplate(newTestTemplate()), ShouldBeNil)
+
+		Convey("a template name is used once", func() {
+			So(s.AddTemplate(newTestTemplate()), ShouldEqual, ErrTemplateAlreadyExists)
+		})
+		Convey("a task is created from the template", func() {
+			mode := false
+			tsk, err := s.InstantiateTemplate("collect-foo", map[string]interface{}{"metric": "bar", "interval": "2s"}, &mode)
+			So(err, ShouldBeNil)
+			So(tsk.GetName(), ShouldEqual, "collect-bar")
+			So(tsk.Schedule().(*schedule.WindowedSchedule).Interval, ShouldEqual, 2*time.Second)
+			So(tsk.WMap().Collect.Metrics, ShouldContainKey, "/foo/bar")
+			So(tsk.State(), ShouldEqual, core.TaskStopped)
+
+			_, err = s.InstantiateTemplate("collect-foo", nil, &mode)
+			So(err, ShouldNotBeNil)
+			_, err = s.InstantiateTemplate("collect-baz", nil, &mode)
+			So(err, ShouldEqual, ErrTemplateNotFound)
+		})
+		Convey("the template is restored by a new scheduler", func() {
+			s.Stop()
+			s2 := New(cfg)
+			s2.SetMetricManager(newMockMetricManager())
+			So(s2.Start(), ShouldBeNil)
+			templates := s2.GetTemplates()
+			So(templates, ShouldHaveLength, 1)
+			So(templates[0].Name, ShouldEqual, "collect-foo")
+			So(templates[0].Parameters, ShouldHaveLength, 2)
+		})
+		Convey("the template is removed from the store when removed", func() {
+			So(s.RemoveTemplate("collect-foo"), ShouldBeNil)
+			So(s.RemoveTemplate("collect-foo"), ShouldEqual, ErrTemplateNotFound)
+			templates, err := s.templateStore.Load()
+			So(err, ShouldBeNil)
+			So(templates, ShouldBeEmpty)
+		})
+	})
+
+	Convey("Given an auto discover path with a template and values files", t, func() {
+		dir, err := ioutil.TempDir("", "snap-autodiscover")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		b, err := json.Marshal(newTestTemplate())
+		So(err, ShouldBeNil)
+		So(ioutil.WriteFile(filepath.Join(dir, "foo.template.json"), b, 0600), ShouldBeNil)
+		So(ioutil.WriteFile(filepath.Join(dir, "bar.values.yaml"), []byte("template: collect-foo\nvalues:\n  metric: bar\n"), 0600), ShouldBeNil)
+		So(ioutil.WriteFile(filepath.Join(dir, "baz.values.yaml"), []byte("template: collect-foo\nvalues:\n  metric: baz\n  interval: 2s\n"), 0600), ShouldBeNil)
+
+		s := newScheduler()
+		s.metricManager.(*mockMetricManager).SetAutodiscoverPaths([]string{dir})
+		So(s.Start(), ShouldBeNil)
+		defer s.Stop()
+
+		Convey("the template is loaded and a task created from each values file", func() {
+			So(s.GetTemplates(), ShouldHaveLength, 1)
+			names := map[string]bool{}
+			for _, tsk := range s.GetTasks() {
+				names[tsk.GetName()] = true
+			}
+			So(names, ShouldResemble, map[string]bool{"collect-bar": true, "collect-baz": true})
+		})
+	})
+}
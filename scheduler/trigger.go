@@ -0,0 +1,82 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"strings"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/pkg/metricfilter"
+	"github.com/intelsdi-x/snap/pkg/schedule"
+)
+
+// handleTaskTriggers feeds the metrics collected by the task with the given ID
+// to the triggered schedules watching them
+func (s *scheduler) handleTaskTriggers(id string, metrics []core.Metric) {
+	src := s.tasks.Get(id)
+	if src == nil {
+		return
+	}
+	name := src.GetName()
+	for _, t := range s.tasks.Table() {
+		sch, ok := t.Schedule().(*schedule.TriggeredSchedule)
+		if !ok || t.ID() == id || (sch.Trigger.Task != id && sch.Trigger.Task != name) {
+			continue
+		}
+		if values := triggerValues(sch.Trigger.Metric, metrics); len(values) > 0 {
+			sch.Observe(values...)
+		}
+	}
+}
+
+// releaseTrigger ends the wait of a stopped task on its triggered schedule
+func releaseTrigger(t *task) {
+	if sch, ok := t.Schedule().(*schedule.TriggeredSchedule); ok {
+		sch.Release()
+	}
+}
+
+// triggerValues returns the values of the metrics matching the namespace
+// watched by a trigger, in which `*` matches any element
+func triggerValues(ns string, metrics []core.Metric) []float64 {
+	elems := strings.Split(strings.TrimPrefix(ns, "/"), "/")
+	var values []float64
+	for _, m := range metrics {
+		if !matchTriggerNamespace(elems, m.Namespace().Strings()) {
+			continue
+		}
+		if v, ok := metricfilter.ToFloat(m.Data()); ok {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func matchTriggerNamespace(elems, ns []string) bool {
+	if len(elems) != len(ns) {
+		return false
+	}
+	for i, e := range elems {
+		if e != "*" && e != ns[i] {
+			return false
+		}
+	}
+	return true
+}
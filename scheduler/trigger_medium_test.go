@@ -0,0 +1,88 @@
+// +build medium
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/intelsdi-x/gomit"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/intelsdi-x/snap/control/plugin"
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/scheduler_event"
+	"github.com/intelsdi-x/snap/pkg/schedule"
+)
+
+func newCPUSample(cpu string, data interface{}) core.Metric {
+	return plugin.MetricType{
+		Namespace_: core.NewNamespace("intel", "procfs", "cpu", cpu, "utilization"),
+		Data_:      data,
+	}
+}
+
+func TestTriggeredTask(t *testing.T) {
+	s := newScheduler()
+	s.Start()
+	defer s.Stop()
+	w := newMockWorkflowMap()
+
+	Convey("Given a task triggered by the metrics of another task", t, func() {
+		src, errs := s.CreateTask(schedule.NewWindowedSchedule(time.Hour, nil, nil, 0), w, false, core.SetTaskName("host-cpu"))
+		So(errs.Errors(), ShouldBeEmpty)
+		sch := schedule.NewTriggeredSchedule(0, schedule.Trigger{
+			Task:       "host-cpu",
+			Metric:     "/intel/procfs/cpu/*/utilization",
+			Threshold:  90,
+			Hysteresis: 10,
+		})
+		tsk, errs := s.CreateTask(sch, w, true)
+		So(errs.Errors(), ShouldBeEmpty)
+		collected := func(metrics ...core.Metric) {
+			s.HandleGomitEvent(gomit.Event{Body: &scheduler_event.MetricCollectedEvent{TaskID: src.ID(), Metrics: metrics}})
+		}
+
+		Convey("the task does not fire below the threshold", func() {
+			collected(newCPUSample("0", 50), newCPUSample("1", uint64(80)))
+			time.Sleep(50 * time.Millisecond)
+			So(tsk.HitCount(), ShouldEqual, 0)
+			So(*sch.TriggerState().Value, ShouldEqual, 80)
+		})
+		Convey("the task fires once the threshold is crossed", func() {
+			collected(newCPUSample("0", 50), newCPUSample("1", 95.5))
+			for i := 0; i < 100 && tsk.HitCount() == 0; i++ {
+				time.Sleep(10 * time.Millisecond)
+			}
+			So(tsk.HitCount(), ShouldEqual, 1)
+			So(sch.TriggerState().Triggered, ShouldBeTrue)
+		})
+		Convey("the metrics of other tasks are ignored", func() {
+			s.HandleGomitEvent(gomit.Event{Body: &scheduler_event.MetricCollectedEvent{TaskID: tsk.ID(), Metrics: []core.Metric{newCPUSample("0", 95)}}})
+			So(sch.TriggerState().Value, ShouldBeNil)
+		})
+		Convey("the wait of the task ends when it is stopped", func() {
+			So(s.StopTask(tsk.ID()), ShouldBeEmpty)
+			So(waitForState(tsk, core.TaskStopped), ShouldEqual, core.TaskStopped)
+		})
+	})
+}
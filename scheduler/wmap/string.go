@@ -21,6 +21,7 @@ package wmap
 
 import (
 	"fmt"
+	"strings"
 )
 
 func (w *WorkflowMap) String() string {
@@ -70,6 +71,13 @@ func (c *CollectWorkflowMapNode) String(pad string) string {
 		out += pu.String(pad) + "\n"
 	}
 	out += "\n"
+	if len(c.Aggregate) > 0 {
+		out += pad + "Aggregate Nodes:\n"
+		for _, ag := range c.Aggregate {
+			out += ag.String(pad) + "\n"
+		}
+		out += "\n"
+	}
 	return out
 }
 
@@ -83,6 +91,9 @@ func (p *ProcessWorkflowMapNode) String(pad string) string {
 		out += pad + "      " + fmt.Sprintf("%s=%+v\n", k, v)
 	}
 	out += pad + "   Target:" + p.Target + "\n"
+	if p.Filter != "" {
+		out += pad + "   Filter: " + p.Filter + "\n"
+	}
 
 	out += pad + "   Process Nodes:\n"
 	for _, pr := range p.Process {
@@ -92,6 +103,33 @@ func (p *ProcessWorkflowMapNode) String(pad string) string {
 	for _, pu := range p.Publish {
 		out += pu.String(pad + "   ")
 	}
+	if len(p.Aggregate) > 0 {
+		out += pad + "   Aggregate Nodes:\n"
+		for _, ag := range p.Aggregate {
+			out += ag.String(pad + "   ")
+		}
+	}
+	return out
+}
+
+func (a *AggregateWorkflowMapNode) String(pad string) string {
+	var out string
+	out += pad + fmt.Sprintf("   Window: %s\n", a.Window)
+	if a.Slide != "" {
+		out += pad + fmt.Sprintf("   Slide: %s\n", a.Slide)
+	}
+	out += pad + fmt.Sprintf("   Functions: %s\n", strings.Join(a.Functions, ", "))
+	if a.Filter != "" {
+		out += pad + "   Filter: " + a.Filter + "\n"
+	}
+	out += pad + "   Process Nodes:\n"
+	for _, pr := range a.Process {
+		out += pr.String(pad + "   ")
+	}
+	out += pad + "   Publish Nodes:\n"
+	for _, pu := range a.Publish {
+		out += pu.String(pad + "   ")
+	}
 	return out
 }
 
@@ -104,5 +142,22 @@ func (p *PublishWorkflowMapNode) String(pad string) string {
 	for k, v := range p.Config {
 		out += pad + "      " + fmt.Sprintf("%s=%+v\n", k, v)
 	}
+	if p.Retry != nil {
+		out += pad + "   Retry:\n"
+		out += pad + fmt.Sprintf("      MaxAttempts: %d\n", p.Retry.MaxAttempts)
+		out += pad + fmt.Sprintf("      InitialInterval: %s\n", p.Retry.InitialInterval)
+		out += pad + fmt.Sprintf("      MaxInterval: %s\n", p.Retry.MaxInterval)
+		out += pad + fmt.Sprintf("      Multiplier: %v\n", p.Retry.Multiplier)
+		out += pad + fmt.Sprintf("      Jitter: %v\n", p.Retry.Jitter)
+	}
+	if p.Buffer != nil {
+		out += pad + "   Buffer:\n"
+		out += pad + fmt.Sprintf("      MaxSize: %d\n", p.Buffer.MaxSize)
+		out += pad + fmt.Sprintf("      MaxAge: %s\n", p.Buffer.MaxAge)
+		out += pad + fmt.Sprintf("      Overflow: %s\n", p.Buffer.Overflow)
+	}
+	if p.Filter != "" {
+		out += pad + "   Filter: " + p.Filter + "\n"
+	}
 	return out
 }
@@ -173,11 +173,12 @@ func (w *WorkflowMap) ToYaml() ([]byte, error) {
 // CollectWorkflowMapNode represents Snap workflow data model.
 type CollectWorkflowMapNode struct {
 	// required: true
-	Metrics map[string]metricInfo             `json:"metrics"yaml:"metrics"`
-	Config  map[string]map[string]interface{} `json:"config,omitempty"yaml:"config"`
-	Tags    map[string]map[string]string      `json:"tags,omitempty"yaml:"tags"`
-	Process []ProcessWorkflowMapNode          `json:"process,omitempty"yaml:"process"`
-	Publish []PublishWorkflowMapNode          `json:"publish,omitempty"yaml:"publish"`
+	Metrics   map[string]metricInfo             `json:"metrics"yaml:"metrics"`
+	Config    map[string]map[string]interface{} `json:"config,omitempty"yaml:"config"`
+	Tags      map[string]map[string]string      `json:"tags,omitempty"yaml:"tags"`
+	Process   []ProcessWorkflowMapNode          `json:"process,omitempty"yaml:"process"`
+	Publish   []PublishWorkflowMapNode          `json:"publish,omitempty"yaml:"publish"`
+	Aggregate []AggregateWorkflowMapNode        `json:"aggregate,omitempty"yaml:"aggregate"`
 }
 
 func (cw *CollectWorkflowMapNode) UnmarshalJSON(data []byte) error {
@@ -207,6 +208,10 @@ func (cw *CollectWorkflowMapNode) UnmarshalJSON(data []byte) error {
 			if err := json.Unmarshal(v, &cw.Publish); err != nil {
 				return err
 			}
+		case "aggregate":
+			if err := json.Unmarshal(v, &cw.Aggregate); err != nil {
+				return err
+			}
 		default:
 			return fmt.Errorf("Unrecognized key '%v' in collect workflow of task.", k)
 		}
@@ -264,6 +269,8 @@ func (c *CollectWorkflowMapNode) Add(node interface{}) error {
 		c.Process = append(c.Process, *x)
 	case *PublishWorkflowMapNode:
 		c.Publish = append(c.Publish, *x)
+	case *AggregateWorkflowMapNode:
+		c.Aggregate = append(c.Aggregate, *x)
 	default:
 		return errors.New(fmt.Sprintf("cannot add workflow node type (%v) to collect node as child", x))
 	}
@@ -286,13 +293,16 @@ func (c *CollectWorkflowMapNode) AddConfigItem(ns, key string, value interface{}
 
 type ProcessWorkflowMapNode struct {
 	// required: true
-	PluginName    string                   `json:"plugin_name"yaml:"plugin_name"`
-	PluginVersion int                      `json:"plugin_version"yaml:"plugin_version"`
-	Process       []ProcessWorkflowMapNode `json:"process,omitempty"yaml:"process"`
-	Publish       []PublishWorkflowMapNode `json:"publish,omitempty"yaml:"publish"`
+	PluginName    string                     `json:"plugin_name"yaml:"plugin_name"`
+	PluginVersion int                        `json:"plugin_version"yaml:"plugin_version"`
+	Process       []ProcessWorkflowMapNode   `json:"process,omitempty"yaml:"process"`
+	Publish       []PublishWorkflowMapNode   `json:"publish,omitempty"yaml:"publish"`
+	Aggregate     []AggregateWorkflowMapNode `json:"aggregate,omitempty"yaml:"aggregate"`
 	// Config the configuration of a processor.
 	Config map[string]interface{} `json:"config,omitempty"yaml:"config"`
 	Target string                 `json:"target"yaml:"target"`
+	// Filter the expression selecting the metrics sent to a processor
+	Filter string `json:"filter,omitempty"yaml:"filter"`
 }
 
 func (pw *ProcessWorkflowMapNode) UnmarshalJSON(data []byte) error {
@@ -318,6 +328,10 @@ func (pw *ProcessWorkflowMapNode) UnmarshalJSON(data []byte) error {
 			if err := json.Unmarshal(v, &pw.Publish); err != nil {
 				return err
 			}
+		case "aggregate":
+			if err := json.Unmarshal(v, &pw.Aggregate); err != nil {
+				return err
+			}
 		case "config":
 			if err := json.Unmarshal(v, &pw.Config); err != nil {
 				return fmt.Errorf("%v (while parsing 'config')", err)
@@ -326,6 +340,10 @@ func (pw *ProcessWorkflowMapNode) UnmarshalJSON(data []byte) error {
 			if err := json.Unmarshal(v, &pw.Target); err != nil {
 				return fmt.Errorf("%v (while parsing 'target')", err)
 			}
+		case "filter":
+			if err := json.Unmarshal(v, &pw.Filter); err != nil {
+				return fmt.Errorf("%v (while parsing 'filter')", err)
+			}
 		default:
 			return fmt.Errorf("Unrecognized key '%v' in process workflow of task.", k)
 		}
@@ -348,6 +366,8 @@ func (p *ProcessWorkflowMapNode) Add(node interface{}) error {
 		p.Process = append(p.Process, *x)
 	case *PublishWorkflowMapNode:
 		p.Publish = append(p.Publish, *x)
+	case *AggregateWorkflowMapNode:
+		p.Aggregate = append(p.Aggregate, *x)
 	default:
 		return errors.New(fmt.Sprintf("cannot add workflow node type (%v) to process node as child", x))
 	}
@@ -368,6 +388,80 @@ func (p *ProcessWorkflowMapNode) GetConfigNode() (*cdata.ConfigDataNode, error)
 	return configtoConfigDataNode(p.Config, "")
 }
 
+// AggregateWorkflowMapNode aggregates the metrics of its parent node over a
+// window of time spanning several runs of the task
+type AggregateWorkflowMapNode struct {
+	// required: true
+	// Window the duration of the window
+	Window string `json:"window"yaml:"window"`
+	// Slide how often aggregates of a sliding window are emitted. A window without slide is tumbling.
+	Slide string `json:"slide,omitempty"yaml:"slide"`
+	// required: true
+	// Functions the aggregates to emit: min, max, mean, sum, count and percentiles such as p95
+	Functions []string `json:"functions"yaml:"functions"`
+	// Filter the expression selecting the metrics which are aggregated
+	Filter  string                   `json:"filter,omitempty"yaml:"filter"`
+	Process []ProcessWorkflowMapNode `json:"process,omitempty"yaml:"process"`
+	Publish []PublishWorkflowMapNode `json:"publish,omitempty"yaml:"publish"`
+}
+
+func (aw *AggregateWorkflowMapNode) UnmarshalJSON(data []byte) error {
+	t := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &t); err != nil {
+		return err
+	}
+	for k, v := range t {
+		switch k {
+		case "window":
+			if err := json.Unmarshal(v, &aw.Window); err != nil {
+				return fmt.Errorf("%v (while parsing 'window')", err)
+			}
+		case "slide":
+			if err := json.Unmarshal(v, &aw.Slide); err != nil {
+				return fmt.Errorf("%v (while parsing 'slide')", err)
+			}
+		case "functions":
+			if err := json.Unmarshal(v, &aw.Functions); err != nil {
+				return fmt.Errorf("%v (while parsing 'functions')", err)
+			}
+		case "filter":
+			if err := json.Unmarshal(v, &aw.Filter); err != nil {
+				return fmt.Errorf("%v (while parsing 'filter')", err)
+			}
+		case "process":
+			if err := json.Unmarshal(v, &aw.Process); err != nil {
+				return err
+			}
+		case "publish":
+			if err := json.Unmarshal(v, &aw.Publish); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("Unrecognized key '%v' in aggregate workflow of task.", k)
+		}
+	}
+	return nil
+}
+
+func NewAggregateNode(window string, functions ...string) *AggregateWorkflowMapNode {
+	return &AggregateWorkflowMapNode{
+		Window:    window,
+		Functions: functions,
+	}
+}
+
+func (a *AggregateWorkflowMapNode) Add(node interface{}) error {
+	switch x := node.(type) {
+	case *ProcessWorkflowMapNode:
+		a.Process = append(a.Process, *x)
+	case *PublishWorkflowMapNode:
+		a.Publish = append(a.Publish, *x)
+	default:
+		return errors.New(fmt.Sprintf("cannot add workflow node type (%v) to aggregate node as child", x))
+	}
+	return nil
+}
+
 type PublishWorkflowMapNode struct {
 	// required: true
 	PluginName    string `json:"plugin_name"yaml:"plugin_name"`
@@ -376,6 +470,12 @@ type PublishWorkflowMapNode struct {
 	// Config the config of a publisher
 	Config map[string]interface{} `json:"config,omitempty"yaml:"config"`
 	Target string                 `json:"target"yaml:"target"`
+	// Retry the retry policy of a publisher
+	Retry *RetryPolicy `json:"retry,omitempty"yaml:"retry"`
+	// Buffer the buffer keeping metrics while a publisher fails
+	Buffer *BufferPolicy `json:"buffer,omitempty"yaml:"buffer"`
+	// Filter the expression selecting the metrics sent to a publisher
+	Filter string `json:"filter,omitempty"yaml:"filter"`
 }
 
 func (pw *PublishWorkflowMapNode) UnmarshalJSON(data []byte) error {
@@ -401,6 +501,18 @@ func (pw *PublishWorkflowMapNode) UnmarshalJSON(data []byte) error {
 			if err := json.Unmarshal(v, &pw.Target); err != nil {
 				return fmt.Errorf("%v (while parsing 'target')", err)
 			}
+		case "retry":
+			if err := json.Unmarshal(v, &pw.Retry); err != nil {
+				return err
+			}
+		case "buffer":
+			if err := json.Unmarshal(v, &pw.Buffer); err != nil {
+				return err
+			}
+		case "filter":
+			if err := json.Unmarshal(v, &pw.Filter); err != nil {
+				return fmt.Errorf("%v (while parsing 'filter')", err)
+			}
 		default:
 			return fmt.Errorf("Unrecognized key '%v' in publish workflow of task.", k)
 		}
@@ -430,6 +542,89 @@ func (p *PublishWorkflowMapNode) GetConfigNode() (*cdata.ConfigDataNode, error)
 	return configtoConfigDataNode(p.Config, "")
 }
 
+// RetryPolicy describes how a failed publish is retried. The delay before
+// each retry starts at InitialInterval and is multiplied by Multiplier after
+// every attempt, up to MaxInterval. Jitter randomizes each delay by up to the
+// given fraction of it.
+type RetryPolicy struct {
+	// MaxAttempts the number of attempts, including the first one
+	MaxAttempts     int     `json:"max_attempts"yaml:"max_attempts"`
+	InitialInterval string  `json:"initial_interval,omitempty"yaml:"initial_interval"`
+	MaxInterval     string  `json:"max_interval,omitempty"yaml:"max_interval"`
+	Multiplier      float64 `json:"multiplier,omitempty"yaml:"multiplier"`
+	Jitter          float64 `json:"jitter,omitempty"yaml:"jitter"`
+}
+
+func (r *RetryPolicy) UnmarshalJSON(data []byte) error {
+	t := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &t); err != nil {
+		return err
+	}
+	for k, v := range t {
+		switch k {
+		case "max_attempts":
+			if err := json.Unmarshal(v, &r.MaxAttempts); err != nil {
+				return fmt.Errorf("%v (while parsing 'max_attempts')", err)
+			}
+		case "initial_interval":
+			if err := json.Unmarshal(v, &r.InitialInterval); err != nil {
+				return fmt.Errorf("%v (while parsing 'initial_interval')", err)
+			}
+		case "max_interval":
+			if err := json.Unmarshal(v, &r.MaxInterval); err != nil {
+				return fmt.Errorf("%v (while parsing 'max_interval')", err)
+			}
+		case "multiplier":
+			if err := json.Unmarshal(v, &r.Multiplier); err != nil {
+				return fmt.Errorf("%v (while parsing 'multiplier')", err)
+			}
+		case "jitter":
+			if err := json.Unmarshal(v, &r.Jitter); err != nil {
+				return fmt.Errorf("%v (while parsing 'jitter')", err)
+			}
+		default:
+			return fmt.Errorf("Unrecognized key '%v' in retry policy of publish workflow of task.", k)
+		}
+	}
+	return nil
+}
+
+// BufferPolicy describes the buffer keeping the metrics a publisher failed to
+// publish until it recovers. MaxSize bounds the size of the buffer in bytes and
+// MaxAge the age of the metrics it keeps. Overflow tells which metrics are
+// dropped when the buffer is full, either drop_oldest or drop_newest.
+type BufferPolicy struct {
+	MaxSize  int64  `json:"max_size,omitempty"yaml:"max_size"`
+	MaxAge   string `json:"max_age,omitempty"yaml:"max_age"`
+	Overflow string `json:"overflow,omitempty"yaml:"overflow"`
+}
+
+func (b *BufferPolicy) UnmarshalJSON(data []byte) error {
+	t := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &t); err != nil {
+		return err
+	}
+	for k, v := range t {
+		switch k {
+		case "max_size":
+			if err := json.Unmarshal(v, &b.MaxSize); err != nil {
+				return fmt.Errorf("%v (while parsing 'max_size')", err)
+			}
+		case "max_age":
+			if err := json.Unmarshal(v, &b.MaxAge); err != nil {
+				return fmt.Errorf("%v (while parsing 'max_age')", err)
+			}
+		case "overflow":
+			if err := json.Unmarshal(v, &b.Overflow); err != nil {
+				return fmt.Errorf("%v (while parsing 'overflow')", err)
+			}
+		default:
+			return fmt.Errorf("Unrecognized key '%v' in buffer of publish workflow of task.", k)
+		}
+	}
+	return nil
+}
+
 type metricInfo struct {
 	Version_ int `json:"version"yaml:"version"`
 }
@@ -244,3 +244,105 @@ func TestMetricSeparator(t *testing.T) {
 		})
 	})
 }
+
+func TestPublishRetryPolicy(t *testing.T) {
+	Convey("Retry policy of a publish node", t, func() {
+		Convey("is parsed from json", func() {
+			wf, err := FromJson(`{"collect": {"metrics": {"/foo/bar": {}}, "publish": [{"plugin_name": "file", "retry": {"max_attempts": 5, "initial_interval": "2s", "max_interval": "1m", "multiplier": 1.5, "jitter": 0.2}}]}}`)
+			So(err, ShouldBeNil)
+			So(wf.Collect.Publish[0].Retry, ShouldResemble, &RetryPolicy{
+				MaxAttempts:     5,
+				InitialInterval: "2s",
+				MaxInterval:     "1m",
+				Multiplier:      1.5,
+				Jitter:          0.2,
+			})
+		})
+		Convey("is parsed from yaml", func() {
+			wf, err := FromYaml("collect:\n  metrics:\n    /foo/bar: {}\n  publish:\n  - plugin_name: file\n    retry:\n      max_attempts: 3\n")
+			So(err, ShouldBeNil)
+			So(wf.Collect.Publish[0].Retry.MaxAttempts, ShouldEqual, 3)
+		})
+		Convey("rejects unknown keys", func() {
+			_, err := FromJson(`{"collect": {"metrics": {"/foo/bar": {}}, "publish": [{"plugin_name": "file", "retry": {"attempts": 5}}]}}`)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "Unrecognized key 'attempts'")
+		})
+	})
+}
+
+func TestPublishBufferPolicy(t *testing.T) {
+	Convey("Buffer policy of a publish node", t, func() {
+		Convey("is parsed from json", func() {
+			wf, err := FromJson(`{"collect": {"metrics": {"/foo/bar": {}}, "publish": [{"plugin_name": "file", "buffer": {"max_size": 1024, "max_age": "1h", "overflow": "drop_newest"}}]}}`)
+			So(err, ShouldBeNil)
+			So(wf.Collect.Publish[0].Buffer, ShouldResemble, &BufferPolicy{
+				MaxSize:  1024,
+				MaxAge:   "1h",
+				Overflow: "drop_newest",
+			})
+		})
+		Convey("is parsed from yaml", func() {
+			wf, err := FromYaml("collect:\n  metrics:\n    /foo/bar: {}\n  publish:\n  - plugin_name: file\n    buffer:\n      max_size: 2048\n")
+			So(err, ShouldBeNil)
+			So(wf.Collect.Publish[0].Buffer.MaxSize, ShouldEqual, 2048)
+		})
+		Convey("rejects unknown keys", func() {
+			_, err := FromJson(`{"collect": {"metrics": {"/foo/bar": {}}, "publish": [{"plugin_name": "file", "buffer": {"size": 1024}}]}}`)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "Unrecognized key 'size'")
+		})
+	})
+}
+
+func TestNodeFilter(t *testing.T) {
+	Convey("Filter of process and publish nodes", t, func() {
+		Convey("is parsed from json", func() {
+			wf, err := FromJson(`{"collect": {"metrics": {"/foo/bar": {}}, "process": [{"plugin_name": "passthru", "filter": "value > 1", "publish": [{"plugin_name": "file", "filter": "tags.host == \"a\""}]}]}}`)
+			So(err, ShouldBeNil)
+			So(wf.Collect.Process[0].Filter, ShouldEqual, "value > 1")
+			So(wf.Collect.Process[0].Publish[0].Filter, ShouldEqual, `tags.host == "a"`)
+		})
+		Convey("is parsed from yaml", func() {
+			wf, err := FromYaml("collect:\n  metrics:\n    /foo/bar: {}\n  publish:\n  - plugin_name: file\n    filter: namespace == \"/foo/*\"\n")
+			So(err, ShouldBeNil)
+			So(wf.Collect.Publish[0].Filter, ShouldEqual, `namespace == "/foo/*"`)
+		})
+	})
+}
+
+func TestAggregateNode(t *testing.T) {
+	Convey("Aggregate nodes", t, func() {
+		Convey("are parsed from json", func() {
+			wf, err := FromJson(`{"collect": {"metrics": {"/foo/bar": {}}, "aggregate": [{"window": "1m", "slide": "10s", "functions": ["mean", "p95"], "publish": [{"plugin_name": "file"}]}]}}`)
+			So(err, ShouldBeNil)
+			ag := wf.Collect.Aggregate[0]
+			So(ag.Window, ShouldEqual, "1m")
+			So(ag.Slide, ShouldEqual, "10s")
+			So(ag.Functions, ShouldResemble, []string{"mean", "p95"})
+			So(ag.Publish[0].PluginName, ShouldEqual, "file")
+		})
+		Convey("are parsed from yaml under a process node", func() {
+			wf, err := FromYaml("collect:\n  metrics:\n    /foo/bar: {}\n  process:\n  - plugin_name: passthru\n    aggregate:\n    - window: 5m\n      functions: [max]\n")
+			So(err, ShouldBeNil)
+			So(wf.Collect.Process[0].Aggregate[0].Window, ShouldEqual, "5m")
+			So(wf.Collect.Process[0].Aggregate[0].Functions, ShouldResemble, []string{"max"})
+		})
+		Convey("reject unknown keys", func() {
+			_, err := FromJson(`{"collect": {"metrics": {"/foo/bar": {}}, "aggregate": [{"window": "1m", "size": 3}]}}`)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "Unrecognized key 'size'")
+		})
+		Convey("can be added to collect and process nodes", func() {
+			ag := NewAggregateNode("1m", "min", "max")
+			So(ag.Add(NewPublishNode("file", 1)), ShouldBeNil)
+			So(ag.Add(NewCollectWorkflowMapNode()), ShouldNotBeNil)
+			c := NewCollectWorkflowMapNode()
+			So(c.Add(ag), ShouldBeNil)
+			So(len(c.Aggregate), ShouldEqual, 1)
+			p := NewProcessNode("passthru", 1)
+			So(p.Add(ag), ShouldBeNil)
+			So(len(p.Aggregate), ShouldEqual, 1)
+		})
+	})
+}
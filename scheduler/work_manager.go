@@ -19,7 +19,12 @@ limitations under the License.
 
 package scheduler
 
-import "sync"
+import (
+	"sync"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/pkg/telemetry"
+)
 
 /*
 
@@ -56,6 +61,7 @@ type workManager struct {
 	collectWkrSize uint
 	publishWkrSize uint
 	processWkrSize uint
+	weights        map[string]uint
 	collectchan    chan queuedJob
 	publishchan    chan queuedJob
 	processchan    chan queuedJob
@@ -105,6 +111,16 @@ func ProcessQSizeOption(v uint) workManagerOption {
 	}
 }
 
+// PriorityWeightsOption sets the weights of the task priority classes in the
+// queues and returns the previous weights option state.
+func PriorityWeightsOption(v map[string]uint) workManagerOption {
+	return func(w *workManager) workManagerOption {
+		previous := w.weights
+		w.weights = v
+		return PriorityWeightsOption(previous)
+	}
+}
+
 // CollectWkrSizeOption sets the collector worker pool size
 // and returns the previous collector worker pool state.
 func CollectWkrSizeOption(v uint) workManagerOption {
@@ -159,6 +175,9 @@ func newWorkManager(opts ...workManagerOption) *workManager {
 	wm.collectq = newQueue(wm.collectQSize, wm.sendToWorker)
 	wm.publishq = newQueue(wm.publishQSize, wm.sendToWorker)
 	wm.processq = newQueue(wm.processQSize, wm.sendToWorker)
+	wm.collectq.SetWeights(wm.weights)
+	wm.publishq.SetWeights(wm.weights)
+	wm.processq.SetWeights(wm.weights)
 
 	wm.publishq.Start()
 	wm.collectq.Start()
@@ -191,6 +210,7 @@ func (w *workManager) Start() {
 
 	if w.state == workManagerStopped {
 		w.state = workManagerRunning
+		telemetry.RegisterSource("work_manager", w.queueDepths)
 		go func() {
 			for {
 				select {
@@ -210,11 +230,42 @@ func (w *workManager) Start() {
 
 // Stop closes the collector queue and worker
 func (w *workManager) Stop() {
+	telemetry.UnregisterSource("work_manager")
 	w.collectq.Stop()
 	close(workerKillChan)
 	close(w.kill)
 }
 
+// queueDepths returns the number of jobs waiting in each queue
+func (w *workManager) queueDepths() []telemetry.Sample {
+	var samples []telemetry.Sample
+	for _, q := range []struct {
+		name string
+		q    *queue
+	}{{"collect", w.collectq}, {"process", w.processq}, {"publish", w.publishq}} {
+		samples = append(samples, telemetry.Sample{Namespace: []string{"scheduler", "work_manager", q.name, "depth"}, Value: float64(q.q.Len())})
+		for _, p := range core.TaskPriorities {
+			samples = append(samples,
+				telemetry.Sample{Namespace: []string{"scheduler", "work_manager", q.name, "priorities", p, "depth"}, Value: float64(q.q.ClassLen(p))},
+				telemetry.Sample{Namespace: []string{"scheduler", "work_manager", q.name, "priorities", p, "dropped"}, Value: float64(q.q.Dropped(p))},
+			)
+		}
+	}
+	return samples
+}
+
+// collectSaturation returns the share of the collect queue holding jobs, or 0
+// when the queue is not limited
+func (w *workManager) collectSaturation() float64 {
+	w.mutex.Lock()
+	limit := w.collectQSize
+	w.mutex.Unlock()
+	if limit == 0 {
+		return 0
+	}
+	return float64(w.collectq.Len()) / float64(limit)
+}
+
 // Work dispatches jobs to worker pools for processing.
 //
 // Returns a queued job to the caller, which will be
@@ -259,6 +310,53 @@ func (w *workManager) AddProcessWorker() {
 	w.processWkrSize++
 }
 
+// SetPriorityWeights changes the weights of the task priority classes in the
+// job queues.
+func (w *workManager) SetPriorityWeights(weights map[string]uint) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.collectq.SetWeights(weights)
+	w.publishq.SetWeights(weights)
+	w.processq.SetWeights(weights)
+	w.weights = weights
+}
+
+// Resize changes the size of the job queues and of the worker pools. Workers
+// removed from a pool finish the job they are running before exiting.
+func (w *workManager) Resize(qSize, wkrSize uint) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.collectq.SetLimit(qSize)
+	w.publishq.SetLimit(qSize)
+	w.processq.SetLimit(qSize)
+	w.collectQSize = qSize
+	w.publishQSize = qSize
+	w.processQSize = qSize
+
+	w.collectWkrs = resizeWorkers(w.collectWkrs, wkrSize, w.collectchan)
+	w.publishWkrs = resizeWorkers(w.publishWkrs, wkrSize, w.publishchan)
+	w.processWkrs = resizeWorkers(w.processWkrs, wkrSize, w.processchan)
+	w.collectWkrSize = wkrSize
+	w.publishWkrSize = wkrSize
+	w.processWkrSize = wkrSize
+}
+
+// resizeWorkers starts or stops workers until the pool holds size workers
+func resizeWorkers(wkrs []*worker, size uint, rcv chan queuedJob) []*worker {
+	for uint(len(wkrs)) < size {
+		nw := newWorker(rcv)
+		go nw.start()
+		wkrs = append(wkrs, nw)
+	}
+	for uint(len(wkrs)) > size {
+		close(wkrs[len(wkrs)-1].kamikaze)
+		wkrs = wkrs[:len(wkrs)-1]
+	}
+	return wkrs
+}
+
 // sendToWorker is the handler given to the queue.
 // it dispatches work to the worker pool.
 func (w *workManager) sendToWorker(j queuedJob) {
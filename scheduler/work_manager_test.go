@@ -30,6 +30,7 @@ import (
 	. "github.com/intelsdi-x/snap/pkg/promise"
 	log "github.com/sirupsen/logrus"
 	. "github.com/smartystreets/goconvey/convey"
+	"golang.org/x/net/context"
 )
 
 type mockJob struct {
@@ -86,6 +87,10 @@ func (mj *mockJob) Deadline() time.Time  { return mj.deadline }
 func (mj *mockJob) Type() jobType        { return collectJobType }
 func (mj *mockJob) TypeString() string   { return "" }
 func (mj *mockJob) TaskID() string       { return "" }
+func (mj *mockJob) Priority() string     { return "" }
+func (mj *mockJob) Context() context.Context {
+	return context.Background()
+}
 
 // Complete the first incomplete rendez-vous (if there is one)
 func (mj *mockJob) RendezVous() {
@@ -162,7 +167,7 @@ func TestWorkerManager(t *testing.T) {
 			So(errs2, ShouldBeEmpty)
 
 			// The work queue should be empty at this point.
-			So(manager.collectq.items, ShouldBeEmpty)
+			So(manager.collectq.Len(), ShouldEqual, 0)
 
 			// The first and second jobs should have been worked.
 			So(j1.worked, ShouldBeTrue)
@@ -172,6 +177,23 @@ func TestWorkerManager(t *testing.T) {
 			So(j3.worked, ShouldBeFalse)
 		})
 
+		Convey("works jobs after being resized", func() {
+			manager := newWorkManager(CollectQSizeOption(1), CollectWkrSizeOption(1))
+			manager.Start()
+			manager.Resize(10, 3)
+			So(manager.collectq.limit, ShouldEqual, 10)
+			So(len(manager.collectWkrs), ShouldEqual, 3)
+			So(len(manager.publishWkrs), ShouldEqual, 3)
+			manager.Resize(10, 2)
+			So(len(manager.collectWkrs), ShouldEqual, 2)
+			So(manager.collectWkrSize, ShouldEqual, 2)
+
+			j := newMockJob()
+			manager.Work(j)
+			j.Await()
+			So(j.worked, ShouldBeTrue)
+		})
+
 		// The below convey is WIP
 		/*Convey("Collect queue error ", func() {
 			wMOption1 := CollectQSizeOption(1)
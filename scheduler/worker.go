@@ -23,6 +23,7 @@ import (
 	"errors"
 
 	"github.com/intelsdi-x/snap/pkg/chrono"
+	"github.com/intelsdi-x/snap/pkg/telemetry"
 	"github.com/pborman/uuid"
 )
 
@@ -47,13 +48,21 @@ func (w *worker) start() {
 	for {
 		select {
 		case q := <-w.rcv:
+			jobType := q.Job().TypeString()
+			now := chrono.Chrono.Now()
+			telemetry.Add(now.Sub(q.Job().StartTime()).Seconds(), "scheduler", "jobs", jobType, "wait_seconds_total")
 			// assert that deadline is not exceeded
-			if chrono.Chrono.Now().Before(q.Job().Deadline()) {
+			if now.Before(q.Job().Deadline()) {
 				q.Job().Run()
 			} else {
 				// the deadline was exceeded and this job will not run
 				q.Job().AddErrors(errors.New("Worker refused to run overdue job."))
 			}
+			telemetry.Add(chrono.Chrono.Now().Sub(now).Seconds(), "scheduler", "jobs", jobType, "run_seconds_total")
+			telemetry.Add(1, "scheduler", "jobs", jobType, "count")
+			if len(q.Job().Errors()) > 0 {
+				telemetry.Add(1, "scheduler", "jobs", jobType, "failures")
+			}
 
 			// mark the job complete
 			q.Promise().Complete(q.Job().Errors())
@@ -30,7 +30,9 @@ import (
 
 	"github.com/intelsdi-x/snap/core"
 	"github.com/intelsdi-x/snap/core/cdata"
+	"github.com/intelsdi-x/snap/core/ctypes"
 	"github.com/intelsdi-x/snap/core/scheduler_event"
+	"github.com/intelsdi-x/snap/pkg/metricfilter"
 	"github.com/intelsdi-x/snap/scheduler/wmap"
 )
 
@@ -103,7 +105,12 @@ func convertCollectionNode(cnode *wmap.CollectWorkflowMapNode, wf *schedulerWork
 	if err != nil {
 		return err
 	}
-	wf.processNodes = pr
+	// Aggregate nodes are process nodes which do not call a plugin
+	ag, err := convertAggregateNode(cnode.Aggregate)
+	if err != nil {
+		return err
+	}
+	wf.processNodes = append(pr, ag...)
 	// Iterate over first level publish nodes
 	pu, err := convertPublishNode(cnode.Publish)
 	if err != nil {
@@ -128,6 +135,11 @@ func convertProcessNode(pr []wmap.ProcessWorkflowMapNode) ([]*processNode, error
 		if err != nil {
 			return nil, err
 		}
+		agC, err := convertAggregateNode(p.Aggregate)
+		if err != nil {
+			return nil, err
+		}
+		prC = append(prC, agC...)
 
 		// If version is not 1+ we use -1 to indicate we want
 		// the plugin manager to select the highest version
@@ -135,6 +147,10 @@ func convertProcessNode(pr []wmap.ProcessWorkflowMapNode) ([]*processNode, error
 		if p.PluginVersion < 1 {
 			p.PluginVersion = -1
 		}
+		filter, err := newFilter(p.Filter)
+		if err != nil {
+			return nil, err
+		}
 		p.PluginName = strings.ToLower(p.PluginName)
 		prNodes[i] = &processNode{
 			name:         p.PluginName,
@@ -143,6 +159,7 @@ func convertProcessNode(pr []wmap.ProcessWorkflowMapNode) ([]*processNode, error
 			Target:       p.Target,
 			ProcessNodes: prC,
 			PublishNodes: puC,
+			filter:       filter,
 		}
 	}
 	return prNodes, nil
@@ -162,17 +179,41 @@ func convertPublishNode(pu []wmap.PublishWorkflowMapNode) ([]*publishNode, error
 		if p.PluginVersion < 1 {
 			p.PluginVersion = -1
 		}
+		retry, err := newRetryPolicy(p.Retry)
+		if err != nil {
+			return nil, err
+		}
+		buffer, err := newBufferPolicy(p.Buffer)
+		if err != nil {
+			return nil, err
+		}
+		filter, err := newFilter(p.Filter)
+		if err != nil {
+			return nil, err
+		}
 		p.PluginName = strings.ToLower(p.PluginName)
 		puNodes[i] = &publishNode{
-			name:    p.PluginName,
-			version: p.PluginVersion,
-			config:  cdn,
-			Target:  p.Target,
+			name:         p.PluginName,
+			version:      p.PluginVersion,
+			config:       cdn,
+			Target:       p.Target,
+			retry:        retry,
+			bufferPolicy: buffer,
+			filter:       filter,
 		}
 	}
 	return puNodes, nil
 }
 
+// newFilter parses the filter expression of a workflow map node.
+// An empty expression means that the node receives all the metrics.
+func newFilter(expr string) (*metricfilter.Filter, error) {
+	if strings.TrimSpace(expr) == "" {
+		return nil, nil
+	}
+	return metricfilter.Parse(expr)
+}
+
 type schedulerWorkflow struct {
 	state WorkflowState
 	// Metrics to collect
@@ -195,6 +236,9 @@ type processNode struct {
 	ProcessNodes       []*processNode
 	PublishNodes       []*publishNode
 	InboundContentType string
+	filter             *metricfilter.Filter
+	// aggregator is set for aggregate nodes, which do not call a plugin
+	aggregator *aggregator
 }
 
 func (p *processNode) Name() string {
@@ -219,6 +263,10 @@ type publishNode struct {
 	config             *cdata.ConfigDataNode
 	Target             string
 	InboundContentType string
+	retry              *retryPolicy
+	bufferPolicy       *bufferPolicy
+	buffer             *publishBuffer
+	filter             *metricfilter.Filter
 }
 
 func (p *publishNode) Name() string {
@@ -237,6 +285,24 @@ func (p *publishNode) TypeName() string {
 	return "publisher"
 }
 
+// maxAttempts returns how many times a publish job of the node is attempted
+func (p *publishNode) maxAttempts() int {
+	if p.retry == nil {
+		return 1
+	}
+	return p.retry.maxAttempts
+}
+
+// walkPublishNodes calls fn for each publish node of a workflow, always in the same order
+func walkPublishNodes(prs []*processNode, pus []*publishNode, fn func(*publishNode)) {
+	for _, pr := range prs {
+		walkPublishNodes(pr.ProcessNodes, pr.PublishNodes, fn)
+	}
+	for _, pu := range pus {
+		fn(pu)
+	}
+}
+
 type wfContentTypes map[string]map[string][]string
 
 // Start starts a workflow
@@ -248,6 +314,9 @@ func (s *schedulerWorkflow) Start(t *task) {
 	}).Debug("Starting workflow")
 	s.state = WorkflowStarted
 	j := newCollectorJob(s.metrics, t.deadlineDuration, t.metricsManager, t.workflow.configTree, t.id, s.tags)
+	j.(*collectorJob).priority = t.priority
+	// the plugin calls of the workflow are canceled once the task stops
+	j.(*collectorJob).ctx = t.runContext()
 
 	// dispatch 'collect' job to be worked
 	// Block until the job has been either run or skipped.
@@ -289,6 +358,7 @@ func (s *schedulerWorkflow) StreamStart(t *task, metrics []core.Metric) {
 		configDataTree: t.workflow.configTree,
 		tags:           t.workflow.tags,
 	}
+	j.priority = t.priority
 	// Send event
 	event := new(scheduler_event.MetricCollectedEvent)
 	event.TaskID = t.id
@@ -343,6 +413,23 @@ func workJobs(prs []*processNode, pus []*publishNode, t *task, pj job) {
 func submitProcessJob(pj job, t *task, wg *sync.WaitGroup, pr *processNode) {
 	// Decrement the waitgroup
 	defer wg.Done()
+	pj, ok := filterJob(pj, pr.filter)
+	if !ok {
+		workflowLogger.WithFields(log.Fields{
+			"_block":           "submit-process-job",
+			"task-id":          t.id,
+			"task-name":        t.name,
+			"process-name":     pr.Name(),
+			"process-version":  pr.Version(),
+			"parent-node-type": pj.TypeString(),
+			"filter":           pr.filter.String(),
+		}).Debug("No metrics match the filter of the process node")
+		return
+	}
+	if pr.aggregator != nil {
+		submitAggregateJob(pj, t, pr)
+		return
+	}
 	// Create a new process job
 	mgr, err := t.RemoteManagers.Get(pr.Target)
 	if err != nil {
@@ -398,6 +485,19 @@ func submitProcessJob(pj job, t *task, wg *sync.WaitGroup, pr *processNode) {
 func submitPublishJob(pj job, t *task, wg *sync.WaitGroup, pu *publishNode) {
 	// Decrement the waitgroup
 	defer wg.Done()
+	pj, ok := filterJob(pj, pu.filter)
+	if !ok {
+		workflowLogger.WithFields(log.Fields{
+			"_block":           "submit-publish-job",
+			"task-id":          t.id,
+			"task-name":        t.name,
+			"publish-name":     pu.Name(),
+			"publish-version":  pu.Version(),
+			"parent-node-type": pj.TypeString(),
+			"filter":           pu.filter.String(),
+		}).Debug("No metrics match the filter of the publish node")
+		return
+	}
 	// Create a new process job
 	mgr, err := t.RemoteManagers.Get(pu.Target)
 	if err != nil {
@@ -412,22 +512,22 @@ func submitPublishJob(pj job, t *task, wg *sync.WaitGroup, pu *publishNode) {
 		}).Warn("Error getting control instance")
 		return
 	}
-	j := newPublishJob(pj, pu.Name(), pu.Version(), pu.InboundContentType, pu.config.Table(), mgr, t.id)
-	workflowLogger.WithFields(log.Fields{
-		"_block":           "submit-publish-job",
-		"task-id":          t.id,
-		"task-name":        t.name,
-		"publish-name":     pu.Name(),
-		"publish-version":  pu.Version(),
-		"parent-node-type": pj.TypeString(),
-	}).Debug("Submitting publish job")
-	// Submit the job against the task.managesWork
-	errors := t.manager.Work(j).Promise().Await()
+	// metrics still buffered are published first so that metrics are published in order
+	if pu.buffer != nil && !drainPublishBuffer(pj, t, pu, mgr) {
+		bufferMetrics(pj, t, pu)
+		return
+	}
+	errs, attempts := publishWithRetry(pj, t, pu, mgr)
 	// Check for errors and update the task
-	if len(errors) != 0 {
+	if len(errs) != 0 {
+		if pu.buffer != nil {
+			// the metrics are published once the publisher recovers
+			bufferMetrics(pj, t, pu)
+			return
+		}
 		// Record the failures in the task
 		// note: this function is thread safe against t
-		t.RecordFailure(errors)
+		t.RecordFailure(errs)
 		workflowLogger.WithFields(log.Fields{
 			"_block":           "submit-publish-job",
 			"task-id":          t.id,
@@ -435,7 +535,9 @@ func submitPublishJob(pj job, t *task, wg *sync.WaitGroup, pu *publishNode) {
 			"publish-name":     pu.Name(),
 			"publish-version":  pu.Version(),
 			"parent-node-type": pj.TypeString(),
+			"attempts":         attempts,
 		}).Warn("Publish job failed")
+		spoolDeadLetter(pj, t, pu, attempts, errs)
 		return
 	}
 	workflowLogger.WithFields(log.Fields{
@@ -445,7 +547,200 @@ func submitPublishJob(pj job, t *task, wg *sync.WaitGroup, pu *publishNode) {
 		"publish-name":     pu.Name(),
 		"publish-version":  pu.Version(),
 		"parent-node-type": pj.TypeString(),
+		"attempts":         attempts,
 	}).Debug("Publish job completed")
 	// Publish nodes cannot contain child nodes (publish is a terminal node)
 	// so unlike process nodes there is not a call to workJobs here for child nodes.
 }
+
+// submitAggregateJob adds the metrics of the parent job to the window of an
+// aggregate node and passes the aggregates on to its children once the window
+// is complete
+func submitAggregateJob(pj job, t *task, pr *processNode) {
+	aggregates := pr.aggregator.add(pj.Metrics(), time.Now())
+	if len(aggregates) == 0 {
+		return
+	}
+	workflowLogger.WithFields(log.Fields{
+		"_block":           "submit-aggregate-job",
+		"task-id":          t.id,
+		"task-name":        t.name,
+		"parent-node-type": pj.TypeString(),
+		"aggregates":       len(aggregates),
+	}).Debug("Aggregate window completed")
+	// Iterate into any child process or publish nodes
+	workJobs(pr.ProcessNodes, pr.PublishNodes, t, &aggregateJob{job: pj, metrics: aggregates})
+}
+
+// filteredJob is a parent job as seen by a node with a filter: only the
+// metrics matching the filter are passed to the node
+type filteredJob struct {
+	job
+	metrics []core.Metric
+}
+
+func (f *filteredJob) Metrics() []core.Metric {
+	return f.metrics
+}
+
+// filterJob returns the parent job of a node with the given filter. It returns
+// false if none of the metrics of the parent job match the filter.
+func filterJob(pj job, filter *metricfilter.Filter) (job, bool) {
+	if filter == nil {
+		return pj, true
+	}
+	mts := filter.Apply(pj.Metrics())
+	if len(mts) == 0 {
+		return pj, false
+	}
+	return &filteredJob{job: pj, metrics: mts}, true
+}
+
+// publishWithRetry submits publish jobs for the metrics of the parent job
+// until one succeeds or the retry policy of the node gives up. It returns the
+// errors of the last attempt and the number of attempts.
+func publishWithRetry(pj job, t *task, pu *publishNode, mgr managesMetrics) ([]error, int) {
+	var errs []error
+	attempts := 0
+	for attempts < pu.maxAttempts() {
+		if attempts > 0 {
+			delay := pu.retry.backoff(attempts + 1)
+			workflowLogger.WithFields(log.Fields{
+				"_block":           "submit-publish-job",
+				"task-id":          t.id,
+				"task-name":        t.name,
+				"publish-name":     pu.Name(),
+				"publish-version":  pu.Version(),
+				"parent-node-type": pj.TypeString(),
+				"attempt":          attempts,
+				"retry-in":         delay.String(),
+				"error":            errs[len(errs)-1].Error(),
+			}).Warn("Publish job failed, retrying")
+			if !waitForRetry(t, delay) {
+				break
+			}
+		}
+		attempts++
+		j := newPublishJob(pj, pu.Name(), pu.Version(), pu.InboundContentType, pu.config.Table(), mgr, t.id)
+		if attempts > 1 {
+			// the deadline of the parent job has likely passed while waiting to retry
+			j.(*publisherJob).deadline = time.Now().Add(t.deadlineDuration)
+		}
+		workflowLogger.WithFields(log.Fields{
+			"_block":           "submit-publish-job",
+			"task-id":          t.id,
+			"task-name":        t.name,
+			"publish-name":     pu.Name(),
+			"publish-version":  pu.Version(),
+			"parent-node-type": pj.TypeString(),
+			"attempt":          attempts,
+		}).Debug("Submitting publish job")
+		// Submit the job against the task.managesWork
+		errs = t.manager.Work(j).Promise().Await()
+		if len(errs) == 0 {
+			break
+		}
+	}
+	return errs, attempts
+}
+
+// drainPublishBuffer publishes the metrics buffered by a publish node. It
+// returns false when metrics are left in the buffer.
+func drainPublishBuffer(pj job, t *task, pu *publishNode, mgr managesMetrics) bool {
+	if pu.buffer.depth() == 0 {
+		return true
+	}
+	published, errs := pu.buffer.drain(func(mts []core.Metric) []error {
+		return publishOnce(t, mgr, pu.Name(), pu.Version(), pu.config.Table(), mts)
+	})
+	logger := workflowLogger.WithFields(log.Fields{
+		"_block":          "drain-publish-buffer",
+		"task-id":         t.id,
+		"task-name":       t.name,
+		"publish-name":    pu.Name(),
+		"publish-version": pu.Version(),
+		"published":       published,
+		"buffered":        pu.buffer.depth(),
+	})
+	if len(errs) > 0 {
+		logger.WithField("error", errs[len(errs)-1].Error()).Warn("Publisher still failing, keeping metrics buffered")
+		return false
+	}
+	logger.Info("Publish buffer drained")
+	return true
+}
+
+// bufferMetrics keeps the metrics of the parent job in the buffer of a
+// publish node until its publisher recovers
+func bufferMetrics(pj job, t *task, pu *publishNode) {
+	dropped, err := pu.buffer.add(pj.Metrics())
+	logger := workflowLogger.WithFields(log.Fields{
+		"_block":          "buffer-metrics",
+		"task-id":         t.id,
+		"task-name":       t.name,
+		"publish-name":    pu.Name(),
+		"publish-version": pu.Version(),
+		"count-metrics":   len(pj.Metrics()),
+		"dropped":         dropped,
+		"buffered":        pu.buffer.depth(),
+	})
+	if err != nil {
+		t.RecordFailure([]error{err})
+		logger.WithField("_error", err.Error()).Error("unable to buffer metrics, metrics are lost")
+		return
+	}
+	if dropped > 0 {
+		logger.Warn("Publish buffer full, metrics dropped")
+		return
+	}
+	logger.Debug("Metrics buffered")
+}
+
+// publishOnce submits a single publish job for the given metrics
+func publishOnce(t *task, mgr publishesMetrics, name string, version int, config map[string]ctypes.ConfigValue, metrics []core.Metric) []error {
+	pj := &collectorJob{
+		metricTypes: []core.RequestedMetric{},
+		metrics:     metrics,
+		coreJob:     newCoreJob(collectJobType, time.Now().Add(t.DeadlineDuration()), t.id, "", 0),
+	}
+	pj.priority = t.Priority()
+	pj.ctx = t.runContext()
+	j := newPublishJob(pj, name, version, "", config, mgr, t.id)
+	return t.manager.Work(j).Promise().Await()
+}
+
+// waitForRetry waits before retrying a publish job. It returns false when
+// the task was stopped while waiting.
+func waitForRetry(t *task, delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-t.runContext().Done():
+		return false
+	}
+}
+
+// spoolDeadLetter keeps the metrics a publish node failed to publish in the
+// dead-letter spool of the task, if any
+func spoolDeadLetter(pj job, t *task, pu *publishNode, attempts int, errs []error) {
+	if t.deadLetters == nil {
+		return
+	}
+	dl := newDeadLetter(pj, t, pu, attempts, errs)
+	logger := workflowLogger.WithFields(log.Fields{
+		"_block":          "spool-dead-letter",
+		"task-id":         t.id,
+		"task-name":       t.name,
+		"publish-name":    pu.Name(),
+		"publish-version": pu.Version(),
+		"dead-letter":     dl.ID,
+		"count-metrics":   len(dl.Metrics),
+	})
+	if err := t.deadLetters.Add(dl); err != nil {
+		logger.WithField("_error", err.Error()).Error("unable to spool dead letter, metrics are lost")
+		return
+	}
+	logger.Info("Metrics spooled to the dead-letter spool")
+}
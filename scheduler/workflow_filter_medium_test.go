@@ -0,0 +1,115 @@
+// +build medium
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/intelsdi-x/snap/control/plugin"
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/ctypes"
+	"github.com/intelsdi-x/snap/pkg/schedule"
+	"github.com/intelsdi-x/snap/scheduler/wmap"
+)
+
+// mockRoutingPublisher records the namespaces of the metrics each publisher receives
+type mockRoutingPublisher struct {
+	*mockMetricManager
+	sync.Mutex
+	published map[string][]string
+}
+
+func (m *mockRoutingPublisher) CollectMetrics(string, map[string]map[string]string) ([]core.Metric, []error) {
+	return []core.Metric{
+		plugin.MetricType{Namespace_: core.NewNamespace("intel", "cpu", "0", "idle"), Data_: 97.5},
+		plugin.MetricType{Namespace_: core.NewNamespace("intel", "cpu", "1", "idle"), Data_: 12.0},
+		plugin.MetricType{Namespace_: core.NewNamespace("intel", "disk", "sda", "reads"), Data_: 1024},
+	}, nil
+}
+
+func (m *mockRoutingPublisher) PublishMetrics(mts []core.Metric, _ map[string]ctypes.ConfigValue, _, name string, _ int) []error {
+	m.Lock()
+	defer m.Unlock()
+	for _, mt := range mts {
+		m.published[name] = append(m.published[name], mt.Namespace().String())
+	}
+	return nil
+}
+
+func (m *mockRoutingPublisher) get(name string) []string {
+	m.Lock()
+	defer m.Unlock()
+	return m.published[name]
+}
+
+func TestWorkflowFilter(t *testing.T) {
+	Convey("Given a scheduler", t, func() {
+		s := New(GetDefaultConfig())
+		mm := &mockRoutingPublisher{mockMetricManager: newMockMetricManager(), published: map[string][]string{}}
+		s.SetMetricManager(mm)
+		So(s.Start(), ShouldBeNil)
+		sch := schedule.NewWindowedSchedule(time.Hour, nil, nil, 0)
+
+		Convey("publish nodes only receive the metrics matching their filter", func() {
+			w := wmap.NewWorkflowMap()
+			w.Collect.AddMetric("/intel/*", 1)
+			cpu := wmap.NewPublishNode("cpu-publisher", -1)
+			cpu.Filter = `namespace == "/intel/cpu/**" and value < 50`
+			disk := wmap.NewPublishNode("disk-publisher", -1)
+			disk.Filter = `namespace == "/intel/disk/**"`
+			none := wmap.NewPublishNode("none-publisher", -1)
+			none.Filter = `tags.missing == "tag"`
+			all := wmap.NewPublishNode("all-publisher", -1)
+			w.Collect.Add(cpu)
+			w.Collect.Add(disk)
+			w.Collect.Add(none)
+			w.Collect.Add(all)
+
+			tsk, errs := s.CreateTask(sch, w, false)
+			So(errs.Errors(), ShouldBeEmpty)
+			task := s.tasks.Get(tsk.ID())
+			task.Spin()
+			time.Sleep(200 * time.Millisecond)
+			task.Stop()
+
+			So(mm.get("cpu-publisher"), ShouldResemble, []string{"/intel/cpu/1/idle"})
+			So(mm.get("disk-publisher"), ShouldResemble, []string{"/intel/disk/sda/reads"})
+			So(mm.get("none-publisher"), ShouldBeEmpty)
+			So(len(mm.get("all-publisher")), ShouldEqual, 3)
+			So(tsk.FailedCount(), ShouldEqual, 0)
+		})
+		Convey("a task with an invalid filter is rejected", func() {
+			w := wmap.NewWorkflowMap()
+			w.Collect.AddMetric("/intel/*", 1)
+			pr := wmap.NewProcessNode("passthru", -1)
+			pr.Filter = `namespace > 1`
+			w.Collect.Add(pr)
+			_, errs := s.CreateTask(sch, w, false)
+			So(errs.Errors(), ShouldNotBeEmpty)
+			So(errs.Errors()[0].Error(), ShouldStartWith, "Invalid filter")
+		})
+	})
+}
@@ -57,11 +57,15 @@ func (p *processNode) String(args ...string) string {
 	if len(args) > 0 {
 		pad = args[0]
 	}
-	out += fmt.Sprintf("%sName: %s\n", pad, p.Name())
-	out += fmt.Sprintf("%s   Version: %d\n", pad, p.Version())
-	out += fmt.Sprintf("%s   Config:\n", pad)
-	for k, v := range p.Config().Table() {
-		out += fmt.Sprintf("%s      %s=%+v\n", pad, k, v)
+	if p.aggregator != nil {
+		out += fmt.Sprintf("%sAggregate: %s\n", pad, p.aggregator)
+	} else {
+		out += fmt.Sprintf("%sName: %s\n", pad, p.Name())
+		out += fmt.Sprintf("%s   Version: %d\n", pad, p.Version())
+		out += fmt.Sprintf("%s   Config:\n", pad)
+		for k, v := range p.Config().Table() {
+			out += fmt.Sprintf("%s      %s=%+v\n", pad, k, v)
+		}
 	}
 	out += fmt.Sprintf("%s   (Processors): \n", pad)
 	for _, p2 := range p.ProcessNodes {
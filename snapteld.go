@@ -44,6 +44,7 @@ import (
 	"github.com/intelsdi-x/snap/mgmt/rest"
 	"github.com/intelsdi-x/snap/mgmt/tribe"
 	"github.com/intelsdi-x/snap/mgmt/tribe/agreement"
+	"github.com/intelsdi-x/snap/pkg/audit"
 	"github.com/intelsdi-x/snap/pkg/cfgfile"
 	"github.com/intelsdi-x/snap/scheduler"
 	"google.golang.org/grpc/grpclog"
@@ -79,6 +80,19 @@ var (
 		Usage:  fmt.Sprintf("1-5 (Debug, Info, Warning, Error, Fatal; default: %v)", defaultLogLevel),
 		EnvVar: "SNAP_LOG_LEVEL",
 	}
+	flAuditLogPath = cli.StringFlag{
+		Name:   "audit-log-path",
+		Usage:  "Path of the audit log file. Empty path disables the audit log.",
+		EnvVar: "SNAP_AUDIT_LOG_PATH",
+	}
+	flAuditLogMaxSize = cli.IntFlag{
+		Name:  "audit-log-max-size",
+		Usage: fmt.Sprintf("Size in megabytes the audit log is rotated at, 0 disables the rotation (default: %v)", defaultAuditLogMaxSize),
+	}
+	flAuditLogMaxBackups = cli.IntFlag{
+		Name:  "audit-log-max-backups",
+		Usage: fmt.Sprintf("Number of rotated audit log files to keep (default: %v)", defaultAuditLogMaxBackups),
+	}
 	flConfig = cli.StringFlag{
 		Name:   "config",
 		Usage:  "A path to a config file",
@@ -115,6 +129,10 @@ const (
 	defaultLogTruncate bool   = false
 	defaultLogColors   bool   = true
 	defaultConfigPath  string = "/etc/snap/snapteld.conf"
+
+	defaultAuditLogPath       string = ""
+	defaultAuditLogMaxSize    int    = 100
+	defaultAuditLogMaxBackups int    = 5
 )
 
 // holds the configuration passed in through the SNAP config file
@@ -131,6 +149,12 @@ type Config struct {
 	Scheduler   *scheduler.Config `json:"scheduler,omitempty"yaml:"scheduler,omitempty"`
 	RestAPI     *rest.Config      `json:"restapi,omitempty"yaml:"restapi,omitempty"`
 	Tribe       *tribe.Config     `json:"tribe,omitempty"yaml:"tribe,omitempty"`
+
+	// AuditLogPath is the file operations changing the state of snapteld are
+	// recorded to, rotated at AuditLogMaxSize megabytes
+	AuditLogPath       string `json:"audit_log_path,omitempty"yaml:"audit_log_path,omitempty"`
+	AuditLogMaxSize    int    `json:"audit_log_max_size,omitempty"yaml:"audit_log_max_size,omitempty"`
+	AuditLogMaxBackups int    `json:"audit_log_max_backups,omitempty"yaml:"audit_log_max_backups,omitempty"`
 }
 
 const (
@@ -157,6 +181,20 @@ const (
 				"description": "log file colored output default is true",
 				"type": "boolean"
 			},
+			"audit_log_path": {
+				"description": "path to the audit log file, empty disables the audit log",
+				"type": "string"
+			},
+			"audit_log_max_size": {
+				"description": "size in megabytes the audit log is rotated at, 0 disables the rotation",
+				"type": "integer",
+				"minimum": 0
+			},
+			"audit_log_max_backups": {
+				"description": "number of rotated audit log files to keep",
+				"type": "integer",
+				"minimum": 0
+			},
 			"gomaxprocs": {
 				"description": "value to be used for gomaxprocs",
 				"type": "integer",
@@ -219,6 +257,9 @@ func main() {
 		flLogTruncate,
 		flLogColors,
 		flMaxProcs,
+		flAuditLogPath,
+		flAuditLogMaxSize,
+		flAuditLogMaxBackups,
 		flConfig,
 	}
 	cliApp.Flags = append(cliApp.Flags, control.Flags...)
@@ -246,6 +287,17 @@ func action(ctx *cli.Context) error {
 	// same variables in that configuration
 	applyCmdLineFlags(cfg, ctx)
 
+	// unless told otherwise, keep the plugin config overlay next to the config file
+	if cfg.Control.PluginConfigWriteBack && cfg.Control.PluginConfigOverlayPath == "" {
+		cfg.Control.PluginConfigOverlayPath = pluginConfigOverlayPath(ctx.String("config"))
+	}
+
+	// keep the settings read so that changes can be found when the configuration is reloaded
+	loadedSettings, err := flattenConfig(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	// test the resulting configuration to ensure the values it contains still pass the
 	// constraints after applying the environment variables and command-line parameters;
 	// if errors are found, report them and exit with a fatal error
@@ -281,6 +333,17 @@ func action(ctx *cli.Context) error {
 		log.SetOutput(file)
 	}
 
+	// If auditLogPath is set, the operations changing the state of snapteld,
+	// like loading plugins or creating tasks, are recorded to the audit log.
+	if cfg.AuditLogPath != "" {
+		auditLog, err := audit.New(cfg.AuditLogPath, int64(cfg.AuditLogMaxSize)*1024*1024, cfg.AuditLogMaxBackups)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer auditLog.Close()
+		audit.SetLogger(auditLog)
+	}
+
 	// verify the temDirPath points to existing directory
 	tempDirPath := cfg.Control.TempDirPath
 	f, err := os.Stat(tempDirPath)
@@ -323,6 +386,11 @@ func action(ctx *cli.Context) error {
 	// Set Max Processors for snapteld.
 	setMaxProcs(cfg.GoMaxProcs)
 
+	// apply the plugin config changes made at runtime by a previous run
+	if err := cfg.Control.LoadPluginConfigOverlay(); err != nil {
+		log.Fatal(err)
+	}
+
 	c := control.New(cfg.Control)
 	if c.Config.AutoDiscoverPath != "" && c.Config.IsTLSEnabled() {
 		log.Fatal("TLS security is not supported in autodiscovery mode")
@@ -335,8 +403,9 @@ func action(ctx *cli.Context) error {
 	s.SetMetricManager(c)
 	coreModules = append(coreModules, s)
 
-	// Auth requested and not provided as part of config
-	if cfg.RestAPI.Enable && cfg.RestAPI.RestAuth && cfg.RestAPI.RestAuthPassword == "" {
+	// Auth requested and not provided as part of config, unless tokens or
+	// client certificates are
+	if cfg.RestAPI.Enable && cfg.RestAPI.RestAuth && cfg.RestAPI.RestAuthPassword == "" && len(cfg.RestAPI.Tokens) == 0 && len(cfg.RestAPI.ClientCerts) == 0 {
 		fmt.Println("What password do you want to use for authentication?")
 		fmt.Print("Password:")
 		password, err := terminal.ReadPassword(0)
@@ -369,6 +438,7 @@ func action(ctx *cli.Context) error {
 	}
 
 	//Setup RESTful API if it was enabled in the configuration
+	var restServer *rest.Server
 	if cfg.RestAPI.Enable {
 		r, err := rest.New(cfg.RestAPI)
 		if err != nil {
@@ -382,8 +452,16 @@ func action(ctx *cli.Context) error {
 		if cfg.RestAPI.RestAuth {
 			log.Info("REST API authentication is enabled")
 			r.SetAPIAuth(cfg.RestAPI.RestAuth)
-			log.Info("REST API authentication password is set")
-			r.SetAPIAuthPwd(cfg.RestAPI.RestAuthPassword)
+			if cfg.RestAPI.RestAuthPassword != "" {
+				log.Info("REST API authentication password is set")
+				r.SetAPIAuthPwd(cfg.RestAPI.RestAuthPassword)
+			}
+			if len(cfg.RestAPI.Tokens) > 0 {
+				log.Infof("REST API authentication tokens are set: %d", len(cfg.RestAPI.Tokens))
+			}
+			if len(cfg.RestAPI.ClientCerts) > 0 {
+				log.Infof("REST API client certificate roles are set: %d", len(cfg.RestAPI.ClientCerts))
+			}
 			if !cfg.RestAPI.HTTPS {
 				log.Warning("Using REST API authentication without HTTPS enabled.")
 			}
@@ -394,14 +472,23 @@ func action(ctx *cli.Context) error {
 		}
 		go monitorErrors(r.Err())
 		coreModules = append(coreModules, r)
+		restServer = r
 		log.Info("REST API is enabled")
 	} else {
 		log.Info("REST API is disabled")
 	}
 
-	// Set interrupt handling so we can either restart the app on a SIGHUP or
-	// die gracefully when an interrupt, kill, etc. are received
-	startInterruptHandling(coreModules...)
+	// Set interrupt handling so we can either reload the configuration on a
+	// SIGHUP or die gracefully when an interrupt, kill, etc. are received
+	reloader := &configReloader{
+		ctx:       ctx,
+		cfg:       cfg,
+		settings:  loadedSettings,
+		control:   c,
+		scheduler: s,
+		rest:      restServer,
+	}
+	startInterruptHandling(reloader.reload, coreModules...)
 
 	// Start our modules
 	var started []coreModule
@@ -558,14 +645,26 @@ func getDefaultConfig() *Config {
 		Scheduler:   scheduler.GetDefaultConfig(),
 		RestAPI:     rest.GetDefaultConfig(),
 		Tribe:       tribe.GetDefaultConfig(),
+
+		AuditLogPath:       defaultAuditLogPath,
+		AuditLogMaxSize:    defaultAuditLogMaxSize,
+		AuditLogMaxBackups: defaultAuditLogMaxBackups,
 	}
 }
 
 // Read the snapteld configuration from a configuration file
 func readConfig(cfg *Config, fpath string) {
+	if err := loadConfig(cfg, fpath); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// loadConfig reads the snapteld configuration from a configuration file,
+// returning an error when the file cannot be read or holds an invalid configuration
+func loadConfig(cfg *Config, fpath string) error {
 	var path string
 	if !defaultConfigFile() && fpath == "" {
-		return
+		return nil
 	}
 	if defaultConfigFile() && fpath == "" {
 		path = defaultConfigPath
@@ -573,10 +672,10 @@ func readConfig(cfg *Config, fpath string) {
 	if fpath != "" {
 		f, err := os.Stat(fpath)
 		if err != nil {
-			log.Fatal(err)
+			return err
 		}
 		if f.IsDir() {
-			log.Fatal("configuration path provided must be a file")
+			return errors.New("configuration path provided must be a file")
 		}
 		path = fpath
 	}
@@ -586,8 +685,21 @@ func readConfig(cfg *Config, fpath string) {
 		for _, serr := range serrs {
 			log.WithFields(serr.Fields()).Error(serr.Error())
 		}
-		log.Fatal("Errors found while parsing global configuration file")
+		return errors.New("Errors found while parsing global configuration file")
 	}
+	return nil
+}
+
+// pluginConfigOverlayPath returns the path of the plugin config overlay file
+// kept next to the configuration file in use
+func pluginConfigOverlayPath(fpath string) string {
+	if fpath == "" {
+		if !defaultConfigFile() {
+			return ""
+		}
+		fpath = defaultConfigPath
+	}
+	return filepath.Join(filepath.Dir(fpath), control.DefaultPluginConfigOverlayFile)
 }
 
 func defaultConfigFile() bool {
@@ -843,6 +955,9 @@ func applyCmdLineFlags(cfg *Config, ctx runtimeFlagsContext) {
 	cfg.LogPath = setStringVal(cfg.LogPath, ctx, "log-path")
 	cfg.LogTruncate = setBoolVal(cfg.LogTruncate, ctx, "log-truncate")
 	cfg.LogColors = setBoolVal(cfg.LogColors, ctx, "log-colors")
+	cfg.AuditLogPath = setStringVal(cfg.AuditLogPath, ctx, "audit-log-path")
+	cfg.AuditLogMaxSize = setIntVal(cfg.AuditLogMaxSize, ctx, "audit-log-max-size")
+	cfg.AuditLogMaxBackups = setIntVal(cfg.AuditLogMaxBackups, ctx, "audit-log-max-backups")
 	// next for the flags related to the control package
 	cfg.Control.MaxRunningPlugins = setIntVal(cfg.Control.MaxRunningPlugins, ctx, "max-running-plugins")
 	cfg.Control.PluginLoadTimeout = setIntVal(cfg.Control.PluginLoadTimeout, ctx, "plugin-load-timeout")
@@ -857,6 +972,9 @@ func applyCmdLineFlags(cfg *Config, ctx runtimeFlagsContext) {
 	cfg.Control.TLSCertPath = setStringVal(cfg.Control.TLSCertPath, ctx, "tls-cert")
 	cfg.Control.TLSKeyPath = setStringVal(cfg.Control.TLSKeyPath, ctx, "tls-key")
 	cfg.Control.CACertPaths = setStringVal(cfg.Control.CACertPaths, ctx, "ca-cert-paths")
+	cfg.Control.PluginConfigWriteBack = setBoolVal(cfg.Control.PluginConfigWriteBack, ctx, "plugin-config-write-back")
+	cfg.Control.SelfTelemetry = setBoolVal(cfg.Control.SelfTelemetry, ctx, "self-telemetry")
+	cfg.Control.PluginConfigOverlayPath = setStringVal(cfg.Control.PluginConfigOverlayPath, ctx, "plugin-config-overlay-path")
 	// next for the RESTful server related flags
 	cfg.RestAPI.Enable = setBoolVal(cfg.RestAPI.Enable, ctx, "disable-api", invertBoolean)
 	cfg.RestAPI.Port = setIntVal(cfg.RestAPI.Port, ctx, "api-port")
@@ -864,6 +982,8 @@ func applyCmdLineFlags(cfg *Config, ctx runtimeFlagsContext) {
 	cfg.RestAPI.HTTPS = setBoolVal(cfg.RestAPI.HTTPS, ctx, "rest-https")
 	cfg.RestAPI.RestCertificate = setStringVal(cfg.RestAPI.RestCertificate, ctx, "rest-cert")
 	cfg.RestAPI.RestKey = setStringVal(cfg.RestAPI.RestKey, ctx, "rest-key")
+	cfg.RestAPI.RestClientCA = setStringVal(cfg.RestAPI.RestClientCA, ctx, "rest-client-ca")
+	cfg.RestAPI.RestClientAuth = setStringVal(cfg.RestAPI.RestClientAuth, ctx, "rest-client-auth")
 	cfg.RestAPI.RestAuth = setBoolVal(cfg.RestAPI.RestAuth, ctx, "rest-auth")
 	cfg.RestAPI.RestAuthPassword = setStringVal(cfg.RestAPI.RestAuthPassword, ctx, "rest-auth-pwd")
 	cfg.RestAPI.Pprof = setBoolVal(cfg.RestAPI.Pprof, ctx, "pprof")
@@ -872,6 +992,9 @@ func applyCmdLineFlags(cfg *Config, ctx runtimeFlagsContext) {
 	// next for the scheduler related flags
 	cfg.Scheduler.WorkManagerQueueSize = setUIntVal(cfg.Scheduler.WorkManagerQueueSize, ctx, "work-manager-queue-size")
 	cfg.Scheduler.WorkManagerPoolSize = setUIntVal(cfg.Scheduler.WorkManagerPoolSize, ctx, "work-manager-pool-size")
+	cfg.Scheduler.TaskStorePath = setStringVal(cfg.Scheduler.TaskStorePath, ctx, "task-store-path")
+	cfg.Scheduler.DeadLetterPath = setStringVal(cfg.Scheduler.DeadLetterPath, ctx, "dead-letter-path")
+	cfg.Scheduler.PublishBufferPath = setStringVal(cfg.Scheduler.PublishBufferPath, ctx, "publish-buffer-path")
 	// and finally for the tribe-related flags
 	cfg.Tribe.Name = setStringVal(cfg.Tribe.Name, ctx, "tribe-node-name")
 	cfg.Tribe.Enable = setBoolVal(cfg.Tribe.Enable, ctx, "tribe")
@@ -1004,6 +1127,18 @@ func (c *Config) UnmarshalJSON(data []byte) error {
 			if err := json.Unmarshal(v, &(c.LogColors)); err != nil {
 				return fmt.Errorf("%v (while parsing 'log_colors')", err)
 			}
+		case "audit_log_path":
+			if err := json.Unmarshal(v, &(c.AuditLogPath)); err != nil {
+				return fmt.Errorf("%v (while parsing 'audit_log_path')", err)
+			}
+		case "audit_log_max_size":
+			if err := json.Unmarshal(v, &(c.AuditLogMaxSize)); err != nil {
+				return fmt.Errorf("%v (while parsing 'audit_log_max_size')", err)
+			}
+		case "audit_log_max_backups":
+			if err := json.Unmarshal(v, &(c.AuditLogMaxBackups)); err != nil {
+				return fmt.Errorf("%v (while parsing 'audit_log_max_backups')", err)
+			}
 		case "control":
 			if err := json.Unmarshal(v, c.Control); err != nil {
 				return err
@@ -1050,13 +1185,24 @@ func printErrorAndExit(name string, err error) {
 		}).Fatal("error starting module")
 }
 
-func startInterruptHandling(modules ...coreModule) {
+func startInterruptHandling(reload func(), modules ...coreModule) {
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, os.Kill, syscall.SIGTERM, syscall.SIGHUP)
 
 	//Let's block until someone tells us to quit
 	go func() {
 		sig := <-c
+		// reload the configuration in place on SIGHUP and wait for the next signal
+		for sig == syscall.SIGHUP {
+			log.WithFields(
+				log.Fields{
+					"block":   "main",
+					"_module": logModule,
+					"signal":  sig.String(),
+				}).Info("reloading configuration")
+			reload()
+			sig = <-c
+		}
 		log.WithFields(
 			log.Fields{
 				"block":   "main",
@@ -1072,28 +1218,13 @@ func startInterruptHandling(modules ...coreModule) {
 				}).Info("stopping module")
 			m.Stop()
 		}
-		if sig == syscall.SIGHUP {
-			// log the action we're taking (restarting the app)
-			log.WithFields(
-				log.Fields{
-					"block":   "main",
-					"_module": logModule,
-					"signal":  sig.String(),
-				}).Info("restarting app")
-			// and restart the app (with the current configuration)
-			err := cliApp.Run(os.Args)
-			if err != nil {
-				os.Exit(1)
-			}
-		} else {
-			log.WithFields(
-				log.Fields{
-					"block":   "main",
-					"_module": logModule,
-					"signal":  sig.String(),
-				}).Info("exiting on signal")
-			os.Exit(0)
-		}
+		log.WithFields(
+			log.Fields{
+				"block":   "main",
+				"_module": logModule,
+				"signal":  sig.String(),
+			}).Info("exiting on signal")
+		os.Exit(0)
 	}()
 }
 
@@ -407,3 +407,50 @@ func Test_applyCmdLineFlags(t *testing.T) {
 		})
 	})
 }
+
+func TestConfigReloadChanges(t *testing.T) {
+	Convey("Given the settings of a configuration", t, func() {
+		cfg := getDefaultConfig()
+		settings, err := flattenConfig(cfg)
+		So(err, ShouldBeNil)
+
+		Convey("unchanged settings are not reported", func() {
+			next, err := flattenConfig(getDefaultConfig())
+			So(err, ShouldBeNil)
+			So(diffSettings(settings, next), ShouldBeEmpty)
+		})
+		Convey("changed settings are reported in order", func() {
+			cfg := getDefaultConfig()
+			cfg.LogLevel = 1
+			cfg.Scheduler.WorkManagerPoolSize = 8
+			cfg.Control.Tags["/intel/psutil"] = map[string]string{"datacenter": "rennes"}
+			next, err := flattenConfig(cfg)
+			So(err, ShouldBeNil)
+			changes := diffSettings(settings, next)
+			So(len(changes), ShouldEqual, 3)
+			So(changes[0].setting, ShouldEqual, "control.tags./intel/psutil.datacenter")
+			So(changes[0].new, ShouldEqual, "rennes")
+			So(changes[1].setting, ShouldEqual, "log_level")
+			So(changes[2].setting, ShouldEqual, "scheduler.work_manager_pool_size")
+			for _, c := range changes {
+				So(c.reloadable(), ShouldBeTrue)
+			}
+		})
+		Convey("changed listen ports require a restart", func() {
+			cfg := getDefaultConfig()
+			cfg.RestAPI.Port = 8282
+			cfg.Control.ListenPort = 8083
+			next, err := flattenConfig(cfg)
+			So(err, ShouldBeNil)
+			changes := diffSettings(settings, next)
+			So(len(changes), ShouldEqual, 2)
+			for _, c := range changes {
+				So(c.reloadable(), ShouldBeFalse)
+			}
+		})
+		Convey("passwords are not logged", func() {
+			c := configChange{setting: "restapi.rest_auth_password", old: "", new: "secret"}
+			So(c.fields()["new"], ShouldEqual, "********")
+		})
+	})
+}